@@ -231,7 +231,7 @@ func main() {
 		var timestamp int64
 		if err := db.QueryRow(sample.query).Scan(&eventID, &threadID, &timestamp); err != nil {
 			fmt.Printf("=== %s ===\n", sample.label)
-			fmt.Println("No matching event found.\n")
+			fmt.Print("No matching event found.\n\n")
 			continue
 		}
 
@@ -7,11 +7,14 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	stdsync "sync"
 	"syscall"
@@ -27,6 +30,7 @@ import (
 	"github.com/Napageneral/mnemonic/internal/gemini"
 	"github.com/Napageneral/mnemonic/internal/identify"
 	"github.com/Napageneral/mnemonic/internal/importer"
+	"github.com/Napageneral/mnemonic/internal/ingest"
 	"github.com/Napageneral/mnemonic/internal/live"
 	"github.com/Napageneral/mnemonic/internal/me"
 	"github.com/Napageneral/mnemonic/internal/query"
@@ -453,7 +457,7 @@ with identity resolution, semantic search, and analysis.`,
 			for name, adapter := range cfg.Adapters {
 				status := "disabled"
 				if adapter.Enabled {
-					status = checkAdapterStatus(name, adapter)
+					status = adapters.CheckStatus(adapter)
 				}
 
 				result.Adapters = append(result.Adapters, AdapterInfo{
@@ -482,6 +486,152 @@ with identity resolution, semantic search, and analysis.`,
 			}
 		},
 	}
+
+	adaptersTypesCmd := &cobra.Command{
+		Use:   "types",
+		Short: "List adapter types that can be configured",
+		Run: func(cmd *cobra.Command, args []string) {
+			type TypeInfo struct {
+				Type        string   `json:"type"`
+				Description string   `json:"description,omitempty"`
+				Live        bool     `json:"live"`
+				Options     []string `json:"options,omitempty"`
+				LiveOptions []string `json:"live_options,omitempty"`
+			}
+
+			type Result struct {
+				OK    bool       `json:"ok"`
+				Types []TypeInfo `json:"types"`
+			}
+
+			result := Result{OK: true}
+			for _, t := range adapters.Types() {
+				reg, _ := adapters.Lookup(t)
+				result.Types = append(result.Types, TypeInfo{
+					Type:        reg.Type,
+					Description: reg.Description,
+					Live:        reg.Live,
+					Options:     reg.OptionNames(),
+					LiveOptions: reg.LiveOptions,
+				})
+			}
+
+			if jsonOutput {
+				printJSON(result)
+				return
+			}
+			fmt.Println("Adapter types:")
+			for _, t := range result.Types {
+				live := ""
+				if t.Live {
+					live = " [live]"
+				}
+				fmt.Printf("  %s%s - %s\n", t.Type, live, t.Description)
+				if len(t.Options) > 0 {
+					fmt.Printf("    options: %s\n", strings.Join(t.Options, ", "))
+				}
+			}
+		},
+	}
+	adaptersDoctorCmd := &cobra.Command{
+		Use:   "doctor [adapter...]",
+		Short: "Diagnose adapter connectivity",
+		Long: `Run read-only probes for each configured adapter (or the named ones):
+binaries on PATH, source paths, auth, the upstream database schema, the
+sync watermark and the last sync error. Problems come with a suggested fix.
+
+Exits non-zero when any check fails, so it can be used from monitoring.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK       bool                `json:"ok"`
+				Message  string              `json:"message,omitempty"`
+				Adapters []sync.DoctorReport `json:"adapters,omitempty"`
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to load config: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			names := args
+			if len(names) == 0 {
+				for name := range cfg.Adapters {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+			}
+			for _, name := range names {
+				if _, ok := cfg.Adapters[name]; !ok {
+					result := Result{OK: false, Message: fmt.Sprintf("Adapter '%s' not configured", name)}
+					if jsonOutput {
+						printJSON(result)
+					} else {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+					}
+					os.Exit(1)
+				}
+			}
+
+			// Sync state checks are skipped if the database can't be opened.
+			database, err := db.Open()
+			if err != nil {
+				database = nil
+			} else {
+				defer database.Close()
+			}
+
+			result := Result{OK: true}
+			for _, name := range names {
+				report := sync.Doctor(cmd.Context(), database, name, cfg.Adapters[name])
+				if !report.OK {
+					result.OK = false
+				}
+				result.Adapters = append(result.Adapters, report)
+			}
+
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				if len(result.Adapters) == 0 {
+					fmt.Println("No adapters configured. Run 'mnemonic connect <adapter>' to configure one.")
+				}
+				symbols := map[string]string{adapters.CheckOK: "✓", adapters.CheckWarn: "!", adapters.CheckFail: "✗"}
+				for _, report := range result.Adapters {
+					symbol := "✓"
+					if !report.OK {
+						symbol = "✗"
+					}
+					enabled := ""
+					if !report.Enabled {
+						enabled = ", disabled"
+					}
+					fmt.Printf("%s %s (%s%s)\n", symbol, report.Adapter, report.Type, enabled)
+					for _, c := range report.Checks {
+						line := fmt.Sprintf("    %s %s", symbols[c.Status], c.Name)
+						if c.Detail != "" {
+							line += ": " + c.Detail
+						}
+						fmt.Println(line)
+						if c.Fix != "" {
+							fmt.Printf("      fix: %s\n", c.Fix)
+						}
+					}
+				}
+			}
+			if !result.OK {
+				os.Exit(1)
+			}
+		},
+	}
+
+	adaptersCmd.AddCommand(adaptersTypesCmd)
+	adaptersCmd.AddCommand(adaptersDoctorCmd)
 	rootCmd.AddCommand(adaptersCmd)
 
 	// connect command
@@ -548,10 +698,10 @@ with identity resolution, semantic search, and analysis.`,
 				os.Exit(1)
 			}
 
-			cfg.Adapters["imessage"] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, "imessage", config.AdapterConfig{
 				Type:    "eve",
 				Enabled: true,
-			}
+			})
 
 			if err := cfg.Save(); err != nil {
 				result := Result{
@@ -637,11 +787,11 @@ with identity resolution, semantic search, and analysis.`,
 				opts["qps"] = qps
 			}
 
-			cfg.Adapters[adapterName] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, adapterName, config.AdapterConfig{
 				Type:    "gogcli",
 				Enabled: true,
 				Options: opts,
-			}
+			})
 
 			if err := cfg.Save(); err != nil {
 				result := Result{
@@ -715,13 +865,13 @@ with identity resolution, semantic search, and analysis.`,
 				os.Exit(1)
 			}
 
-			cfg.Adapters[adapterName] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, adapterName, config.AdapterConfig{
 				Type:    "gogcli_calendar",
 				Enabled: true,
 				Options: map[string]interface{}{
 					"account": account,
 				},
-			}
+			})
 			if err := cfg.Save(); err != nil {
 				result := Result{OK: false, Message: fmt.Sprintf("Failed to save config: %v", err)}
 				if jsonOutput {
@@ -783,13 +933,13 @@ with identity resolution, semantic search, and analysis.`,
 				os.Exit(1)
 			}
 
-			cfg.Adapters[adapterName] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, adapterName, config.AdapterConfig{
 				Type:    "gogcli_contacts",
 				Enabled: true,
 				Options: map[string]interface{}{
 					"account": account,
 				},
-			}
+			})
 			if err := cfg.Save(); err != nil {
 				result := Result{OK: false, Message: fmt.Sprintf("Failed to save config: %v", err)}
 				if jsonOutput {
@@ -840,23 +990,16 @@ with identity resolution, semantic search, and analysis.`,
 			calName := fmt.Sprintf("calendar-%s", account)
 			contactsName := fmt.Sprintf("contacts-%s", account)
 
-			if cfg.Adapters == nil {
-				cfg.Adapters = map[string]config.AdapterConfig{}
-			}
-			cfg.Adapters[gmailName] = config.AdapterConfig{
-				Type:    "gogcli",
-				Enabled: true,
-				Options: map[string]interface{}{"account": account},
-			}
-			cfg.Adapters[calName] = config.AdapterConfig{
-				Type:    "gogcli_calendar",
-				Enabled: true,
-				Options: map[string]interface{}{"account": account},
-			}
-			cfg.Adapters[contactsName] = config.AdapterConfig{
-				Type:    "gogcli_contacts",
-				Enabled: true,
-				Options: map[string]interface{}{"account": account},
+			for name, adapterType := range map[string]string{
+				gmailName:    "gogcli",
+				calName:      "gogcli_calendar",
+				contactsName: "gogcli_contacts",
+			} {
+				mustSetAdapterConfig(cfg, name, config.AdapterConfig{
+					Type:    adapterType,
+					Enabled: true,
+					Options: map[string]interface{}{"account": account},
+				})
 			}
 
 			if err := cfg.Save(); err != nil {
@@ -946,13 +1089,13 @@ with identity resolution, semantic search, and analysis.`,
 				os.Exit(1)
 			}
 
-			cfg.Adapters["cursor"] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, "cursor", config.AdapterConfig{
 				Type:    "aix",
 				Enabled: true,
 				Options: map[string]interface{}{
 					"source": "cursor",
 				},
-			}
+			})
 
 			if err := cfg.Save(); err != nil {
 				result := Result{OK: false, Message: fmt.Sprintf("Failed to save config: %v", err)}
@@ -1001,11 +1144,11 @@ with identity resolution, semantic search, and analysis.`,
 				os.Exit(1)
 			}
 
-			cfg.Adapters["x"] = config.AdapterConfig{
+			mustSetAdapterConfig(cfg, "x", config.AdapterConfig{
 				Type:    "bird",
 				Enabled: true,
 				Options: map[string]interface{}{},
-			}
+			})
 
 			if err := cfg.Save(); err != nil {
 				result := Result{
@@ -1178,6 +1321,14 @@ mnemonic sync --background
 			}
 			defer database.Close()
 
+			if cmd.Flags().Changed("concurrency") {
+				cfg.Sync.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+			}
+			if cmd.Flags().Changed("timeout") {
+				timeout, _ := cmd.Flags().GetDuration("timeout")
+				cfg.Sync.Timeout = timeout.String()
+			}
+
 			// Create context
 			ctx := cmd.Context()
 
@@ -1223,6 +1374,10 @@ mnemonic sync --background
 						fmt.Printf("  Attachments updated: %d\n", adapterResult.AttachmentsUpdated)
 						fmt.Printf("  Reactions created: %d\n", adapterResult.ReactionsCreated)
 						fmt.Printf("  Reactions updated: %d\n", adapterResult.ReactionsUpdated)
+						if adapterResult.EventsDeleted > 0 || adapterResult.EventsPurged > 0 {
+							fmt.Printf("  Events deleted: %d\n", adapterResult.EventsDeleted)
+							fmt.Printf("  Events purged: %d\n", adapterResult.EventsPurged)
+						}
 						fmt.Printf("  Duration: %s\n", adapterResult.Duration)
 						if len(adapterResult.Perf) > 0 {
 							// Print a few high-signal perf keys if present.
@@ -1249,6 +1404,8 @@ mnemonic sync --background
 	syncCmd.Flags().String("adapter", "", "Sync specific adapter (e.g., imessage, gmail)")
 	syncCmd.Flags().Bool("full", false, "Force full re-sync instead of incremental")
 	syncCmd.Flags().Bool("background", false, "Run sync in background (writes logs to mnemonic-sync.log)")
+	syncCmd.Flags().Int("concurrency", sync.DefaultConcurrency, "Maximum adapters to sync at once (overrides sync.concurrency)")
+	syncCmd.Flags().Duration("timeout", 0, "Per-adapter sync timeout, e.g. 2h (overrides sync.timeout)")
 
 	// sync status subcommand
 	syncStatusCmd := &cobra.Command{
@@ -1309,6 +1466,9 @@ mnemonic sync --background
 				if j.Cursor != nil && *j.Cursor != "" {
 					fmt.Printf("  Cursor: %s\n", *j.Cursor)
 				}
+				if j.Status == "running" && j.StartedAt != nil {
+					fmt.Printf("  Running for: %s\n", time.Since(time.Unix(*j.StartedAt, 0)).Round(time.Second))
+				}
 				fmt.Printf("  Updated: %s\n", updated)
 				if j.LastError != nil && *j.LastError != "" {
 					fmt.Printf("  Error: %s\n", *j.LastError)
@@ -1342,7 +1502,175 @@ mnemonic sync --background
 			}
 		},
 	}
+	// sync history subcommand
+	syncHistoryCmd := &cobra.Command{
+		Use:   "history [adapter]",
+		Short: "List past sync runs",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool       `json:"ok"`
+				Runs    []sync.Run `json:"runs"`
+				Message string     `json:"message,omitempty"`
+			}
+
+			adapterName := ""
+			if len(args) > 0 {
+				adapterName = args[0]
+			}
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			runs, err := sync.ListRuns(database, adapterName, limit)
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to list runs: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(Result{OK: true, Runs: runs})
+				return
+			}
+			if len(runs) == 0 {
+				fmt.Println("No sync runs recorded yet. Run: mnemonic sync")
+				return
+			}
+
+			fmt.Println("Sync runs:")
+			for _, r := range runs {
+				started := time.Unix(r.StartedAt, 0).Local().Format("2006-01-02 15:04:05")
+				duration := "-"
+				if r.FinishedAt != nil {
+					duration = (time.Duration(*r.FinishedAt-r.StartedAt) * time.Second).String()
+				}
+				mode := "incremental"
+				if r.Full {
+					mode = "full"
+				}
+				fmt.Printf("\n#%d %s  %s  %s (%s, %s)\n", r.ID, r.Adapter, r.Status, started, mode, duration)
+				if r.Result != nil && r.Result.Success {
+					fmt.Printf("  Events: +%d ~%d  Threads: +%d ~%d  Persons: +%d\n",
+						r.Result.EventsCreated, r.Result.EventsUpdated,
+						r.Result.ThreadsCreated, r.Result.ThreadsUpdated,
+						r.Result.PersonsCreated)
+				}
+				if r.WatermarkBefore != nil || r.WatermarkAfter != nil {
+					fmt.Printf("  Watermark: %s -> %s\n", formatWatermark(r.WatermarkBefore), formatWatermark(r.WatermarkAfter))
+				}
+				if r.Error != "" {
+					fmt.Printf("  Error: %s\n", r.Error)
+				}
+			}
+		},
+	}
+	syncHistoryCmd.Flags().Int("limit", 20, "Maximum number of runs to show")
+
+	// sync diff subcommand
+	syncDiffCmd := &cobra.Command{
+		Use:   "diff <run-id>",
+		Short: "Show events, threads and persons changed by a sync run",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool          `json:"ok"`
+				Diff    *sync.RunDiff `json:"diff,omitempty"`
+				Message string        `json:"message,omitempty"`
+			}
+
+			runID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Invalid run id: %s", args[0])}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			diff, err := sync.DiffRun(database, runID, limit)
+			if err != nil {
+				result := Result{OK: false, Message: err.Error()}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(Result{OK: true, Diff: &diff})
+				return
+			}
+
+			r := diff.Run
+			fmt.Printf("Run #%d %s (%s), started %s\n", r.ID, r.Adapter, r.Status,
+				time.Unix(r.StartedAt, 0).Local().Format("2006-01-02 15:04:05"))
+			sections := []struct {
+				title   string
+				changes []sync.RunChange
+			}{
+				{"Events", diff.Events},
+				{"Threads", diff.Threads},
+				{"Persons", diff.Persons},
+			}
+			for _, sec := range sections {
+				fmt.Printf("\n%s (%d):\n", sec.title, len(sec.changes))
+				for _, c := range sec.changes {
+					marker := "+"
+					if c.Action == "updated" {
+						marker = "~"
+					}
+					line := fmt.Sprintf("  %s %s", marker, c.ID)
+					if c.Timestamp > 0 {
+						line += " " + query.FormatTimestamp(c.Timestamp)
+					}
+					if c.Label != "" {
+						line += "  " + c.Label
+					}
+					fmt.Println(line)
+				}
+			}
+			if len(diff.Events) == limit || len(diff.Threads) == limit || len(diff.Persons) == limit {
+				fmt.Printf("\n(showing up to %d per section; use --limit for more)\n", limit)
+			}
+		},
+	}
+	syncDiffCmd.Flags().Int("limit", 100, "Maximum changes to list per section")
+
 	syncCmd.AddCommand(syncStatusCmd)
+	syncCmd.AddCommand(syncHistoryCmd)
+	syncCmd.AddCommand(syncDiffCmd)
 	rootCmd.AddCommand(syncCmd)
 
 	// import command
@@ -1468,57 +1796,1058 @@ mnemonic sync --background
 	importMBoxCmd.Flags().Int("limit", 0, "Only import first N messages (debug)")
 	importMBoxCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
 
-	importCmd.AddCommand(importMBoxCmd)
-	rootCmd.AddCommand(importCmd)
-
-	// watch command
-	watchCmd := &cobra.Command{
-		Use:   "watch",
-		Short: "Run a local webhook receiver to trigger sync",
-	}
-
-	// watch run: run all live-enabled watchers with restart + heartbeats
-	watchRunCmd := &cobra.Command{
-		Use:   "run",
-		Short: "Run all live-enabled adapter watchers",
+	// import slack (workspace export)
+	importSlackCmd := &cobra.Command{
+		Use:   "slack <export.zip|dir>",
+		Short: "Import a Slack workspace export",
+		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			heartbeatSec, _ := cmd.Flags().GetInt("heartbeat-seconds")
-			restartSec, _ := cmd.Flags().GetInt("restart-seconds")
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
 
-			cfg, err := config.Load()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
-				os.Exit(1)
+				Conversations      int    `json:"conversations,omitempty"`
+				MessagesSeen       int    `json:"messages_seen,omitempty"`
+				EventsCreated      int    `json:"events_created,omitempty"`
+				EventsUpdated      int    `json:"events_updated,omitempty"`
+				PersonsCreated     int    `json:"persons_created,omitempty"`
+				ThreadsCreated     int    `json:"threads_created,omitempty"`
+				AttachmentsCreated int    `json:"attachments_created,omitempty"`
+				ReactionsCreated   int    `json:"reactions_created,omitempty"`
+				Duration           string `json:"duration,omitempty"`
 			}
 
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			me, _ := cmd.Flags().GetString("me")
+			limit, _ := cmd.Flags().GetInt("limit")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
 			database, err := db.Open()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
 				os.Exit(1)
 			}
 			defer database.Close()
 
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-			go func() {
-				<-sigChan
-				fmt.Fprintf(os.Stderr, "\nStopping live watchers...\n")
-				cancel()
-			}()
-
-			manager := live.NewManager(database, cfg)
-			if heartbeatSec > 0 {
-				manager.HeartbeatInterval = time.Duration(heartbeatSec) * time.Second
-			}
-			if restartSec > 0 {
-				manager.RestartBackoff = time.Duration(restartSec) * time.Second
+			res, err := importer.ImportSlack(context.Background(), database, importer.SlackImportOptions{
+				AdapterName:   adapterName,
+				Path:          args[0],
+				Me:            me,
+				LimitMessages: limit,
+				DryRun:        dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
 			}
 
-			fmt.Println("Starting live watchers (Ctrl+C to stop)...")
-			if err := manager.Run(ctx); err != nil {
+			result := Result{
+				OK:                 true,
+				Message:            "Slack import completed",
+				Conversations:      res.Conversations,
+				MessagesSeen:       res.MessagesSeen,
+				EventsCreated:      res.EventsCreated,
+				EventsUpdated:      res.EventsUpdated,
+				PersonsCreated:     res.PersonsCreated,
+				ThreadsCreated:     res.ThreadsCreated,
+				AttachmentsCreated: res.AttachmentsCreated,
+				ReactionsCreated:   res.ReactionsCreated,
+				Duration:           res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ Slack import completed")
+				fmt.Printf("  Conversations: %d\n", res.Conversations)
+				fmt.Printf("  Messages seen: %d\n", res.MessagesSeen)
+				fmt.Printf("  Events created: %d\n", res.EventsCreated)
+				fmt.Printf("  Events updated: %d\n", res.EventsUpdated)
+				fmt.Printf("  Threads created: %d\n", res.ThreadsCreated)
+				fmt.Printf("  Attachments created: %d\n", res.AttachmentsCreated)
+				fmt.Printf("  Reactions created: %d\n", res.ReactionsCreated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run: nothing was written)")
+				}
+			}
+		},
+	}
+	importSlackCmd.Flags().String("adapter", "slack", "Adapter instance name (source_adapter for imported rows)")
+	importSlackCmd.Flags().String("me", "", "Your Slack user id or email (marks your messages as sent)")
+	importSlackCmd.Flags().Int("limit", 0, "Only import first N messages (debug)")
+	importSlackCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	// import whatsapp (Export chat)
+	importWhatsAppCmd := &cobra.Command{
+		Use:   "whatsapp <chat.txt|export.zip|dir>",
+		Short: "Import a WhatsApp chat export (with or without media)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				MessagesSeen       int    `json:"messages_seen,omitempty"`
+				SystemMessages     int    `json:"system_messages,omitempty"`
+				EventsCreated      int    `json:"events_created,omitempty"`
+				EventsUpdated      int    `json:"events_updated,omitempty"`
+				PersonsCreated     int    `json:"persons_created,omitempty"`
+				AttachmentsCreated int    `json:"attachments_created,omitempty"`
+				MediaOmitted       int    `json:"media_omitted,omitempty"`
+				InvalidTimestamps  int    `json:"invalid_timestamps,omitempty"`
+				Duration           string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			chat, _ := cmd.Flags().GetString("chat")
+			threadKey, _ := cmd.Flags().GetString("thread-key")
+			meName, _ := cmd.Flags().GetString("me")
+			dateOrder, _ := cmd.Flags().GetString("date-order")
+			tz, _ := cmd.Flags().GetString("tz")
+			limit, _ := cmd.Flags().GetInt("limit")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			loc := time.Local
+			if tz != "" {
+				l, err := time.LoadLocation(tz)
+				if err != nil {
+					result := Result{OK: false, Message: fmt.Sprintf("Invalid --tz: %v", err)}
+					if jsonOutput {
+						printJSON(result)
+					} else {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+					}
+					os.Exit(1)
+				}
+				loc = l
+			}
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportWhatsApp(context.Background(), database, importer.WhatsAppImportOptions{
+				AdapterName:   adapterName,
+				Path:          args[0],
+				Chat:          chat,
+				ThreadKey:     threadKey,
+				Me:            meName,
+				DateOrder:     dateOrder,
+				Location:      loc,
+				LimitMessages: limit,
+				DryRun:        dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:                 true,
+				Message:            "WhatsApp import completed",
+				MessagesSeen:       res.MessagesSeen,
+				SystemMessages:     res.SystemMessages,
+				EventsCreated:      res.EventsCreated,
+				EventsUpdated:      res.EventsUpdated,
+				PersonsCreated:     res.PersonsCreated,
+				AttachmentsCreated: res.AttachmentsCreated,
+				MediaOmitted:       res.MediaOmitted,
+				InvalidTimestamps:  res.InvalidTimestamps,
+				Duration:           res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ WhatsApp import completed")
+				fmt.Printf("  Messages seen: %d\n", res.MessagesSeen)
+				fmt.Printf("  System messages: %d\n", res.SystemMessages)
+				fmt.Printf("  Events created: %d\n", res.EventsCreated)
+				fmt.Printf("  Events updated: %d\n", res.EventsUpdated)
+				fmt.Printf("  Attachments created: %d\n", res.AttachmentsCreated)
+				if res.MediaOmitted > 0 {
+					fmt.Printf("  Media omitted: %d (re-export with media to include files)\n", res.MediaOmitted)
+				}
+				if res.InvalidTimestamps > 0 {
+					fmt.Printf("  Skipped (unparseable timestamp): %d (check --date-order)\n", res.InvalidTimestamps)
+				}
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run: nothing was written)")
+				}
+			}
+		},
+	}
+	importWhatsAppCmd.Flags().String("adapter", "whatsapp", "Adapter instance name (source_adapter for imported rows)")
+	importWhatsAppCmd.Flags().String("chat", "", "Chat name (defaults to the name in the export file name)")
+	importWhatsAppCmd.Flags().String("thread-key", "", "Stable thread key (defaults to the chat name; pass the original name after renaming a chat)")
+	importWhatsAppCmd.Flags().String("me", "", "Your name as it appears in the export (marks your messages as sent)")
+	importWhatsAppCmd.Flags().String("date-order", "auto", "Date order in the export: auto, dmy, mdy or ymd")
+	importWhatsAppCmd.Flags().String("tz", "", "Time zone of the export's timestamps (default: local)")
+	importWhatsAppCmd.Flags().Int("limit", 0, "Only import first N messages (debug)")
+	importWhatsAppCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	// import telegram (Telegram Desktop export)
+	importTelegramCmd := &cobra.Command{
+		Use:   "telegram <result.json|export-dir|export.zip>",
+		Short: "Import a Telegram Desktop JSON export",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Chats              int    `json:"chats,omitempty"`
+				MessagesSeen       int    `json:"messages_seen,omitempty"`
+				ServiceMessages    int    `json:"service_messages,omitempty"`
+				EventsCreated      int    `json:"events_created,omitempty"`
+				EventsUpdated      int    `json:"events_updated,omitempty"`
+				PersonsCreated     int    `json:"persons_created,omitempty"`
+				ThreadsCreated     int    `json:"threads_created,omitempty"`
+				AttachmentsCreated int    `json:"attachments_created,omitempty"`
+				ReactionsCreated   int    `json:"reactions_created,omitempty"`
+				Duration           string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			meID, _ := cmd.Flags().GetString("me")
+			limit, _ := cmd.Flags().GetInt("limit")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportTelegram(context.Background(), database, importer.TelegramImportOptions{
+				AdapterName:   adapterName,
+				Path:          args[0],
+				Me:            meID,
+				LimitMessages: limit,
+				DryRun:        dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:                 true,
+				Message:            "Telegram import completed",
+				Chats:              res.Chats,
+				MessagesSeen:       res.MessagesSeen,
+				ServiceMessages:    res.ServiceMessages,
+				EventsCreated:      res.EventsCreated,
+				EventsUpdated:      res.EventsUpdated,
+				PersonsCreated:     res.PersonsCreated,
+				ThreadsCreated:     res.ThreadsCreated,
+				AttachmentsCreated: res.AttachmentsCreated,
+				ReactionsCreated:   res.ReactionsCreated,
+				Duration:           res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ Telegram import completed")
+				fmt.Printf("  Chats: %d\n", res.Chats)
+				fmt.Printf("  Messages seen: %d\n", res.MessagesSeen)
+				fmt.Printf("  Service messages: %d\n", res.ServiceMessages)
+				fmt.Printf("  Events created: %d\n", res.EventsCreated)
+				fmt.Printf("  Events updated: %d\n", res.EventsUpdated)
+				fmt.Printf("  Attachments created: %d\n", res.AttachmentsCreated)
+				fmt.Printf("  Reactions created: %d\n", res.ReactionsCreated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run: nothing was written)")
+				}
+			}
+		},
+	}
+	importTelegramCmd.Flags().String("adapter", "telegram", "Adapter instance name (source_adapter for imported rows)")
+	importTelegramCmd.Flags().String("me", "", "Your Telegram user id (only needed for single-chat exports)")
+	importTelegramCmd.Flags().Int("limit", 0, "Only import first N messages (debug)")
+	importTelegramCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importSMSBackupCmd := &cobra.Command{
+		Use:   "sms-backup <sms-*.xml|calls-*.xml|backup-dir>",
+		Short: "Import Android SMS, MMS and call logs from SMS Backup & Restore XML",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Files              int    `json:"files,omitempty"`
+				SMSSeen            int    `json:"sms_seen,omitempty"`
+				MMSSeen            int    `json:"mms_seen,omitempty"`
+				CallsSeen          int    `json:"calls_seen,omitempty"`
+				EventsCreated      int    `json:"events_created,omitempty"`
+				EventsUpdated      int    `json:"events_updated,omitempty"`
+				PersonsCreated     int    `json:"persons_created,omitempty"`
+				ThreadsCreated     int    `json:"threads_created,omitempty"`
+				AttachmentsCreated int    `json:"attachments_created,omitempty"`
+				Duration           string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			meNumber, _ := cmd.Flags().GetString("me")
+			mediaDir, _ := cmd.Flags().GetString("media-dir")
+			skipMedia, _ := cmd.Flags().GetBool("skip-media")
+			limit, _ := cmd.Flags().GetInt("limit")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportSMSBackup(context.Background(), database, importer.SMSBackupImportOptions{
+				AdapterName:   adapterName,
+				Path:          args[0],
+				Me:            meNumber,
+				MediaDir:      mediaDir,
+				SkipMedia:     skipMedia,
+				LimitMessages: limit,
+				DryRun:        dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:                 true,
+				Message:            "SMS backup import completed",
+				Files:              res.Files,
+				SMSSeen:            res.SMSSeen,
+				MMSSeen:            res.MMSSeen,
+				CallsSeen:          res.CallsSeen,
+				EventsCreated:      res.EventsCreated,
+				EventsUpdated:      res.EventsUpdated,
+				PersonsCreated:     res.PersonsCreated,
+				ThreadsCreated:     res.ThreadsCreated,
+				AttachmentsCreated: res.AttachmentsCreated,
+				Duration:           res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ SMS backup import completed")
+				fmt.Printf("  Files: %d\n", res.Files)
+				fmt.Printf("  SMS seen: %d\n", res.SMSSeen)
+				fmt.Printf("  MMS seen: %d\n", res.MMSSeen)
+				fmt.Printf("  Calls seen: %d\n", res.CallsSeen)
+				fmt.Printf("  Events created: %d\n", res.EventsCreated)
+				fmt.Printf("  Events updated: %d\n", res.EventsUpdated)
+				fmt.Printf("  Threads created: %d\n", res.ThreadsCreated)
+				fmt.Printf("  Attachments created: %d\n", res.AttachmentsCreated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run: nothing was written)")
+				}
+			}
+		},
+	}
+	importSMSBackupCmd.Flags().String("adapter", "android-sms", "Adapter instance name (source_adapter for imported rows)")
+	importSMSBackupCmd.Flags().String("me", "", "Your phone number (detected from sent MMS when omitted)")
+	importSMSBackupCmd.Flags().String("media-dir", "", "Directory for MMS attachments (default: <data dir>/attachments/<adapter>)")
+	importSMSBackupCmd.Flags().Bool("skip-media", false, "Record MMS attachments without writing their files")
+	importSMSBackupCmd.Flags().Int("limit", 0, "Only import first N records (debug)")
+	importSMSBackupCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importICSCmd := &cobra.Command{
+		Use:   "ics <file.ics|dir>",
+		Short: "Import calendar events from .ics files (Outlook, Fastmail, iCloud exports)",
+		Long: `Import VEVENTs from an .ics file or a directory of them. Recurring events are
+expanded within the --past-days/--future-days window. Events land in the same
+shape as the Google Calendar adapter. To keep a file in sync, configure an
+adapter with type: ics instead (it supports live watching).`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				EventsCreated  int    `json:"events_created,omitempty"`
+				EventsUpdated  int    `json:"events_updated,omitempty"`
+				PersonsCreated int    `json:"persons_created,omitempty"`
+				Files          string `json:"files,omitempty"`
+				Duration       string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			calendarID, _ := cmd.Flags().GetString("calendar-id")
+			pastDays, _ := cmd.Flags().GetInt("past-days")
+			futureDays, _ := cmd.Flags().GetInt("future-days")
+
+			adapter, err := adapters.NewICSAdapter(adapterName, adapters.ICSAdapterOptions{
+				Path:       args[0],
+				CalendarID: calendarID,
+				PastDays:   pastDays,
+				FutureDays: futureDays,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: err.Error()}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := adapter.Sync(context.Background(), database, true)
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:             true,
+				Message:        "ICS import completed",
+				EventsCreated:  res.EventsCreated,
+				EventsUpdated:  res.EventsUpdated,
+				PersonsCreated: res.PersonsCreated,
+				Files:          res.Perf["files"],
+				Duration:       res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ ICS import completed")
+				fmt.Printf("  Files: %s\n", res.Perf["files"])
+				fmt.Printf("  Events created: %d\n", res.EventsCreated)
+				fmt.Printf("  Events updated: %d\n", res.EventsUpdated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+			}
+		},
+	}
+	importICSCmd.Flags().String("adapter", "ics", "Adapter instance name (source_adapter for imported rows)")
+	importICSCmd.Flags().String("calendar-id", "", "Calendar id (default: file base name)")
+	importICSCmd.Flags().Int("past-days", 365, "Expand recurring events this many days into the past")
+	importICSCmd.Flags().Int("future-days", 365, "Expand recurring events this many days into the future")
+
+	importVCardCmd := &cobra.Command{
+		Use:   "vcard <file.vcf|dir>",
+		Short: "Import contacts from vCard (.vcf) exports (iCloud, Outlook, phones)",
+		Long: `Import vCard 2.1, 3.0 and 4.0 contacts. Every phone number and email becomes a
+contact identifier linked to one person per card, reusing a person already
+known from another source. ORG, TITLE, BDAY, typed numbers/emails and photos
+are stored as person facts.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Files           int    `json:"files,omitempty"`
+				Cards           int    `json:"cards,omitempty"`
+				Skipped         int    `json:"skipped,omitempty"`
+				ContactsCreated int    `json:"contacts_created,omitempty"`
+				PersonsCreated  int    `json:"persons_created,omitempty"`
+				FactsWritten    int    `json:"facts_written,omitempty"`
+				PhotosSaved     int    `json:"photos_saved,omitempty"`
+				Duration        string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			photoDir, _ := cmd.Flags().GetString("photo-dir")
+			skipPhotos, _ := cmd.Flags().GetBool("skip-photos")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportVCard(context.Background(), database, importer.VCardImportOptions{
+				AdapterName: adapterName,
+				Path:        args[0],
+				PhotoDir:    photoDir,
+				SkipPhotos:  skipPhotos,
+				DryRun:      dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:              true,
+				Message:         "vCard import completed",
+				Files:           res.Files,
+				Cards:           res.Cards,
+				Skipped:         res.Skipped,
+				ContactsCreated: res.ContactsCreated,
+				PersonsCreated:  res.PersonsCreated,
+				FactsWritten:    res.FactsWritten,
+				PhotosSaved:     res.PhotosSaved,
+				Duration:        res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ vCard import completed")
+				fmt.Printf("  Files: %d\n", res.Files)
+				fmt.Printf("  Cards: %d\n", res.Cards)
+				fmt.Printf("  Skipped (no phone or email): %d\n", res.Skipped)
+				fmt.Printf("  Contacts created: %d\n", res.ContactsCreated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Facts written: %d\n", res.FactsWritten)
+				fmt.Printf("  Photos saved: %d\n", res.PhotosSaved)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run: nothing was written)")
+				}
+			}
+		},
+	}
+	importVCardCmd.Flags().String("adapter", "vcard", "Source name recorded on created contacts")
+	importVCardCmd.Flags().String("photo-dir", "", "Directory for contact photos (default: <data dir>/photos)")
+	importVCardCmd.Flags().Bool("skip-photos", false, "Ignore PHOTO properties")
+	importVCardCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	// aiChatImportRun builds the Run func shared by the ChatGPT and Claude
+	// export importers.
+	aiChatImportRun := func(label string, importFn func(context.Context, *sql.DB, importer.AIChatImportOptions) (importer.AIChatImportResult, error)) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Conversations   int    `json:"conversations,omitempty"`
+				SessionsCreated int    `json:"sessions_created,omitempty"`
+				Messages        int    `json:"messages,omitempty"`
+				Turns           int    `json:"turns,omitempty"`
+				EventsCreated   int    `json:"events_created,omitempty"`
+				EventsUpdated   int    `json:"events_updated,omitempty"`
+				ThreadsCreated  int    `json:"threads_created,omitempty"`
+				Duration        string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importFn(context.Background(), database, importer.AIChatImportOptions{
+				AdapterName: adapterName,
+				Path:        args[0],
+				DryRun:      dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:              true,
+				Message:         label + " import completed",
+				Conversations:   res.Conversations,
+				SessionsCreated: res.SessionsCreated,
+				Messages:        res.Messages,
+				Turns:           res.Turns,
+				EventsCreated:   res.EventsCreated,
+				EventsUpdated:   res.EventsUpdated,
+				ThreadsCreated:  res.ThreadsCreated,
+				Duration:        res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Printf("✓ %s import completed\n", label)
+				fmt.Printf("  Conversations: %d (%d new)\n", res.Conversations, res.SessionsCreated)
+				fmt.Printf("  Messages: %d\n", res.Messages)
+				fmt.Printf("  Turns: %d\n", res.Turns)
+				fmt.Printf("  Events: %d created, %d updated\n", res.EventsCreated, res.EventsUpdated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run, nothing written)")
+				}
+			}
+		}
+	}
+
+	importChatGPTCmd := &cobra.Command{
+		Use:   "chatgpt <conversations.json|export dir|export.zip>",
+		Short: "Import a ChatGPT data export into the agents ledger",
+		Long: `Import the conversations.json from an OpenAI data export into agent_sessions,
+agent_messages and agent_turns. Regenerated replies and edited prompts are
+branches of the conversation tree and become sibling turns with the same
+parent_turn_id. Each turn is also written to the events ledger (channel
+"chatgpt") so web chats show up in search and route.`,
+		Args: cobra.ExactArgs(1),
+		Run:  aiChatImportRun("ChatGPT", importer.ImportChatGPT),
+	}
+	importChatGPTCmd.Flags().String("adapter", "chatgpt", "Adapter name to attribute events to")
+	importChatGPTCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importClaudeCmd := &cobra.Command{
+		Use:   "claude <conversations.json|export dir|export.zip>",
+		Short: "Import a Claude (claude.ai) data export into the agents ledger",
+		Long: `Import the conversations.json from an Anthropic data export into agent_sessions,
+agent_messages and agent_turns, following parent_message_uuid branches when
+present. Each turn is also written to the events ledger (channel "claude")
+with thinking and tool blocks dropped.`,
+		Args: cobra.ExactArgs(1),
+		Run:  aiChatImportRun("Claude", importer.ImportClaude),
+	}
+	importClaudeCmd.Flags().String("adapter", "claude", "Adapter name to attribute events to")
+	importClaudeCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importXCmd := &cobra.Command{
+		Use:   "x <archive.zip|dir>",
+		Short: "Import an X (Twitter) data archive: tweets, likes and DMs",
+		Long: `Import the official X data archive (Settings > Your account > Download an
+archive). Tweets and likes are written with the same source ids as the bird
+adapter, so the two dedupe; likes bird already synced are left untouched.
+DMs become threads, with direction taken from the archive owner's account id.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Tweets         int    `json:"tweets,omitempty"`
+				Likes          int    `json:"likes,omitempty"`
+				LikesExisting  int    `json:"likes_existing,omitempty"`
+				DMs            int    `json:"dms,omitempty"`
+				Conversations  int    `json:"conversations,omitempty"`
+				EventsCreated  int    `json:"events_created,omitempty"`
+				EventsUpdated  int    `json:"events_updated,omitempty"`
+				PersonsCreated int    `json:"persons_created,omitempty"`
+				ThreadsCreated int    `json:"threads_created,omitempty"`
+				Duration       string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportXArchive(context.Background(), database, importer.XArchiveImportOptions{
+				AdapterName: adapterName,
+				Path:        args[0],
+				DryRun:      dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:             true,
+				Message:        "X archive import completed",
+				Tweets:         res.Tweets,
+				Likes:          res.Likes,
+				LikesExisting:  res.LikesExisting,
+				DMs:            res.DMs,
+				Conversations:  res.Conversations,
+				EventsCreated:  res.EventsCreated,
+				EventsUpdated:  res.EventsUpdated,
+				PersonsCreated: res.PersonsCreated,
+				ThreadsCreated: res.ThreadsCreated,
+				Duration:       res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ X archive import completed")
+				fmt.Printf("  Tweets: %d\n", res.Tweets)
+				fmt.Printf("  Likes: %d (%d already synced by bird)\n", res.Likes, res.LikesExisting)
+				fmt.Printf("  DMs: %d in %d conversations\n", res.DMs, res.Conversations)
+				fmt.Printf("  Events: %d created, %d updated\n", res.EventsCreated, res.EventsUpdated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run, nothing written)")
+				}
+			}
+		},
+	}
+	importXCmd.Flags().String("adapter", "x", "Adapter name to attribute events to (keep \"x\" to dedupe with bird)")
+	importXCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importTranscriptCmd := &cobra.Command{
+		Use:   "transcript <file>",
+		Short: "Import a meeting transcript (WebVTT, SRT or speaker-labelled text)",
+		Long: `Import a meeting transcript as a "meeting" thread with one event per
+utterance. Speakers are resolved to contacts, preferring the attendees of a
+calendar event that overlaps the recording, which also names the thread.
+
+The recording is assumed to end at the file's modification time; pass --start
+when that is wrong (e.g. "2024-05-01T15:00:00-07:00").`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+
+				Format          string `json:"format,omitempty"`
+				Utterances      int    `json:"utterances,omitempty"`
+				Speakers        int    `json:"speakers,omitempty"`
+				EventsCreated   int    `json:"events_created,omitempty"`
+				EventsUpdated   int    `json:"events_updated,omitempty"`
+				PersonsCreated  int    `json:"persons_created,omitempty"`
+				ThreadID        string `json:"thread_id,omitempty"`
+				CalendarEventID string `json:"calendar_event_id,omitempty"`
+				Duration        string `json:"duration,omitempty"`
+			}
+
+			adapterName, _ := cmd.Flags().GetString("adapter")
+			title, _ := cmd.Flags().GetString("title")
+			startStr, _ := cmd.Flags().GetString("start")
+			meLabel, _ := cmd.Flags().GetString("me")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var startTime time.Time
+			if startStr != "" {
+				t, err := time.Parse(time.RFC3339, startStr)
+				if err != nil {
+					t, err = time.ParseInLocation("2006-01-02 15:04", startStr, time.Local)
+				}
+				if err != nil {
+					result := Result{OK: false, Message: fmt.Sprintf("Invalid --start %q (use RFC3339 or \"YYYY-MM-DD HH:MM\")", startStr)}
+					if jsonOutput {
+						printJSON(result)
+					} else {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+					}
+					os.Exit(1)
+				}
+				startTime = t
+			}
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			res, err := importer.ImportTranscript(context.Background(), database, importer.TranscriptImportOptions{
+				AdapterName: adapterName,
+				Path:        args[0],
+				Title:       title,
+				Start:       startTime,
+				Me:          meLabel,
+				DryRun:      dryRun,
+			})
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Import failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{
+				OK:              true,
+				Message:         "Transcript import completed",
+				Format:          res.Format,
+				Utterances:      res.Utterances,
+				Speakers:        res.Speakers,
+				EventsCreated:   res.EventsCreated,
+				EventsUpdated:   res.EventsUpdated,
+				PersonsCreated:  res.PersonsCreated,
+				ThreadID:        res.ThreadID,
+				CalendarEventID: res.CalendarEventID,
+				Duration:        res.Duration.String(),
+			}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Println("✓ Transcript import completed")
+				fmt.Printf("  Format: %s\n", res.Format)
+				fmt.Printf("  Utterances: %d from %d speakers\n", res.Utterances, res.Speakers)
+				fmt.Printf("  Thread: %s\n", res.ThreadID)
+				if res.CalendarEventID != "" {
+					fmt.Printf("  Calendar event: %s\n", res.CalendarEventID)
+				} else {
+					fmt.Println("  Calendar event: none overlapping")
+				}
+				fmt.Printf("  Events: %d created, %d updated\n", res.EventsCreated, res.EventsUpdated)
+				fmt.Printf("  Persons created: %d\n", res.PersonsCreated)
+				fmt.Printf("  Duration: %s\n", res.Duration)
+				if dryRun {
+					fmt.Println("  (dry run, nothing written)")
+				}
+			}
+		},
+	}
+	importTranscriptCmd.Flags().String("adapter", "transcript", "Adapter name to attribute events to")
+	importTranscriptCmd.Flags().String("title", "", "Meeting title (default: the linked calendar event, else the file name)")
+	importTranscriptCmd.Flags().String("start", "", "When the recording started (default: file modification time minus its length)")
+	importTranscriptCmd.Flags().String("me", "", "Speaker label that is you, if it differs from your name")
+	importTranscriptCmd.Flags().Bool("dry-run", false, "Parse and count but do not write to database")
+
+	importCmd.AddCommand(importMBoxCmd)
+	importCmd.AddCommand(importSlackCmd)
+	importCmd.AddCommand(importWhatsAppCmd)
+	importCmd.AddCommand(importTelegramCmd)
+	importCmd.AddCommand(importSMSBackupCmd)
+	importCmd.AddCommand(importICSCmd)
+	importCmd.AddCommand(importVCardCmd)
+	importCmd.AddCommand(importChatGPTCmd)
+	importCmd.AddCommand(importClaudeCmd)
+	importCmd.AddCommand(importXCmd)
+	importCmd.AddCommand(importTranscriptCmd)
+	rootCmd.AddCommand(importCmd)
+
+	// Export command
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export data to interchange formats",
+	}
+
+	exportVCardCmd := &cobra.Command{
+		Use:   "vcard",
+		Short: "Export persons with their phones, emails and facts as vCards",
+		Long: `Write one vCard 3.0 per person that has at least one phone, email or handle.
+Merged persons are written once with every identifier linked to them. Output
+goes to stdout unless --out is given.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool   `json:"ok"`
+				Message string `json:"message,omitempty"`
+				Path    string `json:"path,omitempty"`
+				Persons int    `json:"persons,omitempty"`
+			}
+
+			outPath, _ := cmd.Flags().GetString("out")
+			skipPhotos, _ := cmd.Flags().GetBool("skip-photos")
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			var w io.Writer = os.Stdout
+			var f *os.File
+			if outPath != "" {
+				f, err = os.Create(outPath)
+				if err != nil {
+					result := Result{OK: false, Message: fmt.Sprintf("Failed to create %s: %v", outPath, err)}
+					if jsonOutput {
+						printJSON(result)
+					} else {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+					}
+					os.Exit(1)
+				}
+				w = f
+			} else if jsonOutput {
+				result := Result{OK: false, Message: "--out is required with --json"}
+				printJSON(result)
+				os.Exit(1)
+			}
+
+			res, err := importer.ExportVCard(context.Background(), database, w, importer.VCardExportOptions{SkipPhotos: skipPhotos})
+			if f != nil {
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Export failed: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			if outPath == "" {
+				return
+			}
+			result := Result{OK: true, Message: "vCard export completed", Path: outPath, Persons: res.Persons}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Printf("✓ Exported %d persons to %s\n", res.Persons, outPath)
+			}
+		},
+	}
+	exportVCardCmd.Flags().String("out", "", "Write to this .vcf file instead of stdout")
+	exportVCardCmd.Flags().Bool("skip-photos", false, "Omit contact photos")
+
+	exportCmd.AddCommand(exportVCardCmd)
+	rootCmd.AddCommand(exportCmd)
+
+	// watch command
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Run a local webhook receiver to trigger sync",
+	}
+
+	// watch run: run all live-enabled watchers with restart + heartbeats
+	watchRunCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run all live-enabled adapter watchers",
+		Run: func(cmd *cobra.Command, args []string) {
+			heartbeatSec, _ := cmd.Flags().GetInt("heartbeat-seconds")
+			restartSec, _ := cmd.Flags().GetInt("restart-seconds")
+
+			cfg, err := config.Load()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+				os.Exit(1)
+			}
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				fmt.Fprintf(os.Stderr, "\nStopping live watchers...\n")
+				cancel()
+			}()
+
+			manager := live.NewManager(database, cfg)
+			if heartbeatSec > 0 {
+				manager.HeartbeatInterval = time.Duration(heartbeatSec) * time.Second
+			}
+			if restartSec > 0 {
+				manager.RestartBackoff = time.Duration(restartSec) * time.Second
+			}
+
+			fmt.Println("Starting live watchers (Ctrl+C to stop)...")
+			if err := manager.Run(ctx); err != nil {
 				fmt.Fprintf(os.Stderr, "watch run error: %v\n", err)
 				os.Exit(1)
 			}
@@ -1543,16 +2872,22 @@ mnemonic sync --background
 				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
 				os.Exit(1)
 			}
-			defer database.Close()
+			defer database.Close()
+
+			statuses, err := live.GetStatuses(database, cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get status: %v\n", err)
+				os.Exit(1)
+			}
 
-			statuses, err := live.GetStatuses(database, cfg)
+			pipelines, err := live.GetPipelineStatuses(database, cfg)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: failed to get status: %v\n", err)
 				os.Exit(1)
 			}
 
 			if jsonOutput {
-				printJSON(map[string]any{"ok": true, "watchers": statuses})
+				printJSON(map[string]any{"ok": true, "watchers": statuses, "pipelines": pipelines})
 				return
 			}
 
@@ -1571,6 +2906,30 @@ mnemonic sync --background
 					fmt.Printf("  last_error: %s\n", st.LastError)
 				}
 			}
+
+			for _, p := range pipelines {
+				lastBeat := "-"
+				if p.LastHeartbeat != nil {
+					lastBeat = time.Unix(*p.LastHeartbeat, 0).Format(time.RFC3339)
+				}
+				status := p.Status
+				if status == "" {
+					status = "unknown"
+				}
+				fmt.Printf("pipeline %s (%s) enabled=%v status=%s last_heartbeat=%s pending=%d backlog=%d restarts=%d\n",
+					p.Pipeline, p.Definition, p.Enabled, status, lastBeat, p.Pending, p.Backlog, p.Restarts)
+				if run := p.LastRun; run != nil {
+					fmt.Printf("  last_run: %s threads=%d episodes=%d new/%d extended analysis=%d embeddings=%d\n",
+						time.Unix(run.FinishedAt, 0).Format(time.RFC3339), run.Threads,
+						run.EpisodesCreated, run.EpisodesExtended, run.AnalysisQueued, run.EmbeddingsQueued)
+					if run.Error != "" {
+						fmt.Printf("  last_run_error: %s\n", run.Error)
+					}
+				}
+				if p.LastError != "" {
+					fmt.Printf("  last_error: %s\n", p.LastError)
+				}
+			}
 		},
 	}
 
@@ -1898,17 +3257,424 @@ mnemonic sync --background
 			}
 		},
 	}
-	busListCmd.Flags().Int64("since", 0, "Only show events with seq > since")
-	busListCmd.Flags().Int("limit", 200, "Max events to return")
+	busListCmd.Flags().Int64("since", 0, "Only show events with seq > since")
+	busListCmd.Flags().Int("limit", 200, "Max events to return")
+
+	busTailCmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Tail bus events (optionally follow)",
+		Run: func(cmd *cobra.Command, args []string) {
+			since, _ := cmd.Flags().GetInt64("since")
+			consumer, _ := cmd.Flags().GetString("consumer")
+			follow, _ := cmd.Flags().GetBool("follow")
+			intervalSec, _ := cmd.Flags().GetInt("interval-seconds")
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			emit := func(events []bus.Event) {
+				for _, e := range events {
+					t := time.Unix(e.CreatedAt, 0).Local().Format(time.RFC3339)
+					adapter := ""
+					if e.Adapter != nil {
+						adapter = *e.Adapter
+					}
+					ce := ""
+					if e.CommsEvent != nil {
+						ce = *e.CommsEvent
+					}
+					fmt.Printf("%d\t%s\t%s\t%s\t%s\n", e.Seq, t, e.Type, adapter, ce)
+					since = e.Seq
+				}
+			}
+
+			for {
+				var events []bus.Event
+				if consumer != "" {
+					// Ack only after the events are printed, so a crash
+					// mid-batch redelivers instead of skipping.
+					deliveries, err := bus.Receive(database, consumer, limit)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: failed to receive bus events: %v\n", err)
+						os.Exit(1)
+					}
+					for _, d := range deliveries {
+						events = append(events, d.Event)
+					}
+				} else {
+					events, err = bus.List(database, since, limit)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: failed to list bus events: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				if jsonOutput {
+					printJSON(map[string]any{"ok": true, "events": events})
+				} else {
+					emit(events)
+				}
+				if consumer != "" && len(events) > 0 {
+					seqs := make([]int64, len(events))
+					for i, e := range events {
+						seqs[i] = e.Seq
+					}
+					if _, err := bus.Ack(database, consumer, seqs...); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: failed to ack bus events: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				if !follow {
+					return
+				}
+				if intervalSec <= 0 {
+					intervalSec = 1
+				}
+				time.Sleep(time.Duration(intervalSec) * time.Second)
+			}
+		},
+	}
+	busTailCmd.Flags().Int64("since", 0, "Start tailing from seq > since")
+	busTailCmd.Flags().Bool("follow", true, "Keep polling for new events")
+	busTailCmd.Flags().Int("interval-seconds", 1, "Polling interval in seconds when following")
+	busTailCmd.Flags().Int("limit", 200, "Max events per poll")
+	busTailCmd.Flags().String("consumer", "", "Read through a durable consumer, acking what is printed (ignores --since)")
+
+	printDeliveries := func(deliveries []bus.Delivery) {
+		for _, d := range deliveries {
+			t := time.Unix(d.CreatedAt, 0).Local().Format(time.RFC3339)
+			adapter := ""
+			if d.Adapter != nil {
+				adapter = *d.Adapter
+			}
+			ce := ""
+			if d.CommsEvent != nil {
+				ce = *d.CommsEvent
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\tattempt=%d\n", d.Seq, t, d.Type, adapter, ce, d.Attempt)
+		}
+	}
+
+	parseSeqs := func(args []string) []int64 {
+		seqs := make([]int64, 0, len(args))
+		for _, arg := range args {
+			seq, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid seq %q\n", arg)
+				os.Exit(1)
+			}
+			seqs = append(seqs, seq)
+		}
+		return seqs
+	}
+
+	busConsumersCmd := &cobra.Command{
+		Use:   "consumers",
+		Short: "List durable bus consumers and their lag",
+		Long: `List durable bus consumers and their lag.
+
+A consumer has a stored cursor. Each event it receives stays in flight until
+it is acked; unacked events are redelivered after the ack timeout and
+dead-lettered after max attempts. Lag is unread plus in-flight events.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			consumers, err := bus.ListConsumers(database)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to list consumers: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "consumers": consumers})
+				return
+			}
+
+			if len(consumers) == 0 {
+				fmt.Println("No bus consumers. Create one with 'mnemonic bus consumers create <name>'.")
+				return
+			}
+			fmt.Printf("%-28s %8s %8s %8s %8s %8s %8s\n", "CONSUMER", "CURSOR", "UNREAD", "INFLIGHT", "OVERDUE", "DEAD", "LAG")
+			for _, c := range consumers {
+				fmt.Printf("%-28s %8d %8d %8d %8d %8d %8d\n", c.Name, c.Cursor, c.Unread, c.InFlight, c.Overdue, c.Dead, c.Lag)
+			}
+		},
+	}
+
+	busConsumersCreateCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a durable bus consumer",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ackTimeout, _ := cmd.Flags().GetDuration("ack-timeout")
+			maxAttempts, _ := cmd.Flags().GetInt("max-attempts")
+			fromStart, _ := cmd.Flags().GetBool("from-start")
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			c, err := bus.CreateConsumer(database, args[0], bus.ConsumerOptions{
+				AckTimeout:  ackTimeout,
+				MaxAttempts: maxAttempts,
+				FromStart:   fromStart,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "consumer": c})
+				return
+			}
+			fmt.Printf("✓ Created consumer %s at seq %d (ack timeout %ds, max attempts %d)\n", c.Name, c.Cursor, c.AckTimeout, c.MaxAttempts)
+		},
+	}
+	busConsumersCreateCmd.Flags().Duration("ack-timeout", bus.DefaultAckTimeout, "Redeliver events not acked within this long")
+	busConsumersCreateCmd.Flags().Int("max-attempts", bus.DefaultMaxAttempts, "Dead-letter events after this many deliveries")
+	busConsumersCreateCmd.Flags().Bool("from-start", false, "Deliver the whole bus history instead of only new events")
+
+	busConsumersDeleteCmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a bus consumer and its pending deliveries",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			if err := bus.DeleteConsumer(database, args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true})
+				return
+			}
+			fmt.Printf("✓ Deleted consumer %s\n", args[0])
+		},
+	}
+
+	busReceiveCmd := &cobra.Command{
+		Use:   "receive <consumer>",
+		Short: "Receive events for a consumer (ack or nack each one)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			deliveries, err := bus.Receive(database, args[0], limit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "events": deliveries})
+				return
+			}
+			printDeliveries(deliveries)
+		},
+	}
+	busReceiveCmd.Flags().Int("limit", 100, "Max events to receive")
+
+	busAckCmd := &cobra.Command{
+		Use:   "ack <consumer> <seq>...",
+		Short: "Acknowledge received events",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			seqs := parseSeqs(args[1:])
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			acked, err := bus.Ack(database, args[0], seqs...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "acked": acked})
+				return
+			}
+			fmt.Printf("✓ Acked %d of %d events\n", acked, len(seqs))
+		},
+	}
+
+	busNackCmd := &cobra.Command{
+		Use:   "nack <consumer> <seq>",
+		Short: "Return a received event for redelivery",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			delay, _ := cmd.Flags().GetDuration("delay")
+			reason, _ := cmd.Flags().GetString("reason")
+			seq := parseSeqs(args[1:])[0]
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			dead, err := bus.Nack(database, args[0], seq, delay, reason)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "dead": dead})
+				return
+			}
+			if dead {
+				fmt.Printf("✓ Event %d used its attempts and was dead-lettered\n", seq)
+			} else {
+				fmt.Printf("✓ Event %d will be redelivered\n", seq)
+			}
+		},
+	}
+	busNackCmd.Flags().Duration("delay", 0, "Wait this long before redelivering")
+	busNackCmd.Flags().String("reason", "", "Error to record with the event")
+
+	busDeadCmd := &cobra.Command{
+		Use:   "dead <consumer>",
+		Short: "List a consumer's dead-lettered events",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			dead, err := bus.ListDead(database, args[0], limit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "events": dead})
+				return
+			}
+			if len(dead) == 0 {
+				fmt.Println("No dead-lettered events.")
+				return
+			}
+			for _, d := range dead {
+				t := time.Unix(d.CreatedAt, 0).Local().Format(time.RFC3339)
+				fmt.Printf("%d\t%s\t%s\tattempts=%d\t%s\n", d.Seq, t, d.Type, d.Attempts, d.LastError)
+			}
+		},
+	}
+	busDeadCmd.Flags().Int("limit", 100, "Max events to list")
+
+	busRedriveCmd := &cobra.Command{
+		Use:   "redrive <consumer> [seq...]",
+		Short: "Redeliver dead-lettered events (all of them unless seqs are given)",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			seqs := parseSeqs(args[1:])
+
+			database, err := db.Open()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to open database: %v\n", err)
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			n, err := bus.Redrive(database, args[0], seqs...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if jsonOutput {
+				printJSON(map[string]any{"ok": true, "redriven": n})
+				return
+			}
+			fmt.Printf("✓ Redriving %d events\n", n)
+		},
+	}
 
-	busTailCmd := &cobra.Command{
-		Use:   "tail",
-		Short: "Tail bus events (optionally follow)",
+	busServeCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Stream bus events over SSE and deliver configured webhooks",
+		Long: `Stream bus events over Server-Sent Events and deliver configured webhooks.
+
+GET <path> streams events as they are emitted. Each message's id is the
+event seq, so EventSource clients resume from Last-Event-ID after a
+reconnect; ?since=<seq> picks the starting point otherwise. Filter with
+?type= (glob patterns such as event.*), ?adapter= and ?channel=.
+
+Webhooks under bus.webhooks in the config are POSTed one event at a time,
+signed with HMAC-SHA256 when a secret is set, and retried with backoff.
+Each reads the bus as consumer webhook:<name>; see 'bus consumers' and
+'bus dead webhook:<name>'.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			since, _ := cmd.Flags().GetInt64("since")
-			follow, _ := cmd.Flags().GetBool("follow")
+			bind, _ := cmd.Flags().GetString("bind")
+			port, _ := cmd.Flags().GetInt("port")
+			path, _ := cmd.Flags().GetString("path")
+			token, _ := cmd.Flags().GetString("token")
 			intervalSec, _ := cmd.Flags().GetInt("interval-seconds")
-			limit, _ := cmd.Flags().GetInt("limit")
+			noWebhooks, _ := cmd.Flags().GetBool("no-webhooks")
+			if intervalSec <= 0 {
+				intervalSec = 1
+			}
+			interval := time.Duration(intervalSec) * time.Second
+
+			cfg, err := config.Load()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+				os.Exit(1)
+			}
+
+			var hooks []*bus.Webhook
+			if !noWebhooks {
+				var names []string
+				for name, hookCfg := range cfg.Bus.Webhooks {
+					if hookCfg.Enabled {
+						names = append(names, name)
+					}
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					hook, err := bus.NewWebhook(name, cfg.Bus.Webhooks[name])
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+					hooks = append(hooks, hook)
+				}
+			}
 
 			database, err := db.Open()
 			if err != nil {
@@ -1917,53 +3683,160 @@ mnemonic sync --background
 			}
 			defer database.Close()
 
-			emit := func(events []bus.Event) {
-				for _, e := range events {
-					t := time.Unix(e.CreatedAt, 0).Local().Format(time.RFC3339)
-					adapter := ""
-					if e.Adapter != nil {
-						adapter = *e.Adapter
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				fmt.Fprintf(os.Stderr, "\nStopping bus server...\n")
+				cancel()
+			}()
+
+			logf := func(format string, args ...any) {
+				fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+			}
+			for _, hook := range hooks {
+				go func(hook *bus.Webhook) {
+					for ctx.Err() == nil {
+						if err := hook.Run(ctx, database, interval, logf); err != nil {
+							logf("webhook %s error: %v (restarting)", hook.Name, err)
+							select {
+							case <-ctx.Done():
+							case <-time.After(5 * time.Second):
+							}
+						}
 					}
-					ce := ""
-					if e.CommsEvent != nil {
-						ce = *e.CommsEvent
+				}(hook)
+			}
+
+			sse := bus.SSEHandler(database, interval)
+			mux := http.NewServeMux()
+			mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+				if token != "" {
+					ok := false
+					// EventSource cannot set headers, so ?token= is accepted too.
+					auth := r.Header.Get("Authorization")
+					if strings.HasPrefix(strings.ToLower(auth), "bearer ") && strings.TrimSpace(auth[7:]) == token {
+						ok = true
+					}
+					if r.URL.Query().Get("token") == token {
+						ok = true
+					}
+					if !ok {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
 					}
-					fmt.Printf("%d\t%s\t%s\t%s\t%s\n", e.Seq, t, e.Type, adapter, ce)
-					since = e.Seq
 				}
+				sse.ServeHTTP(w, r)
+			})
+
+			addr := fmt.Sprintf("%s:%d", bind, port)
+			fmt.Printf("Streaming bus events on http://%s%s (Ctrl+C to stop)\n", addr, path)
+			if token != "" {
+				fmt.Println("  (token configured)")
+			}
+			for _, hook := range hooks {
+				fmt.Printf("Delivering webhook %s to %s\n", hook.Name, hook.URL)
 			}
 
-			for {
-				events, err := bus.List(database, since, limit)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error: failed to list bus events: %v\n", err)
+			srv := &http.Server{
+				Addr:    addr,
+				Handler: mux,
+				// Open streams end with ctx instead of holding up Shutdown.
+				BaseContext: func(net.Listener) context.Context { return ctx },
+			}
+			go func() {
+				<-ctx.Done()
+				_ = srv.Shutdown(context.Background())
+			}()
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "bus server stopped: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	busServeCmd.Flags().String("bind", "127.0.0.1", "Bind address")
+	busServeCmd.Flags().Int("port", 8798, "Listen port")
+	busServeCmd.Flags().String("path", "/events", "SSE endpoint path")
+	busServeCmd.Flags().String("token", "", "Shared token (Authorization Bearer or ?token=)")
+	busServeCmd.Flags().Int("interval-seconds", 1, "How often to poll the bus for new events")
+	busServeCmd.Flags().Bool("no-webhooks", false, "Only serve SSE; do not deliver configured webhooks")
+
+	busTypesCmd := &cobra.Command{
+		Use:   "types [type]",
+		Short: "List bus event types and their payload schemas",
+		Long: `List the bus event catalogue.
+
+Every event on the bus has one of these types, and its payload_json
+follows the type's JSON Schema at the recorded version. Pass a type, or
+--schema for all of them, to print the schemas.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			showSchema, _ := cmd.Flags().GetBool("schema")
+
+			types := bus.Types()
+			if len(args) == 1 {
+				t, ok := bus.LookupType(args[0])
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Error: unknown bus event type %q (see 'mnemonic bus types')\n", args[0])
 					os.Exit(1)
 				}
+				types = []bus.EventType{t}
+				showSchema = true
+			}
+
+			if jsonOutput || showSchema {
+				type typeInfo struct {
+					bus.EventType
+					Schema map[string]any `json:"schema"`
+				}
+				out := make([]typeInfo, 0, len(types))
+				for _, t := range types {
+					out = append(out, typeInfo{EventType: t, Schema: t.Schema()})
+				}
 				if jsonOutput {
-					printJSON(map[string]any{"ok": true, "events": events})
-					if !follow {
-						return
-					}
-				} else {
-					emit(events)
-					if !follow {
-						return
-					}
+					printJSON(map[string]any{"ok": true, "types": out})
+					return
 				}
-				if intervalSec <= 0 {
-					intervalSec = 1
+				if len(out) == 1 {
+					printJSON(out[0].Schema)
+					return
 				}
-				time.Sleep(time.Duration(intervalSec) * time.Second)
+				schemas := make([]map[string]any, 0, len(out))
+				for _, t := range out {
+					schemas = append(schemas, t.Schema)
+				}
+				printJSON(schemas)
+				return
+			}
+
+			fmt.Printf("%-20s %7s  %-5s  %s\n", "TYPE", "VERSION", "EVENT", "DESCRIPTION")
+			for _, t := range types {
+				scoped := "no"
+				if t.EventScoped {
+					scoped = "yes"
+				}
+				fmt.Printf("%-20s %7d  %-5s  %s\n", t.Name, t.Version, scoped, t.Description)
 			}
 		},
 	}
-	busTailCmd.Flags().Int64("since", 0, "Start tailing from seq > since")
-	busTailCmd.Flags().Bool("follow", true, "Keep polling for new events")
-	busTailCmd.Flags().Int("interval-seconds", 1, "Polling interval in seconds when following")
-	busTailCmd.Flags().Int("limit", 200, "Max events per poll")
+	busTypesCmd.Flags().Bool("schema", false, "Print the JSON Schema of every type")
+
+	busConsumersCmd.AddCommand(busConsumersCreateCmd)
+	busConsumersCmd.AddCommand(busConsumersDeleteCmd)
 
 	busCmd.AddCommand(busListCmd)
 	busCmd.AddCommand(busTailCmd)
+	busCmd.AddCommand(busConsumersCmd)
+	busCmd.AddCommand(busReceiveCmd)
+	busCmd.AddCommand(busAckCmd)
+	busCmd.AddCommand(busNackCmd)
+	busCmd.AddCommand(busDeadCmd)
+	busCmd.AddCommand(busRedriveCmd)
+	busCmd.AddCommand(busServeCmd)
+	busCmd.AddCommand(busTypesCmd)
 	rootCmd.AddCommand(busCmd)
 
 	// identify command
@@ -2982,6 +4855,13 @@ The algorithm runs in three phases:
 				Role string `json:"role"`
 			}
 
+			type RevisionInfo struct {
+				Revision   int    `json:"revision"`
+				Content    string `json:"content"`
+				ObservedAt int64  `json:"observed_at"`
+				ReplacedAt int64  `json:"replaced_at"`
+			}
+
 			type EventInfo struct {
 				ID           string            `json:"id"`
 				Timestamp    int64             `json:"timestamp"`
@@ -2993,6 +4873,7 @@ The algorithm runs in three phases:
 				ThreadID     *string           `json:"thread_id,omitempty"`
 				ReplyTo      *string           `json:"reply_to,omitempty"`
 				Participants []ParticipantInfo `json:"participants"`
+				Revisions    []RevisionInfo    `json:"revisions,omitempty"`
 			}
 
 			type Result struct {
@@ -3009,13 +4890,17 @@ The algorithm runs in three phases:
 			untilStr, _ := cmd.Flags().GetString("until")
 			direction, _ := cmd.Flags().GetString("direction")
 			limit, _ := cmd.Flags().GetInt("limit")
+			includeDeleted, _ := cmd.Flags().GetBool("include-deleted")
+			showRevisions, _ := cmd.Flags().GetBool("show-revisions")
 
 			// Build filters
 			filters := query.EventFilters{
-				PersonName: personName,
-				Channel:    channel,
-				Direction:  direction,
-				Limit:      limit,
+				PersonName:     personName,
+				Channel:        channel,
+				Direction:      direction,
+				Limit:          limit,
+				IncludeDeleted: includeDeleted,
+				ShowRevisions:  showRevisions,
 			}
 
 			// Parse since date
@@ -3124,6 +5009,14 @@ The algorithm runs in three phases:
 						Role: p.Role,
 					})
 				}
+				for _, r := range e.Revisions {
+					eventInfo.Revisions = append(eventInfo.Revisions, RevisionInfo{
+						Revision:   r.Revision,
+						Content:    r.Content,
+						ObservedAt: r.ObservedAt,
+						ReplacedAt: r.ReplacedAt,
+					})
+				}
 
 				result.Events = append(result.Events, eventInfo)
 			}
@@ -3187,6 +5080,20 @@ The algorithm runs in three phases:
 						fmt.Printf("Content: %s\n", content)
 					}
 
+					if len(e.Revisions) > 0 {
+						fmt.Println("Revisions:")
+						for _, r := range e.Revisions {
+							content := r.Content
+							if len(content) > 200 {
+								content = content[:200] + "..."
+							}
+							if content == "" {
+								content = "(empty)"
+							}
+							fmt.Printf("  %d. [%s] %s\n", r.Revision, query.FormatTimestamp(r.ObservedAt), content)
+						}
+					}
+
 					if e.ThreadID != nil {
 						fmt.Printf("Thread: %s\n", *e.ThreadID)
 					}
@@ -3201,6 +5108,8 @@ The algorithm runs in three phases:
 	eventsCmd.Flags().String("until", "", "Filter by end date (YYYY-MM-DD)")
 	eventsCmd.Flags().String("direction", "", "Filter by direction (sent, received, observed)")
 	eventsCmd.Flags().Int("limit", 100, "Maximum number of events to return")
+	eventsCmd.Flags().Bool("include-deleted", false, "Include events deleted at the source")
+	eventsCmd.Flags().Bool("show-revisions", false, "Show earlier content of edited events")
 	rootCmd.AddCommand(eventsCmd)
 
 	// people command
@@ -4659,6 +6568,164 @@ Examples:
 
 	dbQueryCmd.Flags().Bool("write", false, "Allow mutation queries (INSERT, UPDATE, DELETE, etc.)")
 	dbCmd.AddCommand(dbQueryCmd)
+
+	// db fts commands
+	dbFTSCmd := &cobra.Command{
+		Use:   "fts",
+		Short: "Maintain full-text search indexes",
+		Long: `Check, rebuild or optimize the FTS5 indexes over events, thread names,
+attachment filenames and document titles.
+
+The indexes are kept current by triggers, so none of these are needed
+after a normal sync. Use check after a crash or manual edits, rebuild to
+repair an index that check reports, and optimize after large imports.`,
+	}
+
+	dbFTSCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Verify FTS indexes against their source tables",
+		Run: func(cmd *cobra.Command, args []string) {
+			type Result struct {
+				OK      bool           `json:"ok"`
+				Message string         `json:"message,omitempty"`
+				Indexes []db.FTSStatus `json:"indexes,omitempty"`
+			}
+
+			database, err := db.Open()
+			if err != nil {
+				result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			defer database.Close()
+
+			statuses, err := db.CheckFTS(database)
+			if err != nil {
+				result := Result{OK: false, Message: err.Error()}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+
+			result := Result{OK: true, Indexes: statuses}
+			for _, st := range statuses {
+				if !st.OK {
+					result.OK = false
+				}
+			}
+
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				for _, st := range statuses {
+					if st.OK {
+						fmt.Printf("✓ %s: %d/%d rows\n", st.Name, st.IndexedRows, st.SourceRows)
+					} else {
+						fmt.Printf("✗ %s: %s\n", st.Name, st.Error)
+					}
+				}
+				if !result.OK {
+					fmt.Println("\nRun 'mnemonic db fts rebuild' to repair.")
+				}
+			}
+			if !result.OK {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// runFTSMaintenance applies op to the named index, or to all of them.
+	runFTSMaintenance := func(args []string, verb string, op func(*sql.DB, db.FTSIndex) error) {
+		type Result struct {
+			OK      bool     `json:"ok"`
+			Message string   `json:"message,omitempty"`
+			Indexes []string `json:"indexes,omitempty"`
+		}
+
+		indexes := db.FTSIndexes
+		if len(args) > 0 {
+			idx, ok := db.LookupFTSIndex(args[0])
+			if !ok {
+				result := Result{OK: false, Message: fmt.Sprintf("Unknown FTS index '%s'", args[0])}
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			indexes = []db.FTSIndex{idx}
+		}
+
+		database, err := db.Open()
+		if err != nil {
+			result := Result{OK: false, Message: fmt.Sprintf("Failed to open database: %v", err)}
+			if jsonOutput {
+				printJSON(result)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+			}
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		result := Result{OK: true}
+		for _, idx := range indexes {
+			start := time.Now()
+			if err := op(database, idx); err != nil {
+				result.OK = false
+				result.Message = err.Error()
+				if jsonOutput {
+					printJSON(result)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+				}
+				os.Exit(1)
+			}
+			result.Indexes = append(result.Indexes, idx.Name)
+			if !jsonOutput {
+				fmt.Printf("✓ %s %s in %s\n", verb, idx.Name, time.Since(start).Round(time.Millisecond))
+			}
+		}
+		if jsonOutput {
+			printJSON(result)
+		}
+	}
+
+	dbFTSRebuildCmd := &cobra.Command{
+		Use:   "rebuild [index]",
+		Short: "Rebuild FTS indexes from their source tables",
+		Long: `Rebuild one FTS index, or all of them, from the source table.
+
+An index can be named by its FTS table (events_fts) or its source table
+(events). Each rebuild is a single transaction, so searches keep using the
+old index until it finishes.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runFTSMaintenance(args, "Rebuilt", db.RebuildFTS)
+		},
+	}
+
+	dbFTSOptimizeCmd := &cobra.Command{
+		Use:   "optimize [index]",
+		Short: "Merge FTS index segments",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runFTSMaintenance(args, "Optimized", db.OptimizeFTS)
+		},
+	}
+
+	dbFTSCmd.AddCommand(dbFTSCheckCmd)
+	dbFTSCmd.AddCommand(dbFTSRebuildCmd)
+	dbFTSCmd.AddCommand(dbFTSOptimizeCmd)
+	dbCmd.AddCommand(dbFTSCmd)
 	rootCmd.AddCommand(dbCmd)
 
 	// chunk command
@@ -4937,6 +7004,23 @@ Examples:
 			}
 			created++
 
+			// Web chat exports (import chatgpt / import claude) use the same turn shape
+			for _, webChannel := range []string{"chatgpt", "claude"} {
+				name := webChannel + "_turn_pair"
+				_, err = chunk.CreateDefinition(ctx, database, name, webChannel, "turn_pair", chunk.TurnPairConfig{},
+					"Web chat turn pairs (user prompt + assistant reply)")
+				if err != nil {
+					result := Result{OK: false, Message: fmt.Sprintf("Failed to create %s: %v", name, err)}
+					if jsonOutput {
+						printJSON(result)
+					} else {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", result.Message)
+					}
+					os.Exit(1)
+				}
+				created++
+			}
+
 			result := Result{OK: true, Created: created}
 
 			if jsonOutput {
@@ -4948,6 +7032,7 @@ Examples:
 				fmt.Println("  - single_event (one event per segment)")
 				fmt.Println("  - ai_session (AI sessions by thread)")
 				fmt.Println("  - ai_turn_pair (AI turn pairs, includes tools)")
+				fmt.Println("  - chatgpt_turn_pair, claude_turn_pair (web chat turn pairs)")
 			}
 		},
 	}
@@ -5712,6 +7797,7 @@ Conversation:
 	var searchChannel string
 	var searchLimit int
 	var searchModel string
+	var searchIncludeDeleted bool
 
 	searchCmd := &cobra.Command{
 		Use:   "search [query]",
@@ -5791,11 +7877,12 @@ Examples:
 
 			searcher := search.NewSearcher(database, embedder)
 			resp, err := searcher.SearchSegments(ctx, search.SegmentSearchRequest{
-				Query:         queryText,
-				Channel:       searchChannel,
-				Limit:         searchLimit,
-				Model:         model,
-				UseEmbeddings: true,
+				Query:          queryText,
+				Channel:        searchChannel,
+				Limit:          searchLimit,
+				Model:          model,
+				UseEmbeddings:  true,
+				IncludeDeleted: searchIncludeDeleted,
 			})
 			if err != nil {
 				result := Result{OK: false, Query: queryText, Message: fmt.Sprintf("Failed to search segments: %v", err)}
@@ -5823,7 +7910,7 @@ Examples:
 
 			// Get preview for top results
 			for i := range results {
-				preview, _ := getEpisodePreview(ctx, database, results[i].EpisodeID, 200)
+				preview, _ := getEpisodePreview(ctx, database, results[i].EpisodeID, 200, searchIncludeDeleted)
 				results[i].Preview = preview
 			}
 
@@ -5868,6 +7955,7 @@ Examples:
 	searchCmd.Flags().StringVar(&searchChannel, "channel", "", "Filter by channel (imessage, gmail, aix, etc.)")
 	searchCmd.Flags().IntVar(&searchLimit, "limit", 10, "Maximum number of results")
 	searchCmd.Flags().StringVar(&searchModel, "model", "gemini-embedding-001", "Embedding model to use")
+	searchCmd.Flags().BoolVar(&searchIncludeDeleted, "include-deleted", false, "Include events deleted at the source")
 	rootCmd.AddCommand(searchCmd)
 
 	// route command - candidate segments for routing
@@ -5985,7 +8073,7 @@ Examples:
 			}
 
 			for i := range candidates {
-				preview, _ := getEpisodePreview(cmd.Context(), database, candidates[i].EpisodeID, 200)
+				preview, _ := getEpisodePreview(cmd.Context(), database, candidates[i].EpisodeID, 200, false)
 				candidates[i].Preview = preview
 			}
 
@@ -6040,6 +8128,7 @@ Examples:
 	var docSearchEmbeddings bool
 	var docSearchLexical bool
 	var docSearchTrack bool
+	var docSearchIncludeDeleted bool
 
 	documentsCmd := &cobra.Command{
 		Use:   "documents",
@@ -6118,6 +8207,7 @@ Examples:
 				UseEmbeddings:  useEmbeddings,
 				UseLexical:     useLexical,
 				TrackRetrieval: docSearchTrack,
+				IncludeDeleted: docSearchIncludeDeleted,
 			})
 			if err != nil {
 				if jsonOutput {
@@ -6162,6 +8252,7 @@ Examples:
 	documentsSearchCmd.Flags().BoolVar(&docSearchEmbeddings, "embeddings", true, "Enable embedding-based search")
 	documentsSearchCmd.Flags().BoolVar(&docSearchLexical, "lexical", true, "Enable lexical search")
 	documentsSearchCmd.Flags().BoolVar(&docSearchTrack, "track", false, "Track retrieval metrics")
+	documentsSearchCmd.Flags().BoolVar(&docSearchIncludeDeleted, "include-deleted", false, "Include documents deleted at the source")
 
 	// documents index - index skills and docs into document_heads
 	var indexSkillsPath string
@@ -7383,72 +9474,41 @@ func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
 }
 
-// checkAdapterStatus checks if an adapter's prerequisites are met
-func checkAdapterStatus(name string, adapter config.AdapterConfig) string {
-	switch adapter.Type {
-	case "eve":
-		// Check if Eve database exists
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return "error"
-		}
-		eveDBPath := filepath.Join(home, "Library", "Application Support", "Eve", "eve.db")
-		if _, err := os.Stat(eveDBPath); os.IsNotExist(err) {
-			return "missing Eve database"
-		}
-		return "ready"
-
-	case "gogcli":
-		// Check if account is configured
-		if account, ok := adapter.Options["account"].(string); ok && account != "" {
-			// TODO: Check if gogcli is installed and authenticated
-			// For now, assume ready if account is configured
-			return "ready (check gogcli auth)"
-		}
-		return "missing account"
-
-	case "aix", "aix-events", "aix-agents":
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return "error"
-		}
-		aixDBPath := filepath.Join(home, "Library", "Application Support", "aix", "aix.db")
-		if _, err := os.Stat(aixDBPath); os.IsNotExist(err) {
-			return "missing aix database (run: aix sync --all)"
-		}
-		if source, ok := adapter.Options["source"].(string); ok && source != "" {
-			return "ready"
-		}
-		return "missing source"
-
-	case "nexus":
-		eventsDir := ""
-		if v, ok := adapter.Options["events_dir"].(string); ok && v != "" {
-			eventsDir = v
-		} else if v, ok := adapter.Options["state_dir"].(string); ok && v != "" {
-			eventsDir = filepath.Join(v, "events")
-		} else {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return "error"
-			}
-			eventsDir = filepath.Join(home, "nexus", "state", "events")
-		}
-		if _, err := os.Stat(eventsDir); os.IsNotExist(err) {
-			return "missing nexus events dir"
-		}
-		return "ready"
+// formatWatermark renders a sync watermark (unix seconds) for display.
+func formatWatermark(ts *int64) string {
+	if ts == nil {
+		return "none"
+	}
+	return time.Unix(*ts, 0).Local().Format("2006-01-02 15:04:05")
+}
 
-	case "bird":
-		// Check if bird CLI is available
-		if _, err := exec.LookPath("bird"); err != nil {
-			return "bird not installed (brew install steipete/tap/bird)"
-		}
-		return "ready"
+// setAdapterConfig validates an adapter config against the registry before
+// storing it, so connect never writes options that sync would reject.
+func setAdapterConfig(cfg *config.Config, name string, adapterCfg config.AdapterConfig) error {
+	if err := adapters.ValidateConfig(adapterCfg); err != nil {
+		return err
+	}
+	if cfg.Adapters == nil {
+		cfg.Adapters = map[string]config.AdapterConfig{}
+	}
+	cfg.Adapters[name] = adapterCfg
+	return nil
+}
 
-	default:
-		return "unknown adapter type"
+// mustSetAdapterConfig is setAdapterConfig for connect commands: an invalid
+// config is reported (as JSON with --json) and exits.
+func mustSetAdapterConfig(cfg *config.Config, name string, adapterCfg config.AdapterConfig) {
+	err := setAdapterConfig(cfg, name, adapterCfg)
+	if err == nil {
+		return
 	}
+	message := fmt.Sprintf("Invalid adapter config: %v", err)
+	if jsonOutput {
+		printJSON(map[string]any{"ok": false, "message": message})
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	}
+	os.Exit(1)
 }
 
 // blobToFloat64Slice converts embedding blob to float64 slice (little-endian)
@@ -7488,7 +9548,11 @@ func cosineSimilarity(a, b []float64) float64 {
 }
 
 // getEpisodePreview returns a text preview of an episode
-func getEpisodePreview(ctx context.Context, database *sql.DB, episodeID string, maxLen int) (string, error) {
+func getEpisodePreview(ctx context.Context, database *sql.DB, episodeID string, maxLen int, includeDeleted bool) (string, error) {
+	deletedFilter := ""
+	if !includeDeleted {
+		deletedFilter = " AND " + ingest.NotDeleted("e")
+	}
 	rows, err := database.QueryContext(ctx, `
 		SELECT e.content, COALESCE(p.canonical_name, c.display_name)
 		FROM episode_events ee
@@ -7501,7 +9565,7 @@ func getEpisodePreview(ctx context.Context, database *sql.DB, episodeID string,
 			ORDER BY confidence DESC, last_seen_at DESC
 			LIMIT 1
 		)
-		WHERE ee.episode_id = ?
+		WHERE ee.episode_id = ?`+deletedFilter+`
 		ORDER BY ee.position
 		LIMIT 5
 	`, episodeID)
@@ -27,7 +27,9 @@ type SyncResult struct {
 	AttachmentsUpdated int
 	ReactionsCreated   int
 	ReactionsUpdated   int
-	Duration           time.Duration
+	// EventsDeleted counts events the source reported as deleted.
+	EventsDeleted int
+	Duration      time.Duration
 	// Perf is an optional breakdown of phase timings (human-readable durations).
 	Perf map[string]string `json:"perf,omitempty"`
 }
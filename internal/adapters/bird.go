@@ -199,8 +199,13 @@ func (b *BirdAdapter) syncTweets(cortexDB *sql.DB, tweets []BirdTweet, interacti
 		// Check if this was an insert
 		var existingEventID string
 		row := cortexDB.QueryRow("SELECT id FROM events WHERE source_adapter = ? AND source_id = ?", b.Name(), sourceID)
-		if err := row.Scan(&existingEventID); err == nil && existingEventID == eventID {
-			eventsCreated++
+		if err := row.Scan(&existingEventID); err == nil {
+			if existingEventID == eventID {
+				eventsCreated++
+			}
+			// The row may predate this sync (or come from the X archive
+			// importer); participants must point at its real id.
+			eventID = existingEventID
 		}
 
 		// Create contact for author
@@ -0,0 +1,426 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register(Registration{
+		Type:        "eve",
+		Description: "iMessage via Eve's database",
+		Live:        true,
+		LiveOptions: []string{
+			"debounce_seconds", "eve_db", "ensure_upstream", "upstream_cmd", "upstream_poll_ms",
+			"upstream_debounce_ms", "upstream_forward_comms", "upstream_pid_file", "upstream_check_seconds",
+		},
+		Factory: func(name string, _ any) (Adapter, error) {
+			// Reads from ~/Library/Application Support/Eve/eve.db. Useful for full
+			// rebuilds when Eve has already materialized a clean dataset.
+			return NewEveAdapter()
+		},
+		Status: func(_ any) string {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "error"
+			}
+			eveDBPath := filepath.Join(home, "Library", "Application Support", "Eve", "eve.db")
+			if _, err := os.Stat(eveDBPath); os.IsNotExist(err) {
+				return "missing Eve database"
+			}
+			return "ready"
+		},
+		Doctor: eveDoctor,
+	})
+
+	Register(Registration{
+		Type:        "gogcli",
+		Description: "Gmail via gogcli",
+		Live:        true,
+		LiveOptions: []string{
+			"bind", "port", "path", "token", "debounce_seconds", "adapter", "ensure_upstream",
+			"upstream_cmd", "upstream_bind", "upstream_port", "upstream_path", "upstream_token",
+			"upstream_pid_file", "upstream_check_seconds",
+		},
+		NewOptions: func() any { return &GoogleSyncOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			o := opts.(*GoogleSyncOptions)
+			// Standardize instance name across installs: gmail-<account email>
+			return NewGmailAdapter(googleInstanceName("gmail", o.Account), o.Account, GmailAdapterOptions{
+				Workers: o.Workers,
+				QPS:     o.QPS,
+			})
+		},
+		Status: gogStatus,
+		Doctor: gogDoctor("gmail", "search", "newer_than:1d", "--max", "1", "--json"),
+	})
+
+	Register(Registration{
+		Type:        "gogcli_calendar",
+		Description: "Google Calendar via gogcli",
+		Live:        true,
+		LiveOptions: []string{"poll_seconds", "jitter_seconds"},
+		NewOptions:  func() any { return &GoogleAccountOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			o := opts.(*GoogleAccountOptions)
+			return NewCalendarAdapter(googleInstanceName("calendar", o.Account), o.Account)
+		},
+		Status: gogStatus,
+		Doctor: gogDoctor("calendar", "calendars", "--max", "1", "--json"),
+	})
+
+	Register(Registration{
+		Type:        "ics",
+		Description: "iCalendar (.ics) file or directory",
+		Live:        true,
+		LiveOptions: []string{"debounce_seconds"},
+		NewOptions:  func() any { return &ICSAdapterOptions{} },
+		Factory: func(name string, opts any) (Adapter, error) {
+			return NewICSAdapter(name, *opts.(*ICSAdapterOptions))
+		},
+		Status: func(opts any) string {
+			if _, err := os.Stat(opts.(*ICSAdapterOptions).Path); err != nil {
+				return fmt.Sprintf("missing ics path: %s", opts.(*ICSAdapterOptions).Path)
+			}
+			return "ready"
+		},
+		Doctor: func(_ context.Context, opts any) []Check {
+			return []Check{checkPath("path", opts.(*ICSAdapterOptions).Path, "point 'path' at an .ics file or a directory of them")}
+		},
+	})
+
+	Register(Registration{
+		Type:        "maildir",
+		Description: "Local Maildir (mbsync, offlineimap)",
+		Live:        true,
+		LiveOptions: []string{"debounce_seconds"},
+		NewOptions:  func() any { return &MaildirAdapterOptions{} },
+		Factory: func(name string, opts any) (Adapter, error) {
+			return NewMaildirAdapter(name, *opts.(*MaildirAdapterOptions))
+		},
+		Status: func(opts any) string {
+			if _, err := os.Stat(opts.(*MaildirAdapterOptions).Path); err != nil {
+				return fmt.Sprintf("missing maildir path: %s", opts.(*MaildirAdapterOptions).Path)
+			}
+			return "ready"
+		},
+		Doctor: func(_ context.Context, opts any) []Check {
+			return []Check{checkPath("path", opts.(*MaildirAdapterOptions).Path, "point 'path' at the Maildir root your mail sync tool writes to")}
+		},
+	})
+
+	Register(Registration{
+		Type:        "vault",
+		Description: "Markdown notes directory (Obsidian vault)",
+		NewOptions:  func() any { return &VaultAdapterOptions{} },
+		Factory: func(name string, opts any) (Adapter, error) {
+			return NewVaultAdapter(name, *opts.(*VaultAdapterOptions))
+		},
+		Status: func(opts any) string {
+			if _, err := os.Stat(opts.(*VaultAdapterOptions).Path); err != nil {
+				return fmt.Sprintf("missing vault path: %s", opts.(*VaultAdapterOptions).Path)
+			}
+			return "ready"
+		},
+		Doctor: func(_ context.Context, opts any) []Check {
+			return []Check{checkPath("path", opts.(*VaultAdapterOptions).Path, "point 'path' at the vault's root directory")}
+		},
+	})
+
+	Register(Registration{
+		Type:        "gogcli_contacts",
+		Description: "Google Contacts via gogcli",
+		Live:        true,
+		LiveOptions: []string{"poll_seconds", "jitter_seconds"},
+		NewOptions:  func() any { return &GoogleSyncOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			o := opts.(*GoogleSyncOptions)
+			return NewContactsAdapter(googleInstanceName("contacts", o.Account), o.Account, ContactsAdapterOptions{
+				Workers: o.Workers,
+				QPS:     o.QPS,
+			})
+		},
+		Status: gogStatus,
+		Doctor: gogDoctor("contacts", "list", "--max", "1", "--json"),
+	})
+
+	Register(Registration{
+		Type:        "aix",
+		Description: "AI coding sessions via aix",
+		Live:        true,
+		LiveOptions: []string{
+			"debounce_seconds", "extract_metadata", "ensure_upstream", "upstream_cmd", "upstream_poll_ms",
+			"upstream_debounce_ms", "upstream_pid_file", "upstream_check_seconds",
+		},
+		NewOptions: func() any { return &AixConfigOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			return NewAixAdapter(opts.(*AixConfigOptions).Source)
+		},
+		Status: aixStatus,
+		Doctor: aixDoctor,
+	})
+
+	Register(Registration{
+		Type:        "aix-events",
+		Description: "aix trimmed turns into the events ledger",
+		NewOptions:  func() any { return &AixConfigOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			return NewAixEventsAdapter(opts.(*AixConfigOptions).Source)
+		},
+		Status: aixStatus,
+		Doctor: aixDoctor,
+	})
+
+	Register(Registration{
+		Type:        "aix-agents",
+		Description: "aix sessions into the agents ledger",
+		NewOptions:  func() any { return &AixConfigOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			return NewAixAgentsAdapter(opts.(*AixConfigOptions).Source)
+		},
+		Status: aixStatus,
+		Doctor: aixDoctor,
+	})
+
+	Register(Registration{
+		Type:        "nexus",
+		Description: "Nexus JSONL event logs",
+		Live:        true,
+		LiveOptions: []string{"debounce_seconds"},
+		NewOptions:  func() any { return &NexusAdapterOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			return NewNexusAdapter(*opts.(*NexusAdapterOptions))
+		},
+		Status: func(opts any) string {
+			eventsDir, err := nexusEventsDir(opts.(*NexusAdapterOptions))
+			if err != nil {
+				return "error"
+			}
+			if _, err := os.Stat(eventsDir); os.IsNotExist(err) {
+				return "missing nexus events dir"
+			}
+			return "ready"
+		},
+		Doctor: nexusDoctor,
+	})
+
+	Register(Registration{
+		Type:        "bird",
+		Description: "X/Twitter via bird",
+		NewOptions:  func() any { return &BirdConfigOptions{} },
+		Factory: func(_ string, opts any) (Adapter, error) {
+			return NewBirdAdapter(opts.(*BirdConfigOptions).Username)
+		},
+		Status: func(_ any) string {
+			if _, err := exec.LookPath("bird"); err != nil {
+				return "bird not installed (brew install steipete/tap/bird)"
+			}
+			return "ready"
+		},
+		Doctor: func(ctx context.Context, _ any) []Check {
+			return probeChain(
+				func() Check { return checkBinary("bird", "brew install steipete/tap/bird") },
+				func() Check {
+					return checkCommand(ctx, "auth", "log in to x.com in your browser, then re-run 'bird whoami'", "bird", "whoami", "--plain")
+				},
+			)
+		},
+	})
+
+	Register(Registration{
+		Type:        "tabular",
+		Description: "CSV or JSONL export mapped by config",
+		NewOptions:  func() any { return &TabularAdapterOptions{} },
+		Factory: func(name string, opts any) (Adapter, error) {
+			return NewTabularAdapter(name, *opts.(*TabularAdapterOptions))
+		},
+		Status: func(opts any) string {
+			if _, err := os.Stat(opts.(*TabularAdapterOptions).Path); err != nil {
+				return fmt.Sprintf("missing path: %s", opts.(*TabularAdapterOptions).Path)
+			}
+			return "ready"
+		},
+		Doctor: func(_ context.Context, opts any) []Check {
+			return []Check{checkPath("path", opts.(*TabularAdapterOptions).Path, "re-download the export or update 'path'")}
+		},
+	})
+
+	Register(Registration{
+		Type:        "exec",
+		Description: "External extractor speaking the NDJSON exec protocol",
+		NewOptions:  func() any { return &ExecAdapterOptions{} },
+		Factory: func(name string, opts any) (Adapter, error) {
+			return NewExecAdapter(name, *opts.(*ExecAdapterOptions))
+		},
+		Status: func(opts any) string {
+			command := opts.(*ExecAdapterOptions).Command
+			if _, err := exec.LookPath(command); err != nil {
+				return fmt.Sprintf("command not found: %s", command)
+			}
+			return "ready"
+		},
+		Doctor: func(_ context.Context, opts any) []Check {
+			return []Check{checkBinary(opts.(*ExecAdapterOptions).Command, "install the extractor or set 'command' to its full path")}
+		},
+	})
+}
+
+// GoogleAccountOptions configures adapters keyed by a Google account.
+type GoogleAccountOptions struct {
+	Account string `yaml:"account"`
+}
+
+func (o *GoogleAccountOptions) Validate() error {
+	if o.Account == "" {
+		return fmt.Errorf("'account' is required (e.g. user@gmail.com)")
+	}
+	return nil
+}
+
+// GoogleSyncOptions is the config.yaml shape for the gogcli and
+// gogcli_contacts types, which fan out fetches across workers.
+type GoogleSyncOptions struct {
+	Account string  `yaml:"account"`
+	Workers int     `yaml:"workers"`
+	QPS     float64 `yaml:"qps"`
+}
+
+func (o *GoogleSyncOptions) Validate() error {
+	if o.Account == "" {
+		return fmt.Errorf("'account' is required (e.g. user@gmail.com)")
+	}
+	if o.Workers < 0 || o.QPS < 0 {
+		return fmt.Errorf("'workers' and 'qps' must not be negative")
+	}
+	return nil
+}
+
+// AixConfigOptions is the config.yaml shape for the aix adapter family.
+type AixConfigOptions struct {
+	Source string `yaml:"source"`
+}
+
+func (o *AixConfigOptions) Validate() error {
+	if o.Source == "" {
+		return fmt.Errorf("'source' is required (e.g. cursor)")
+	}
+	return nil
+}
+
+// BirdConfigOptions is the config.yaml shape for type bird.
+type BirdConfigOptions struct {
+	Username string `yaml:"username"`
+}
+
+func googleInstanceName(prefix, account string) string {
+	return fmt.Sprintf("%s-%s", prefix, strings.TrimSpace(strings.ToLower(account)))
+}
+
+func gogStatus(_ any) string {
+	if _, err := exec.LookPath("gog"); err != nil {
+		return "gogcli not installed (brew install steipete/tap/gogcli)"
+	}
+	return "ready (check gogcli auth)"
+}
+
+func aixStatus(_ any) string {
+	aixDBPath, err := defaultAixDBPath()
+	if err != nil {
+		return "error"
+	}
+	if _, err := os.Stat(aixDBPath); os.IsNotExist(err) {
+		return "missing aix database (run: aix sync --all)"
+	}
+	return "ready"
+}
+
+func gogDoctor(probe ...string) func(context.Context, any) []Check {
+	return func(ctx context.Context, opts any) []Check {
+		var account string
+		switch o := opts.(type) {
+		case *GoogleSyncOptions:
+			account = o.Account
+		case *GoogleAccountOptions:
+			account = o.Account
+		}
+		args := append(append([]string{}, probe...), "--account", account)
+		return probeChain(
+			func() Check { return checkBinary("gog", "brew install steipete/tap/gogcli") },
+			func() Check {
+				return checkCommand(ctx, "auth", fmt.Sprintf("re-authorize %s with gogcli (see 'gog auth --help')", account), "gog", args...)
+			},
+		)
+	}
+}
+
+func eveDoctor(_ context.Context, _ any) []Check {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return []Check{{Name: "database", Status: CheckFail, Detail: err.Error()}}
+	}
+	eveDBPath := filepath.Join(home, "Library", "Application Support", "Eve", "eve.db")
+	return probeChain(
+		func() Check {
+			return checkPath("database", eveDBPath, "install Eve and let it finish its first import")
+		},
+		func() Check {
+			return checkSQLiteSchema(eveDBPath,
+				[]string{"chats", "messages", "attachments", "reactions", "membership_events", "contacts", "contact_identifiers"},
+				"update Eve; its database predates the tables this adapter reads")
+		},
+	)
+}
+
+func aixDoctor(_ context.Context, _ any) []Check {
+	aixDBPath, err := defaultAixDBPath()
+	if err != nil {
+		return []Check{{Name: "database", Status: CheckFail, Detail: err.Error()}}
+	}
+	return probeChain(
+		func() Check {
+			return checkPath("database", aixDBPath, "run 'aix sync --all', or set AIX_DB_PATH if the database moved")
+		},
+		func() Check {
+			return checkSQLiteSchema(aixDBPath,
+				[]string{"sessions", "messages", "message_metadata", "turns"},
+				"update aix and re-run 'aix sync --all'")
+		},
+	)
+}
+
+func nexusEventsDir(o *NexusAdapterOptions) (string, error) {
+	if o.EventsDir != "" {
+		return o.EventsDir, nil
+	}
+	if o.StateDir != "" {
+		return filepath.Join(o.StateDir, "events"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "nexus", "state", "events"), nil
+}
+
+func nexusDoctor(_ context.Context, opts any) []Check {
+	eventsDir, err := nexusEventsDir(opts.(*NexusAdapterOptions))
+	if err != nil {
+		return []Check{{Name: "events dir", Status: CheckFail, Detail: err.Error()}}
+	}
+	return probeChain(
+		func() Check {
+			return checkPath("events dir", eventsDir, "start Nexus once so it creates its state dir, or set 'events_dir'")
+		},
+		func() Check {
+			logs, _ := filepath.Glob(filepath.Join(eventsDir, "*.jsonl"))
+			if len(logs) == 0 {
+				return Check{Name: "event logs", Status: CheckWarn, Detail: "no *.jsonl files yet", Fix: "run a Nexus command to produce an event log"}
+			}
+			return Check{Name: "event logs", Status: CheckOK, Detail: fmt.Sprintf("%d files", len(logs))}
+		},
+	)
+}
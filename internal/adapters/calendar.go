@@ -168,22 +168,26 @@ func (c *CalendarAdapter) fetchEvents(ctx context.Context, calendarID string, fr
 }
 
 func parseEventStartUTC(e gogCalendarEvent) (int64, error) {
-	if e.Start.DateTime != "" {
-		t, err := time.Parse(time.RFC3339, e.Start.DateTime)
+	return parseEventTimeUTC(e.Start)
+}
+
+func parseEventTimeUTC(t gogEventTime) (int64, error) {
+	if t.DateTime != "" {
+		parsed, err := time.Parse(time.RFC3339, t.DateTime)
 		if err != nil {
 			return 0, err
 		}
-		return t.UTC().Unix(), nil
+		return parsed.UTC().Unix(), nil
 	}
-	if e.Start.Date != "" {
+	if t.Date != "" {
 		// All-day: interpret as midnight UTC.
-		t, err := time.Parse("2006-01-02", e.Start.Date)
+		parsed, err := time.Parse("2006-01-02", t.Date)
 		if err != nil {
 			return 0, err
 		}
-		return t.UTC().Unix(), nil
+		return parsed.UTC().Unix(), nil
 	}
-	return 0, fmt.Errorf("missing start time")
+	return 0, fmt.Errorf("missing time")
 }
 
 func (c *CalendarAdapter) getOrCreateContactByEmail(db *sql.DB, email, displayName string, cache map[string]string) (string, bool, error) {
@@ -203,28 +207,38 @@ func (c *CalendarAdapter) getOrCreateContactByEmail(db *sql.DB, email, displayNa
 	return contactID, created, nil
 }
 
-func (c *CalendarAdapter) upsertEvent(db *sql.DB, eventID string, ts int64, content string, threadID string, sourceID string) (created bool, updated bool, err error) {
+func (c *CalendarAdapter) upsertEvent(db *sql.DB, eventID string, ts int64, content string, threadID string, sourceID string, metadata map[string]any) (created bool, updated bool, err error) {
 	contentTypes := `["calendar_event"]`
 	direction := "observed"
+	var metadataJSON any
+	if len(metadata) > 0 {
+		b, err := json.Marshal(metadata)
+		if err != nil {
+			return false, false, err
+		}
+		metadataJSON = string(b)
+	}
 
 	res, err := db.Exec(`
 		INSERT OR IGNORE INTO events (
 			id, timestamp, channel, content_types, content,
-			direction, thread_id, reply_to, source_adapter, source_id
-		) VALUES (?, ?, 'calendar', ?, ?, ?, ?, '', ?, ?)
-	`, eventID, ts, contentTypes, content, direction, threadID, c.Name(), sourceID)
+			direction, thread_id, reply_to, source_adapter, source_id, metadata_json
+		) VALUES (?, ?, 'calendar', ?, ?, ?, ?, '', ?, ?, ?)
+	`, eventID, ts, contentTypes, content, direction, threadID, c.Name(), sourceID, metadataJSON)
 	if err != nil {
 		return false, false, err
 	}
 	if n, _ := res.RowsAffected(); n > 0 {
-		_ = bus.Emit(db, "cortex.event.created", c.Name(), eventID, map[string]any{
-			"channel":        "calendar",
-			"direction":      direction,
-			"timestamp":      ts,
-			"thread_id":      threadID,
-			"source_id":      sourceID,
-			"source_adapter": c.Name(),
-		})
+		if err := bus.Emit(db, bus.TypeEventCreated, c.Name(), eventID, bus.EventPayload{
+			Channel:       "calendar",
+			Direction:     direction,
+			Timestamp:     ts,
+			ThreadID:      threadID,
+			SourceAdapter: c.Name(),
+			SourceID:      sourceID,
+		}); err != nil {
+			return false, false, err
+		}
 		return true, false, nil
 	}
 
@@ -233,21 +247,24 @@ func (c *CalendarAdapter) upsertEvent(db *sql.DB, eventID string, ts int64, cont
 		SET
 			timestamp = ?,
 			content = ?,
-			thread_id = ?
+			thread_id = ?,
+			metadata_json = COALESCE(?, metadata_json)
 		WHERE source_adapter = ? AND source_id = ?
-	`, ts, content, threadID, c.Name(), sourceID)
+	`, ts, content, threadID, metadataJSON, c.Name(), sourceID)
 	if err != nil {
 		return false, false, err
 	}
 	if n, _ := res.RowsAffected(); n > 0 {
-		_ = bus.Emit(db, "cortex.event.updated", c.Name(), eventID, map[string]any{
-			"channel":        "calendar",
-			"direction":      direction,
-			"timestamp":      ts,
-			"thread_id":      threadID,
-			"source_id":      sourceID,
-			"source_adapter": c.Name(),
-		})
+		if err := bus.Emit(db, bus.TypeEventUpdated, c.Name(), eventID, bus.EventPayload{
+			Channel:       "calendar",
+			Direction:     direction,
+			Timestamp:     ts,
+			ThreadID:      threadID,
+			SourceAdapter: c.Name(),
+			SourceID:      sourceID,
+		}); err != nil {
+			return false, false, err
+		}
 		return false, true, nil
 	}
 	return false, false, nil
@@ -291,6 +308,86 @@ func (c *CalendarAdapter) setCursor(db *sql.DB, v string) {
 	_ = state.Set(db, c.Name(), "calendar_backfill_cursor", v)
 }
 
+// writeEvent upserts one calendar event with its organizer/attendee
+// participants, status and calendar tag. Events without a parseable start
+// are skipped. Other calendar sources (ICS) write through here so both
+// produce the same shape.
+func (c *CalendarAdapter) writeEvent(db *sql.DB, cal gogCalendar, ev gogCalendarEvent, cache map[string]string, res *SyncResult) error {
+	ts, err := parseEventStartUTC(ev)
+	if err != nil {
+		return nil
+	}
+
+	sourceID := fmt.Sprintf("%s:%s", cal.ID, ev.ID)
+	eventID := fmt.Sprintf("%s:%s", c.Name(), sourceID)
+	threadID := "calendar:" + cal.ID
+	content := fmt.Sprintf("Summary: %s\nCalendar: %s\nStatus: %s\nLink: %s\nLocation: %s\n\n%s",
+		ev.Summary, cal.Summary, ev.Status, ev.HTMLLink, ev.Location, ev.Description)
+
+	created, updated, err := c.upsertEvent(db, eventID, ts, content, threadID, sourceID, calendarEventMetadata(ev))
+	if err != nil {
+		return err
+	}
+	if created {
+		res.EventsCreated++
+	} else if updated {
+		res.EventsUpdated++
+	}
+
+	// Participants: organizer + attendees
+	if ev.Organizer != nil && ev.Organizer.Email != "" {
+		contactID, _, err := c.getOrCreateContactByEmail(db, ev.Organizer.Email, ev.Organizer.DisplayName, cache)
+		if err == nil {
+			if _, created, err := contacts.EnsurePersonForContact(db, contactID, ev.Organizer.DisplayName, "deterministic", 0.9); err == nil && created {
+				res.PersonsCreated++
+			}
+			_, _ = db.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'organizer')`, eventID, contactID)
+		}
+	}
+	for _, a := range ev.Attendees {
+		if a.Email == "" {
+			continue
+		}
+		contactID, _, err := c.getOrCreateContactByEmail(db, a.Email, a.DisplayName, cache)
+		if err == nil {
+			if _, created, err := contacts.EnsurePersonForContact(db, contactID, a.DisplayName, "deterministic", 0.9); err == nil && created {
+				res.PersonsCreated++
+			}
+			_, _ = db.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'attendee')`, eventID, contactID)
+		}
+	}
+
+	_ = c.upsertStateAndTags(db, eventID, cal.ID, ev)
+	return nil
+}
+
+// calendarEventMetadata records the end time and each attendee's response,
+// which the events row and event_participants have no columns for.
+func calendarEventMetadata(ev gogCalendarEvent) map[string]any {
+	meta := map[string]any{}
+	if end, err := parseEventTimeUTC(ev.End); err == nil {
+		meta["end"] = end
+	}
+	var attendees []map[string]any
+	for _, a := range ev.Attendees {
+		if a.Email == "" {
+			continue
+		}
+		entry := map[string]any{"email": contacts.NormalizeIdentifier(a.Email, "email")}
+		if a.Response != "" {
+			entry["response"] = a.Response
+		}
+		attendees = append(attendees, entry)
+	}
+	if len(attendees) > 0 {
+		meta["attendees"] = attendees
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
 func (c *CalendarAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
 	start := time.Now()
 	res := SyncResult{Perf: map[string]string{}}
@@ -344,52 +441,9 @@ func (c *CalendarAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool)
 					if strings.TrimSpace(ev.ID) == "" {
 						continue
 					}
-					ts, err := parseEventStartUTC(ev)
-					if err != nil {
-						continue
-					}
-
-					sourceID := fmt.Sprintf("%s:%s", cal.ID, ev.ID)
-					eventID := fmt.Sprintf("%s:%s", c.Name(), sourceID)
-					threadID := "calendar:" + cal.ID
-
-					content := fmt.Sprintf("Summary: %s\nCalendar: %s\nStatus: %s\nLink: %s\nLocation: %s\n\n%s",
-						ev.Summary, cal.Summary, ev.Status, ev.HTMLLink, ev.Location, ev.Description)
-
-					created, updated, err := c.upsertEvent(cortexDB, eventID, ts, content, threadID, sourceID)
-					if err != nil {
+					if err := c.writeEvent(cortexDB, cal, ev, cache, &res); err != nil {
 						return res, err
 					}
-					if created {
-						res.EventsCreated++
-					} else if updated {
-						res.EventsUpdated++
-					}
-
-					// Participants: organizer + attendees
-					if ev.Organizer != nil && ev.Organizer.Email != "" {
-						contactID, _, err := c.getOrCreateContactByEmail(cortexDB, ev.Organizer.Email, ev.Organizer.DisplayName, cache)
-						if err == nil {
-							if _, created, err := contacts.EnsurePersonForContact(cortexDB, contactID, ev.Organizer.DisplayName, "deterministic", 0.9); err == nil && created {
-								res.PersonsCreated++
-							}
-							_, _ = cortexDB.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'organizer')`, eventID, contactID)
-						}
-					}
-					for _, a := range ev.Attendees {
-						if a.Email == "" {
-							continue
-						}
-						contactID, _, err := c.getOrCreateContactByEmail(cortexDB, a.Email, a.DisplayName, cache)
-						if err == nil {
-							if _, created, err := contacts.EnsurePersonForContact(cortexDB, contactID, a.DisplayName, "deterministic", 0.9); err == nil && created {
-								res.PersonsCreated++
-							}
-							_, _ = cortexDB.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'attendee')`, eventID, contactID)
-						}
-					}
-
-					_ = c.upsertStateAndTags(cortexDB, eventID, cal.ID, ev)
 				}
 			}
 
@@ -413,47 +467,9 @@ func (c *CalendarAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool)
 				return res, err
 			}
 			for _, ev := range events {
-				ts, err := parseEventStartUTC(ev)
-				if err != nil {
-					continue
-				}
-				sourceID := fmt.Sprintf("%s:%s", cal.ID, ev.ID)
-				eventID := fmt.Sprintf("%s:%s", c.Name(), sourceID)
-				threadID := "calendar:" + cal.ID
-				content := fmt.Sprintf("Summary: %s\nCalendar: %s\nStatus: %s\nLink: %s\nLocation: %s\n\n%s",
-					ev.Summary, cal.Summary, ev.Status, ev.HTMLLink, ev.Location, ev.Description)
-
-				created, updated, err := c.upsertEvent(cortexDB, eventID, ts, content, threadID, sourceID)
-				if err != nil {
+				if err := c.writeEvent(cortexDB, cal, ev, cache, &res); err != nil {
 					return res, err
 				}
-				if created {
-					res.EventsCreated++
-				} else if updated {
-					res.EventsUpdated++
-				}
-				if ev.Organizer != nil && ev.Organizer.Email != "" {
-					contactID, _, err := c.getOrCreateContactByEmail(cortexDB, ev.Organizer.Email, ev.Organizer.DisplayName, cache)
-					if err == nil {
-						if _, created, err := contacts.EnsurePersonForContact(cortexDB, contactID, ev.Organizer.DisplayName, "deterministic", 0.9); err == nil && created {
-							res.PersonsCreated++
-						}
-						_, _ = cortexDB.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'organizer')`, eventID, contactID)
-					}
-				}
-				for _, a := range ev.Attendees {
-					if a.Email == "" {
-						continue
-					}
-					contactID, _, err := c.getOrCreateContactByEmail(cortexDB, a.Email, a.DisplayName, cache)
-					if err == nil {
-						if _, created, err := contacts.EnsurePersonForContact(cortexDB, contactID, a.DisplayName, "deterministic", 0.9); err == nil && created {
-							res.PersonsCreated++
-						}
-						_, _ = cortexDB.Exec(`INSERT OR IGNORE INTO event_participants (event_id, contact_id, role) VALUES (?, ?, 'attendee')`, eventID, contactID)
-					}
-				}
-				_ = c.upsertStateAndTags(cortexDB, eventID, cal.ID, ev)
 			}
 		}
 	}
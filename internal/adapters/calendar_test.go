@@ -34,3 +34,33 @@ func TestCalendarAdapter_EnsureTables(t *testing.T) {
 	cancel()
 	_, _ = c.Sync(ctx, d, false)
 }
+
+func TestCalendarAdapter_UpsertKeepsMetadata(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	d, err := db.Open()
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+
+	c := &CalendarAdapter{name: "calendar-test"}
+	attendees := map[string]any{"attendees": []map[string]any{{"email": "bob@example.com"}}}
+	if _, _, err := c.upsertEvent(d, "calendar-test:cal:1", 100, "v1", "calendar:cal", "cal:1", attendees); err != nil {
+		t.Fatalf("upsertEvent: %v", err)
+	}
+	// A later sync without attendee data must not clear what was recorded.
+	if _, updated, err := c.upsertEvent(d, "calendar-test:cal:1", 100, "v2", "calendar:cal", "cal:1", nil); err != nil || !updated {
+		t.Fatalf("upsertEvent update: updated=%v err=%v", updated, err)
+	}
+	var metadata string
+	if err := d.QueryRow(`SELECT metadata_json FROM events WHERE id = 'calendar-test:cal:1'`).Scan(&metadata); err != nil {
+		t.Fatalf("query metadata: %v", err)
+	}
+	if metadata != `{"attendees":[{"email":"bob@example.com"}]}` {
+		t.Fatalf("metadata = %s", metadata)
+	}
+}
@@ -0,0 +1,183 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+)
+
+// Check statuses reported by `mnemonic adapters doctor`.
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// probeTimeout bounds each external command a doctor probe runs.
+const probeTimeout = 20 * time.Second
+
+// Check is the result of one doctor probe. Fix is a suggested next step and
+// is only set when Status is not ok.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// Diagnose runs the doctor probes for a configured adapter. Types without a
+// Doctor fall back to their Status hint. Probes never write to the source or
+// the local database.
+func Diagnose(ctx context.Context, cfg config.AdapterConfig) []Check {
+	reg, ok := Lookup(cfg.Type)
+	if !ok {
+		return []Check{{
+			Name:   "config",
+			Status: CheckFail,
+			Detail: fmt.Sprintf("unknown adapter type: %s", cfg.Type),
+			Fix:    "run 'mnemonic adapters types' for valid types",
+		}}
+	}
+	opts, err := reg.DecodeOptions(cfg.Options)
+	if err != nil {
+		return []Check{{
+			Name:   "config",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Fix:    "fix the adapter's options in config.yaml",
+		}}
+	}
+	checks := []Check{{Name: "config", Status: CheckOK}}
+
+	switch {
+	case reg.Doctor != nil:
+		checks = append(checks, reg.Doctor(ctx, opts)...)
+	case reg.Status != nil:
+		status := reg.Status(opts)
+		c := Check{Name: "prerequisites", Status: CheckOK, Detail: status}
+		if !strings.HasPrefix(status, "ready") {
+			c.Status = CheckFail
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func checkBinary(name, install string) Check {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return Check{Name: "binary", Status: CheckFail, Detail: fmt.Sprintf("%s not found on PATH", name), Fix: install}
+	}
+	return Check{Name: "binary", Status: CheckOK, Detail: path}
+}
+
+func checkPath(name, path, fix string) Check {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: fmt.Sprintf("%s: %v", path, err), Fix: fix}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: fmt.Sprintf("%s is not readable: %v", path, err), Fix: "check the file permissions"}
+	}
+	f.Close()
+	detail := path
+	if info.IsDir() {
+		detail += " (directory)"
+	}
+	return Check{Name: name, Status: CheckOK, Detail: detail}
+}
+
+// checkCommand runs a read-only command and fails the check when it exits
+// non-zero, quoting the first line of its output.
+func checkCommand(ctx context.Context, name, fix, bin string, args ...string) Check {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, args...).CombinedOutput()
+	if err != nil {
+		detail := err.Error()
+		if ctx.Err() == context.DeadlineExceeded {
+			detail = fmt.Sprintf("no response after %s", probeTimeout)
+		} else if line := firstLine(string(out)); line != "" {
+			detail = line
+		}
+		return Check{Name: name, Status: CheckFail, Detail: detail, Fix: fix}
+	}
+	return Check{Name: name, Status: CheckOK, Detail: firstLine(string(out))}
+}
+
+// checkSQLiteSchema opens an upstream database read-only and confirms the
+// tables the adapter reads are present.
+func checkSQLiteSchema(path string, tables []string, fix string) Check {
+	src, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return Check{Name: "schema", Status: CheckFail, Detail: err.Error(), Fix: fix}
+	}
+	defer src.Close()
+
+	var version int
+	if err := src.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return Check{Name: "schema", Status: CheckFail, Detail: fmt.Sprintf("cannot read %s: %v", path, err), Fix: fix}
+	}
+	present := map[string]bool{}
+	rows, err := src.Query(`SELECT name FROM sqlite_master WHERE type IN ('table', 'view')`)
+	if err != nil {
+		return Check{Name: "schema", Status: CheckFail, Detail: err.Error(), Fix: fix}
+	}
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			present[name] = true
+		}
+	}
+	rows.Close()
+
+	var missing []string
+	for _, t := range tables {
+		if !present[t] {
+			missing = append(missing, t)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		return Check{
+			Name:   "schema",
+			Status: CheckFail,
+			Detail: fmt.Sprintf("user_version %d, missing tables: %s", version, strings.Join(missing, ", ")),
+			Fix:    fix,
+		}
+	}
+	return Check{Name: "schema", Status: CheckOK, Detail: fmt.Sprintf("user_version %d", version)}
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+// probeChain runs probes in order and stops after the first failure, so a
+// missing binary is not also reported as an auth failure.
+func probeChain(probes ...func() Check) []Check {
+	var checks []Check
+	for _, probe := range probes {
+		c := probe()
+		checks = append(checks, c)
+		if c.Status == CheckFail {
+			break
+		}
+	}
+	return checks
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
@@ -143,12 +144,13 @@ func (e *EveAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (Syn
 
 	// Sync messages
 	messagesStart := time.Now()
-	eventsCreated, eventsUpdated, maxImportedTimestamp, perfMessages, err := e.syncMessages(ctx, eveDB, cortexDB, lastSyncTimestamp, contactMap, meContactID)
+	eventsCreated, eventsUpdated, eventsDeleted, maxImportedTimestamp, perfMessages, err := e.syncMessages(ctx, eveDB, cortexDB, lastSyncTimestamp, contactMap, meContactID)
 	if err != nil {
 		return result, fmt.Errorf("failed to sync messages: %w", err)
 	}
 	result.EventsCreated = eventsCreated
 	result.EventsUpdated = eventsUpdated
+	result.EventsDeleted = eventsDeleted
 	for k, v := range perfMessages {
 		result.Perf["messages."+k] = v
 	}
@@ -600,8 +602,7 @@ func (e *EveAdapter) syncChats(ctx context.Context, eveDB, cortexDB *sql.DB) (th
 }
 
 // syncMessages syncs Eve messages to cortex events
-func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB, lastSyncTimestamp int64, contactMap map[int64]string, meContactID string) (created int, updated int, maxImportedTimestamp int64, perf map[string]string, err error) {
-	_ = ctx
+func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB, lastSyncTimestamp int64, contactMap map[int64]string, meContactID string) (created int, updated int, deleted int, maxImportedTimestamp int64, perf map[string]string, err error) {
 	perf = map[string]string{}
 
 	adapterPrefix := e.Name() + ":"
@@ -633,61 +634,37 @@ func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB,
 	qStart := time.Now()
 	rows, err := eveDB.Query(query, lastSyncTimestamp)
 	if err != nil {
-		return 0, 0, 0, perf, fmt.Errorf("failed to query Eve messages: %w", err)
+		return 0, 0, 0, 0, perf, fmt.Errorf("failed to query Eve messages: %w", err)
 	}
 	defer rows.Close()
 	perf["query"] = time.Since(qStart).String()
 
-	// Bulk write in a single transaction for SQLite performance.
+	// Bulk write in batches of eveMessageBatch rows: large transactions for
+	// SQLite performance, with the connection released between them.
 	txStart := time.Now()
-	tx, err := cortexDB.Begin()
-	if err != nil {
-		return 0, 0, 0, perf, fmt.Errorf("begin cortex tx: %w", err)
-	}
-	defer tx.Rollback()
-
-	stmtInsertEvent, err := tx.Prepare(`
-		INSERT OR IGNORE INTO events (
-			id, timestamp, channel, content_types, content,
-			direction, thread_id, reply_to, source_adapter, source_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	wtx, err := beginEveMessageTx(cortexDB)
 	if err != nil {
-		return 0, 0, 0, perf, fmt.Errorf("prepare insert event: %w", err)
+		return 0, 0, 0, 0, perf, err
 	}
-	defer stmtInsertEvent.Close()
-
-	stmtUpdateEvent, err := tx.Prepare(`
-		UPDATE events
-		SET
-			content = ?,
-			content_types = ?,
-			thread_id = ?,
-			reply_to = ?
-		WHERE source_adapter = ?
-		  AND source_id = ?
-		  AND (
-		    content IS NOT ?
-		    OR content_types IS NOT ?
-		    OR thread_id IS NOT ?
-		    OR reply_to IS NOT ?
-		  )
-	`)
-	if err != nil {
-		return 0, 0, 0, perf, fmt.Errorf("prepare update event: %w", err)
-	}
-	defer stmtUpdateEvent.Close()
-
-	stmtInsertParticipant, err := tx.Prepare(`
-		INSERT OR IGNORE INTO event_participants (event_id, contact_id, role)
-		VALUES (?, ?, ?)
-	`)
-	if err != nil {
-		return 0, 0, 0, perf, fmt.Errorf("prepare insert participant: %w", err)
-	}
-	defer stmtInsertParticipant.Close()
+	defer func() { _ = wtx.tx.Rollback() }()
 
+	pending := 0
 	for rows.Next() {
+		if pending == eveMessageBatch {
+			if err := ctx.Err(); err != nil {
+				return created, updated, deleted, maxImportedTimestamp, perf, err
+			}
+			if err := wtx.tx.Commit(); err != nil {
+				return created, updated, deleted, maxImportedTimestamp, perf, fmt.Errorf("commit cortex tx: %w", err)
+			}
+			next, err := beginEveMessageTx(cortexDB)
+			if err != nil {
+				return created, updated, deleted, maxImportedTimestamp, perf, err
+			}
+			wtx, pending = next, 0
+		}
+		pending++
+
 		var messageID int64
 		var guid, threadID string
 		var chatID, senderID sql.NullInt64
@@ -697,7 +674,7 @@ func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB,
 		var attachmentCount int
 
 		if err := rows.Scan(&messageID, &guid, &chatID, &senderID, &content, &timestamp, &isFromMe, &serviceName, &replyToGuid, &threadID, &attachmentCount); err != nil {
-			return created, updated, maxImportedTimestamp, perf, fmt.Errorf("failed to scan message row: %w", err)
+			return created, updated, deleted, maxImportedTimestamp, perf, fmt.Errorf("failed to scan message row: %w", err)
 		}
 
 		if timestamp > maxImportedTimestamp {
@@ -726,23 +703,38 @@ func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB,
 		// Deterministic event ID to avoid UUID cost and extra lookups.
 		eventID := adapterPrefix + guid
 
-		res, err := stmtInsertEvent.Exec(
+		res, err := wtx.insertEvent.Exec(
 			eventID, timestamp, "imessage", contentTypesJSON, content.String,
 			direction, threadID, replyToGuid.String, e.Name(), guid,
 		)
 		if err != nil {
-			return created, updated, maxImportedTimestamp, perf, fmt.Errorf("insert event: %w", err)
+			return created, updated, deleted, maxImportedTimestamp, perf, fmt.Errorf("insert event: %w", err)
 		}
 		if n, _ := res.RowsAffected(); n == 1 {
 			created++
 		} else {
-			res2, err := stmtUpdateEvent.Exec(
+			// Edits and unsends rewrite the message text; keep what it said before.
+			revised, err := ingest.RecordRevision(wtx.tx, e.Name(), guid, content.String)
+			if err != nil {
+				return created, updated, deleted, maxImportedTimestamp, perf, err
+			}
+			// An unsend leaves nothing behind: no text and no attachments.
+			if revised && !hasText && !hasAttachment {
+				ok, err := ingest.MarkDeleted(wtx.tx, e.Name(), eventID)
+				if err != nil {
+					return created, updated, deleted, maxImportedTimestamp, perf, err
+				}
+				if ok {
+					deleted++
+				}
+			}
+			res2, err := wtx.updateEvent.Exec(
 				content.String, contentTypesJSON, threadID, replyToGuid.String,
 				e.Name(), guid,
 				content.String, contentTypesJSON, threadID, replyToGuid.String,
 			)
 			if err != nil {
-				return created, updated, maxImportedTimestamp, perf, fmt.Errorf("update event: %w", err)
+				return created, updated, deleted, maxImportedTimestamp, perf, fmt.Errorf("update event: %w", err)
 			}
 			if n2, _ := res2.RowsAffected(); n2 == 1 {
 				updated++
@@ -756,22 +748,83 @@ func (e *EveAdapter) syncMessages(ctx context.Context, eveDB, cortexDB *sql.DB,
 				if isFromMe {
 					role = "recipient"
 				}
-				_, _ = stmtInsertParticipant.Exec(eventID, contactID, role)
+				_, _ = wtx.insertParticipant.Exec(eventID, contactID, role)
 			}
 		}
 		if isFromMe && meContactID != "" {
-			_, _ = stmtInsertParticipant.Exec(eventID, meContactID, "sender")
+			_, _ = wtx.insertParticipant.Exec(eventID, meContactID, "sender")
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return created, updated, maxImportedTimestamp, perf, err
+		return created, updated, deleted, maxImportedTimestamp, perf, err
 	}
-	if err := tx.Commit(); err != nil {
-		return created, updated, maxImportedTimestamp, perf, fmt.Errorf("commit cortex tx: %w", err)
+	if err := wtx.tx.Commit(); err != nil {
+		return created, updated, deleted, maxImportedTimestamp, perf, fmt.Errorf("commit cortex tx: %w", err)
 	}
 	perf["tx_commit"] = time.Since(txStart).String()
-	return created, updated, maxImportedTimestamp, perf, nil
+	return created, updated, deleted, maxImportedTimestamp, perf, nil
+}
+
+// eveMessageBatch is how many messages syncMessages writes per transaction.
+// The watermark only advances once every batch is in, so a failed sync
+// replays the committed ones, which updates rows in place.
+const eveMessageBatch = 1000
+
+// eveMessageTx is one syncMessages transaction and its prepared statements,
+// which close with the transaction.
+type eveMessageTx struct {
+	tx                *sql.Tx
+	insertEvent       *sql.Stmt
+	updateEvent       *sql.Stmt
+	insertParticipant *sql.Stmt
+}
+
+func beginEveMessageTx(cortexDB *sql.DB) (*eveMessageTx, error) {
+	tx, err := cortexDB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin cortex tx: %w", err)
+	}
+	t := &eveMessageTx{tx: tx}
+	t.insertEvent, err = tx.Prepare(`
+		INSERT OR IGNORE INTO events (
+			id, timestamp, channel, content_types, content,
+			direction, thread_id, reply_to, source_adapter, source_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("prepare insert event: %w", err)
+	}
+	t.updateEvent, err = tx.Prepare(`
+		UPDATE events
+		SET
+			content = ?,
+			content_types = ?,
+			thread_id = ?,
+			reply_to = ?
+		WHERE source_adapter = ?
+		  AND source_id = ?
+		  AND (
+		    content IS NOT ?
+		    OR content_types IS NOT ?
+		    OR thread_id IS NOT ?
+		    OR reply_to IS NOT ?
+		  )
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("prepare update event: %w", err)
+	}
+	t.insertParticipant, err = tx.Prepare(`
+		INSERT OR IGNORE INTO event_participants (event_id, contact_id, role)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("prepare insert participant: %w", err)
+	}
+	return t, nil
 }
 
 // syncAttachments syncs Eve attachments to cortex attachments table
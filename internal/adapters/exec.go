@@ -0,0 +1,636 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/state"
+)
+
+// ExecProtocolVersion is the NDJSON protocol version spoken by the exec adapter.
+// See docs/EXEC_ADAPTER_PROTOCOL.md.
+const ExecProtocolVersion = 1
+
+// ExecAdapterOptions is the config.yaml shape for type exec.
+type ExecAdapterOptions struct {
+	Command        string            `yaml:"command"`
+	Args           []string          `yaml:"args"`
+	Env            map[string]string `yaml:"env"`
+	Dir            string            `yaml:"dir"`
+	Channel        string            `yaml:"channel"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
+
+func (o *ExecAdapterOptions) Validate() error {
+	if o.Command == "" {
+		return fmt.Errorf("'command' is required")
+	}
+	if o.TimeoutSeconds < 0 {
+		return fmt.Errorf("'timeout_seconds' must not be negative")
+	}
+	return nil
+}
+
+// ExecAdapter runs an external extractor and ingests the NDJSON records it
+// writes to stdout.
+type ExecAdapter struct {
+	name string
+	opts ExecAdapterOptions
+}
+
+// NewExecAdapter creates an adapter for an external extractor. The instance
+// name is used as source_adapter and as the adapter_state key.
+func NewExecAdapter(name string, opts ExecAdapterOptions) (*ExecAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("adapter instance name is required for exec adapter")
+	}
+	if strings.TrimSpace(opts.Command) == "" {
+		return nil, fmt.Errorf("exec adapter requires a command")
+	}
+	if _, err := exec.LookPath(opts.Command); err != nil {
+		return nil, fmt.Errorf("exec adapter command %q not found: %w", opts.Command, err)
+	}
+	if opts.Channel == "" {
+		opts.Channel = name
+	}
+	return &ExecAdapter{name: name, opts: opts}, nil
+}
+
+func (a *ExecAdapter) Name() string {
+	return a.name
+}
+
+// execParticipant identifies a person on an event.
+type execParticipant struct {
+	Role           string `json:"role"`
+	Identifier     string `json:"identifier"`
+	IdentifierType string `json:"identifier_type"`
+	Name           string `json:"name"`
+}
+
+// execRecord is one NDJSON line. Fields are shared across record types.
+type execRecord struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+
+	ID        string          `json:"id"`
+	Channel   string          `json:"channel"`
+	Timestamp json.RawMessage `json:"timestamp"`
+	Metadata  map[string]any  `json:"metadata"`
+
+	// thread
+	Name     string `json:"name"`
+	IsGroup  bool   `json:"is_group"`
+	ParentID string `json:"parent_id"`
+
+	// event
+	ThreadID     string            `json:"thread_id"`
+	Content      string            `json:"content"`
+	ContentTypes []string          `json:"content_types"`
+	Direction    string            `json:"direction"`
+	ReplyTo      string            `json:"reply_to"`
+	Participants []execParticipant `json:"participants"`
+
+	// participant, attachment, reaction
+	EventID        string           `json:"event_id"`
+	Role           string           `json:"role"`
+	Identifier     string           `json:"identifier"`
+	IdentifierType string           `json:"identifier_type"`
+	Sender         *execParticipant `json:"sender"`
+	Emoji          string           `json:"emoji"`
+
+	// attachment
+	Filename    string `json:"filename"`
+	MimeType    string `json:"mime_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	MediaType   string `json:"media_type"`
+	URI         string `json:"uri"`
+	ContentHash string `json:"content_hash"`
+
+	// cursor
+	Value string `json:"value"`
+}
+
+func (a *ExecAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
+	start := time.Now()
+	var result SyncResult
+	perf := map[string]string{}
+
+	if _, err := cortexDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return result, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	cursor := ""
+	if !full {
+		v, _, err := state.Get(cortexDB, a.name, "cursor")
+		if err != nil {
+			return result, err
+		}
+		cursor = v
+	}
+
+	if a.opts.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(a.opts.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, a.opts.Command, a.opts.Args...)
+	cmd.Dir = a.opts.Dir
+	cmd.Env = append(os.Environ(),
+		"MNEMONIC_ADAPTER="+a.name,
+		"MNEMONIC_CHANNEL="+a.opts.Channel,
+		"MNEMONIC_CURSOR="+cursor,
+		"MNEMONIC_FULL="+boolEnv(full),
+		"MNEMONIC_PROTOCOL_VERSION="+strconv.Itoa(ExecProtocolVersion),
+	)
+	for k, v := range a.opts.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stderr := &tailBuffer{max: 4096}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return result, fmt.Errorf("exec adapter stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return result, fmt.Errorf("failed to start %s: %w", a.opts.Command, err)
+	}
+
+	session := &execSession{ctx: ctx, db: cortexDB, adapter: a.name, channel: a.opts.Channel}
+	defer session.close()
+
+	ingestStart := time.Now()
+	stop := make(chan struct{})
+	lines := readExecLines(stdout, stop)
+	streamErr := session.ingest(lines)
+	close(stop)
+	if streamErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	// The reader drains stdout before closing lines, so the child never
+	// blocks on a full pipe and Wait comes after the last read.
+	for range lines {
+	}
+	waitErr := cmd.Wait()
+	perf["ingest"] = time.Since(ingestStart).String()
+
+	if streamErr != nil {
+		return result, streamErr
+	}
+	if waitErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return result, fmt.Errorf("%s failed: %w (stderr: %s)", a.opts.Command, waitErr, msg)
+		}
+		return result, fmt.Errorf("%s failed: %w", a.opts.Command, waitErr)
+	}
+	if err := session.flush(); err != nil {
+		return result, err
+	}
+
+	if session.cursor != "" {
+		if err := state.Set(cortexDB, a.name, "cursor", session.cursor); err != nil {
+			return result, err
+		}
+	}
+	if _, err := cortexDB.Exec(`
+		INSERT INTO sync_watermarks (adapter, last_sync_at)
+		VALUES (?, ?)
+		ON CONFLICT(adapter) DO UPDATE SET last_sync_at = excluded.last_sync_at
+	`, a.name, time.Now().Unix()); err != nil {
+		return result, fmt.Errorf("failed to update sync watermark: %w", err)
+	}
+
+	s := session.stats
+	result.EventsCreated = s.EventsCreated
+	result.EventsUpdated = s.EventsUpdated
+	result.PersonsCreated = s.PersonsCreated
+	result.ThreadsCreated = s.ThreadsCreated
+	result.ThreadsUpdated = s.ThreadsUpdated
+	result.AttachmentsCreated = s.AttachmentsCreated
+	result.AttachmentsUpdated = s.AttachmentsUpdated
+	result.ReactionsCreated = s.ReactionsCreated
+	result.ReactionsUpdated = s.ReactionsUpdated
+	result.EventsDeleted = s.EventsDeleted
+	result.Duration = time.Since(start)
+	result.Perf = perf
+	return result, nil
+}
+
+// execCommitEvery and execCommitInterval bound an exec sync's write
+// batches. Records are buffered as they are read and written in one short
+// transaction once either limit is reached, so the connection is never held
+// while waiting on the child. The cursor is only saved once the whole stream
+// is in, so a failed run replays from the previous cursor over what it
+// already committed.
+const execCommitEvery = 500
+
+var execCommitInterval = time.Second
+
+// execLine is one non-blank stdout line, or the error that ended the read.
+type execLine struct {
+	n   int
+	raw []byte
+	err error
+}
+
+// readExecLines scans r on its own goroutine. Once the stream ends or stop
+// is closed it discards the rest of r, then closes the returned channel.
+func readExecLines(r io.Reader, stop <-chan struct{}) <-chan execLine {
+	out := make(chan execLine)
+	go func() {
+		defer close(out)
+		defer func() { _, _ = io.Copy(io.Discard, r) }()
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		n := 0
+		for scanner.Scan() {
+			n++
+			raw := bytes.TrimSpace(scanner.Bytes())
+			if len(raw) == 0 {
+				continue
+			}
+			select {
+			case out <- execLine{n: n, raw: append([]byte(nil), raw...)}:
+			case <-stop:
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case out <- execLine{n: n, err: err}:
+			case <-stop:
+			}
+		}
+	}()
+	return out
+}
+
+// execSession applies one run's records through a writer.
+type execSession struct {
+	ctx     context.Context
+	db      *sql.DB
+	adapter string
+	channel string
+	w       *ingest.Writer
+	stats   ingest.Stats
+
+	pending []execPending
+	cursor  string
+}
+
+// execPending is a decoded record waiting for the next batch.
+type execPending struct {
+	line int
+	rec  execRecord
+}
+
+// ingest decodes records until the stream ends, writing them in batches and
+// remembering the last cursor seen. The final batch is left pending until
+// the child has exited cleanly.
+func (s *execSession) ingest(lines <-chan execLine) error {
+	tick := time.NewTicker(execCommitInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			if l.err != nil {
+				return fmt.Errorf("read stream: %w", l.err)
+			}
+			var rec execRecord
+			if err := json.Unmarshal(l.raw, &rec); err != nil {
+				return fmt.Errorf("line %d: invalid JSON: %w", l.n, err)
+			}
+			s.pending = append(s.pending, execPending{line: l.n, rec: rec})
+			if len(s.pending) >= execCommitEvery {
+				if err := s.flush(); err != nil {
+					return err
+				}
+			}
+		case <-tick.C:
+			if err := s.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flush writes the buffered records in one transaction.
+func (s *execSession) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin cortex tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if s.w == nil {
+		if s.w, err = ingest.NewWriter(tx, s.adapter); err != nil {
+			return err
+		}
+	} else if err := s.w.Rebind(tx); err != nil {
+		return err
+	}
+	for _, p := range s.pending {
+		if err := s.apply(p.rec); err != nil {
+			return fmt.Errorf("line %d (%s): %w", p.line, p.rec.Type, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit cortex tx: %w", err)
+	}
+	s.stats = s.w.Stats
+	s.pending = s.pending[:0]
+	return nil
+}
+
+func (s *execSession) close() {
+	if s.w != nil {
+		s.w.Close()
+	}
+}
+
+func (s *execSession) apply(rec execRecord) error {
+	w := s.w
+	switch rec.Type {
+	case "hello":
+		if rec.Version > ExecProtocolVersion {
+			return fmt.Errorf("protocol version %d not supported (max %d)", rec.Version, ExecProtocolVersion)
+		}
+		return nil
+
+	case "cursor":
+		s.cursor = rec.Value
+		return nil
+
+	case "thread":
+		parent := ""
+		if rec.ParentID != "" {
+			parent = w.ID(rec.ParentID)
+		}
+		_, err := w.UpsertThread(ingest.Thread{
+			SourceID: rec.ID,
+			Channel:  s.channelFor(rec.Channel),
+			Name:     rec.Name,
+			IsGroup:  rec.IsGroup,
+			ParentID: parent,
+		})
+		return err
+
+	case "event":
+		ts, err := parseExecTimestamp(rec.Timestamp)
+		if err != nil {
+			return err
+		}
+		direction, err := execDirection(rec.Direction)
+		if err != nil {
+			return err
+		}
+		eventID, _, err := w.UpsertEvent(ingest.Event{
+			SourceID:     rec.ID,
+			Timestamp:    ts,
+			Channel:      s.channelFor(rec.Channel),
+			ContentTypes: rec.ContentTypes,
+			Content:      rec.Content,
+			Direction:    direction,
+			ThreadID:     refID(w, rec.ThreadID),
+			ReplyTo:      refID(w, rec.ReplyTo),
+			Metadata:     rec.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range rec.Participants {
+			if err := s.addParticipant(eventID, p); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "participant":
+		if rec.EventID == "" {
+			return fmt.Errorf("event_id is required")
+		}
+		return s.addParticipant(w.ID(rec.EventID), execParticipant{
+			Role:           rec.Role,
+			Identifier:     rec.Identifier,
+			IdentifierType: rec.IdentifierType,
+			Name:           rec.Name,
+		})
+
+	case "attachment":
+		if rec.EventID == "" {
+			return fmt.Errorf("event_id is required")
+		}
+		ts, _ := parseExecTimestamp(rec.Timestamp)
+		storageType := ""
+		switch {
+		case strings.HasPrefix(rec.URI, "file://"):
+			storageType = "local"
+		case strings.HasPrefix(rec.URI, "http://"), strings.HasPrefix(rec.URI, "https://"):
+			storageType = "url"
+		case rec.URI != "":
+			storageType = "external"
+		}
+		_, err := w.UpsertAttachment(ingest.Attachment{
+			SourceID:    rec.ID,
+			EventID:     w.ID(rec.EventID),
+			Filename:    rec.Filename,
+			MimeType:    rec.MimeType,
+			SizeBytes:   rec.SizeBytes,
+			MediaType:   rec.MediaType,
+			StorageURI:  rec.URI,
+			StorageType: storageType,
+			ContentHash: rec.ContentHash,
+			Metadata:    rec.Metadata,
+			CreatedAt:   ts,
+		})
+		return err
+
+	case "reaction":
+		// Reactions are events that reply to their target, like iMessage tapbacks.
+		if rec.EventID == "" {
+			return fmt.Errorf("event_id is required")
+		}
+		if rec.Emoji == "" {
+			return fmt.Errorf("emoji is required")
+		}
+		ts, err := parseExecTimestamp(rec.Timestamp)
+		if err != nil {
+			return err
+		}
+		direction, err := execDirection(rec.Direction)
+		if err != nil {
+			return err
+		}
+		targetID := w.ID(rec.EventID)
+		threadID := refID(w, rec.ThreadID)
+		if threadID == "" {
+			var t sql.NullString
+			_ = w.Tx().QueryRow(`SELECT thread_id FROM events WHERE id = ?`, targetID).Scan(&t)
+			threadID = t.String
+		}
+		sourceID := rec.ID
+		if sourceID == "" {
+			sender := ""
+			if rec.Sender != nil {
+				sender = rec.Sender.Identifier
+			}
+			sourceID = "reaction:" + rec.EventID + ":" + sender + ":" + rec.Emoji
+		}
+		eventID, _, err := w.UpsertReaction(ingest.Event{
+			SourceID:     sourceID,
+			Timestamp:    ts,
+			Channel:      s.channelFor(rec.Channel),
+			ContentTypes: []string{"reaction"},
+			Content:      rec.Emoji,
+			Direction:    direction,
+			ThreadID:     threadID,
+			ReplyTo:      targetID,
+			Metadata:     rec.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+		if rec.Sender != nil {
+			p := *rec.Sender
+			if p.Role == "" {
+				p.Role = "sender"
+			}
+			return s.addParticipant(eventID, p)
+		}
+		return nil
+
+	case "delete":
+		if rec.ID == "" {
+			return fmt.Errorf("id is required")
+		}
+		_, err := w.Tombstone(rec.ID)
+		return err
+
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+}
+
+func (s *execSession) addParticipant(eventID string, p execParticipant) error {
+	if strings.TrimSpace(p.Identifier) == "" {
+		return fmt.Errorf("participant identifier is required")
+	}
+	identType := p.IdentifierType
+	if identType == "" {
+		identType = "handle"
+	}
+	switch identType {
+	case "email", "phone", "handle":
+	default:
+		return fmt.Errorf("unsupported identifier_type %q (use email, phone or handle)", identType)
+	}
+	role := p.Role
+	if role == "" {
+		role = "sender"
+	}
+	contactID, err := s.w.Contact(identType, p.Identifier, p.Name)
+	if err != nil {
+		return err
+	}
+	return s.w.AddParticipant(eventID, contactID, role)
+}
+
+func (s *execSession) channelFor(recordChannel string) string {
+	if recordChannel != "" {
+		return recordChannel
+	}
+	return s.channel
+}
+
+func refID(w *ingest.Writer, sourceID string) string {
+	if sourceID == "" {
+		return ""
+	}
+	return w.ID(sourceID)
+}
+
+func execDirection(d string) (string, error) {
+	switch d {
+	case "":
+		return "received", nil
+	case "sent", "received", "observed":
+		return d, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q (use sent, received or observed)", d)
+	}
+}
+
+// parseExecTimestamp accepts unix seconds, unix milliseconds or an RFC 3339 string.
+func parseExecTimestamp(raw json.RawMessage) (int64, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, fmt.Errorf("timestamp is required")
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		v, err := n.Int64()
+		if err != nil {
+			f, ferr := n.Float64()
+			if ferr != nil {
+				return 0, fmt.Errorf("invalid timestamp %s", raw)
+			}
+			v = int64(f)
+		}
+		if v > 1e12 {
+			v /= 1000
+		}
+		return v, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, fmt.Errorf("invalid timestamp %s", raw)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t.Unix(), nil
+}
+
+func boolEnv(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return string(t.buf)
+}
@@ -0,0 +1,366 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/query"
+	"github.com/Napageneral/mnemonic/internal/state"
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+const fakeExtractor = `#!/bin/sh
+echo "cursor-in=$MNEMONIC_CURSOR full=$MNEMONIC_FULL" >&2
+cat <<'EOF'
+{"type":"hello","version":1}
+{"type":"thread","id":"C1","name":"general","is_group":true}
+{"type":"thread","id":"C1/t1","name":"general thread","parent_id":"C1"}
+{"type":"event","id":"m1","thread_id":"C1","timestamp":1700000000,"content":"hello team","participants":[{"role":"sender","identifier":"alice","identifier_type":"handle","name":"Alice Smith"}]}
+{"type":"event","id":"m2","thread_id":"C1/t1","timestamp":"2023-11-14T22:14:00Z","content":"reply","direction":"sent","reply_to":"m1","participants":[{"identifier":"me@example.com","identifier_type":"email"}]}
+{"type":"participant","event_id":"m1","role":"recipient","identifier":"bob","name":"Bob Jones"}
+{"type":"attachment","id":"f1","event_id":"m1","filename":"notes.pdf","mime_type":"application/pdf","size_bytes":42,"uri":"https://files.example.com/f1"}
+{"type":"reaction","event_id":"m1","emoji":"👍","timestamp":1700000100,"sender":{"identifier":"bob"}}
+{"type":"cursor","value":"ts:1700000100"}
+EOF
+`
+
+func writeFakeExtractor(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "extract.sh")
+	if err := os.WriteFile(path, []byte(body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func TestExecAdapterSync(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	a, err := NewExecAdapter("slack-test", ExecAdapterOptions{Command: writeFakeExtractor(t, fakeExtractor), Channel: "slack"})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+
+	res, err := a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.EventsCreated != 2 || res.ThreadsCreated != 2 || res.AttachmentsCreated != 1 || res.ReactionsCreated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	var parent string
+	if err := db.QueryRow(`SELECT parent_thread_id FROM threads WHERE id = 'slack-test:C1/t1'`).Scan(&parent); err != nil || parent != "slack-test:C1" {
+		t.Fatalf("expected child thread parent slack-test:C1, got %q (%v)", parent, err)
+	}
+
+	var replyTo, direction string
+	if err := db.QueryRow(`SELECT reply_to, direction FROM events WHERE id = 'slack-test:m2'`).Scan(&replyTo, &direction); err != nil {
+		t.Fatalf("query reply: %v", err)
+	}
+	if replyTo != "slack-test:m1" || direction != "sent" {
+		t.Fatalf("unexpected reply_to=%q direction=%q", replyTo, direction)
+	}
+
+	var participants int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = 'slack-test:m1'`).Scan(&participants); err != nil {
+		t.Fatalf("count participants: %v", err)
+	}
+	if participants != 2 {
+		t.Fatalf("expected 2 participants on m1, got %d", participants)
+	}
+
+	var reactionThread, reactionContent string
+	if err := db.QueryRow(`
+		SELECT thread_id, content FROM events
+		WHERE reply_to = 'slack-test:m1' AND content_types = '["reaction"]'
+	`).Scan(&reactionThread, &reactionContent); err != nil {
+		t.Fatalf("query reaction: %v", err)
+	}
+	if reactionThread != "slack-test:C1" || reactionContent != "👍" {
+		t.Fatalf("unexpected reaction thread=%q content=%q", reactionThread, reactionContent)
+	}
+
+	cursor, ok, err := state.Get(db, "slack-test", "cursor")
+	if err != nil || !ok || cursor != "ts:1700000100" {
+		t.Fatalf("expected stored cursor, got %q ok=%v err=%v", cursor, ok, err)
+	}
+
+	// Re-running is idempotent.
+	res, err = a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.ReactionsCreated != 0 {
+		t.Fatalf("expected no changes on re-run, got %+v", res)
+	}
+}
+
+func TestExecAdapterPassesCursor(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	if err := state.Set(db, "echo", "cursor", "abc"); err != nil {
+		t.Fatalf("state.Set: %v", err)
+	}
+	script := writeFakeExtractor(t, `#!/bin/sh
+printf '{"type":"cursor","value":"%s-next"}\n' "$MNEMONIC_CURSOR"
+`)
+	a, err := NewExecAdapter("echo", ExecAdapterOptions{Command: script})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+	if _, err := a.Sync(context.Background(), db, false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	cursor, _, _ := state.Get(db, "echo", "cursor")
+	if cursor != "abc-next" {
+		t.Fatalf("expected cursor abc-next, got %q", cursor)
+	}
+}
+
+func TestExecAdapterDelete(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	a, err := NewExecAdapter("slack-test", ExecAdapterOptions{Command: writeFakeExtractor(t, fakeExtractor), Channel: "slack"})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+	if _, err := a.Sync(context.Background(), db, false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	a.opts.Command = writeFakeExtractor(t, `#!/bin/sh
+echo '{"type":"delete","id":"m1"}'
+echo '{"type":"delete","id":"never-seen"}'
+`)
+	res, err := a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("delete Sync: %v", err)
+	}
+	if res.EventsDeleted != 1 {
+		t.Fatalf("expected 1 deleted event, got %+v", res)
+	}
+
+	var visible int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM events e WHERE e.source_adapter = 'slack-test' AND ` + ingest.NotDeleted("e")).Scan(&visible)
+	if visible != 2 {
+		t.Fatalf("expected m2 and the reaction to remain visible, got %d", visible)
+	}
+	var payload string
+	if err := db.QueryRow(`SELECT payload_json FROM bus_events WHERE type = 'event.deleted' AND mnemonic_event_id = 'slack-test:m1'`).Scan(&payload); err != nil {
+		t.Fatalf("expected event.deleted bus event: %v", err)
+	}
+	if !strings.Contains(payload, `"source_id":"m1"`) {
+		t.Fatalf("unexpected payload %s", payload)
+	}
+
+	// Deleting again is a no-op.
+	if res, err = a.Sync(context.Background(), db, false); err != nil || res.EventsDeleted != 0 {
+		t.Fatalf("expected repeat delete to be ignored, got %+v (%v)", res, err)
+	}
+
+	if _, err := ingest.RecordRevision(db, "slack-test", "m1", "edited before purge"); err != nil {
+		t.Fatalf("RecordRevision: %v", err)
+	}
+	purged, err := ingest.PurgeDeleted(db, "slack-test")
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	var content string
+	var attachments, revisions int
+	_ = db.QueryRow(`SELECT content FROM events WHERE id = 'slack-test:m1'`).Scan(&content)
+	_ = db.QueryRow(`SELECT COUNT(*) FROM attachments WHERE event_id = 'slack-test:m1'`).Scan(&attachments)
+	_ = db.QueryRow(`SELECT COUNT(*) FROM event_revisions WHERE event_id = 'slack-test:m1'`).Scan(&revisions)
+	if purged != 1 || content != "" || attachments != 0 || revisions != 0 {
+		t.Fatalf("expected purged m1, got purged=%d content=%q attachments=%d revisions=%d", purged, content, attachments, revisions)
+	}
+}
+
+func TestExecAdapterEditHistory(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	a, err := NewExecAdapter("slack-test", ExecAdapterOptions{Command: writeFakeExtractor(t, fakeExtractor), Channel: "slack"})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+	if _, err := a.Sync(context.Background(), db, false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	for _, content := range []string{"hello everyone", "hello everyone!"} {
+		a.opts.Command = writeFakeExtractor(t, `#!/bin/sh
+echo '{"type":"event","id":"m1","thread_id":"C1","timestamp":1700000000,"content":"`+content+`"}'
+`)
+		if _, err := a.Sync(context.Background(), db, false); err != nil {
+			t.Fatalf("edit Sync: %v", err)
+		}
+	}
+	// An unchanged resync records nothing.
+	if _, err := a.Sync(context.Background(), db, false); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+
+	events, err := query.QueryEvents(db, query.EventFilters{Channel: "slack", ShowRevisions: true})
+	if err != nil {
+		t.Fatalf("QueryEvents: %v", err)
+	}
+	var revisions []query.Revision
+	for _, e := range events {
+		if e.ID == "slack-test:m1" {
+			revisions = e.Revisions
+		}
+	}
+	if len(revisions) != 2 || revisions[0].Content != "hello team" || revisions[1].Content != "hello everyone" {
+		t.Fatalf("unexpected revisions %+v", revisions)
+	}
+	if revisions[0].ObservedAt != 1700000000 || revisions[1].ObservedAt != revisions[0].ReplacedAt {
+		t.Fatalf("unexpected revision times %+v", revisions)
+	}
+	var edited int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM bus_events WHERE type = 'event.edited' AND mnemonic_event_id = 'slack-test:m1'`).Scan(&edited)
+	if edited != 2 {
+		t.Fatalf("expected 2 event.edited bus events, got %d", edited)
+	}
+	var created, updated int
+	_ = db.QueryRow(`
+		SELECT COALESCE(SUM(type = 'event.created'), 0), COALESCE(SUM(type = 'event.updated'), 0)
+		FROM bus_events WHERE mnemonic_event_id = 'slack-test:m1'
+	`).Scan(&created, &updated)
+	if created != 1 || updated != 2 {
+		t.Fatalf("expected 1 event.created and 2 event.updated bus events, got %d and %d", created, updated)
+	}
+}
+
+func TestExecAdapterErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"unknown record", `#!/bin/sh
+echo '{"type":"bogus"}'
+`, `unknown record type "bogus"`},
+		{"bad json", `#!/bin/sh
+echo 'not json'
+`, "line 1: invalid JSON"},
+		{"non-zero exit", `#!/bin/sh
+echo '{"type":"thread","id":"C1"}'
+echo 'token expired' >&2
+exit 3
+`, "token expired"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testutil.OpenTestDB(t)
+			defer db.Close()
+			a, err := NewExecAdapter("broken", ExecAdapterOptions{Command: writeFakeExtractor(t, tc.script)})
+			if err != nil {
+				t.Fatalf("NewExecAdapter: %v", err)
+			}
+			_, err = a.Sync(context.Background(), db, false)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+			var threads int
+			_ = db.QueryRow(`SELECT COUNT(*) FROM threads`).Scan(&threads)
+			if threads != 0 {
+				t.Fatalf("expected failed run to roll back, found %d threads", threads)
+			}
+		})
+	}
+}
+
+func TestExecAdapterCommitsInBatches(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	defer func(d time.Duration) { execCommitInterval = d }(execCommitInterval)
+	execCommitInterval = time.Hour
+
+	script := `#!/bin/sh
+i=0
+while [ $i -le 500 ]; do
+  echo "{\"type\":\"event\",\"id\":\"m$i\",\"timestamp\":1700000000,\"content\":\"msg $i\"}"
+  i=$((i+1))
+done
+echo '{"type":"cursor","value":"c1"}'
+exit 3
+`
+	a, err := NewExecAdapter("batched", ExecAdapterOptions{Command: writeFakeExtractor(t, script)})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+	if _, err := a.Sync(context.Background(), db, false); err == nil {
+		t.Fatalf("expected non-zero exit to fail the sync")
+	}
+
+	// The first full batch stays; the one in progress and the cursor do not.
+	var events int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM events WHERE source_adapter = 'batched'`).Scan(&events)
+	if events != execCommitEvery {
+		t.Fatalf("expected %d committed events, got %d", execCommitEvery, events)
+	}
+	if v, ok, _ := state.Get(db, "batched", "cursor"); ok {
+		t.Fatalf("expected cursor to stay unset, got %q", v)
+	}
+}
+
+func TestExecAdapterCommitsWhileChildWaits(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	defer func(d time.Duration) { execCommitInterval = d }(execCommitInterval)
+	execCommitInterval = 10 * time.Millisecond
+
+	script := `#!/bin/sh
+echo '{"type":"event","id":"m1","timestamp":1700000000,"content":"early"}'
+while [ ! -f "$RELEASE" ]; do sleep 0.01; done
+echo '{"type":"cursor","value":"c1"}'
+`
+	release := filepath.Join(t.TempDir(), "release")
+	a, err := NewExecAdapter("slow", ExecAdapterOptions{
+		Command: writeFakeExtractor(t, script),
+		Env:     map[string]string{"RELEASE": release},
+	})
+	if err != nil {
+		t.Fatalf("NewExecAdapter: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Sync(context.Background(), db, false)
+		done <- err
+	}()
+
+	// The buffered record is written while the child is still running, and
+	// the connection is free for other queries in the meantime.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var events int
+		_ = db.QueryRow(`SELECT COUNT(*) FROM events WHERE source_adapter = 'slow'`).Scan(&events)
+		if events == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the first batch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := os.WriteFile(release, nil, 0o644); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if v, _, _ := state.Get(db, "slow", "cursor"); v != "c1" {
+		t.Fatalf("expected cursor c1, got %q", v)
+	}
+}
@@ -11,11 +11,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"encoding/base64"
 	"github.com/Napageneral/mnemonic/internal/bus"
 	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
 	"github.com/Napageneral/mnemonic/internal/state"
 	_ "modernc.org/sqlite"
 )
@@ -25,6 +27,10 @@ type GmailAdapter struct {
 	name    string
 	account string
 	opts    GmailAdapterOptions
+
+	// tombstoned counts messages tombstoned during the current Sync; history
+	// workers update it concurrently.
+	tombstoned atomic.Int64
 }
 
 type GmailAdapterOptions struct {
@@ -212,6 +218,7 @@ func (g *GmailAdapter) syncGmailStateAndTags(cortexDB contacts.DBTX, eventID str
 	flagged := 0
 	archived := 1
 	status := "unknown"
+	trashed := false
 	for _, l := range labelIDs {
 		switch l {
 		case "STARRED", "IMPORTANT":
@@ -220,8 +227,13 @@ func (g *GmailAdapter) syncGmailStateAndTags(cortexDB contacts.DBTX, eventID str
 			archived = 0
 		case "DRAFT":
 			status = "draft"
+		case "TRASH":
+			trashed = true
 		}
 	}
+	if trashed {
+		status = ingest.DeletedStatus
+	}
 	if status == "unknown" {
 		if direction == "sent" {
 			status = "sent"
@@ -249,6 +261,7 @@ func (g *GmailAdapter) syncGmailStateAndTags(cortexDB contacts.DBTX, eventID str
 func (g *GmailAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
 	startTime := time.Now()
 	result := SyncResult{Perf: map[string]string{}}
+	g.tombstoned.Store(0)
 
 	// Enable foreign keys on cortex DB
 	if _, err := cortexDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
@@ -508,6 +521,7 @@ func (g *GmailAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (S
 	}
 
 	// Update sync watermark
+	result.EventsDeleted = int(g.tombstoned.Load())
 	result.Duration = time.Since(startTime)
 	result.Perf["total"] = result.Duration.String()
 	return result, nil
@@ -1084,6 +1098,21 @@ func (g *GmailAdapter) syncMessageWithDB(cortexDB contacts.DBTX, message GmailMe
 		return false, false, participantsCreated, fmt.Errorf("failed to sync participants: %w", err)
 	}
 
+	// Deleting a message in Gmail moves it to the trash, which only history
+	// reports. Tombstone it before the state below records it as deleted.
+	for _, label := range message.LabelIDs {
+		if label == "TRASH" {
+			deleted, err := ingest.MarkDeleted(cortexDB, g.Name(), eventID)
+			if err != nil {
+				return false, false, participantsCreated, err
+			}
+			if deleted {
+				g.tombstoned.Add(1)
+			}
+			break
+		}
+	}
+
 	if err := g.syncGmailStateAndTags(cortexDB, eventID, message.LabelIDs, direction); err != nil {
 		return false, false, participantsCreated, err
 	}
@@ -1103,18 +1132,24 @@ func (g *GmailAdapter) upsertEvent(cortexDB contacts.DBTX, eventID string, times
 		return false, false, fmt.Errorf("failed to insert event: %w", err)
 	}
 	if n, _ := res.RowsAffected(); n > 0 {
-		_ = bus.Emit(cortexDB, "cortex.event.created", g.Name(), eventID, map[string]any{
-			"channel":        "gmail",
-			"direction":      direction,
-			"timestamp":      timestamp,
-			"thread_id":      threadID,
-			"source_id":      sourceID,
-			"source_adapter": g.Name(),
-		})
+		if err := bus.Emit(cortexDB, bus.TypeEventCreated, g.Name(), eventID, bus.EventPayload{
+			Channel:       "gmail",
+			Direction:     direction,
+			Timestamp:     timestamp,
+			ThreadID:      threadID,
+			SourceAdapter: g.Name(),
+			SourceID:      sourceID,
+		}); err != nil {
+			return false, false, err
+		}
 		return true, false, nil
 	}
 
-	// Already exists: update selectively.
+	// Already exists: update selectively, keeping the prior body when a
+	// draft was rewritten.
+	if _, err := ingest.RecordRevision(cortexDB, g.Name(), sourceID, content); err != nil {
+		return false, false, err
+	}
 	res, err = cortexDB.Exec(`
 		UPDATE events
 		SET
@@ -1129,14 +1164,16 @@ func (g *GmailAdapter) upsertEvent(cortexDB contacts.DBTX, eventID string, times
 		return false, false, fmt.Errorf("failed to update event: %w", err)
 	}
 	if n, _ := res.RowsAffected(); n > 0 {
-		_ = bus.Emit(cortexDB, "cortex.event.updated", g.Name(), eventID, map[string]any{
-			"channel":        "gmail",
-			"direction":      direction,
-			"timestamp":      timestamp,
-			"thread_id":      threadID,
-			"source_id":      sourceID,
-			"source_adapter": g.Name(),
-		})
+		if err := bus.Emit(cortexDB, bus.TypeEventUpdated, g.Name(), eventID, bus.EventPayload{
+			Channel:       "gmail",
+			Direction:     direction,
+			Timestamp:     timestamp,
+			ThreadID:      threadID,
+			SourceAdapter: g.Name(),
+			SourceID:      sourceID,
+		}); err != nil {
+			return false, false, err
+		}
 		return false, true, nil
 	}
 	return false, false, nil
@@ -0,0 +1,348 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ical"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/state"
+)
+
+// ICSAdapterOptions is the config.yaml shape for type ics.
+type ICSAdapterOptions struct {
+	// Path is an .ics file or a directory of them.
+	Path string `yaml:"path"`
+	// CalendarID overrides the calendar id (default: the file's base name).
+	// For directories it prefixes each file's base name.
+	CalendarID string `yaml:"calendar_id"`
+	// PastDays and FutureDays bound recurrence expansion around now.
+	PastDays   int `yaml:"past_days"`
+	FutureDays int `yaml:"future_days"`
+}
+
+func (o *ICSAdapterOptions) Validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("'path' is required (an .ics file or directory)")
+	}
+	if o.PastDays < 0 || o.FutureDays < 0 {
+		return fmt.Errorf("'past_days' and 'future_days' must not be negative")
+	}
+	return nil
+}
+
+// ICSAdapter syncs events from exported iCalendar files (Outlook, Fastmail,
+// iCloud). It writes the same events, participants, state and tags as
+// CalendarAdapter so both sources read the same downstream.
+type ICSAdapter struct {
+	name string
+	opts ICSAdapterOptions
+	cal  *CalendarAdapter
+}
+
+func NewICSAdapter(name string, opts ICSAdapterOptions) (*ICSAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("adapter instance name is required for ics adapter")
+	}
+	if strings.TrimSpace(opts.Path) == "" {
+		return nil, fmt.Errorf("ics adapter requires a path")
+	}
+	if strings.HasPrefix(opts.Path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Path = filepath.Join(home, opts.Path[2:])
+	}
+	if opts.PastDays == 0 {
+		opts.PastDays = 365
+	}
+	if opts.FutureDays == 0 {
+		opts.FutureDays = 365
+	}
+	return &ICSAdapter{name: name, opts: opts, cal: &CalendarAdapter{name: name}}, nil
+}
+
+func (a *ICSAdapter) Name() string { return a.name }
+
+// Path returns the watched file or directory.
+func (a *ICSAdapter) Path() string { return a.opts.Path }
+
+// Files lists the .ics files to sync.
+func (a *ICSAdapter) Files() ([]string, error) {
+	info, err := os.Stat(a.opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{a.opts.Path}, nil
+	}
+	entries, err := os.ReadDir(a.opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".ics") {
+			files = append(files, filepath.Join(a.opts.Path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (a *ICSAdapter) calendarID(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if a.opts.CalendarID == "" {
+		return base
+	}
+	if info, err := os.Stat(a.opts.Path); err == nil && info.IsDir() {
+		return a.opts.CalendarID + "/" + base
+	}
+	return a.opts.CalendarID
+}
+
+func (a *ICSAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
+	start := time.Now()
+	res := SyncResult{Perf: map[string]string{}}
+
+	if _, err := cortexDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return res, err
+	}
+	if err := a.cal.ensureCalendarTables(cortexDB); err != nil {
+		return res, err
+	}
+
+	files, err := a.Files()
+	if err != nil {
+		return res, fmt.Errorf("ics adapter: %w", err)
+	}
+
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, -a.opts.PastDays)
+	to := now.AddDate(0, 0, a.opts.FutureDays)
+
+	cache := map[string]string{}
+	unchanged := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return res, err
+		}
+		// Unchanged files are skipped, but only within the same day: the
+		// recurrence window moves with the clock.
+		stateKey := "ics_file:" + file
+		stamp := fmt.Sprintf("%d:%d:%s", info.ModTime().UnixNano(), info.Size(), now.Format("2006-01-02"))
+		if !full {
+			if v, ok, _ := state.Get(cortexDB, a.name, stateKey); ok && v == stamp {
+				unchanged++
+				continue
+			}
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return res, err
+		}
+		parsed, err := ical.Parse(f)
+		f.Close()
+		if err != nil {
+			return res, fmt.Errorf("parse %s: %w", filepath.Base(file), err)
+		}
+
+		cal := gogCalendar{ID: a.calendarID(file), Summary: parsed.Name}
+		if cal.Summary == "" {
+			cal.Summary = cal.ID
+		}
+		written := map[string]bool{}
+		for _, ev := range expandICSEvents(parsed.Events, from, to) {
+			if err := a.cal.writeEvent(cortexDB, cal, ev, cache, &res); err != nil {
+				return res, err
+			}
+			written[ev.ID] = true
+		}
+		removed, err := a.tombstoneRemoved(cortexDB, cal.ID, parsed.Events, written, from, to)
+		if err != nil {
+			return res, err
+		}
+		res.EventsDeleted += removed
+		if err := state.Set(cortexDB, a.name, stateKey, stamp); err != nil {
+			return res, err
+		}
+	}
+
+	res.Duration = time.Since(start)
+	res.Perf["files"] = fmt.Sprintf("%d", len(files))
+	res.Perf["files_unchanged"] = fmt.Sprintf("%d", unchanged)
+	res.Perf["total"] = res.Duration.String()
+	return res, nil
+}
+
+// tombstoneRemoved tombstones events of a calendar that its file no longer
+// produces. Instances of a recurring event still in the file are kept when
+// they only fell outside the expansion window.
+func (a *ICSAdapter) tombstoneRemoved(db *sql.DB, calendarID string, events []ical.Event, written map[string]bool, from, to time.Time) (int, error) {
+	recurring := map[string]bool{}
+	for _, ev := range events {
+		if ev.UID != "" && ev.IsRecurring() {
+			recurring[ev.UID] = true
+		}
+	}
+
+	rows, err := db.Query(`
+		SELECT e.id, e.source_id, e.timestamp FROM events e
+		WHERE e.source_adapter = ? AND e.thread_id = ? AND `+ingest.NotDeleted("e"),
+		a.name, "calendar:"+calendarID)
+	if err != nil {
+		return 0, fmt.Errorf("query ics events: %w", err)
+	}
+	var stale []string
+	for rows.Next() {
+		var id, sourceID string
+		var ts int64
+		if err := rows.Scan(&id, &sourceID, &ts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan ics event: %w", err)
+		}
+		evID := strings.TrimPrefix(sourceID, calendarID+":")
+		if written[evID] {
+			continue
+		}
+		if ts < from.Unix() || ts >= to.Unix() {
+			if i := strings.LastIndex(evID, "_"); i > 0 && recurring[evID[:i]] {
+				continue
+			}
+		}
+		stale = append(stale, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, fmt.Errorf("iterate ics events: %w", err)
+	}
+
+	removed := 0
+	for _, id := range stale {
+		deleted, err := ingest.MarkDeleted(db, a.name, id)
+		if err != nil {
+			return removed, err
+		}
+		if deleted {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// expandICSEvents turns VEVENTs into calendar events. Recurring events are
+// expanded within [from, to) into instances whose ids follow Google's
+// "<uid>_<start>" convention; RECURRENCE-ID overrides replace the instance
+// they modify. Non-recurring events are always included.
+func expandICSEvents(events []ical.Event, from, to time.Time) []gogCalendarEvent {
+	overrides := map[string]map[int64]ical.Event{}
+	masters := map[string]bool{}
+	for _, ev := range events {
+		if ev.RecurrenceID.IsZero() {
+			masters[ev.UID] = true
+			continue
+		}
+		if overrides[ev.UID] == nil {
+			overrides[ev.UID] = map[int64]ical.Event{}
+		}
+		overrides[ev.UID][ev.RecurrenceID.Unix()] = ev
+	}
+
+	var out []gogCalendarEvent
+	for _, ev := range events {
+		if ev.UID == "" {
+			continue
+		}
+		if !ev.RecurrenceID.IsZero() {
+			// Orphaned override: its master is not in this file.
+			if !masters[ev.UID] && !ev.Start.Before(from) && ev.Start.Before(to) {
+				out = append(out, icsToCalendarEvent(ev, icsInstanceID(ev.UID, ev.RecurrenceID, ev.AllDay), ev.Start, ev.End))
+			}
+			continue
+		}
+		if !ev.IsRecurring() {
+			out = append(out, icsToCalendarEvent(ev, ev.UID, ev.Start, ev.End))
+			continue
+		}
+		starts, err := ev.Occurrences(from, to)
+		if err != nil {
+			// Unsupported rule: keep the first instance rather than dropping it.
+			out = append(out, icsToCalendarEvent(ev, ev.UID, ev.Start, ev.End))
+			continue
+		}
+		duration := ev.End.Sub(ev.Start)
+		for _, t := range starts {
+			id := icsInstanceID(ev.UID, t, ev.AllDay)
+			if o, ok := overrides[ev.UID][t.Unix()]; ok {
+				out = append(out, icsToCalendarEvent(o, id, o.Start, o.End))
+				continue
+			}
+			out = append(out, icsToCalendarEvent(ev, id, t, t.Add(duration)))
+		}
+	}
+	return out
+}
+
+func icsInstanceID(uid string, start time.Time, allDay bool) string {
+	if allDay {
+		return uid + "_" + start.Format("20060102")
+	}
+	return uid + "_" + start.UTC().Format("20060102T150405Z")
+}
+
+// icsPartStat maps PARTSTAT to the Google responseStatus vocabulary.
+var icsPartStat = map[string]string{
+	"NEEDS-ACTION": "needsAction",
+	"ACCEPTED":     "accepted",
+	"DECLINED":     "declined",
+	"TENTATIVE":    "tentative",
+	"DELEGATED":    "delegated",
+}
+
+func icsToCalendarEvent(ev ical.Event, id string, start, end time.Time) gogCalendarEvent {
+	out := gogCalendarEvent{
+		ID:          id,
+		Status:      strings.ToLower(ev.Status),
+		Summary:     ev.Summary,
+		Description: ev.Description,
+		Location:    ev.Location,
+		HTMLLink:    ev.URL,
+		Start:       icsEventTime(start, ev.AllDay),
+		End:         icsEventTime(end, ev.AllDay),
+	}
+	if ev.Organizer != nil {
+		out.Organizer = &gogEventPerson{
+			Email:       ev.Organizer.Email,
+			DisplayName: ev.Organizer.Name,
+			Response:    icsPartStat[ev.Organizer.PartStat],
+		}
+	}
+	for _, p := range ev.Attendees {
+		out.Attendees = append(out.Attendees, gogEventPerson{
+			Email:       p.Email,
+			DisplayName: p.Name,
+			Response:    icsPartStat[p.PartStat],
+		})
+	}
+	return out
+}
+
+func icsEventTime(t time.Time, allDay bool) gogEventTime {
+	if allDay {
+		return gogEventTime{Date: t.Format("2006-01-02")}
+	}
+	return gogEventTime{DateTime: t.Format(time.RFC3339), TimeZone: t.Location().String()}
+}
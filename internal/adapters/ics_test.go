@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/db"
+)
+
+func TestICSAdapter_Sync(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	d, err := db.Open()
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+
+	// A weekly series starting last week with four instances; the third is
+	// excluded and the fourth rescheduled by an override.
+	first := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -7).Add(15 * time.Hour)
+	stamp := func(t time.Time) string { return t.Format("20060102T150405Z") }
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:Fastmail",
+		"BEGIN:VEVENT",
+		"UID:sync-1",
+		"SUMMARY:Weekly sync",
+		"DTSTART:" + stamp(first),
+		"DTEND:" + stamp(first.Add(30*time.Minute)),
+		"RRULE:FREQ=WEEKLY;COUNT=4",
+		"EXDATE:" + stamp(first.AddDate(0, 0, 14)),
+		"STATUS:CONFIRMED",
+		"ORGANIZER;CN=Alice:mailto:alice@example.com",
+		"ATTENDEE;CN=Bob;PARTSTAT=TENTATIVE:mailto:bob@example.com",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:sync-1",
+		"RECURRENCE-ID:" + stamp(first.AddDate(0, 0, 21)),
+		"SUMMARY:Weekly sync (moved)",
+		"DTSTART:" + stamp(first.AddDate(0, 0, 22)),
+		"DTEND:" + stamp(first.AddDate(0, 0, 22).Add(30*time.Minute)),
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:dinner-1",
+		"SUMMARY:Dinner",
+		"DTSTART;VALUE=DATE:20200105",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	path := filepath.Join(tmp, "personal.ics")
+	if err := os.WriteFile(path, []byte(ics), 0o644); err != nil {
+		t.Fatalf("write ics: %v", err)
+	}
+
+	reg, ok := Lookup("ics")
+	if !ok {
+		t.Fatalf("ics adapter not registered")
+	}
+	opts, err := reg.DecodeOptions(map[string]any{"path": path})
+	if err != nil {
+		t.Fatalf("DecodeOptions: %v", err)
+	}
+	a, err := reg.Factory("ics-personal", opts)
+	if err != nil {
+		t.Fatalf("Factory: %v", err)
+	}
+
+	res, err := a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	// 3 weekly instances (one excluded) + the all-day dinner; the cancelled
+	// override keeps its status and is not tombstoned.
+	if res.EventsCreated != 4 || res.EventsDeleted != 0 {
+		t.Fatalf("expected 4 events, got %+v", res)
+	}
+
+	firstID := fmt.Sprintf("ics-personal:personal:sync-1_%s", stamp(first))
+	var channel, contentTypes, direction, threadID, content, metadata string
+	if err := d.QueryRow(`
+		SELECT channel, content_types, direction, thread_id, content, metadata_json FROM events WHERE id = ?
+	`, firstID).Scan(&channel, &contentTypes, &direction, &threadID, &content, &metadata); err != nil {
+		t.Fatalf("query first instance: %v", err)
+	}
+	if channel != "calendar" || contentTypes != `["calendar_event"]` || direction != "observed" || threadID != "calendar:personal" {
+		t.Fatalf("unexpected shape channel=%q types=%s direction=%q thread=%q", channel, contentTypes, direction, threadID)
+	}
+	if !strings.Contains(content, "Summary: Weekly sync\nCalendar: Fastmail") {
+		t.Fatalf("unexpected content %q", content)
+	}
+	wantMetadata := fmt.Sprintf(`{"attendees":[{"email":"bob@example.com","response":"tentative"}],"end":%d}`, first.Add(30*time.Minute).Unix())
+	if metadata != wantMetadata {
+		t.Fatalf("unexpected metadata %s", metadata)
+	}
+
+	var roles string
+	if err := d.QueryRow(`
+		SELECT GROUP_CONCAT(role, ',') FROM (SELECT role FROM event_participants WHERE event_id = ? ORDER BY role)
+	`, firstID).Scan(&roles); err != nil || roles != "attendee,organizer" {
+		t.Fatalf("expected attendee and organizer, got %q (%v)", roles, err)
+	}
+
+	var tag string
+	if err := d.QueryRow(`SELECT tag FROM event_tags WHERE event_id = ? AND source = 'calendar'`, firstID).Scan(&tag); err != nil || tag != "calendar_id:personal" {
+		t.Fatalf("expected calendar_id tag, got %q (%v)", tag, err)
+	}
+
+	var excluded int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM events WHERE source_id = ?`, "personal:sync-1_"+stamp(first.AddDate(0, 0, 14))).Scan(&excluded)
+	if excluded != 0 {
+		t.Fatalf("EXDATE instance was imported")
+	}
+
+	movedID := fmt.Sprintf("ics-personal:personal:sync-1_%s", stamp(first.AddDate(0, 0, 21)))
+	var movedTS int64
+	var status string
+	if err := d.QueryRow(`
+		SELECT e.timestamp, s.status FROM events e JOIN event_state s ON s.event_id = e.id WHERE e.id = ?
+	`, movedID).Scan(&movedTS, &status); err != nil {
+		t.Fatalf("query override: %v", err)
+	}
+	if movedTS != first.AddDate(0, 0, 22).Unix() || status != "cancelled" {
+		t.Fatalf("unexpected override ts=%d status=%q", movedTS, status)
+	}
+
+	// Unchanged files are skipped on the next incremental sync.
+	res, err = a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.Perf["files_unchanged"] != "1" {
+		t.Fatalf("expected unchanged file to be skipped, got %+v", res)
+	}
+
+	// Events removed from the file are tombstoned.
+	withoutDinner := strings.Replace(ics, "BEGIN:VEVENT\r\nUID:dinner-1\r\nSUMMARY:Dinner\r\nDTSTART;VALUE=DATE:20200105\r\nEND:VEVENT\r\n", "", 1)
+	if withoutDinner == ics {
+		t.Fatalf("dinner not found in fixture")
+	}
+	if err := os.WriteFile(path, []byte(withoutDinner), 0o644); err != nil {
+		t.Fatalf("rewrite ics: %v", err)
+	}
+	res, err = a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("third Sync: %v", err)
+	}
+	var dinnerStatus string
+	_ = d.QueryRow(`SELECT status FROM event_state WHERE event_id = 'ics-personal:personal:dinner-1'`).Scan(&dinnerStatus)
+	if res.EventsDeleted != 1 || dinnerStatus != "deleted" {
+		t.Fatalf("expected removed dinner tombstoned, got %+v status=%q", res, dinnerStatus)
+	}
+}
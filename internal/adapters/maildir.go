@@ -0,0 +1,693 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/state"
+)
+
+// MaildirAdapterOptions is the config.yaml shape for type maildir.
+type MaildirAdapterOptions struct {
+	// Path is a single Maildir (cur/new/tmp) or a root holding one per
+	// folder, as written by mbsync or offlineimap.
+	Path string `yaml:"path"`
+	// Addresses are your own addresses; mail from them is "sent".
+	Addresses []string `yaml:"addresses"`
+	// Inbox is the folder whose messages are not archived (default INBOX;
+	// a Maildir at the root of Path is named INBOX).
+	Inbox string `yaml:"inbox"`
+	// Exclude lists folders to skip, e.g. Junk.
+	Exclude []string `yaml:"exclude"`
+}
+
+func (o *MaildirAdapterOptions) Validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("'path' is required (a Maildir or a directory of Maildir folders)")
+	}
+	return nil
+}
+
+// MaildirAdapter syncs mail kept locally by mbsync, offlineimap or any MDA
+// that writes Maildir. Messages become email events keyed by Message-ID, so a
+// message filed in several folders is stored once. Files already seen are
+// remembered in adapter_state; a renamed file (Maildir's way of changing
+// flags) only refreshes event_state.
+type MaildirAdapter struct {
+	name      string
+	opts      MaildirAdapterOptions
+	addresses map[string]bool
+	exclude   map[string]bool
+}
+
+func NewMaildirAdapter(name string, opts MaildirAdapterOptions) (*MaildirAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("adapter instance name is required for maildir adapter")
+	}
+	if strings.TrimSpace(opts.Path) == "" {
+		return nil, fmt.Errorf("maildir adapter requires a path")
+	}
+	if strings.HasPrefix(opts.Path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Path = filepath.Join(home, opts.Path[2:])
+	}
+	if opts.Inbox == "" {
+		opts.Inbox = "INBOX"
+	}
+	a := &MaildirAdapter{name: name, opts: opts, addresses: map[string]bool{}, exclude: map[string]bool{}}
+	for _, addr := range opts.Addresses {
+		if addr = strings.ToLower(strings.TrimSpace(addr)); addr != "" {
+			a.addresses[addr] = true
+		}
+	}
+	for _, f := range opts.Exclude {
+		a.exclude[strings.ToLower(strings.Trim(f, "/"))] = true
+	}
+	return a, nil
+}
+
+func (a *MaildirAdapter) Name() string { return a.name }
+
+// Path returns the configured Maildir root.
+func (a *MaildirAdapter) Path() string { return a.opts.Path }
+
+// MaildirFolder is one Maildir directory under the adapter's root.
+type MaildirFolder struct {
+	Name string // IMAP-style name, e.g. INBOX or Archive/2023
+	Dir  string
+}
+
+// Folders lists the Maildir folders under Path, skipping excluded ones.
+// Nested (mbsync "SubFolders Verbatim") and Maildir++ (".Sent.2023") layouts
+// are both understood.
+func (a *MaildirAdapter) Folders() ([]MaildirFolder, error) {
+	if _, err := os.Stat(a.opts.Path); err != nil {
+		return nil, err
+	}
+	var out []MaildirFolder
+	err := filepath.WalkDir(a.opts.Path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case "cur", "new", "tmp":
+			return filepath.SkipDir
+		}
+		if !isMaildir(p) {
+			return nil
+		}
+		name := maildirFolderName(a.opts.Path, p)
+		if a.exclude[strings.ToLower(name)] {
+			return nil
+		}
+		out = append(out, MaildirFolder{Name: name, Dir: p})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func isMaildir(dir string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+func maildirFolderName(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return "INBOX"
+	}
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(part, ".") {
+			parts = append(parts, strings.Split(strings.TrimPrefix(part, "."), ".")...)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// splitMaildirName separates a Maildir file name into its unique part and
+// its flags (the letters after ":2,").
+func splitMaildirName(name string) (unique, flags string) {
+	if i := strings.Index(name, ":2,"); i >= 0 {
+		return name[:i], name[i+3:]
+	}
+	return name, ""
+}
+
+// maildirSeen is the adapter_state value recorded for each message file.
+type maildirSeen struct {
+	EventID   string `json:"event_id,omitempty"`
+	Flags     string `json:"flags"`
+	Direction string `json:"direction,omitempty"`
+}
+
+const maildirStatePrefix = "maildir_file:"
+
+const maildirCommitEvery = 500
+
+func (a *MaildirAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
+	start := time.Now()
+	res := SyncResult{Perf: map[string]string{}}
+
+	if _, err := cortexDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return res, err
+	}
+	folders, err := a.Folders()
+	if err != nil {
+		return res, fmt.Errorf("maildir adapter: %w", err)
+	}
+	known, err := state.List(cortexDB, a.name, maildirStatePrefix)
+	if err != nil {
+		return res, err
+	}
+
+	var tx *sql.Tx
+	var w *ingest.Writer
+	pending := 0
+	begin := func() error {
+		var err error
+		if tx, err = cortexDB.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("begin maildir tx: %w", err)
+		}
+		if w, err = ingest.NewWriter(tx, a.name); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		pending = 0
+		return nil
+	}
+	commit := func() error {
+		w.Close()
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit maildir tx: %w", err)
+		}
+		s := w.Stats
+		res.EventsCreated += s.EventsCreated
+		res.EventsUpdated += s.EventsUpdated
+		res.PersonsCreated += s.PersonsCreated
+		res.ThreadsCreated += s.ThreadsCreated
+		res.ThreadsUpdated += s.ThreadsUpdated
+		res.EventsDeleted += s.EventsDeleted
+		return nil
+	}
+	if err := begin(); err != nil {
+		return res, err
+	}
+	defer func() {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	present := map[string]bool{}
+	parsed, flagsChanged, unchanged := 0, 0, 0
+	for _, folder := range folders {
+		for _, sub := range []string{"new", "cur"} {
+			entries, err := os.ReadDir(filepath.Join(folder.Dir, sub))
+			if err != nil {
+				return res, err
+			}
+			for _, e := range entries {
+				if err := ctx.Err(); err != nil {
+					return res, err
+				}
+				if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+					continue
+				}
+				unique, flags := splitMaildirName(e.Name())
+				key := maildirStatePrefix + folder.Name + "/" + unique
+				present[key] = true
+
+				var seen maildirSeen
+				if raw, ok := known[key]; ok && !full && json.Unmarshal([]byte(raw), &seen) == nil {
+					if seen.Flags == flags {
+						unchanged++
+						continue
+					}
+					if seen.EventID != "" {
+						if err := a.applyState(w, seen.EventID, folder.Name, flags, seen.Direction); err != nil {
+							return res, err
+						}
+					}
+					seen.Flags = flags
+					if err := a.setSeen(tx, key, seen); err != nil {
+						return res, err
+					}
+					flagsChanged++
+					continue
+				}
+
+				seen, err = a.importMessage(w, folder, filepath.Join(folder.Dir, sub, e.Name()), unique, flags)
+				if err != nil {
+					return res, fmt.Errorf("%s/%s: %w", folder.Name, e.Name(), err)
+				}
+				if err := a.setSeen(tx, key, seen); err != nil {
+					return res, err
+				}
+				parsed++
+				pending++
+				if pending >= maildirCommitEvery {
+					if err := commit(); err != nil {
+						return res, err
+					}
+					if err := begin(); err != nil {
+						return res, err
+					}
+				}
+			}
+		}
+	}
+
+	// Files that disappeared were expunged or moved to another folder; the
+	// event loses this folder's tag, and once no folder holds it the message
+	// is tombstoned.
+	removed := 0
+	for key, raw := range known {
+		if present[key] {
+			continue
+		}
+		var seen maildirSeen
+		if json.Unmarshal([]byte(raw), &seen) == nil && seen.EventID != "" {
+			folder := strings.TrimPrefix(key, maildirStatePrefix)
+			folder = folder[:strings.LastIndex(folder, "/")]
+			if _, err := tx.Exec(`
+				DELETE FROM event_tags WHERE event_id = ? AND tag = ? AND source = 'maildir'
+			`, seen.EventID, "maildir_folder:"+folder); err != nil {
+				return res, fmt.Errorf("remove folder tag: %w", err)
+			}
+			var folders int
+			if err := tx.QueryRow(`
+				SELECT COUNT(*) FROM event_tags WHERE event_id = ? AND tag LIKE 'maildir_folder:%' AND source = 'maildir'
+			`, seen.EventID).Scan(&folders); err != nil {
+				return res, fmt.Errorf("count folder tags: %w", err)
+			}
+			if folders == 0 {
+				deleted, err := ingest.MarkDeleted(tx, a.name, seen.EventID)
+				if err != nil {
+					return res, err
+				}
+				if deleted {
+					res.EventsDeleted++
+				}
+			}
+		}
+		if err := state.Delete(tx, a.name, key); err != nil {
+			return res, err
+		}
+		removed++
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO sync_watermarks (adapter, last_sync_at)
+		VALUES (?, ?)
+		ON CONFLICT(adapter) DO UPDATE SET last_sync_at = excluded.last_sync_at
+	`, a.name, time.Now().Unix()); err != nil {
+		return res, fmt.Errorf("failed to update sync watermark: %w", err)
+	}
+	if err := commit(); err != nil {
+		return res, err
+	}
+	tx = nil
+
+	res.Duration = time.Since(start)
+	res.Perf["folders"] = strconv.Itoa(len(folders))
+	res.Perf["files_parsed"] = strconv.Itoa(parsed)
+	res.Perf["files_flags_changed"] = strconv.Itoa(flagsChanged)
+	res.Perf["files_unchanged"] = strconv.Itoa(unchanged)
+	res.Perf["files_removed"] = strconv.Itoa(removed)
+	res.Perf["total"] = res.Duration.String()
+	return res, nil
+}
+
+func (a *MaildirAdapter) setSeen(tx *sql.Tx, key string, seen maildirSeen) error {
+	b, err := json.Marshal(seen)
+	if err != nil {
+		return err
+	}
+	return state.Set(tx, a.name, key, string(b))
+}
+
+// eventState maps Maildir flags onto event_state: S(een), F(lagged),
+// D(raft) and T(rashed). Anything outside the inbox counts as archived, the
+// same rule the Gmail adapter applies to the INBOX label.
+func (a *MaildirAdapter) eventState(folder, flags, direction string) ingest.EventState {
+	s := ingest.EventState{
+		ReadState: "unread",
+		Flagged:   strings.Contains(flags, "F"),
+		Archived:  !strings.EqualFold(folder, a.opts.Inbox),
+		Status:    direction,
+	}
+	if strings.Contains(flags, "S") {
+		s.ReadState = "read"
+	}
+	switch {
+	case strings.Contains(flags, "D"):
+		s.Status = "draft"
+	case strings.Contains(flags, "T"):
+		s.Status = ingest.DeletedStatus
+	}
+	return s
+}
+
+// applyState writes a message's flag state. Trashed messages are tombstoned
+// first, so event.deleted is emitted once and the status written afterwards
+// keeps them deleted.
+func (a *MaildirAdapter) applyState(w *ingest.Writer, eventID, folder, flags, direction string) error {
+	s := a.eventState(folder, flags, direction)
+	if s.Status == ingest.DeletedStatus {
+		if _, err := w.TombstoneEvent(eventID); err != nil {
+			return err
+		}
+	}
+	return w.SetState(eventID, s)
+}
+
+func (a *MaildirAdapter) importMessage(w *ingest.Writer, folder MaildirFolder, path, unique, flags string) (maildirSeen, error) {
+	seen := maildirSeen{Flags: flags}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return seen, err
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		// Unparseable files are remembered so they are not retried every sync.
+		return seen, nil
+	}
+
+	h := msg.Header
+	subject := decodeMIMEHeader(h.Get("Subject"))
+	from := h.Get("From")
+	ts := maildirTimestamp(h.Get("Date"), unique, path)
+
+	body, hasAttachment := maildirBody(h.Get("Content-Type"), h.Get("Content-Transfer-Encoding"), msg.Body)
+	content := subject
+	if body != "" {
+		content = fmt.Sprintf("Subject: %s\n\n%s", subject, body)
+	}
+	contentTypes := []string{"text"}
+	if hasAttachment {
+		contentTypes = append(contentTypes, "attachment")
+	}
+
+	messageID := maildirMessageID(h.Get("Message-ID"))
+	if messageID == "" {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s|%s", ts, from, subject, body)))
+		messageID = hex.EncodeToString(sum[:])
+	}
+
+	direction := "received"
+	for _, p := range parseEmailAddresses(from) {
+		if a.addresses[p.Email] {
+			direction = "sent"
+		}
+	}
+	if strings.Contains(strings.ToLower(folder.Name), "sent") {
+		direction = "sent"
+	}
+
+	inReplyTo := ""
+	if ids := maildirMessageIDs(h.Get("In-Reply-To")); len(ids) > 0 {
+		inReplyTo = ids[0]
+	}
+	threadID, err := a.thread(w, messageID, inReplyTo, maildirMessageIDs(h.Get("References")), subject)
+	if err != nil {
+		return seen, err
+	}
+	replyTo := ""
+	if inReplyTo != "" {
+		replyTo = w.ID(inReplyTo)
+	}
+
+	eventID, _, err := w.UpsertEvent(ingest.Event{
+		SourceID:     messageID,
+		Timestamp:    ts,
+		Channel:      "email",
+		ContentTypes: contentTypes,
+		Content:      content,
+		Direction:    direction,
+		ThreadID:     threadID,
+		ReplyTo:      replyTo,
+	})
+	if err != nil {
+		return seen, err
+	}
+
+	for _, field := range []struct{ header, role string }{
+		{"From", "sender"}, {"To", "recipient"}, {"Cc", "cc"}, {"Bcc", "bcc"},
+	} {
+		for _, p := range parseEmailAddresses(h.Get(field.header)) {
+			contactID, err := w.Contact("email", p.Email, decodeMIMEHeader(p.Name))
+			if err != nil {
+				continue
+			}
+			if err := w.AddParticipant(eventID, contactID, field.role); err != nil {
+				return seen, err
+			}
+		}
+	}
+
+	if err := w.AddTag(eventID, "maildir_folder:"+folder.Name, "maildir"); err != nil {
+		return seen, err
+	}
+	if err := a.applyState(w, eventID, folder.Name, flags, direction); err != nil {
+		return seen, err
+	}
+
+	seen.EventID = eventID
+	seen.Direction = direction
+	return seen, nil
+}
+
+// thread returns the thread for a message. A message joins the thread of any
+// message it references that is already stored; otherwise the thread is
+// keyed by the oldest reference, so replies that arrive before their root
+// still land in the root's thread.
+func (a *MaildirAdapter) thread(w *ingest.Writer, messageID, inReplyTo string, references []string, subject string) (string, error) {
+	refs := references
+	if inReplyTo != "" && !containsString(refs, inReplyTo) {
+		refs = append(refs, inReplyTo)
+	}
+	for i := len(refs) - 1; i >= 0; i-- {
+		var threadID sql.NullString
+		err := w.Tx().QueryRow(`
+			SELECT thread_id FROM events WHERE source_adapter = ? AND source_id = ?
+		`, a.name, refs[i]).Scan(&threadID)
+		if err == nil && threadID.Valid && threadID.String != "" {
+			return threadID.String, nil
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("lookup referenced message: %w", err)
+		}
+	}
+
+	root := messageID
+	if len(refs) > 0 {
+		root = refs[0]
+	}
+	return w.UpsertThread(ingest.Thread{
+		SourceID: root,
+		Channel:  "email",
+		Name:     maildirThreadName(subject),
+	})
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+var maildirSubjectPrefix = regexp.MustCompile(`(?i)^\s*((re|fwd?|aw|wg|sv)(\[\d+\])?\s*:\s*)+`)
+
+func maildirThreadName(subject string) string {
+	return strings.TrimSpace(maildirSubjectPrefix.ReplaceAllString(subject, ""))
+}
+
+var maildirIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+// maildirMessageIDs extracts the <id> tokens of a References or In-Reply-To
+// header, in order, without brackets.
+func maildirMessageIDs(v string) []string {
+	var out []string
+	for _, m := range maildirIDPattern.FindAllString(v, -1) {
+		out = append(out, strings.Trim(m, "<>"))
+	}
+	return out
+}
+
+func maildirMessageID(v string) string {
+	if ids := maildirMessageIDs(v); len(ids) > 0 {
+		return ids[0]
+	}
+	return strings.Trim(strings.TrimSpace(v), "<>")
+}
+
+// maildirTimestamp prefers the Date header, then the delivery time encoded at
+// the start of Maildir file names, then the file's mtime.
+func maildirTimestamp(date, unique, path string) int64 {
+	if t, err := mail.ParseDate(date); err == nil {
+		return t.Unix()
+	}
+	if i := strings.Index(unique, "."); i > 0 {
+		if sec, err := strconv.ParseInt(unique[:i], 10, 64); err == nil && sec > 0 {
+			return sec
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime().Unix()
+	}
+	return time.Now().Unix()
+}
+
+const maildirMaxBody = 2 * 1024 * 1024
+
+// maildirBody returns the message text, preferring text/plain over HTML, and
+// whether any part is an attachment.
+func maildirBody(contentType, encoding string, r io.Reader) (string, bool) {
+	plain, htmlText, hasAttachment := maildirWalk(contentType, encoding, "", r, 0)
+	if plain == "" && htmlText != "" {
+		plain = htmlToText(htmlText)
+	}
+	return strings.TrimSpace(plain), hasAttachment
+}
+
+func maildirWalk(contentType, encoding, disposition string, r io.Reader, depth int) (plain, htmlText string, hasAttachment bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || contentType == "" {
+		mediaType = "text/plain"
+	}
+	if d, dparams, err := mime.ParseMediaType(disposition); err == nil && (d == "attachment" || dparams["filename"] != "") {
+		return "", "", true
+	}
+	if params["name"] != "" && !strings.HasPrefix(mediaType, "multipart/") {
+		return "", "", true
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && depth < 10 {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err != nil {
+				break
+			}
+			p, ht, att := maildirWalk(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part, depth+1)
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = ht
+			}
+			hasAttachment = hasAttachment || att
+		}
+		return plain, htmlText, hasAttachment
+	}
+
+	switch mediaType {
+	case "text/plain", "text/html":
+	default:
+		return "", "", !strings.HasPrefix(mediaType, "multipart/")
+	}
+	body := r
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(r)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	}
+	b, _ := io.ReadAll(io.LimitReader(body, maildirMaxBody))
+	text := maildirCharset(params["charset"], b)
+	if mediaType == "text/html" {
+		return "", text, false
+	}
+	return text, "", false
+}
+
+// maildirCharset converts the Latin-1 family to UTF-8; other charsets are
+// passed through, which is right for UTF-8 and ASCII.
+func maildirCharset(charset string, b []byte) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252", "us-ascii":
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	return string(b)
+}
+
+// newlineStripper drops CR and LF so base64 bodies wrapped at 76 columns
+// decode with the standard decoder.
+type newlineStripper struct{ r io.Reader }
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		k, err := n.r.Read(p)
+		j := 0
+		for _, c := range p[:k] {
+			if c != '\r' && c != '\n' {
+				p[j] = c
+				j++
+			}
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]+>`)
+	blankLines       = regexp.MustCompile(`\n[ \t]*\n[ \t\n]*`)
+)
+
+func htmlToText(s string) string {
+	s = htmlDropPattern.ReplaceAllString(s, "")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,221 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/db"
+)
+
+func writeMaildirMessage(t *testing.T, dir, name string, lines ...string) string {
+	t.Helper()
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(strings.Join(lines, "\r\n")), 0o644); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	return p
+}
+
+func TestMaildirAdapter_Sync(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	d, err := db.Open()
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+
+	root := filepath.Join(tmp, "mail")
+	// The reply is delivered before the message it answers.
+	writeMaildirMessage(t, root, "new/1700000100.M1P1.host",
+		"From: Bob <bob@example.com>",
+		"To: me@example.com",
+		"Subject: Re: Lunch?",
+		"Date: Tue, 14 Nov 2023 22:15:00 +0000",
+		"Message-ID: <b1@example.com>",
+		"In-Reply-To: <a1@example.com>",
+		"References: <a1@example.com>",
+		"",
+		"Sure, noon works.",
+	)
+	replied := writeMaildirMessage(t, root, "cur/1700000000.M2P1.host:2,S",
+		"From: Alice <alice@example.com>",
+		"To: Me <me@example.com>, bob@example.com",
+		"Subject: Lunch?",
+		"Date: Tue, 14 Nov 2023 22:13:20 +0000",
+		"Message-ID: <a1@example.com>",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=XX",
+		"",
+		"--XX",
+		"Content-Type: text/html; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"<p>Lunch tomorrow=3F</p><style>p{}</style>",
+		"--XX",
+		"Content-Type: application/pdf; name=menu.pdf",
+		"Content-Disposition: attachment; filename=menu.pdf",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"JVBERi0=",
+		"--XX--",
+	)
+	writeMaildirMessage(t, filepath.Join(root, ".Sent"), "cur/1700000200.M3P1.host:2,S",
+		"From: Me <me@example.com>",
+		"To: alice@example.com",
+		"Subject: RE: Lunch?",
+		"Date: Tue, 14 Nov 2023 22:16:40 +0000",
+		"Message-ID: <c1@example.com>",
+		"In-Reply-To: <b1@example.com>",
+		"",
+		"See you both.",
+	)
+	writeMaildirMessage(t, filepath.Join(root, ".Junk"), "cur/1700000300.M4P1.host:2,",
+		"From: spam@example.com",
+		"Subject: Win",
+		"Message-ID: <spam@example.com>",
+		"",
+		"spam",
+	)
+
+	reg, ok := Lookup("maildir")
+	if !ok {
+		t.Fatalf("maildir adapter not registered")
+	}
+	opts, err := reg.DecodeOptions(map[string]any{"path": root, "exclude": []any{"Junk"}})
+	if err != nil {
+		t.Fatalf("DecodeOptions: %v", err)
+	}
+	a, err := reg.Factory("mail", opts)
+	if err != nil {
+		t.Fatalf("Factory: %v", err)
+	}
+
+	res, err := a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.EventsCreated != 3 || res.ThreadsCreated != 1 {
+		t.Fatalf("expected 3 events in 1 thread, got %+v", res)
+	}
+
+	rows, err := d.Query(`SELECT source_id, thread_id, direction, content_types, content, COALESCE(reply_to, '') FROM events WHERE source_adapter = 'mail' ORDER BY timestamp`)
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+	type row struct{ sourceID, threadID, direction, types, content, replyTo string }
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.sourceID, &r.threadID, &r.direction, &r.types, &r.content, &r.replyTo); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, r)
+	}
+	rows.Close()
+	if len(got) != 3 || got[0].sourceID != "a1@example.com" {
+		t.Fatalf("unexpected events %+v", got)
+	}
+	for _, r := range got {
+		if r.threadID != "mail:a1@example.com" {
+			t.Fatalf("event %s in thread %q", r.sourceID, r.threadID)
+		}
+	}
+	if got[0].types != `["text","attachment"]` || got[0].content != "Subject: Lunch?\n\nLunch tomorrow?" {
+		t.Fatalf("unexpected root %+v", got[0])
+	}
+	if got[2].direction != "sent" || got[2].replyTo != "mail:b1@example.com" {
+		t.Fatalf("unexpected sent reply %+v", got[2])
+	}
+
+	var threadName string
+	if err := d.QueryRow(`SELECT name FROM threads WHERE id = 'mail:a1@example.com'`).Scan(&threadName); err != nil || threadName != "Lunch?" {
+		t.Fatalf("unexpected thread name %q (%v)", threadName, err)
+	}
+
+	readState := func(sourceID string) (string, int, int) {
+		var rs string
+		var flagged, archived int
+		if err := d.QueryRow(`
+			SELECT s.read_state, s.flagged, s.archived FROM event_state s
+			JOIN events e ON e.id = s.event_id WHERE e.source_adapter = 'mail' AND e.source_id = ?
+		`, sourceID).Scan(&rs, &flagged, &archived); err != nil {
+			t.Fatalf("event_state %s: %v", sourceID, err)
+		}
+		return rs, flagged, archived
+	}
+	if rs, _, archived := readState("b1@example.com"); rs != "unread" || archived != 0 {
+		t.Fatalf("new/ message should be unread in inbox, got %s archived=%d", rs, archived)
+	}
+	if _, _, archived := readState("c1@example.com"); archived != 1 {
+		t.Fatalf("Sent message should be archived")
+	}
+
+	// Flagging renames the file; only its state changes.
+	if err := os.Rename(replied, strings.TrimSuffix(replied, "S")+"FS"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	res, err = a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.Perf["files_flags_changed"] != "1" || res.Perf["files_unchanged"] != "2" {
+		t.Fatalf("expected a flag-only update, got %+v", res)
+	}
+	if rs, flagged, _ := readState("a1@example.com"); rs != "read" || flagged != 1 {
+		t.Fatalf("expected read+flagged, got %s flagged=%d", rs, flagged)
+	}
+
+	// Expunged files drop their folder tag.
+	if err := os.Remove(filepath.Join(root, ".Sent", "cur", "1700000200.M3P1.host:2,S")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	res, err = a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("third Sync: %v", err)
+	}
+	var tags int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM event_tags WHERE event_id = 'mail:c1@example.com' AND source = 'maildir'`).Scan(&tags)
+	if res.Perf["files_removed"] != "1" || tags != 0 {
+		t.Fatalf("expected folder tag removed, got %d tags (%+v)", tags, res.Perf)
+	}
+	var status string
+	_ = d.QueryRow(`SELECT status FROM event_state WHERE event_id = 'mail:c1@example.com'`).Scan(&status)
+	var emitted int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM bus_events WHERE type = 'event.deleted' AND mnemonic_event_id = 'mail:c1@example.com'`).Scan(&emitted)
+	if res.EventsDeleted != 1 || status != "deleted" || emitted != 1 {
+		t.Fatalf("expected expunged message tombstoned, got deleted=%d status=%q bus=%d", res.EventsDeleted, status, emitted)
+	}
+
+	// Trashing (the T flag) tombstones the message too, once.
+	flaggedPath := strings.TrimSuffix(replied, "S") + "FS"
+	if err := os.Rename(flaggedPath, flaggedPath+"T"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	res, err = a.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("fourth Sync: %v", err)
+	}
+	if err := os.Rename(flaggedPath+"T", strings.TrimSuffix(replied, "S")+"ST"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := a.Sync(context.Background(), d, false); err != nil {
+		t.Fatalf("fifth Sync: %v", err)
+	}
+	_ = d.QueryRow(`SELECT status FROM event_state WHERE event_id = 'mail:a1@example.com'`).Scan(&status)
+	_ = d.QueryRow(`SELECT COUNT(*) FROM bus_events WHERE type = 'event.deleted' AND mnemonic_event_id = 'mail:a1@example.com'`).Scan(&emitted)
+	if res.EventsDeleted != 1 || status != "deleted" || emitted != 1 {
+		t.Fatalf("expected trashed message tombstoned once, got deleted=%d status=%q bus=%d", res.EventsDeleted, status, emitted)
+	}
+}
@@ -2,10 +2,12 @@ package adapters
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -14,9 +16,9 @@ import (
 )
 
 type NexusAdapterOptions struct {
-	EventsDir string
-	StateDir  string
-	Source    string
+	EventsDir string `yaml:"events_dir"`
+	StateDir  string `yaml:"state_dir"`
+	Source    string `yaml:"source"`
 }
 
 // NexusAdapter syncs Nexus event logs into cortex events.
@@ -104,60 +106,13 @@ func (a *NexusAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (S
 		return result, err
 	}
 
-	adapterPrefix := a.Name() + ":"
-	threadPrefix := "nexus_session:"
-	const contentTypesText = "[\"text\"]"
-
 	txStart := time.Now()
-	tx, err := cortexDB.BeginTx(ctx, nil)
-	if err != nil {
-		return result, fmt.Errorf("begin cortex tx: %w", err)
-	}
-	defer tx.Rollback()
-
-	stmtInsertThread, err := tx.Prepare(`
-		INSERT INTO threads (id, channel, name, source_adapter, source_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(source_adapter, source_id) DO UPDATE SET
-			name = excluded.name,
-			updated_at = excluded.updated_at
-	`)
+	batch, err := a.beginBatch(ctx, cortexDB, &result)
 	if err != nil {
-		return result, fmt.Errorf("prepare insert thread: %w", err)
-	}
-	defer stmtInsertThread.Close()
-
-	stmtInsertEvent, err := tx.Prepare(`
-		INSERT OR IGNORE INTO events (
-			id, timestamp, channel, content_types, content,
-			direction, thread_id, reply_to, source_adapter, source_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, NULL, ?, ?)
-	`)
-	if err != nil {
-		return result, fmt.Errorf("prepare insert event: %w", err)
-	}
-	defer stmtInsertEvent.Close()
-
-	stmtUpdateEvent, err := tx.Prepare(`
-		UPDATE events
-		SET
-			content = ?,
-			content_types = ?,
-			thread_id = ?
-		WHERE source_adapter = ?
-		  AND source_id = ?
-		  AND (
-		    content IS NOT ?
-		    OR content_types IS NOT ?
-		    OR thread_id IS NOT ?
-		  )
-	`)
-	if err != nil {
-		return result, fmt.Errorf("prepare update event: %w", err)
+		return result, err
 	}
-	defer stmtUpdateEvent.Close()
+	defer batch.tx.Rollback()
 
-	threadsSeen := make(map[string]struct{})
 	maxTS := lastSync
 	maxEventID := ""
 	if lastEventID.Valid {
@@ -166,10 +121,7 @@ func (a *NexusAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (S
 
 	for _, file := range files {
 		if err := a.processFile(file, func(entry nexusEventLogEntry) error {
-			if a.source != "" && entry.Source != a.source {
-				return nil
-			}
-			if entry.ID == "" || entry.Ts == 0 || entry.SessionID == "" {
+			if !a.wants(entry) {
 				return nil
 			}
 			tsSec := entry.Ts / 1000
@@ -188,97 +140,263 @@ func (a *NexusAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (S
 			} else if tsSec == maxTS && entry.ID > maxEventID {
 				maxEventID = entry.ID
 			}
+			return batch.add(entry)
+		}); err != nil {
+			return result, err
+		}
+	}
 
-			channel := "nexus"
-			if strings.Contains(entry.Source, "agent") {
-				channel = "nexus_agent"
-			}
-			threadID := threadPrefix + entry.SessionID
-			if _, ok := threadsSeen[threadID]; !ok {
-				now := time.Now().Unix()
-				threadName := entry.Source
-				if threadName == "" {
-					threadName = "nexus"
-				}
-				res, err := stmtInsertThread.Exec(
-					threadID,
-					channel,
-					threadName,
-					a.Name(),
-					entry.SessionID,
-					now,
-					now,
-				)
-				if err != nil {
-					return fmt.Errorf("insert thread: %w", err)
-				}
-				var exists int
-				err = tx.QueryRow("SELECT 1 FROM threads WHERE source_adapter = ? AND source_id = ? AND updated_at < ?",
-					a.Name(), entry.SessionID, now).Scan(&exists)
-				if err == sql.ErrNoRows {
-					result.ThreadsCreated++
-				} else if err == nil {
-					result.ThreadsUpdated++
-				} else if n, _ := res.RowsAffected(); n > 0 {
-					result.ThreadsCreated++
-				}
-				threadsSeen[threadID] = struct{}{}
-			}
+	if err := batch.tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit cortex tx: %w", err)
+	}
 
-			contentBytes, err := json.Marshal(entry)
-			if err != nil {
-				return fmt.Errorf("marshal entry: %w", err)
-			}
-			content := string(contentBytes)
-			eventID := adapterPrefix + entry.ID
+	if err := a.saveWatermark(cortexDB, maxTS, maxEventID); err != nil {
+		return result, err
+	}
 
-			res, err := stmtInsertEvent.Exec(eventID, tsSec, channel, contentTypesText, content, "observed", threadID, a.Name(), entry.ID)
-			if err != nil {
-				return fmt.Errorf("insert event: %w", err)
-			}
-			if n, _ := res.RowsAffected(); n == 1 {
-				result.EventsCreated++
-			} else {
-				res2, err := stmtUpdateEvent.Exec(
-					content, contentTypesText, threadID,
-					a.Name(), entry.ID,
-					content, contentTypesText, threadID,
-				)
-				if err != nil {
-					return fmt.Errorf("update event: %w", err)
-				}
-				if n2, _ := res2.RowsAffected(); n2 == 1 {
-					result.EventsUpdated++
-				}
-			}
+	result.Duration = time.Since(start)
+	if result.Perf == nil {
+		result.Perf = map[string]string{}
+	}
+	result.Perf["tx_commit"] = time.Since(txStart).String()
+	return result, nil
+}
 
-			return nil
-		}); err != nil {
-			return result, err
+// SyncAppended ingests the complete lines appended to one event log since
+// offset and returns the offset to resume from. A trailing line without a
+// newline is left for the next call; a file shorter than offset is assumed
+// to have been truncated or replaced and is read from the start.
+func (a *NexusAdapter) SyncAppended(ctx context.Context, cortexDB *sql.DB, path string, offset int64) (SyncResult, int64, error) {
+	start := time.Now()
+	var result SyncResult
+
+	f, err := os.Open(path)
+	if err != nil {
+		return result, offset, fmt.Errorf("open events file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return result, offset, fmt.Errorf("stat events file: %w", err)
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return result, offset, nil
+	}
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return result, offset, fmt.Errorf("read events file: %w", err)
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return result, offset, nil
+	}
+	data = data[:end+1]
+
+	var lastSync int64
+	var lastEventID sql.NullString
+	row := cortexDB.QueryRow("SELECT last_sync_at, last_event_id FROM sync_watermarks WHERE adapter = ?", a.Name())
+	if err := row.Scan(&lastSync, &lastEventID); err != nil && err != sql.ErrNoRows {
+		return result, offset, fmt.Errorf("failed to get sync watermark: %w", err)
+	}
+	maxTS, maxEventID := lastSync, lastEventID.String
+
+	batch, err := a.beginBatch(ctx, cortexDB, &result)
+	if err != nil {
+		return result, offset, err
+	}
+	defer batch.tx.Rollback()
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry nexusEventLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil || !a.wants(entry) {
+			continue
+		}
+		if err := batch.add(entry); err != nil {
+			return result, offset, err
+		}
+		if tsSec := entry.Ts / 1000; tsSec > maxTS || (tsSec == maxTS && entry.ID > maxEventID) {
+			maxTS, maxEventID = tsSec, entry.ID
+		}
+	}
+	if err := batch.tx.Commit(); err != nil {
+		return result, offset, fmt.Errorf("commit cortex tx: %w", err)
+	}
+	if maxTS != lastSync || maxEventID != lastEventID.String {
+		if err := a.saveWatermark(cortexDB, maxTS, maxEventID); err != nil {
+			return result, offset, err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return result, fmt.Errorf("commit cortex tx: %w", err)
+	result.Duration = time.Since(start)
+	return result, offset + int64(len(data)), nil
+}
+
+// EventsDir is the directory of JSONL event logs the adapter reads.
+func (a *NexusAdapter) EventsDir() string {
+	return a.eventsDir
+}
+
+func (a *NexusAdapter) wants(entry nexusEventLogEntry) bool {
+	if a.source != "" && entry.Source != a.source {
+		return false
 	}
+	return entry.ID != "" && entry.Ts != 0 && entry.SessionID != ""
+}
 
-	_, err = cortexDB.Exec(`
+func (a *NexusAdapter) saveWatermark(cortexDB *sql.DB, ts int64, eventID string) error {
+	_, err := cortexDB.Exec(`
 		INSERT INTO sync_watermarks (adapter, last_sync_at, last_event_id)
 		VALUES (?, ?, ?)
 		ON CONFLICT(adapter) DO UPDATE SET
 			last_sync_at = excluded.last_sync_at,
 			last_event_id = excluded.last_event_id
-	`, a.Name(), maxTS, nullIfEmpty(maxEventID))
+	`, a.Name(), ts, nullIfEmpty(eventID))
 	if err != nil {
-		return result, fmt.Errorf("failed to update sync watermark: %w", err)
+		return fmt.Errorf("failed to update sync watermark: %w", err)
 	}
+	return nil
+}
 
-	result.Duration = time.Since(start)
-	if result.Perf == nil {
-		result.Perf = map[string]string{}
+// nexusBatch writes log entries inside one transaction.
+type nexusBatch struct {
+	a           *NexusAdapter
+	tx          *sql.Tx
+	result      *SyncResult
+	threadsSeen map[string]struct{}
+
+	stmtInsertThread *sql.Stmt
+	stmtInsertEvent  *sql.Stmt
+	stmtUpdateEvent  *sql.Stmt
+}
+
+func (a *NexusAdapter) beginBatch(ctx context.Context, cortexDB *sql.DB, result *SyncResult) (*nexusBatch, error) {
+	tx, err := cortexDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin cortex tx: %w", err)
 	}
-	result.Perf["tx_commit"] = time.Since(txStart).String()
-	return result, nil
+	b := &nexusBatch{a: a, tx: tx, result: result, threadsSeen: make(map[string]struct{})}
+
+	// Statements are prepared on tx and closed with it.
+	b.stmtInsertThread, err = tx.Prepare(`
+		INSERT INTO threads (id, channel, name, source_adapter, source_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source_adapter, source_id) DO UPDATE SET
+			name = excluded.name,
+			updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("prepare insert thread: %w", err)
+	}
+
+	b.stmtInsertEvent, err = tx.Prepare(`
+		INSERT OR IGNORE INTO events (
+			id, timestamp, channel, content_types, content,
+			direction, thread_id, reply_to, source_adapter, source_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, NULL, ?, ?)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("prepare insert event: %w", err)
+	}
+
+	b.stmtUpdateEvent, err = tx.Prepare(`
+		UPDATE events
+		SET
+			content = ?,
+			content_types = ?,
+			thread_id = ?
+		WHERE source_adapter = ?
+		  AND source_id = ?
+		  AND (
+		    content IS NOT ?
+		    OR content_types IS NOT ?
+		    OR thread_id IS NOT ?
+		  )
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("prepare update event: %w", err)
+	}
+	return b, nil
+}
+
+func (b *nexusBatch) add(entry nexusEventLogEntry) error {
+	const threadPrefix = "nexus_session:"
+	const contentTypesText = "[\"text\"]"
+	a, tx, result := b.a, b.tx, b.result
+
+	tsSec := entry.Ts / 1000
+	channel := "nexus"
+	if strings.Contains(entry.Source, "agent") {
+		channel = "nexus_agent"
+	}
+	threadID := threadPrefix + entry.SessionID
+	if _, ok := b.threadsSeen[threadID]; !ok {
+		now := time.Now().Unix()
+		threadName := entry.Source
+		if threadName == "" {
+			threadName = "nexus"
+		}
+		res, err := b.stmtInsertThread.Exec(
+			threadID,
+			channel,
+			threadName,
+			a.Name(),
+			entry.SessionID,
+			now,
+			now,
+		)
+		if err != nil {
+			return fmt.Errorf("insert thread: %w", err)
+		}
+		var exists int
+		err = tx.QueryRow("SELECT 1 FROM threads WHERE source_adapter = ? AND source_id = ? AND updated_at < ?",
+			a.Name(), entry.SessionID, now).Scan(&exists)
+		if err == sql.ErrNoRows {
+			result.ThreadsCreated++
+		} else if err == nil {
+			result.ThreadsUpdated++
+		} else if n, _ := res.RowsAffected(); n > 0 {
+			result.ThreadsCreated++
+		}
+		b.threadsSeen[threadID] = struct{}{}
+	}
+
+	contentBytes, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+	content := string(contentBytes)
+	eventID := a.Name() + ":" + entry.ID
+
+	res, err := b.stmtInsertEvent.Exec(eventID, tsSec, channel, contentTypesText, content, "observed", threadID, a.Name(), entry.ID)
+	if err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		result.EventsCreated++
+		return nil
+	}
+	res2, err := b.stmtUpdateEvent.Exec(
+		content, contentTypesText, threadID,
+		a.Name(), entry.ID,
+		content, contentTypesText, threadID,
+	)
+	if err != nil {
+		return fmt.Errorf("update event: %w", err)
+	}
+	if n2, _ := res2.RowsAffected(); n2 == 1 {
+		result.EventsUpdated++
+	}
+	return nil
 }
 
 func (a *NexusAdapter) listEventFiles() ([]string, error) {
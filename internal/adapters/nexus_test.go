@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestNexusAdapterSyncAppended(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2024-01-01.jsonl")
+	first := `{"id":"n1","ts":1704067200000,"session_id":"s1","source":"cli","event_type":"command"}` + "\n"
+	partial := `{"id":"n2","ts":1704067260000,"session_id":"s1",`
+	if err := os.WriteFile(path, []byte(first+partial), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	a, err := NewNexusAdapter(NexusAdapterOptions{EventsDir: dir})
+	if err != nil {
+		t.Fatalf("NewNexusAdapter: %v", err)
+	}
+	res, offset, err := a.SyncAppended(context.Background(), db, path, 0)
+	if err != nil {
+		t.Fatalf("SyncAppended: %v", err)
+	}
+	if res.EventsCreated != 1 || offset != int64(len(first)) {
+		t.Fatalf("expected the complete line only, got %+v offset=%d", res, offset)
+	}
+
+	// The writer finishes the line and appends another.
+	rest := `"source":"cli","event_type":"command"}` + "\n" +
+		`{"id":"n3","ts":1704067320000,"session_id":"s2","source":"agent","event_type":"turn"}` + "\n"
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	if _, err := f.WriteString(rest); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	f.Close()
+
+	res, offset, err = a.SyncAppended(context.Background(), db, path, offset)
+	if err != nil {
+		t.Fatalf("second SyncAppended: %v", err)
+	}
+	if res.EventsCreated != 2 {
+		t.Fatalf("unexpected tail result %+v", res)
+	}
+	var last string
+	if err := db.QueryRow(`SELECT last_event_id FROM sync_watermarks WHERE adapter = 'nexus'`).Scan(&last); err != nil || last != "n3" {
+		t.Fatalf("expected watermark at n3, got %q (%v)", last, err)
+	}
+
+	// A rotated (shorter) file is read from the start.
+	if err := os.WriteFile(path, []byte(`{"id":"n4","ts":1704067380000,"session_id":"s2","source":"agent"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	res, _, err = a.SyncAppended(context.Background(), db, path, offset)
+	if err != nil || res.EventsCreated != 1 {
+		t.Fatalf("expected rotated file to be re-read, got %+v (%v)", res, err)
+	}
+
+	// A regular sync after tailing finds nothing new.
+	res, err = a.Sync(context.Background(), db, false)
+	if err != nil || res.EventsCreated != 0 {
+		t.Fatalf("expected incremental sync to be a no-op, got %+v (%v)", res, err)
+	}
+}
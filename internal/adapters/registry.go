@@ -0,0 +1,361 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+)
+
+// Registration describes an adapter type that can be configured in config.yaml.
+//
+// Options are decoded from AdapterConfig.Options into the struct returned by
+// NewOptions using each field's yaml tag. Unknown keys are rejected so a
+// misspelled option fails loudly instead of silently falling back to a default.
+type Registration struct {
+	// Type is the value of `type:` in config.yaml (e.g. "gogcli").
+	Type        string
+	Description string
+
+	// Live reports whether `mnemonic watch` has a watcher for this type.
+	Live bool
+	// LiveOptions lists keys consumed by the live watcher. They may appear in
+	// live.options or, as a fallback, in options.
+	LiveOptions []string
+
+	// NewOptions returns a pointer to a zero options struct. Nil means the
+	// type takes no options.
+	NewOptions func() any
+	// Factory builds an adapter from the decoded options.
+	Factory func(name string, opts any) (Adapter, error)
+	// Status reports whether prerequisites are met ("ready" or a hint).
+	Status func(opts any) string
+	// Doctor runs the non-mutating probes behind `mnemonic adapters doctor`
+	// (binaries, paths, auth, upstream schema). Nil falls back to Status.
+	Doctor func(ctx context.Context, opts any) []Check
+}
+
+// OptionsValidator is implemented by options structs that need checks beyond
+// decoding (required fields, ranges).
+type OptionsValidator interface {
+	Validate() error
+}
+
+var registry = map[string]Registration{}
+
+// Register adds an adapter type to the registry. It panics on duplicate or
+// incomplete registrations since those are programming errors.
+func Register(reg Registration) {
+	reg.Type = strings.TrimSpace(reg.Type)
+	if reg.Type == "" {
+		panic("adapters: registration without type")
+	}
+	if reg.Factory == nil {
+		panic(fmt.Sprintf("adapters: registration %q without factory", reg.Type))
+	}
+	if _, exists := registry[reg.Type]; exists {
+		panic(fmt.Sprintf("adapters: type %q registered twice", reg.Type))
+	}
+	registry[reg.Type] = reg
+}
+
+// Lookup returns the registration for an adapter type.
+func Lookup(adapterType string) (Registration, bool) {
+	reg, ok := registry[adapterType]
+	return reg, ok
+}
+
+// Types returns all registered adapter types, sorted.
+func Types() []string {
+	out := make([]string, 0, len(registry))
+	for t := range registry {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Build decodes cfg.Options and constructs the adapter for a configured instance.
+func Build(name string, cfg config.AdapterConfig) (Adapter, error) {
+	reg, ok := Lookup(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown adapter type: %s", cfg.Type)
+	}
+	opts, err := reg.DecodeOptions(cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	return reg.Factory(name, opts)
+}
+
+// ValidateConfig checks that an adapter config names a registered type and
+// that its options (and live options, if any) decode cleanly.
+func ValidateConfig(cfg config.AdapterConfig) error {
+	reg, ok := Lookup(cfg.Type)
+	if !ok {
+		return fmt.Errorf("unknown adapter type: %s", cfg.Type)
+	}
+	if _, err := reg.DecodeOptions(cfg.Options); err != nil {
+		return err
+	}
+	if cfg.Live != nil {
+		if err := reg.ValidateLiveOptions(cfg.Live.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckStatus reports whether a configured adapter is ready to sync.
+func CheckStatus(cfg config.AdapterConfig) string {
+	reg, ok := Lookup(cfg.Type)
+	if !ok {
+		return "unknown adapter type"
+	}
+	opts, err := reg.DecodeOptions(cfg.Options)
+	if err != nil {
+		return fmt.Sprintf("invalid options: %v", err)
+	}
+	if reg.Status == nil {
+		return "ready"
+	}
+	return reg.Status(opts)
+}
+
+// OptionNames returns the option keys accepted in `options:`, sorted.
+func (r Registration) OptionNames() []string {
+	if r.NewOptions == nil {
+		return nil
+	}
+	names := optionFields(reflect.TypeOf(r.NewOptions()).Elem())
+	out := make([]string, 0, len(names))
+	for name := range names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DecodeOptions converts raw config options into the registration's typed
+// options struct and runs its validator.
+func (r Registration) DecodeOptions(raw map[string]any) (any, error) {
+	var opts any
+	var fields map[string]int
+	if r.NewOptions != nil {
+		opts = r.NewOptions()
+		fields = optionFields(reflect.TypeOf(opts).Elem())
+	}
+
+	accepted := map[string]bool{}
+	for name := range fields {
+		accepted[name] = true
+	}
+	for _, name := range r.LiveOptions {
+		accepted[name] = true
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !accepted[key] {
+			return nil, unknownOptionError(r.Type, "option", key, accepted)
+		}
+		idx, ok := fields[key]
+		if !ok {
+			// Live-only key kept in options as a fallback for the watcher.
+			continue
+		}
+		field := reflect.ValueOf(opts).Elem().Field(idx)
+		if err := setOption(field, raw[key]); err != nil {
+			return nil, fmt.Errorf("%s adapter: option %q %v", r.Type, key, err)
+		}
+	}
+
+	if v, ok := opts.(OptionsValidator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("%s adapter: %w", r.Type, err)
+		}
+	}
+	return opts, nil
+}
+
+// ValidateLiveOptions rejects keys in live.options that neither the watcher
+// nor the adapter understands.
+func (r Registration) ValidateLiveOptions(raw map[string]any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if !r.Live {
+		return fmt.Errorf("%s adapter: live mode is not supported", r.Type)
+	}
+	accepted := map[string]bool{}
+	for _, name := range r.LiveOptions {
+		accepted[name] = true
+	}
+	for _, name := range r.OptionNames() {
+		accepted[name] = true
+	}
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !accepted[key] {
+			return unknownOptionError(r.Type, "live option", key, accepted)
+		}
+	}
+	return nil
+}
+
+func optionFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+func setOption(field reflect.Value, value any) error {
+	switch field.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, got %T", value)
+		}
+		field.SetString(strings.TrimSpace(s))
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be true or false, got %T", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		switch t := value.(type) {
+		case int:
+			field.SetInt(int64(t))
+		case int64:
+			field.SetInt(t)
+		case float64:
+			if t != float64(int64(t)) {
+				return fmt.Errorf("must be a whole number, got %v", t)
+			}
+			field.SetInt(int64(t))
+		default:
+			return fmt.Errorf("must be a number, got %T", value)
+		}
+	case reflect.Float64:
+		switch t := value.(type) {
+		case int:
+			field.SetFloat(float64(t))
+		case int64:
+			field.SetFloat(float64(t))
+		case float64:
+			field.SetFloat(t)
+		default:
+			return fmt.Errorf("must be a number, got %T", value)
+		}
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("has unsupported type %s", field.Type())
+		}
+		var items []string
+		switch t := value.(type) {
+		case []string:
+			items = t
+		case []any:
+			for _, item := range t {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("must be a list of strings, got %T element", item)
+				}
+				items = append(items, s)
+			}
+		case string:
+			items = []string{t}
+		default:
+			return fmt.Errorf("must be a list of strings, got %T", value)
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("has unsupported type %s", field.Type())
+		}
+		m, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("must be a mapping, got %T", value)
+		}
+		out := make(map[string]string, len(m))
+		for k, v := range m {
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("must map to strings, got %T for %q", v, k)
+			}
+			out[k] = s
+		}
+		field.Set(reflect.ValueOf(out))
+	default:
+		return fmt.Errorf("has unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func unknownOptionError(adapterType, what, key string, accepted map[string]bool) error {
+	names := make([]string, 0, len(accepted))
+	for name := range accepted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return fmt.Errorf("%s adapter: unknown %s %q (this adapter takes no options)", adapterType, what, key)
+	}
+	best, bestDist := "", 3
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("%s adapter: unknown %s %q (did you mean %q?)", adapterType, what, key, best)
+	}
+	return fmt.Errorf("%s adapter: unknown %s %q (valid: %s)", adapterType, what, key, strings.Join(names, ", "))
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,90 @@
+package adapters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+)
+
+func TestDecodeOptionsTyped(t *testing.T) {
+	reg, ok := Lookup("gogcli")
+	if !ok {
+		t.Fatalf("gogcli not registered")
+	}
+	opts, err := reg.DecodeOptions(map[string]any{
+		"account": " user@example.com ",
+		"workers": 4,
+		"qps":     2,
+	})
+	if err != nil {
+		t.Fatalf("DecodeOptions: %v", err)
+	}
+	o := opts.(*GoogleSyncOptions)
+	if o.Account != "user@example.com" || o.Workers != 4 || o.QPS != 2 {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+}
+
+func TestDecodeOptionsErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		typ     string
+		options map[string]any
+		want    string
+	}{
+		{"misspelled", "gogcli", map[string]any{"acount": "a@b.com"}, `did you mean "account"`},
+		{"missing required", "aix", map[string]any{}, "'source' is required"},
+		{"wrong type", "gogcli", map[string]any{"account": "a@b.com", "workers": "eight"}, `option "workers" must be a number`},
+		{"no options", "eve", map[string]any{"path": "/tmp"}, "unknown option"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg, _ := Lookup(tc.typ)
+			_, err := reg.DecodeOptions(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigLiveOptions(t *testing.T) {
+	cfg := config.AdapterConfig{
+		Type:    "eve",
+		Enabled: true,
+		Options: map[string]any{"debounce_seconds": 5},
+		Live: &config.LiveConfig{
+			Enabled: true,
+			Options: map[string]any{"upstream_cmd": "eve"},
+		},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("expected live keys to be accepted, got %v", err)
+	}
+
+	cfg.Live.Options = map[string]any{"upstream_cmdd": "eve"}
+	if err := ValidateConfig(cfg); err == nil || !strings.Contains(err.Error(), "live option") {
+		t.Fatalf("expected unknown live option error, got %v", err)
+	}
+
+	if err := ValidateConfig(config.AdapterConfig{Type: "nope"}); err == nil {
+		t.Fatalf("expected unknown type error")
+	}
+}
+
+func TestRegisteredTypesHaveFactories(t *testing.T) {
+	for _, typ := range Types() {
+		reg, _ := Lookup(typ)
+		if reg.Factory == nil {
+			t.Fatalf("%s has no factory", typ)
+		}
+		if reg.NewOptions != nil {
+			v := reflect.TypeOf(reg.NewOptions())
+			if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+				t.Fatalf("%s options must be a pointer to a struct, got %s", typ, v)
+			}
+		}
+	}
+}
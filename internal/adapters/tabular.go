@@ -0,0 +1,603 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/state"
+)
+
+// TabularAdapterOptions is the config.yaml shape for type tabular. Column
+// options name CSV header fields or, for JSONL, keys (dotted for nested
+// objects, e.g. "author.email").
+type TabularAdapterOptions struct {
+	// Path is a .csv/.jsonl file or a directory of them.
+	Path string `yaml:"path"`
+	// Format is csv or jsonl (default: from the file extension).
+	Format string `yaml:"format"`
+	// Delimiter is the CSV field separator (default ",").
+	Delimiter string `yaml:"delimiter"`
+	Channel   string `yaml:"channel"`
+
+	// IDColumn uniquely identifies a row; without it rows are keyed by a
+	// hash of their contents, so an edited row becomes a new event.
+	IDColumn string `yaml:"id_column"`
+	// TimestampFormat is unix, unix_ms, rfc3339 or a Go time layout
+	// (default: detect). Layouts without a zone use Timezone (default UTC).
+	TimestampColumn string `yaml:"timestamp_column"`
+	TimestampFormat string `yaml:"timestamp_format"`
+	Timezone        string `yaml:"timezone"`
+	ContentColumn   string `yaml:"content_column"`
+
+	// SenderType and RecipientType are email, phone or handle (default handle).
+	SenderColumn       string   `yaml:"sender_column"`
+	SenderNameColumn   string   `yaml:"sender_name_column"`
+	SenderType         string   `yaml:"sender_type"`
+	RecipientColumn    string   `yaml:"recipient_column"`
+	RecipientType      string   `yaml:"recipient_type"`
+	RecipientSeparator string   `yaml:"recipient_separator"`
+	ThreadColumn       string   `yaml:"thread_column"`
+	ThreadNameColumn   string   `yaml:"thread_name_column"`
+	MetadataColumns    []string `yaml:"metadata_columns"`
+
+	// Direction fixes every row's direction. Otherwise DirectionColumn is
+	// mapped through DirectionValues, and failing that a row is sent when
+	// its sender is one of Me and received otherwise.
+	Direction       string            `yaml:"direction"`
+	DirectionColumn string            `yaml:"direction_column"`
+	DirectionValues map[string]string `yaml:"direction_values"`
+	Me              []string          `yaml:"me"`
+
+	// CursorColumn makes syncs incremental: rows whose cursor is below the
+	// highest value seen so far are skipped. Numeric values compare as
+	// numbers; the timestamp column compares as time.
+	CursorColumn string `yaml:"cursor_column"`
+}
+
+func (o *TabularAdapterOptions) Validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("'path' is required (a .csv or .jsonl file, or a directory of them)")
+	}
+	if o.TimestampColumn == "" || o.ContentColumn == "" {
+		return fmt.Errorf("'timestamp_column' and 'content_column' are required")
+	}
+	switch o.Format {
+	case "", "csv", "jsonl":
+	default:
+		return fmt.Errorf("'format' must be csv or jsonl")
+	}
+	if o.Delimiter != "" && utf8.RuneCountInString(o.Delimiter) != 1 {
+		return fmt.Errorf("'delimiter' must be a single character")
+	}
+	for _, t := range []string{o.SenderType, o.RecipientType} {
+		switch t {
+		case "", "email", "phone", "handle":
+		default:
+			return fmt.Errorf("identifier type %q is not supported (use email, phone or handle)", t)
+		}
+	}
+	if o.Direction != "" {
+		if _, err := execDirection(o.Direction); err != nil {
+			return fmt.Errorf("'direction': %w", err)
+		}
+	}
+	for raw, d := range o.DirectionValues {
+		if _, err := execDirection(d); err != nil {
+			return fmt.Errorf("'direction_values' %q: %w", raw, err)
+		}
+	}
+	if o.Timezone != "" {
+		if _, err := time.LoadLocation(o.Timezone); err != nil {
+			return fmt.Errorf("'timezone': %w", err)
+		}
+	}
+	return nil
+}
+
+// TabularAdapter ingests one-off CSV and JSONL exports (CRM notes, support
+// tickets, LinkedIn messages) described entirely by a column mapping in
+// config.yaml, writing the same contacts, threads and events as the
+// built-in adapters.
+type TabularAdapter struct {
+	name string
+	opts TabularAdapterOptions
+	loc  *time.Location
+	me   map[string]bool
+}
+
+func NewTabularAdapter(name string, opts TabularAdapterOptions) (*TabularAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("adapter instance name is required for tabular adapter")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("tabular adapter: %w", err)
+	}
+	if strings.HasPrefix(opts.Path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Path = filepath.Join(home, opts.Path[2:])
+	}
+	if opts.Channel == "" {
+		opts.Channel = name
+	}
+	if opts.SenderType == "" {
+		opts.SenderType = "handle"
+	}
+	if opts.RecipientType == "" {
+		opts.RecipientType = opts.SenderType
+	}
+	if opts.RecipientSeparator == "" {
+		opts.RecipientSeparator = ","
+	}
+	a := &TabularAdapter{name: name, opts: opts, loc: time.UTC, me: map[string]bool{}}
+	if opts.Timezone != "" {
+		a.loc, _ = time.LoadLocation(opts.Timezone)
+	}
+	for _, id := range opts.Me {
+		a.me[strings.ToLower(strings.TrimSpace(id))] = true
+	}
+	return a, nil
+}
+
+func (a *TabularAdapter) Name() string { return a.name }
+
+// Files lists the export files to read, sorted by name.
+func (a *TabularAdapter) Files() ([]string, error) {
+	info, err := os.Stat(a.opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{a.opts.Path}, nil
+	}
+	entries, err := os.ReadDir(a.opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && a.formatOf(e.Name()) != "" {
+			files = append(files, filepath.Join(a.opts.Path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (a *TabularAdapter) formatOf(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv", ".tsv":
+		return "csv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	}
+	if info, err := os.Stat(a.opts.Path); err == nil && !info.IsDir() {
+		return a.opts.Format
+	}
+	return ""
+}
+
+// tabularRow is one record with every value flattened to a string.
+type tabularRow map[string]string
+
+func (a *TabularAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
+	start := time.Now()
+	var result SyncResult
+	perf := map[string]string{}
+
+	if _, err := cortexDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return result, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	files, err := a.Files()
+	if err != nil {
+		return result, fmt.Errorf("tabular adapter: %w", err)
+	}
+
+	cursor := ""
+	if !full && a.opts.CursorColumn != "" {
+		v, _, err := state.Get(cortexDB, a.name, "cursor")
+		if err != nil {
+			return result, err
+		}
+		cursor = v
+	}
+	maxCursor := cursor
+
+	tx, err := cortexDB.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("begin cortex tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	w, err := ingest.NewWriter(tx, a.name)
+	if err != nil {
+		return result, err
+	}
+	defer w.Close()
+
+	rows, skipped := 0, 0
+	for _, file := range files {
+		format := a.opts.Format
+		if format == "" {
+			format = a.formatOf(file)
+		}
+		if format == "" {
+			return result, fmt.Errorf("cannot tell the format of %s (set 'format')", filepath.Base(file))
+		}
+		err := a.readFile(file, format, func(row tabularRow) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if a.opts.CursorColumn != "" {
+				v, err := a.cursorValue(row)
+				if err != nil {
+					return err
+				}
+				if cursor != "" && compareCursor(v, cursor) < 0 {
+					skipped++
+					return nil
+				}
+				if maxCursor == "" || compareCursor(v, maxCursor) > 0 {
+					maxCursor = v
+				}
+			}
+			if err := a.writeRow(w, row); err != nil {
+				return err
+			}
+			rows++
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit cortex tx: %w", err)
+	}
+	if maxCursor != "" && maxCursor != cursor {
+		if err := state.Set(cortexDB, a.name, "cursor", maxCursor); err != nil {
+			return result, err
+		}
+	}
+	if _, err := cortexDB.Exec(`
+		INSERT INTO sync_watermarks (adapter, last_sync_at)
+		VALUES (?, ?)
+		ON CONFLICT(adapter) DO UPDATE SET last_sync_at = excluded.last_sync_at
+	`, a.name, time.Now().Unix()); err != nil {
+		return result, fmt.Errorf("failed to update sync watermark: %w", err)
+	}
+
+	s := w.Stats
+	result.EventsCreated = s.EventsCreated
+	result.EventsUpdated = s.EventsUpdated
+	result.PersonsCreated = s.PersonsCreated
+	result.ThreadsCreated = s.ThreadsCreated
+	result.ThreadsUpdated = s.ThreadsUpdated
+	result.Duration = time.Since(start)
+	perf["files"] = strconv.Itoa(len(files))
+	perf["rows"] = strconv.Itoa(rows)
+	perf["rows_before_cursor"] = strconv.Itoa(skipped)
+	perf["total"] = result.Duration.String()
+	result.Perf = perf
+	return result, nil
+}
+
+// readFile calls fn for each record in file.
+func (a *TabularAdapter) readFile(file, format string, fn func(row tabularRow) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	// Spreadsheet exports often start with a byte order mark.
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = br.Discard(3)
+	}
+
+	if format == "jsonl" {
+		scanner := bufio.NewScanner(br)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			raw := bytes.TrimSpace(scanner.Bytes())
+			if len(raw) == 0 {
+				continue
+			}
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			var obj map[string]any
+			if err := dec.Decode(&obj); err != nil {
+				return fmt.Errorf("line %d: invalid JSON: %w", line, err)
+			}
+			row := tabularRow{}
+			flattenJSON("", obj, row)
+			if err := fn(row); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		return scanner.Err()
+	}
+
+	r := csv.NewReader(br)
+	r.FieldsPerRecord = -1
+	if a.opts.Delimiter != "" {
+		r.Comma, _ = utf8.DecodeRuneInString(a.opts.Delimiter)
+	} else if strings.EqualFold(filepath.Ext(file), ".tsv") {
+		r.Comma = '\t'
+	}
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	for record := 1; ; record++ {
+		fields, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := tabularRow{}
+		for i, v := range fields {
+			if i < len(header) {
+				row[header[i]] = v
+			}
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("record %d: %w", record, err)
+		}
+	}
+}
+
+// flattenJSON stores scalars under dotted keys; arrays of scalars are
+// joined with commas so they can feed recipient_column.
+func flattenJSON(prefix string, v any, out tabularRow) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenJSON(key, child, out)
+		}
+	case []any:
+		var parts []string
+		for _, item := range t {
+			switch item.(type) {
+			case map[string]any, []any:
+				continue
+			}
+			parts = append(parts, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(parts, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(t)
+	}
+}
+
+func (a *TabularAdapter) writeRow(w *ingest.Writer, row tabularRow) error {
+	o := a.opts
+	ts, err := a.parseTimestamp(row[o.TimestampColumn])
+	if err != nil {
+		return err
+	}
+	content := row[o.ContentColumn]
+
+	sourceID := strings.TrimSpace(row[o.IDColumn])
+	if o.IDColumn == "" || sourceID == "" {
+		sourceID = "row:" + hashRow(row)
+	}
+
+	sender := strings.TrimSpace(row[o.SenderColumn])
+	var recipients []string
+	if o.RecipientColumn != "" {
+		for _, r := range strings.Split(row[o.RecipientColumn], o.RecipientSeparator) {
+			if r = strings.TrimSpace(r); r != "" {
+				recipients = append(recipients, r)
+			}
+		}
+	}
+
+	threadID := ""
+	if key := strings.TrimSpace(row[o.ThreadColumn]); o.ThreadColumn != "" && key != "" {
+		threadID, err = w.UpsertThread(ingest.Thread{
+			SourceID: "thread:" + key,
+			Channel:  o.Channel,
+			Name:     strings.TrimSpace(row[o.ThreadNameColumn]),
+			IsGroup:  len(recipients) > 1,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var metadata map[string]any
+	for _, col := range o.MetadataColumns {
+		if v, ok := row[col]; ok && v != "" {
+			if metadata == nil {
+				metadata = map[string]any{}
+			}
+			metadata[col] = v
+		}
+	}
+
+	eventID, _, err := w.UpsertEvent(ingest.Event{
+		SourceID:  sourceID,
+		Timestamp: ts,
+		Channel:   o.Channel,
+		Content:   content,
+		Direction: a.direction(row, sender),
+		ThreadID:  threadID,
+		Metadata:  metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	if sender != "" {
+		contactID, err := w.Contact(o.SenderType, sender, strings.TrimSpace(row[o.SenderNameColumn]))
+		if err != nil {
+			return err
+		}
+		if err := w.AddParticipant(eventID, contactID, "sender"); err != nil {
+			return err
+		}
+	}
+	for _, r := range recipients {
+		contactID, err := w.Contact(o.RecipientType, r, "")
+		if err != nil {
+			return err
+		}
+		if err := w.AddParticipant(eventID, contactID, "recipient"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *TabularAdapter) direction(row tabularRow, sender string) string {
+	if a.opts.Direction != "" {
+		return a.opts.Direction
+	}
+	if a.opts.DirectionColumn != "" {
+		v := strings.TrimSpace(row[a.opts.DirectionColumn])
+		if d, ok := a.opts.DirectionValues[v]; ok {
+			return d
+		}
+		if d, err := execDirection(strings.ToLower(v)); err == nil && v != "" {
+			return d
+		}
+	}
+	if sender != "" && a.me[strings.ToLower(sender)] {
+		return "sent"
+	}
+	return "received"
+}
+
+var tabularTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func (a *TabularAdapter) parseTimestamp(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, fmt.Errorf("timestamp is required")
+	}
+	switch a.opts.TimestampFormat {
+	case "unix", "unix_ms":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", raw)
+		}
+		if a.opts.TimestampFormat == "unix_ms" {
+			f /= 1000
+		}
+		return int64(f), nil
+	case "rfc3339":
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", raw, err)
+		}
+		return t.Unix(), nil
+	case "":
+		if ts, err := parseExecTimestamp(json.RawMessage(raw)); err == nil {
+			return ts, nil
+		}
+		for _, layout := range tabularTimeLayouts {
+			if t, err := time.ParseInLocation(layout, raw, a.loc); err == nil {
+				return t.Unix(), nil
+			}
+		}
+		return 0, fmt.Errorf("unrecognized timestamp %q (set 'timestamp_format')", raw)
+	default:
+		t, err := time.ParseInLocation(a.opts.TimestampFormat, raw, a.loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", raw, err)
+		}
+		return t.Unix(), nil
+	}
+}
+
+// cursorValue returns the row's cursor. The timestamp column is stored as
+// unix seconds so it compares numerically whatever its format.
+func (a *TabularAdapter) cursorValue(row tabularRow) (string, error) {
+	raw := strings.TrimSpace(row[a.opts.CursorColumn])
+	if a.opts.CursorColumn == a.opts.TimestampColumn {
+		ts, err := a.parseTimestamp(raw)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(ts, 10), nil
+	}
+	return raw, nil
+}
+
+func compareCursor(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func hashRow(row tabularRow) string {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, row[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
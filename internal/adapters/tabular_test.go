@@ -0,0 +1,148 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+// Shaped like LinkedIn's messages.csv.
+const linkedInMessages = "CONVERSATION ID,CONVERSATION TITLE,FROM,SENDER PROFILE URL,TO,DATE,CONTENT\n" +
+	"c1,Hiring,Alice Smith,https://www.linkedin.com/in/alice,Me,2024-01-02 10:00:00 UTC,\"Are you open to roles?\"\n" +
+	"c1,Hiring,Me,https://www.linkedin.com/in/me,Alice Smith,2024-01-02 11:30:00 UTC,\"Not right now, thanks\"\n"
+
+func TestTabularAdapterCSV(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "messages.csv")
+	if err := os.WriteFile(path, []byte(linkedInMessages), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	opts := TabularAdapterOptions{
+		Path:             path,
+		Channel:          "linkedin",
+		TimestampColumn:  "DATE",
+		ContentColumn:    "CONTENT",
+		SenderColumn:     "SENDER PROFILE URL",
+		SenderNameColumn: "FROM",
+		ThreadColumn:     "CONVERSATION ID",
+		ThreadNameColumn: "CONVERSATION TITLE",
+		Me:               []string{"https://www.linkedin.com/in/me"},
+		CursorColumn:     "DATE",
+	}
+	a, err := NewTabularAdapter("linkedin", opts)
+	if err != nil {
+		t.Fatalf("NewTabularAdapter: %v", err)
+	}
+
+	res, err := a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.EventsCreated != 2 || res.ThreadsCreated != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	dirs := map[int64]string{}
+	rows, err := db.Query(`SELECT timestamp, direction FROM events WHERE thread_id = 'linkedin:thread:c1'`)
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+	for rows.Next() {
+		var ts int64
+		var dir string
+		if err := rows.Scan(&ts, &dir); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		dirs[ts] = dir
+	}
+	rows.Close()
+	if dirs[1704189600] != "received" || dirs[1704195000] != "sent" {
+		t.Fatalf("unexpected directions %v", dirs)
+	}
+
+	var name string
+	if err := db.QueryRow(`
+		SELECT c.display_name FROM event_participants p JOIN contacts c ON c.id = p.contact_id
+		JOIN events e ON e.id = p.event_id
+		WHERE e.timestamp = 1704189600 AND p.role = 'sender'
+	`).Scan(&name); err != nil || name != "Alice Smith" {
+		t.Fatalf("unexpected sender %q (%v)", name, err)
+	}
+
+	// Only rows at or after the cursor are read on the next sync.
+	more := linkedInMessages + "c1,Hiring,Alice Smith,https://www.linkedin.com/in/alice,Me,2024-01-03 09:00:00 UTC,\"Maybe later then\"\n"
+	if err := os.WriteFile(path, []byte(more), 0o644); err != nil {
+		t.Fatalf("rewrite csv: %v", err)
+	}
+	res, err = a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.EventsCreated != 1 || res.Perf["rows"] != "2" || res.Perf["rows_before_cursor"] != "1" {
+		t.Fatalf("unexpected incremental result %+v", res)
+	}
+}
+
+func TestTabularAdapterJSONL(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "tickets.jsonl")
+	body := `{"id": 7, "created": 1700000000000, "body": "Printer is on fire", "requester": {"email": "Bob@Example.com", "name": "Bob"}, "agents": ["help@example.com", "ops@example.com"], "status": "open", "kind": "inbound"}
+{"id": 8, "created": 1700000500000, "body": "Extinguished", "requester": {"email": "help@example.com"}, "agents": ["bob@example.com"], "status": "solved", "kind": "outbound"}
+`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write jsonl: %v", err)
+	}
+	a, err := NewTabularAdapter("tickets", TabularAdapterOptions{
+		Path:             path,
+		IDColumn:         "id",
+		TimestampColumn:  "created",
+		TimestampFormat:  "unix_ms",
+		ContentColumn:    "body",
+		SenderColumn:     "requester.email",
+		SenderNameColumn: "requester.name",
+		SenderType:       "email",
+		RecipientColumn:  "agents",
+		DirectionColumn:  "kind",
+		DirectionValues:  map[string]string{"inbound": "received", "outbound": "sent"},
+		MetadataColumns:  []string{"status"},
+	})
+	if err != nil {
+		t.Fatalf("NewTabularAdapter: %v", err)
+	}
+	res, err := a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.EventsCreated != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	var ts int64
+	var direction, channel, status string
+	if err := db.QueryRow(`SELECT timestamp, direction, channel, json_extract(metadata_json, '$.status') FROM events WHERE id = 'tickets:8'`).Scan(&ts, &direction, &channel, &status); err != nil {
+		t.Fatalf("ticket 8: %v", err)
+	}
+	if ts != 1700000500 || direction != "sent" || channel != "tickets" || status != "solved" {
+		t.Fatalf("unexpected ticket 8 ts=%d direction=%s channel=%s status=%s", ts, direction, channel, status)
+	}
+	var recipients int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = 'tickets:7' AND role = 'recipient'`).Scan(&recipients)
+	if recipients != 2 {
+		t.Fatalf("expected 2 recipients, got %d", recipients)
+	}
+
+	res, err = a.Sync(context.Background(), db, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 {
+		t.Fatalf("expected idempotent resync, got %+v", res)
+	}
+}
@@ -0,0 +1,451 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Napageneral/mnemonic/internal/documents"
+)
+
+// VaultAdapterOptions is the config.yaml shape for type vault.
+type VaultAdapterOptions struct {
+	// Path is the root of a directory of Markdown notes (an Obsidian vault).
+	Path string `yaml:"path"`
+	// Channel is the document channel notes are stored under (default doc).
+	Channel string `yaml:"channel"`
+	// Exclude lists vault-relative directories to skip, e.g. Templates.
+	// Hidden directories (.obsidian, .trash, .git) are always skipped.
+	Exclude []string `yaml:"exclude"`
+}
+
+func (o *VaultAdapterOptions) Validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("'path' is required (a directory of Markdown files)")
+	}
+	return nil
+}
+
+// VaultAdapter syncs a directory of Markdown notes into document_heads. Each
+// edit is a new document version via documents.UpsertDocument; frontmatter
+// goes to metadata_json and [[wikilinks]] to document_links. A note that
+// disappears while a new file with identical content appears is treated as
+// a rename and keeps its doc key.
+type VaultAdapter struct {
+	name    string
+	opts    VaultAdapterOptions
+	exclude map[string]bool
+}
+
+func NewVaultAdapter(name string, opts VaultAdapterOptions) (*VaultAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("adapter instance name is required for vault adapter")
+	}
+	if strings.TrimSpace(opts.Path) == "" {
+		return nil, fmt.Errorf("vault adapter requires a path")
+	}
+	if strings.HasPrefix(opts.Path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Path = filepath.Join(home, opts.Path[2:])
+	}
+	if opts.Channel == "" {
+		opts.Channel = "doc"
+	}
+	a := &VaultAdapter{name: name, opts: opts, exclude: map[string]bool{}}
+	for _, dir := range opts.Exclude {
+		a.exclude[strings.Trim(filepath.ToSlash(dir), "/")] = true
+	}
+	return a, nil
+}
+
+func (a *VaultAdapter) Name() string { return a.name }
+
+// Path returns the vault root.
+func (a *VaultAdapter) Path() string { return a.opts.Path }
+
+// vaultNote is a Markdown file as read from disk.
+type vaultNote struct {
+	Rel         string // slash-separated path relative to the vault root
+	Content     string
+	Hash        string
+	ModTime     time.Time
+	Frontmatter map[string]any
+	Body        string
+}
+
+type vaultHead struct {
+	DocKey string
+	Path   string
+	Hash   string
+}
+
+func (a *VaultAdapter) Sync(ctx context.Context, cortexDB *sql.DB, full bool) (SyncResult, error) {
+	start := time.Now()
+	res := SyncResult{Perf: map[string]string{}}
+
+	notes, err := a.readNotes()
+	if err != nil {
+		return res, fmt.Errorf("vault adapter: %w", err)
+	}
+	heads, err := a.loadHeads(ctx, cortexDB)
+	if err != nil {
+		return res, err
+	}
+
+	onDisk := map[string]bool{}
+	for _, n := range notes {
+		onDisk[n.Rel] = true
+	}
+	byPath := map[string]string{}
+	usedKeys := map[string]bool{}
+	// Heads whose file is gone are rename candidates, matched by content hash.
+	orphans := map[string][]string{}
+	for _, h := range heads {
+		usedKeys[h.DocKey] = true
+		if onDisk[h.Path] {
+			byPath[h.Path] = h.DocKey
+		} else {
+			orphans[h.Hash] = append(orphans[h.Hash], h.DocKey)
+		}
+	}
+
+	var created, updated, renamed, unchanged int
+	keys := make(map[string]string, len(notes)) // rel -> doc key
+	for _, n := range notes {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		key, ok := byPath[n.Rel]
+		if !ok {
+			if c := orphans[n.Hash]; len(c) > 0 {
+				key, orphans[n.Hash] = c[0], c[1:]
+				renamed++
+			} else {
+				key = "vault:" + a.name + ":" + strings.TrimSuffix(n.Rel, path.Ext(n.Rel))
+				if usedKeys[key] {
+					// A renamed note still holds the key for this path.
+					key += "@" + n.Hash[:8]
+				}
+				usedKeys[key] = true
+			}
+		}
+		keys[n.Rel] = key
+
+		out, err := documents.UpsertDocument(ctx, cortexDB, documents.DocumentInput{
+			DocKey:        key,
+			Channel:       a.opts.Channel,
+			Title:         vaultTitle(n),
+			Description:   frontmatterString(n.Frontmatter, "description", "summary"),
+			Content:       n.Content,
+			Metadata:      a.noteMetadata(n),
+			SourceAdapter: a.name,
+			Timestamp:     n.ModTime.Unix(),
+		})
+		if err != nil {
+			return res, fmt.Errorf("upsert %s: %w", n.Rel, err)
+		}
+		switch {
+		case out.Created:
+			created++
+		case out.Updated:
+			updated++
+		default:
+			unchanged++
+		}
+	}
+	missing := 0
+	for _, c := range orphans {
+		missing += len(c)
+	}
+
+	linkCount := 0
+	if full || created+updated+renamed > 0 {
+		links := resolveVaultLinks(notes, keys)
+		for _, l := range links {
+			linkCount += len(l)
+		}
+		if err := documents.ReplaceLinks(ctx, cortexDB, links); err != nil {
+			return res, err
+		}
+	}
+
+	// Every document version is a new event.
+	res.EventsCreated = created + updated
+	res.Duration = time.Since(start)
+	res.Perf["files"] = fmt.Sprintf("%d", len(notes))
+	res.Perf["docs_created"] = fmt.Sprintf("%d", created)
+	res.Perf["docs_updated"] = fmt.Sprintf("%d", updated)
+	res.Perf["docs_renamed"] = fmt.Sprintf("%d", renamed)
+	res.Perf["docs_unchanged"] = fmt.Sprintf("%d", unchanged-renamed)
+	res.Perf["docs_missing"] = fmt.Sprintf("%d", missing)
+	res.Perf["links"] = fmt.Sprintf("%d", linkCount)
+	res.Perf["total"] = res.Duration.String()
+	return res, nil
+}
+
+// readNotes returns the vault's Markdown files sorted by path.
+func (a *VaultAdapter) readNotes() ([]vaultNote, error) {
+	var notes []vaultNote
+	err := filepath.WalkDir(a.opts.Path, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(a.opts.Path, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || a.exclude[rel]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(path.Ext(rel), ".md") {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content := strings.ReplaceAll(string(data), "\r\n", "\n")
+		if strings.TrimSpace(content) == "" {
+			return nil
+		}
+		fm, body := splitFrontmatter(content)
+		notes = append(notes, vaultNote{
+			Rel:         rel,
+			Content:     content,
+			Hash:        documents.HashContent(content),
+			ModTime:     info.ModTime(),
+			Frontmatter: fm,
+			Body:        body,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Rel < notes[j].Rel })
+	return notes, nil
+}
+
+func (a *VaultAdapter) loadHeads(ctx context.Context, cortexDB *sql.DB) ([]vaultHead, error) {
+	rows, err := cortexDB.QueryContext(ctx, `
+		SELECT doc_key, COALESCE(json_extract(metadata_json, '$.path'), ''), content_hash
+		FROM document_heads
+		WHERE json_extract(metadata_json, '$.vault') = ?
+		ORDER BY doc_key
+	`, a.name)
+	if err != nil {
+		return nil, fmt.Errorf("query document heads: %w", err)
+	}
+	defer rows.Close()
+	var out []vaultHead
+	for rows.Next() {
+		var h vaultHead
+		if err := rows.Scan(&h.DocKey, &h.Path, &h.Hash); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+func (a *VaultAdapter) noteMetadata(n vaultNote) map[string]any {
+	meta := map[string]any{
+		"vault": a.name,
+		"path":  n.Rel,
+	}
+	if len(n.Frontmatter) > 0 {
+		meta["frontmatter"] = n.Frontmatter
+	}
+	if tags := vaultTags(n); len(tags) > 0 {
+		meta["tags"] = tags
+	}
+	if aliases := frontmatterList(n.Frontmatter, "aliases"); len(aliases) > 0 {
+		meta["aliases"] = aliases
+	}
+	if targets := wikilinkTargets(n.Body); len(targets) > 0 {
+		meta["links"] = targets
+	}
+	return meta
+}
+
+// splitFrontmatter separates a leading "---" YAML block from the body.
+// Frontmatter that does not parse is left in the body.
+func splitFrontmatter(content string) (map[string]any, string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return nil, content
+	}
+	rest := "\n" + content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, content
+	}
+	after := rest[end+len("\n---"):]
+	if after != "" && after[0] != '\n' {
+		return nil, content
+	}
+	var fm map[string]any
+	if err := yaml.Unmarshal([]byte(rest[:end]), &fm); err != nil {
+		return nil, content
+	}
+	return fm, strings.TrimPrefix(after, "\n")
+}
+
+var (
+	wikilinkRe  = regexp.MustCompile(`!?\[\[([^\[\]|#^]*)(?:[#^][^\[\]|]*)?(?:\|[^\[\]]*)?\]\]`)
+	inlineTagRe = regexp.MustCompile(`(?:^|\s)#([A-Za-z][\w/-]*)`)
+	fenceRe     = regexp.MustCompile("(?ms)^```.*?^```")
+	headingRe   = regexp.MustCompile(`(?m)^#\s+(.+?)\s*$`)
+)
+
+// wikilinkTargets returns the distinct note targets of [[wikilinks]] and
+// ![[embeds]] outside code fences, ignoring headings, block references,
+// aliases and embedded attachments.
+func wikilinkTargets(body string) []string {
+	body = fenceRe.ReplaceAllString(body, "")
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range wikilinkRe.FindAllStringSubmatch(body, -1) {
+		target := strings.TrimSpace(m[1])
+		if target == "" {
+			continue // [[#Heading]] links within the note
+		}
+		if ext := path.Ext(target); ext != "" && !strings.EqualFold(ext, ".md") && !strings.Contains(ext, " ") {
+			continue
+		}
+		if !seen[target] {
+			seen[target] = true
+			out = append(out, target)
+		}
+	}
+	return out
+}
+
+// resolveVaultLinks resolves wikilink targets the way Obsidian does: by
+// vault-relative path, then by file name, then by alias, case-insensitively.
+func resolveVaultLinks(notes []vaultNote, keys map[string]string) map[string][]documents.Link {
+	byPath := map[string]string{}
+	byName := map[string]string{}
+	nameDepth := map[string]int{}
+	byAlias := map[string]string{}
+	for _, n := range notes {
+		key := keys[n.Rel]
+		noExt := strings.ToLower(strings.TrimSuffix(n.Rel, path.Ext(n.Rel)))
+		byPath[noExt] = key
+		// When several notes share a name, the one nearest the root wins.
+		name, depth := path.Base(noExt), strings.Count(noExt, "/")
+		if d, ok := nameDepth[name]; !ok || depth < d {
+			byName[name], nameDepth[name] = key, depth
+		}
+		for _, alias := range frontmatterList(n.Frontmatter, "aliases") {
+			if _, ok := byAlias[strings.ToLower(alias)]; !ok {
+				byAlias[strings.ToLower(alias)] = key
+			}
+		}
+	}
+
+	out := make(map[string][]documents.Link, len(notes))
+	for _, n := range notes {
+		links := []documents.Link{}
+		for _, target := range wikilinkTargets(n.Body) {
+			t := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(target, "/"), ".md"))
+			to, ok := byPath[t]
+			if !ok {
+				to, ok = byName[path.Base(t)]
+				if ok && strings.Contains(t, "/") {
+					to, ok = "", false
+				}
+			}
+			if !ok {
+				to = byAlias[t]
+			}
+			links = append(links, documents.Link{Target: target, ToDocKey: to})
+		}
+		out[keys[n.Rel]] = links
+	}
+	return out
+}
+
+func vaultTitle(n vaultNote) string {
+	if t := frontmatterString(n.Frontmatter, "title"); t != "" {
+		return t
+	}
+	if m := headingRe.FindStringSubmatch(fenceRe.ReplaceAllString(n.Body, "")); m != nil {
+		return m[1]
+	}
+	return strings.TrimSuffix(path.Base(n.Rel), path.Ext(n.Rel))
+}
+
+// vaultTags merges frontmatter tags with inline #tags, without the "#".
+func vaultTags(n vaultNote) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(t string) {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "#")
+		if t != "" && !seen[strings.ToLower(t)] {
+			seen[strings.ToLower(t)] = true
+			out = append(out, t)
+		}
+	}
+	for _, t := range frontmatterList(n.Frontmatter, "tags", "tag") {
+		for _, part := range strings.Fields(t) {
+			add(part)
+		}
+	}
+	for _, m := range inlineTagRe.FindAllStringSubmatch(fenceRe.ReplaceAllString(n.Body, ""), -1) {
+		add(m[1])
+	}
+	return out
+}
+
+func frontmatterString(fm map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := fm[k].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// frontmatterList reads a key that may be a list or a single string
+// ("aliases: [a, b]", "aliases: a, b" or "aliases: a").
+func frontmatterList(fm map[string]any, keys ...string) []string {
+	var out []string
+	for _, k := range keys {
+		switch v := fm[k].(type) {
+		case string:
+			for _, part := range strings.Split(v, ",") {
+				if strings.TrimSpace(part) != "" {
+					out = append(out, strings.TrimSpace(part))
+				}
+			}
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+					out = append(out, strings.TrimSpace(s))
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/db"
+)
+
+func writeVaultNote(t *testing.T, root, rel, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write note: %v", err)
+	}
+}
+
+func TestVaultAdapter_Sync(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	d, err := db.Open()
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+
+	root := filepath.Join(tmp, "vault")
+	writeVaultNote(t, root, "Launch.md", "---\ntitle: Launch plan\ntags: [project, q3]\naliases: Launch\n---\nSee [[Timeline|the timeline]], [[Budget#Totals]] and ![[diagram.png]]. #urgent\n")
+	writeVaultNote(t, root, "Timeline.md", "# Timeline\n\nShip in September, back to [[Launch]].\n")
+	writeVaultNote(t, root, ".obsidian/workspace.md", "editor state")
+
+	adapter, err := NewVaultAdapter("notes", VaultAdapterOptions{Path: root})
+	if err != nil {
+		t.Fatalf("NewVaultAdapter: %v", err)
+	}
+	res, err := adapter.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.EventsCreated != 2 || res.Perf["docs_created"] != "2" || res.Perf["links"] != "3" {
+		t.Fatalf("unexpected first sync %+v", res)
+	}
+
+	var title, tags, fmTitle string
+	if err := d.QueryRow(`
+		SELECT title, json_extract(metadata_json, '$.tags'), json_extract(metadata_json, '$.frontmatter.title')
+		FROM document_heads WHERE doc_key = 'vault:notes:Launch'
+	`).Scan(&title, &tags, &fmTitle); err != nil {
+		t.Fatalf("launch head: %v", err)
+	}
+	if title != "Launch plan" || tags != `["project","q3","urgent"]` || fmTitle != "Launch plan" {
+		t.Fatalf("unexpected launch head title=%q tags=%s frontmatter title=%q", title, tags, fmTitle)
+	}
+
+	links := map[string]string{}
+	rows, err := d.Query(`SELECT target, COALESCE(to_doc_key, '') FROM document_links WHERE from_doc_key = 'vault:notes:Launch'`)
+	if err != nil {
+		t.Fatalf("query links: %v", err)
+	}
+	for rows.Next() {
+		var target, to string
+		if err := rows.Scan(&target, &to); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		links[target] = to
+	}
+	rows.Close()
+	if len(links) != 2 || links["Timeline"] != "vault:notes:Timeline" || links["Budget"] != "" {
+		t.Fatalf("unexpected links %v", links)
+	}
+
+	// Move Timeline into a folder and edit Launch to follow it.
+	if err := os.MkdirAll(filepath.Join(root, "plans"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Rename(filepath.Join(root, "Timeline.md"), filepath.Join(root, "plans", "Schedule.md")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	writeVaultNote(t, root, "Launch.md", "---\ntitle: Launch plan\n---\nSee [[plans/Schedule]].\n")
+
+	res, err = adapter.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.Perf["docs_renamed"] != "1" || res.Perf["docs_updated"] != "1" || res.Perf["docs_created"] != "0" {
+		t.Fatalf("unexpected second sync %+v", res.Perf)
+	}
+
+	var path string
+	if err := d.QueryRow(`SELECT json_extract(metadata_json, '$.path') FROM document_heads WHERE doc_key = 'vault:notes:Timeline'`).Scan(&path); err != nil || path != "plans/Schedule.md" {
+		t.Fatalf("expected renamed note to keep its key, path=%q (%v)", path, err)
+	}
+	var versions int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM events WHERE source_id LIKE 'vault:notes:Timeline@%'`).Scan(&versions)
+	if versions != 1 {
+		t.Fatalf("expected rename without a new version, got %d", versions)
+	}
+	_ = d.QueryRow(`SELECT COUNT(*) FROM events WHERE source_id LIKE 'vault:notes:Launch@%'`).Scan(&versions)
+	if versions != 2 {
+		t.Fatalf("expected an edit to add a version, got %d", versions)
+	}
+	var to string
+	if err := d.QueryRow(`SELECT to_doc_key FROM document_links WHERE from_doc_key = 'vault:notes:Launch' AND target = 'plans/Schedule'`).Scan(&to); err != nil || to != "vault:notes:Timeline" {
+		t.Fatalf("expected link to the renamed note, got %q (%v)", to, err)
+	}
+
+	res, err = adapter.Sync(context.Background(), d, false)
+	if err != nil {
+		t.Fatalf("third Sync: %v", err)
+	}
+	if res.EventsCreated != 0 || res.Perf["docs_unchanged"] != "2" {
+		t.Fatalf("expected no-op resync, got %+v", res)
+	}
+}
@@ -13,8 +13,9 @@ type Event struct {
 	Seq        int64   `json:"seq"`
 	ID         string  `json:"id"`
 	Type       string  `json:"type"`
+	Version    int     `json:"version,omitempty"` // payload schema version; 0 for events emitted before the catalogue
 	Adapter    *string `json:"adapter,omitempty"`
-	CommsEvent *string `json:"cortex_event_id,omitempty"`
+	CommsEvent *string `json:"mnemonic_event_id,omitempty"`
 	CreatedAt  int64   `json:"created_at"`
 	Payload    *string `json:"payload_json,omitempty"`
 }
@@ -30,9 +31,10 @@ func ensureTable(db ExecDB) error {
 			id TEXT NOT NULL UNIQUE,
 			type TEXT NOT NULL,
 			adapter TEXT,
-			cortex_event_id TEXT,
+			mnemonic_event_id TEXT,
 			created_at INTEGER NOT NULL,
-			payload_json TEXT
+			payload_json TEXT,
+			version INTEGER
 		)
 	`)
 	if err != nil {
@@ -41,13 +43,24 @@ func ensureTable(db ExecDB) error {
 	return nil
 }
 
+// Emit appends an event to the bus. typ must be in the catalogue (see
+// Types) and payload must be its payload struct; event-scoped types also
+// need the mnemonic event id. Anything else is rejected. The bus_events
+// table comes from db.Init, so an emit is a single insert.
 func Emit(db ExecDB, typ string, adapter string, cortexEventID string, payload any) error {
 	if typ == "" {
 		return fmt.Errorf("type is required")
 	}
-	if err := ensureTable(db); err != nil {
+	t, ok := LookupType(typ)
+	if !ok {
+		return fmt.Errorf("unknown bus event type %q", typ)
+	}
+	if err := t.Validate(payload); err != nil {
 		return err
 	}
+	if t.EventScoped && cortexEventID == "" {
+		return fmt.Errorf("%s requires an event id", typ)
+	}
 	now := time.Now().Unix()
 	id := uuid.New().String()
 
@@ -59,19 +72,15 @@ func Emit(db ExecDB, typ string, adapter string, cortexEventID string, payload a
 	if cortexEventID != "" {
 		eventVal = cortexEventID
 	}
-	var payloadVal any
-	if payload != nil {
-		b, err := json.Marshal(payload)
-		if err != nil {
-			return fmt.Errorf("failed to marshal payload: %w", err)
-		}
-		payloadVal = string(b)
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	_, err := db.Exec(`
-		INSERT INTO bus_events (id, type, adapter, cortex_event_id, created_at, payload_json)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, id, typ, adapterVal, eventVal, now, payloadVal)
+	_, err = db.Exec(`
+		INSERT INTO bus_events (id, type, adapter, mnemonic_event_id, created_at, payload_json, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, id, typ, adapterVal, eventVal, now, string(b), t.Version)
 	if err != nil {
 		return fmt.Errorf("failed to insert bus event: %w", err)
 	}
@@ -86,7 +95,7 @@ func List(db *sql.DB, afterSeq int64, limit int) ([]Event, error) {
 		limit = 100
 	}
 	rows, err := db.Query(`
-		SELECT seq, id, type, adapter, cortex_event_id, created_at, payload_json
+		SELECT seq, id, type, adapter, mnemonic_event_id, created_at, payload_json, COALESCE(version, 0)
 		FROM bus_events
 		WHERE seq > ?
 		ORDER BY seq ASC
@@ -97,13 +106,17 @@ func List(db *sql.DB, afterSeq int64, limit int) ([]Event, error) {
 	}
 	defer rows.Close()
 
+	return scanEvents(rows)
+}
+
+func scanEvents(rows *sql.Rows) ([]Event, error) {
 	var out []Event
 	for rows.Next() {
 		var e Event
 		var adapter sql.NullString
 		var cortexEvent sql.NullString
 		var payload sql.NullString
-		if err := rows.Scan(&e.Seq, &e.ID, &e.Type, &adapter, &cortexEvent, &e.CreatedAt, &payload); err != nil {
+		if err := rows.Scan(&e.Seq, &e.ID, &e.Type, &adapter, &cortexEvent, &e.CreatedAt, &payload, &e.Version); err != nil {
 			return nil, fmt.Errorf("failed to scan bus event: %w", err)
 		}
 		if adapter.Valid {
@@ -122,3 +135,16 @@ func List(db *sql.DB, afterSeq int64, limit int) ([]Event, error) {
 	}
 	return out, nil
 }
+
+// LastSeq returns the newest event's sequence number, or 0 when the bus is
+// empty. Consumers that only care about new events start from it.
+func LastSeq(db *sql.DB) (int64, error) {
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+	var seq int64
+	if err := db.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM bus_events`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to query bus events: %w", err)
+	}
+	return seq, nil
+}
@@ -0,0 +1,240 @@
+package bus
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Event types in the catalogue. Emit rejects any other type.
+const (
+	TypeEventCreated      = "event.created"
+	TypeEventUpdated      = "event.updated"
+	TypeEventEdited       = "event.edited"
+	TypeEventDeleted      = "event.deleted"
+	TypeThreadUpdated     = "thread.updated"
+	TypePersonMerged      = "person.merged"
+	TypeSyncCompleted     = "sync.completed"
+	TypeAnalysisCompleted = "analysis.completed"
+)
+
+// Payload structs describe their fields with tags: `desc` documents a field,
+// `enum` lists its allowed values, and fields without omitempty are
+// required (non-empty, for strings).
+
+// EventPayload accompanies event.created and event.updated.
+type EventPayload struct {
+	Channel       string `json:"channel" desc:"Channel of the event, such as gmail or imessage"`
+	Direction     string `json:"direction" enum:"sent,received,observed"`
+	Timestamp     int64  `json:"timestamp" desc:"Event time, unix seconds"`
+	ThreadID      string `json:"thread_id,omitempty"`
+	SourceAdapter string `json:"source_adapter" desc:"Adapter that wrote the event"`
+	SourceID      string `json:"source_id" desc:"Identifier of the event in its source"`
+}
+
+// EventEditedPayload accompanies event.edited.
+type EventEditedPayload struct {
+	Channel       string `json:"channel"`
+	SourceAdapter string `json:"source_adapter"`
+	SourceID      string `json:"source_id"`
+	Revision      int    `json:"revision" desc:"Number of the revision that kept the replaced content"`
+}
+
+// EventDeletedPayload accompanies event.deleted.
+type EventDeletedPayload struct {
+	Channel       string `json:"channel"`
+	SourceAdapter string `json:"source_adapter"`
+	SourceID      string `json:"source_id"`
+}
+
+// ThreadUpdatedPayload accompanies thread.updated.
+type ThreadUpdatedPayload struct {
+	ThreadID       string `json:"thread_id"`
+	Channel        string `json:"channel"`
+	Name           string `json:"name,omitempty"`
+	IsGroup        bool   `json:"is_group"`
+	ParentThreadID string `json:"parent_thread_id,omitempty"`
+	SourceAdapter  string `json:"source_adapter"`
+	SourceID       string `json:"source_id"`
+}
+
+// PersonMergedPayload accompanies person.merged.
+type PersonMergedPayload struct {
+	PersonID       string `json:"person_id" desc:"Person that remains"`
+	MergedPersonID string `json:"merged_person_id" desc:"Person merged into person_id"`
+	MergeEventID   string `json:"merge_event_id,omitempty" desc:"Merge suggestion that was executed"`
+	Method         string `json:"method" enum:"manual,suggestion" desc:"manual for identify --merge, suggestion when a merge suggestion was executed"`
+}
+
+// SyncCompletedPayload accompanies sync.completed.
+type SyncCompletedPayload struct {
+	Adapter       string `json:"adapter" desc:"Configured adapter name"`
+	EventsCreated int    `json:"events_created"`
+	EventsUpdated int    `json:"events_updated"`
+}
+
+// AnalysisCompletedPayload accompanies analysis.completed.
+type AnalysisCompletedPayload struct {
+	RunID        string `json:"run_id"`
+	AnalysisType string `json:"analysis_type"`
+	EpisodeID    string `json:"episode_id"`
+}
+
+// EventType is a catalogue entry. Version is bumped whenever the payload
+// changes incompatibly and is stored with every emitted event.
+type EventType struct {
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	// EventScoped types refer to a mnemonic event and require its id.
+	EventScoped bool `json:"event_scoped"`
+
+	payload reflect.Type
+}
+
+var catalog = map[string]EventType{}
+
+func register(t EventType, payload any) {
+	t.payload = reflect.TypeOf(payload)
+	catalog[t.Name] = t
+}
+
+func init() {
+	register(EventType{Name: TypeEventCreated, Version: 1, EventScoped: true,
+		Description: "An adapter wrote a new event"}, EventPayload{})
+	register(EventType{Name: TypeEventUpdated, Version: 1, EventScoped: true,
+		Description: "An adapter changed an existing event"}, EventPayload{})
+	register(EventType{Name: TypeEventEdited, Version: 1, EventScoped: true,
+		Description: "The source edited an event's content; the prior content is kept as a revision"}, EventEditedPayload{})
+	register(EventType{Name: TypeEventDeleted, Version: 1, EventScoped: true,
+		Description: "The source deleted an event and it was tombstoned"}, EventDeletedPayload{})
+	register(EventType{Name: TypeThreadUpdated, Version: 1,
+		Description: "A thread's name, group flag or parent changed"}, ThreadUpdatedPayload{})
+	register(EventType{Name: TypePersonMerged, Version: 1,
+		Description: "Two people were merged by identity resolution"}, PersonMergedPayload{})
+	register(EventType{Name: TypeSyncCompleted, Version: 1,
+		Description: "An adapter sync wrote events"}, SyncCompletedPayload{})
+	register(EventType{Name: TypeAnalysisCompleted, Version: 1,
+		Description: "An analysis run finished for an episode"}, AnalysisCompletedPayload{})
+}
+
+// Types returns the catalogue sorted by name.
+func Types() []EventType {
+	out := make([]EventType, 0, len(catalog))
+	for _, t := range catalog {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// LookupType returns the catalogue entry for name.
+func LookupType(name string) (EventType, bool) {
+	t, ok := catalog[name]
+	return t, ok
+}
+
+type payloadField struct {
+	name     string
+	index    int
+	kind     reflect.Kind
+	required bool
+	enum     []string
+	desc     string
+}
+
+func (t EventType) fields() []payloadField {
+	var out []payloadField
+	for i := 0; i < t.payload.NumField(); i++ {
+		f := t.payload.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		pf := payloadField{
+			name:     name,
+			index:    i,
+			kind:     f.Type.Kind(),
+			required: !strings.Contains(opts, "omitempty"),
+			desc:     f.Tag.Get("desc"),
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			pf.enum = strings.Split(enum, ",")
+		}
+		out = append(out, pf)
+	}
+	return out
+}
+
+// Validate checks that payload is this type's payload struct (or a pointer
+// to one) with its required fields set and enum fields in range.
+func (t EventType) Validate(payload any) error {
+	v := reflect.ValueOf(payload)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Type() != t.payload {
+		return fmt.Errorf("%s payload must be %s, got %T", t.Name, t.payload.Name(), payload)
+	}
+	for _, f := range t.fields() {
+		fv := v.Field(f.index)
+		if f.kind != reflect.String {
+			continue
+		}
+		s := fv.String()
+		if f.required && s == "" {
+			return fmt.Errorf("%s payload: %s is required", t.Name, f.name)
+		}
+		if len(f.enum) > 0 && s != "" && !contains(f.enum, s) {
+			return fmt.Errorf("%s payload: %s must be one of %s, got %q", t.Name, f.name, strings.Join(f.enum, ", "), s)
+		}
+	}
+	return nil
+}
+
+// Schema returns the JSON Schema of the type's payload.
+func (t EventType) Schema() map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, f := range t.fields() {
+		prop := map[string]any{}
+		switch f.kind {
+		case reflect.String:
+			prop["type"] = "string"
+			if f.required {
+				prop["minLength"] = 1
+			}
+		case reflect.Int, reflect.Int64:
+			prop["type"] = "integer"
+		case reflect.Bool:
+			prop["type"] = "boolean"
+		}
+		if len(f.enum) > 0 {
+			prop["enum"] = f.enum
+		}
+		if f.desc != "" {
+			prop["description"] = f.desc
+		}
+		properties[f.name] = prop
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  fmt.Sprintf("mnemonic:bus:%s:v%d", t.Name, t.Version),
+		"title":                t.Name,
+		"description":          t.Description,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
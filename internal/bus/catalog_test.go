@@ -0,0 +1,90 @@
+package bus
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestEmitRejectsInvalidEvents(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	valid := EventPayload{Channel: "gmail", Direction: "sent", SourceAdapter: "gmail", SourceID: "m1"}
+	cases := []struct {
+		name    string
+		typ     string
+		eventID string
+		payload any
+		want    string
+	}{
+		{"unknown type", "cortex.event.created", "gmail:m1", valid, "unknown bus event type"},
+		{"missing payload", TypeEventCreated, "gmail:m1", nil, "payload must be EventPayload"},
+		{"untyped payload", TypeSyncCompleted, "", map[string]any{"adapter": "gmail"}, "payload must be SyncCompletedPayload"},
+		{"wrong payload", TypeEventDeleted, "gmail:m1", valid, "payload must be EventDeletedPayload"},
+		{"missing field", TypeEventCreated, "gmail:m1", EventPayload{Channel: "gmail", Direction: "sent", SourceAdapter: "gmail"}, "source_id is required"},
+		{"bad enum", TypeEventCreated, "gmail:m1", EventPayload{Channel: "gmail", Direction: "outbound", SourceAdapter: "gmail", SourceID: "m1"}, "direction must be one of"},
+		{"missing event id", TypeEventCreated, "", valid, "requires an event id"},
+	}
+	for _, tc := range cases {
+		err := Emit(db, tc.typ, "gmail", tc.eventID, tc.payload)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	if err := Emit(db, TypeEventCreated, "gmail", "gmail:m1", &valid); err != nil {
+		t.Fatalf("valid emit: %v", err)
+	}
+	events, err := List(db, 0, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(events) != 1 || events[0].Version != 1 || events[0].Payload == nil {
+		t.Fatalf("events: %+v", events)
+	}
+	var got EventPayload
+	if err := json.Unmarshal([]byte(*events[0].Payload), &got); err != nil || got != valid {
+		t.Fatalf("payload round trip: %+v err=%v", got, err)
+	}
+}
+
+func TestCatalogSchemas(t *testing.T) {
+	types := Types()
+	if len(types) == 0 {
+		t.Fatalf("empty catalogue")
+	}
+	for _, typ := range types {
+		if typ.Version < 1 || typ.Description == "" {
+			t.Errorf("%s: version %d, description %q", typ.Name, typ.Version, typ.Description)
+		}
+		schema := typ.Schema()
+		if _, err := json.Marshal(schema); err != nil {
+			t.Errorf("%s: schema does not marshal: %v", typ.Name, err)
+		}
+		properties := schema["properties"].(map[string]any)
+		if len(properties) != typ.payload.NumField() {
+			t.Errorf("%s: %d schema properties for %d fields", typ.Name, len(properties), typ.payload.NumField())
+		}
+		for name, prop := range properties {
+			if name == "" || prop.(map[string]any)["type"] == nil {
+				t.Errorf("%s: field %q has no JSON name or schema type", typ.Name, name)
+			}
+		}
+
+		// The zero payload fails exactly when the schema requires a string.
+		requiresString := false
+		for _, name := range schema["required"].([]string) {
+			if properties[name].(map[string]any)["type"] == "string" {
+				requiresString = true
+			}
+		}
+		zero := reflect.New(typ.payload).Elem().Interface()
+		if err := typ.Validate(zero); (err != nil) != requiresString {
+			t.Errorf("%s: zero payload validation = %v", typ.Name, err)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package bus
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Consumer defaults used when ConsumerOptions leaves them zero.
+const (
+	DefaultAckTimeout  = 60 * time.Second
+	DefaultMaxAttempts = 5
+)
+
+// Consumer is a named reader of the bus with a durable cursor.
+type Consumer struct {
+	Name        string `json:"name"`
+	Cursor      int64  `json:"cursor"`
+	AckTimeout  int64  `json:"ack_timeout_seconds"`
+	MaxAttempts int    `json:"max_attempts"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+// ConsumerOptions configures a new consumer. FromStart delivers the whole
+// bus history; otherwise the consumer starts at the current end of the bus.
+type ConsumerOptions struct {
+	AckTimeout  time.Duration
+	MaxAttempts int
+	FromStart   bool
+}
+
+// ConsumerStatus reports how far a consumer is behind. Lag counts every
+// event the consumer still owes an ack for: unread plus in flight.
+type ConsumerStatus struct {
+	Consumer
+	Unread   int64 `json:"unread"`
+	InFlight int64 `json:"in_flight"`
+	Overdue  int64 `json:"overdue"` // in flight past the ack timeout
+	Dead     int64 `json:"dead"`
+	Lag      int64 `json:"lag"`
+}
+
+// Delivery is an event handed to a consumer. Attempt is 1 on first delivery.
+type Delivery struct {
+	Event
+	Attempt int `json:"attempt"`
+}
+
+// DeadLetter is an event a consumer gave up on.
+type DeadLetter struct {
+	Event
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+const consumerDDL = `
+	CREATE TABLE IF NOT EXISTS bus_consumers (
+		name TEXT PRIMARY KEY,
+		cursor INTEGER NOT NULL DEFAULT 0,
+		ack_timeout INTEGER NOT NULL,
+		max_attempts INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS bus_deliveries (
+		consumer TEXT NOT NULL REFERENCES bus_consumers(name) ON DELETE CASCADE,
+		seq INTEGER NOT NULL,
+		state TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		visible_at INTEGER NOT NULL,
+		last_error TEXT,
+		PRIMARY KEY (consumer, seq)
+	);
+	CREATE INDEX IF NOT EXISTS idx_bus_deliveries_state ON bus_deliveries(consumer, state, visible_at);
+`
+
+func ensureConsumerTables(db *sql.DB) error {
+	if err := ensureTable(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(consumerDDL); err != nil {
+		return fmt.Errorf("failed to ensure bus consumer tables: %w", err)
+	}
+	return nil
+}
+
+// CreateConsumer registers a consumer. It fails if the name is taken.
+func CreateConsumer(db *sql.DB, name string, opts ConsumerOptions) (Consumer, error) {
+	if name == "" {
+		return Consumer{}, fmt.Errorf("consumer name is required")
+	}
+	if err := ensureConsumerTables(db); err != nil {
+		return Consumer{}, err
+	}
+	if opts.AckTimeout <= 0 {
+		opts.AckTimeout = DefaultAckTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	var cursor int64
+	if !opts.FromStart {
+		var err error
+		if cursor, err = LastSeq(db); err != nil {
+			return Consumer{}, err
+		}
+	}
+	now := time.Now().Unix()
+	c := Consumer{
+		Name:        name,
+		Cursor:      cursor,
+		AckTimeout:  int64(opts.AckTimeout / time.Second),
+		MaxAttempts: opts.MaxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	res, err := db.Exec(`
+		INSERT INTO bus_consumers (name, cursor, ack_timeout, max_attempts, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO NOTHING
+	`, c.Name, c.Cursor, c.AckTimeout, c.MaxAttempts, c.CreatedAt, c.UpdatedAt)
+	if err != nil {
+		return Consumer{}, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Consumer{}, fmt.Errorf("consumer %q already exists", name)
+	}
+	return c, nil
+}
+
+// EnsureConsumer returns the named consumer, creating it with opts if it
+// does not exist yet. Existing consumers keep their settings.
+func EnsureConsumer(db *sql.DB, name string, opts ConsumerOptions) (Consumer, error) {
+	if c, err := GetConsumer(db, name); err == nil {
+		return c, nil
+	}
+	c, err := CreateConsumer(db, name, opts)
+	if err != nil {
+		// Lost a race with another process creating it.
+		if existing, getErr := GetConsumer(db, name); getErr == nil {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+// GetConsumer loads a consumer by name.
+func GetConsumer(db *sql.DB, name string) (Consumer, error) {
+	if err := ensureConsumerTables(db); err != nil {
+		return Consumer{}, err
+	}
+	var c Consumer
+	err := db.QueryRow(`
+		SELECT name, cursor, ack_timeout, max_attempts, created_at, updated_at
+		FROM bus_consumers WHERE name = ?
+	`, name).Scan(&c.Name, &c.Cursor, &c.AckTimeout, &c.MaxAttempts, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return c, fmt.Errorf("consumer %q not found", name)
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to load consumer: %w", err)
+	}
+	return c, nil
+}
+
+// DeleteConsumer removes a consumer and its pending deliveries.
+func DeleteConsumer(db *sql.DB, name string) error {
+	if err := ensureConsumerTables(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE FROM bus_deliveries WHERE consumer = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete deliveries: %w", err)
+	}
+	res, err := db.Exec(`DELETE FROM bus_consumers WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete consumer: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("consumer %q not found", name)
+	}
+	return nil
+}
+
+// ListConsumers returns every consumer with its lag.
+func ListConsumers(db *sql.DB) ([]ConsumerStatus, error) {
+	if err := ensureConsumerTables(db); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	rows, err := db.Query(`
+		SELECT c.name, c.cursor, c.ack_timeout, c.max_attempts, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM bus_events e WHERE e.seq > c.cursor),
+			(SELECT COUNT(*) FROM bus_deliveries d WHERE d.consumer = c.name AND d.state = 'inflight'),
+			(SELECT COUNT(*) FROM bus_deliveries d WHERE d.consumer = c.name AND d.state = 'inflight' AND d.visible_at <= ?),
+			(SELECT COUNT(*) FROM bus_deliveries d WHERE d.consumer = c.name AND d.state = 'dead')
+		FROM bus_consumers c
+		ORDER BY c.name
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consumers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ConsumerStatus
+	for rows.Next() {
+		var s ConsumerStatus
+		if err := rows.Scan(&s.Name, &s.Cursor, &s.AckTimeout, &s.MaxAttempts, &s.CreatedAt, &s.UpdatedAt,
+			&s.Unread, &s.InFlight, &s.Overdue, &s.Dead); err != nil {
+			return nil, fmt.Errorf("failed to scan consumer: %w", err)
+		}
+		s.Lag = s.Unread + s.InFlight
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating consumers: %w", err)
+	}
+	return out, nil
+}
+
+// Receive hands up to limit events to a consumer: first deliveries whose ack
+// timeout expired (or that were nacked and are due again), oldest first, then
+// events past the cursor. Every returned event must be acked or nacked; one
+// that is neither comes back after the ack timeout, and one that has used
+// max_attempts is dead-lettered instead.
+func Receive(db *sql.DB, name string, limit int) ([]Delivery, error) {
+	if err := ensureConsumerTables(db); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin receive: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Write first so the transaction holds the write lock before it reads
+	// the cursor; concurrent receivers then serialize instead of both
+	// delivering the same events.
+	now := time.Now().Unix()
+	res, err := tx.Exec(`UPDATE bus_consumers SET updated_at = ? WHERE name = ?`, now, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock consumer: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("consumer %q not found", name)
+	}
+	var cursor, ackTimeout int64
+	var maxAttempts int
+	if err := tx.QueryRow(`
+		SELECT cursor, ack_timeout, max_attempts FROM bus_consumers WHERE name = ?
+	`, name).Scan(&cursor, &ackTimeout, &maxAttempts); err != nil {
+		return nil, fmt.Errorf("failed to load consumer: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE bus_deliveries
+		SET state = 'dead', last_error = COALESCE(last_error, 'ack timeout')
+		WHERE consumer = ? AND state = 'inflight' AND visible_at <= ? AND attempts >= ?
+	`, name, now, maxAttempts); err != nil {
+		return nil, fmt.Errorf("failed to dead-letter deliveries: %w", err)
+	}
+
+	attempts := map[int64]int{}
+	var seqs []int64
+	rows, err := tx.Query(`
+		SELECT seq, attempts FROM bus_deliveries
+		WHERE consumer = ? AND state = 'inflight' AND visible_at <= ?
+		ORDER BY seq
+		LIMIT ?
+	`, name, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query redeliveries: %w", err)
+	}
+	for rows.Next() {
+		var seq int64
+		var n int
+		if err := rows.Scan(&seq, &n); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan redelivery: %w", err)
+		}
+		seqs = append(seqs, seq)
+		attempts[seq] = n + 1
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed iterating redeliveries: %w", err)
+	}
+	rows.Close()
+	for _, seq := range seqs {
+		if _, err := tx.Exec(`
+			UPDATE bus_deliveries SET attempts = attempts + 1, visible_at = ?
+			WHERE consumer = ? AND seq = ?
+		`, now+ackTimeout, name, seq); err != nil {
+			return nil, fmt.Errorf("failed to redeliver %d: %w", seq, err)
+		}
+	}
+
+	if remaining := limit - len(seqs); remaining > 0 {
+		rows, err := tx.Query(`SELECT seq FROM bus_events WHERE seq > ? ORDER BY seq LIMIT ?`, cursor, remaining)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query bus events: %w", err)
+		}
+		var fresh []int64
+		for rows.Next() {
+			var seq int64
+			if err := rows.Scan(&seq); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan bus event: %w", err)
+			}
+			fresh = append(fresh, seq)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed iterating bus events: %w", err)
+		}
+		rows.Close()
+		for _, seq := range fresh {
+			if _, err := tx.Exec(`
+				INSERT INTO bus_deliveries (consumer, seq, state, attempts, visible_at)
+				VALUES (?, ?, 'inflight', 1, ?)
+			`, name, seq, now+ackTimeout); err != nil {
+				return nil, fmt.Errorf("failed to record delivery %d: %w", seq, err)
+			}
+			seqs = append(seqs, seq)
+			attempts[seq] = 1
+		}
+		if len(fresh) > 0 {
+			if _, err := tx.Exec(`UPDATE bus_consumers SET cursor = ? WHERE name = ?`, fresh[len(fresh)-1], name); err != nil {
+				return nil, fmt.Errorf("failed to advance cursor: %w", err)
+			}
+		}
+	}
+
+	if len(seqs) == 0 {
+		return nil, tx.Commit()
+	}
+	events, err := eventsBySeq(tx, seqs)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit receive: %w", err)
+	}
+
+	out := make([]Delivery, 0, len(events))
+	for _, e := range events {
+		out = append(out, Delivery{Event: e, Attempt: attempts[e.Seq]})
+	}
+	return out, nil
+}
+
+// Ack marks deliveries as processed. It returns how many were in flight;
+// acking an event twice, or after it was dead-lettered, is not an error.
+func Ack(db *sql.DB, name string, seqs ...int64) (int, error) {
+	if err := ensureConsumerTables(db); err != nil {
+		return 0, err
+	}
+	if _, err := GetConsumer(db, name); err != nil {
+		return 0, err
+	}
+	acked := 0
+	for _, seq := range seqs {
+		res, err := db.Exec(`
+			DELETE FROM bus_deliveries WHERE consumer = ? AND seq = ? AND state = 'inflight'
+		`, name, seq)
+		if err != nil {
+			return acked, fmt.Errorf("failed to ack %d: %w", seq, err)
+		}
+		n, _ := res.RowsAffected()
+		acked += int(n)
+	}
+	return acked, nil
+}
+
+// Nack returns a delivery for redelivery after delay, recording reason. A
+// delivery that has used its attempts is dead-lettered instead; the return
+// value reports which happened.
+func Nack(db *sql.DB, name string, seq int64, delay time.Duration, reason string) (dead bool, err error) {
+	if err := ensureConsumerTables(db); err != nil {
+		return false, err
+	}
+	c, err := GetConsumer(db, name)
+	if err != nil {
+		return false, err
+	}
+	var attempts int
+	err = db.QueryRow(`
+		SELECT attempts FROM bus_deliveries WHERE consumer = ? AND seq = ? AND state = 'inflight'
+	`, name, seq).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("event %d is not in flight for consumer %q", seq, name)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load delivery: %w", err)
+	}
+
+	var errVal any
+	if reason != "" {
+		errVal = reason
+	}
+	if attempts >= c.MaxAttempts {
+		_, err = db.Exec(`
+			UPDATE bus_deliveries SET state = 'dead', last_error = COALESCE(?, last_error, 'nacked')
+			WHERE consumer = ? AND seq = ?
+		`, errVal, name, seq)
+		if err != nil {
+			return false, fmt.Errorf("failed to dead-letter %d: %w", seq, err)
+		}
+		return true, nil
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	_, err = db.Exec(`
+		UPDATE bus_deliveries SET visible_at = ?, last_error = COALESCE(?, last_error)
+		WHERE consumer = ? AND seq = ?
+	`, time.Now().Add(delay).Unix(), errVal, name, seq)
+	if err != nil {
+		return false, fmt.Errorf("failed to nack %d: %w", seq, err)
+	}
+	return false, nil
+}
+
+// ListDead returns a consumer's dead-lettered events, oldest first.
+func ListDead(db *sql.DB, name string, limit int) ([]DeadLetter, error) {
+	if _, err := GetConsumer(db, name); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(`
+		SELECT e.seq, e.id, e.type, e.adapter, e.mnemonic_event_id, e.created_at, e.payload_json, COALESCE(e.version, 0),
+			d.attempts, COALESCE(d.last_error, '')
+		FROM bus_deliveries d
+		JOIN bus_events e ON e.seq = d.seq
+		WHERE d.consumer = ? AND d.state = 'dead'
+		ORDER BY d.seq
+		LIMIT ?
+	`, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DeadLetter
+	for rows.Next() {
+		var d DeadLetter
+		var adapter, cortexEvent, payload sql.NullString
+		if err := rows.Scan(&d.Seq, &d.ID, &d.Type, &adapter, &cortexEvent, &d.CreatedAt, &payload, &d.Version,
+			&d.Attempts, &d.LastError); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		if adapter.Valid {
+			d.Adapter = &adapter.String
+		}
+		if cortexEvent.Valid {
+			d.CommsEvent = &cortexEvent.String
+		}
+		if payload.Valid {
+			d.Payload = &payload.String
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating dead letters: %w", err)
+	}
+	return out, nil
+}
+
+// Redrive puts dead-lettered events back in flight with a fresh attempt
+// count, so the next Receive delivers them again. With no seqs it redrives
+// all of the consumer's dead letters.
+func Redrive(db *sql.DB, name string, seqs ...int64) (int, error) {
+	if _, err := GetConsumer(db, name); err != nil {
+		return 0, err
+	}
+	query := `
+		UPDATE bus_deliveries SET state = 'inflight', attempts = 0, visible_at = 0
+		WHERE consumer = ? AND state = 'dead'
+	`
+	args := []any{name}
+	if len(seqs) > 0 {
+		query += ` AND seq IN (?` + strings.Repeat(", ?", len(seqs)-1) + `)`
+		for _, seq := range seqs {
+			args = append(args, seq)
+		}
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to redrive dead letters: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func eventsBySeq(tx *sql.Tx, seqs []int64) ([]Event, error) {
+	args := make([]any, len(seqs))
+	for i, seq := range seqs {
+		args[i] = seq
+	}
+	rows, err := tx.Query(`
+		SELECT seq, id, type, adapter, mnemonic_event_id, created_at, payload_json, COALESCE(version, 0)
+		FROM bus_events
+		WHERE seq IN (?`+strings.Repeat(", ?", len(seqs)-1)+`)
+		ORDER BY seq ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bus events: %w", err)
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
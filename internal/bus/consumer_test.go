@@ -0,0 +1,122 @@
+package bus
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestConsumerDelivery(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	for _, adapter := range []string{"a", "b", "c"} {
+		emitSync(t, db, adapter)
+	}
+
+	if _, err := CreateConsumer(db, "late", ConsumerOptions{}); err != nil {
+		t.Fatalf("create late: %v", err)
+	}
+	if _, err := CreateConsumer(db, "scripts", ConsumerOptions{MaxAttempts: 2, FromStart: true}); err != nil {
+		t.Fatalf("create scripts: %v", err)
+	}
+	if _, err := CreateConsumer(db, "scripts", ConsumerOptions{}); err == nil {
+		t.Fatalf("expected duplicate consumer to fail")
+	}
+
+	got, err := Receive(db, "scripts", 2)
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if len(got) != 2 || adapterOf(got[0].Event) != "a" || adapterOf(got[1].Event) != "b" || got[0].Attempt != 1 {
+		t.Fatalf("first receive: %+v", got)
+	}
+	if n, err := Ack(db, "scripts", got[0].Seq); err != nil || n != 1 {
+		t.Fatalf("ack: n=%d err=%v", n, err)
+	}
+	if dead, err := Nack(db, "scripts", got[1].Seq, 0, "boom"); err != nil || dead {
+		t.Fatalf("nack: dead=%v err=%v", dead, err)
+	}
+
+	// The nacked event comes back ahead of the unread one.
+	got, err = Receive(db, "scripts", 10)
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if len(got) != 2 || adapterOf(got[0].Event) != "b" || got[0].Attempt != 2 || adapterOf(got[1].Event) != "c" {
+		t.Fatalf("second receive: %+v", got)
+	}
+	if _, err := Ack(db, "scripts", got[1].Seq); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if dead, err := Nack(db, "scripts", got[0].Seq, 0, "boom again"); err != nil || !dead {
+		t.Fatalf("final nack: dead=%v err=%v", dead, err)
+	}
+
+	letters, err := ListDead(db, "scripts", 0)
+	if err != nil {
+		t.Fatalf("list dead: %v", err)
+	}
+	if len(letters) != 1 || adapterOf(letters[0].Event) != "b" || letters[0].Attempts != 2 || letters[0].LastError != "boom again" {
+		t.Fatalf("dead letters: %+v", letters)
+	}
+	if got, err := Receive(db, "scripts", 10); err != nil || len(got) != 0 {
+		t.Fatalf("dead letters must not be delivered: %+v err=%v", got, err)
+	}
+
+	if n, err := Redrive(db, "scripts"); err != nil || n != 1 {
+		t.Fatalf("redrive: n=%d err=%v", n, err)
+	}
+	got, err = Receive(db, "scripts", 10)
+	if err != nil || len(got) != 1 || adapterOf(got[0].Event) != "b" || got[0].Attempt != 1 {
+		t.Fatalf("redriven receive: %+v err=%v", got, err)
+	}
+
+	emitSync(t, db, "d")
+	statuses, err := ListConsumers(db)
+	if err != nil {
+		t.Fatalf("list consumers: %v", err)
+	}
+	lag := map[string]int64{}
+	for _, s := range statuses {
+		lag[s.Name] = s.Lag
+	}
+	// late started after a-c; scripts still owes b and has not read d.
+	if lag["late"] != 1 || lag["scripts"] != 2 {
+		t.Fatalf("lag: %+v", statuses)
+	}
+
+	got, err = Receive(db, "late", 10)
+	if err != nil || len(got) != 1 || adapterOf(got[0].Event) != "d" {
+		t.Fatalf("late receive: %+v err=%v", got, err)
+	}
+}
+
+// emitSync emits a sync.completed event; tests tell them apart by adapter.
+func emitSync(t *testing.T, db *sql.DB, adapter string) {
+	t.Helper()
+	if err := Emit(db, TypeSyncCompleted, adapter, "", SyncCompletedPayload{Adapter: adapter, EventsCreated: 1}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+}
+
+// emitEvent emits an event-scoped type for eventID.
+func emitEvent(t *testing.T, db *sql.DB, typ, eventID string) {
+	t.Helper()
+	var payload any = EventPayload{Channel: "imessage", Direction: "received", SourceAdapter: "imessage", SourceID: eventID}
+	if typ == TypeEventDeleted {
+		payload = EventDeletedPayload{Channel: "imessage", SourceAdapter: "imessage", SourceID: eventID}
+	}
+	if err := Emit(db, typ, "imessage", eventID, payload); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+}
+
+func adapterOf(e Event) string {
+	if e.Adapter == nil {
+		return ""
+	}
+	return *e.Adapter
+}
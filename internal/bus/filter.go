@@ -0,0 +1,107 @@
+package bus
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects bus events by type, adapter and channel. Empty fields match
+// everything; within a field any value may match. Types are glob patterns,
+// so "event.*" matches every event-level type.
+//
+// Channels are those of the mnemonic event an entry refers to, so entries
+// without one (such as sync.completed) never match a channel filter.
+type Filter struct {
+	Types    []string `json:"types,omitempty"`
+	Adapters []string `json:"adapters,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+// Validate reports malformed type patterns.
+func (f Filter) Validate() error {
+	for _, pattern := range f.Types {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid type pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Apply returns the events that match f, in order.
+func (f Filter) Apply(db *sql.DB, events []Event) ([]Event, error) {
+	if len(f.Types) == 0 && len(f.Adapters) == 0 && len(f.Channels) == 0 {
+		return events, nil
+	}
+	var channels map[string]string
+	if len(f.Channels) > 0 {
+		var err error
+		if channels, err = eventChannels(db, events); err != nil {
+			return nil, err
+		}
+	}
+	out := events[:0:0]
+	for _, e := range events {
+		if len(f.Types) > 0 && !matchAny(f.Types, e.Type, true) {
+			continue
+		}
+		if len(f.Adapters) > 0 && (e.Adapter == nil || !matchAny(f.Adapters, *e.Adapter, false)) {
+			continue
+		}
+		if len(f.Channels) > 0 && (e.CommsEvent == nil || !matchAny(f.Channels, channels[*e.CommsEvent], false)) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func matchAny(values []string, s string, glob bool) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+		if glob {
+			if ok, _ := path.Match(v, s); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eventChannels maps the mnemonic event ids referenced by events to their
+// channel.
+func eventChannels(db *sql.DB, events []Event) (map[string]string, error) {
+	var ids []any
+	seen := map[string]bool{}
+	for _, e := range events {
+		if e.CommsEvent != nil && !seen[*e.CommsEvent] {
+			seen[*e.CommsEvent] = true
+			ids = append(ids, *e.CommsEvent)
+		}
+	}
+	out := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := db.Query(`
+		SELECT id, channel FROM events WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+	`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event channels: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, channel string
+		if err := rows.Scan(&id, &channel); err != nil {
+			return nil, fmt.Errorf("failed to scan event channel: %w", err)
+		}
+		out[id] = channel
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating event channels: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package bus
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseKeepalive is how often an idle stream sends a comment line so proxies
+// and clients do not time it out.
+const sseKeepalive = 15 * time.Second
+
+// FilterFromQuery reads type, adapter and channel query parameters. Each
+// may be repeated or given as a comma-separated list.
+func FilterFromQuery(q map[string][]string) Filter {
+	split := func(key string) []string {
+		var out []string
+		for _, v := range q[key] {
+			for _, part := range strings.Split(v, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					out = append(out, part)
+				}
+			}
+		}
+		return out
+	}
+	return Filter{
+		Types:    split("type"),
+		Adapters: split("adapter"),
+		Channels: split("channel"),
+	}
+}
+
+// SSEHandler streams bus events as Server-Sent Events, polling the bus every
+// pollInterval. Each message carries the event's seq as its id and its type
+// as the event name, with the Event as JSON data.
+//
+// A stream starts after the Last-Event-ID header when the client reconnects,
+// else after the since query parameter, else at the current end of the bus.
+// Query parameters type, adapter and channel filter the stream (see Filter).
+func SSEHandler(db *sql.DB, pollInterval time.Duration) http.Handler {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		filter := FilterFromQuery(r.URL.Query())
+		if err := filter.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cursor := int64(-1)
+		for _, v := range []string{r.Header.Get("Last-Event-ID"), r.URL.Query().Get("since")} {
+			if v == "" {
+				continue
+			}
+			seq, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || seq < 0 {
+				http.Error(w, fmt.Sprintf("invalid seq %q", v), http.StatusBadRequest)
+				return
+			}
+			cursor = seq
+			break
+		}
+		if cursor < 0 {
+			seq, err := LastSeq(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			cursor = seq
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", pollInterval.Milliseconds())
+		flusher.Flush()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		lastWrite := time.Now()
+		for {
+			for {
+				events, err := List(db, cursor, 500)
+				if err != nil {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", strings.ReplaceAll(err.Error(), "\n", " "))
+					flusher.Flush()
+					return
+				}
+				if len(events) == 0 {
+					break
+				}
+				cursor = events[len(events)-1].Seq
+				matched, err := filter.Apply(db, events)
+				if err != nil {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", strings.ReplaceAll(err.Error(), "\n", " "))
+					flusher.Flush()
+					return
+				}
+				for _, e := range matched {
+					data, err := json.Marshal(e)
+					if err != nil {
+						continue
+					}
+					if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data); err != nil {
+						return
+					}
+				}
+				if len(matched) > 0 {
+					flusher.Flush()
+					lastWrite = time.Now()
+				}
+				if len(events) < 500 {
+					break
+				}
+			}
+			if time.Since(lastWrite) >= sseKeepalive {
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+				lastWrite = time.Now()
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package bus
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestSSEHandler(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`
+		INSERT INTO events (id, timestamp, channel, content_types, content, direction, source_adapter, source_id)
+		VALUES ('ev1', 0, 'imessage', '["text"]', 'hi', 'received', 'imessage', 'ev1'),
+			('ev2', 0, 'gmail', '["text"]', 'hi', 'received', 'gmail', 'ev2')
+	`); err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+	emitEvent(t, db, TypeEventCreated, "ev1") // seq 1
+	emitEvent(t, db, TypeEventCreated, "ev2") // seq 2
+	emitSync(t, db, "imessage")               // seq 3
+	emitEvent(t, db, TypeEventUpdated, "ev1") // seq 4
+
+	srv := httptest.NewServer(SSEHandler(db, 10*time.Millisecond))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?channel=imessage", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "id: ") {
+			ids = append(ids, strings.TrimPrefix(line, "id: "))
+			if len(ids) == 1 {
+				// Events emitted after the stream started are delivered too.
+				emitEvent(t, db, TypeEventDeleted, "ev2") // seq 5, filtered out
+				emitEvent(t, db, TypeEventDeleted, "ev1") // seq 6
+			}
+		}
+	}
+	if strings.Join(ids, ",") != "4,6" {
+		t.Fatalf("streamed ids = %v (err %v)", ids, scanner.Err())
+	}
+
+	resp, err = http.Get(srv.URL + "?type=[")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad pattern status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,260 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+)
+
+// Webhook defaults used when the config leaves them unset.
+const (
+	DefaultWebhookTimeout = 10 * time.Second
+	DefaultWebhookBackoff = 10 * time.Second
+
+	maxWebhookBackoff = time.Hour
+	webhookBatchSize  = 20
+)
+
+// Webhook delivers matching bus events to a URL, one POST per event.
+type Webhook struct {
+	Name        string
+	URL         string
+	Secret      string
+	Filter      Filter
+	MaxAttempts int
+	Timeout     time.Duration
+	Backoff     time.Duration
+	Client      *http.Client
+}
+
+// WebhookConsumer is the durable consumer a webhook reads the bus through.
+func WebhookConsumer(name string) string {
+	return "webhook:" + name
+}
+
+// NewWebhook validates a configured webhook and fills in defaults.
+func NewWebhook(name string, cfg config.WebhookConfig) (*Webhook, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook %s: invalid url %q", name, cfg.URL)
+	}
+	w := &Webhook{
+		Name:   name,
+		URL:    cfg.URL,
+		Secret: cfg.Secret,
+		Filter: Filter{
+			Types:    cfg.Types,
+			Adapters: cfg.Adapters,
+			Channels: cfg.Channels,
+		},
+		MaxAttempts: cfg.MaxAttempts,
+		Timeout:     DefaultWebhookTimeout,
+		Backoff:     DefaultWebhookBackoff,
+	}
+	if err := w.Filter.Validate(); err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", name, err)
+	}
+	if w.MaxAttempts <= 0 {
+		w.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.Timeout != "" {
+		if w.Timeout, err = time.ParseDuration(cfg.Timeout); err != nil || w.Timeout <= 0 {
+			return nil, fmt.Errorf("webhook %s: invalid timeout %q", name, cfg.Timeout)
+		}
+	}
+	if cfg.Backoff != "" {
+		if w.Backoff, err = time.ParseDuration(cfg.Backoff); err != nil || w.Backoff < 0 {
+			return nil, fmt.Errorf("webhook %s: invalid backoff %q", name, cfg.Backoff)
+		}
+	}
+	return w, nil
+}
+
+// Sign returns the X-Mnemonic-Signature value for a request body sent at
+// timestamp: "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers should recompute it and reject stale timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers events until ctx is cancelled, polling the bus every
+// pollInterval when it is caught up. Delivery is at least once and retries
+// are not ordered: a failing event is retried with backoff while later
+// events go out, and after MaxAttempts it is dead-lettered on the consumer.
+func (w *Webhook) Run(ctx context.Context, db *sql.DB, pollInterval time.Duration, logf func(format string, args ...any)) error {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	if err := w.ensureConsumer(db); err != nil {
+		return err
+	}
+	for {
+		n, err := w.deliver(ctx, db, logf)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// ensureConsumer creates the webhook's consumer, or brings an existing one
+// in line with the current config.
+func (w *Webhook) ensureConsumer(db *sql.DB) error {
+	// A batch is posted one delivery at a time, so the last one must not
+	// time out while the requests ahead of it are still running. One extra
+	// request's worth covers the acks in between.
+	ackTimeout := DefaultAckTimeout
+	if floor := (webhookBatchSize + 1) * w.Timeout; floor > ackTimeout {
+		ackTimeout = floor
+	}
+	name := WebhookConsumer(w.Name)
+	if _, err := EnsureConsumer(db, name, ConsumerOptions{AckTimeout: ackTimeout, MaxAttempts: w.MaxAttempts}); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		UPDATE bus_consumers SET ack_timeout = ?, max_attempts = ?, updated_at = ? WHERE name = ?
+	`, int64(ackTimeout/time.Second), w.MaxAttempts, time.Now().Unix(), name)
+	if err != nil {
+		return fmt.Errorf("failed to update consumer %s: %w", name, err)
+	}
+	return nil
+}
+
+// deliver sends one batch and returns how many events it received.
+func (w *Webhook) deliver(ctx context.Context, db *sql.DB, logf func(format string, args ...any)) (int, error) {
+	consumer := WebhookConsumer(w.Name)
+	deliveries, err := Receive(db, consumer, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	events := make([]Event, len(deliveries))
+	for i, d := range deliveries {
+		events[i] = d.Event
+	}
+	matched, err := w.Filter.Apply(db, events)
+	if err != nil {
+		return 0, err
+	}
+	wanted := make(map[int64]bool, len(matched))
+	for _, e := range matched {
+		wanted[e.Seq] = true
+	}
+
+	var skipped []int64
+	for _, d := range deliveries {
+		if !wanted[d.Seq] {
+			skipped = append(skipped, d.Seq)
+			continue
+		}
+		if ctx.Err() != nil {
+			// Unsent deliveries come back after the ack timeout.
+			break
+		}
+		if err := w.post(ctx, d); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			dead, nackErr := Nack(db, consumer, d.Seq, w.retryDelay(d.Attempt), err.Error())
+			if nackErr != nil {
+				return 0, nackErr
+			}
+			if dead {
+				logf("webhook %s: gave up on event %d (%s) after %d attempts: %v", w.Name, d.Seq, d.Type, d.Attempt, err)
+			} else {
+				logf("webhook %s: event %d (%s) attempt %d failed: %v", w.Name, d.Seq, d.Type, d.Attempt, err)
+			}
+			continue
+		}
+		if _, err := Ack(db, consumer, d.Seq); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := Ack(db, consumer, skipped...); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+func (w *Webhook) retryDelay(attempt int) time.Duration {
+	delay := w.Backoff
+	for i := 1; i < attempt && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxWebhookBackoff {
+		delay = maxWebhookBackoff
+	}
+	return delay
+}
+
+func (w *Webhook) post(ctx context.Context, d Delivery) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mnemonic-bus")
+	req.Header.Set("X-Mnemonic-Event", d.Type)
+	req.Header.Set("X-Mnemonic-Seq", strconv.FormatInt(d.Seq, 10))
+	req.Header.Set("X-Mnemonic-Delivery", d.ID)
+	req.Header.Set("X-Mnemonic-Attempt", strconv.Itoa(d.Attempt))
+	req.Header.Set("X-Mnemonic-Timestamp", strconv.FormatInt(now, 10))
+	if w.Secret != "" {
+		req.Header.Set("X-Mnemonic-Signature", Sign(w.Secret, now, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(snippet))
+		if msg == "" {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var received []Delivery
+	failNext := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Mnemonic-Timestamp"), 10, 64)
+		if got := r.Header.Get("X-Mnemonic-Signature"); got != Sign("s3cret", ts, body) {
+			t.Errorf("bad signature %q", got)
+		}
+		if failNext > 0 {
+			failNext--
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		var d Delivery
+		if err := json.Unmarshal(body, &d); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received = append(received, d)
+	}))
+	defer srv.Close()
+
+	hook, err := NewWebhook("bot", config.WebhookConfig{
+		URL:         srv.URL,
+		Secret:      "s3cret",
+		Types:       []string{"event.*"},
+		MaxAttempts: 2,
+		Backoff:     "0s",
+	})
+	if err != nil {
+		t.Fatalf("new webhook: %v", err)
+	}
+	if err := hook.ensureConsumer(db); err != nil {
+		t.Fatalf("ensure consumer: %v", err)
+	}
+	// The last delivery of a batch waits on every request ahead of it.
+	if c, err := GetConsumer(db, WebhookConsumer("bot")); err != nil || c.AckTimeout < int64(webhookBatchSize*DefaultWebhookTimeout/time.Second) {
+		t.Fatalf("expected ack timeout to cover a whole batch, got %+v (%v)", c, err)
+	}
+
+	emitEvent(t, db, TypeEventCreated, "ev1")
+	emitSync(t, db, "imessage")
+	emitEvent(t, db, TypeEventUpdated, "ev1")
+
+	ctx := context.Background()
+	logf := func(string, ...any) {}
+	if _, err := hook.deliver(ctx, db, logf); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	// The first event failed once and is retried on the next pass.
+	if len(received) != 1 || received[0].Type != "event.updated" {
+		t.Fatalf("first pass: %+v", received)
+	}
+	if _, err := hook.deliver(ctx, db, logf); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(received) != 2 || received[1].Type != "event.created" || received[1].Attempt != 2 {
+		t.Fatalf("retry pass: %+v", received)
+	}
+
+	// Exhausted attempts end up as dead letters.
+	failNext = 2
+	emitEvent(t, db, TypeEventDeleted, "ev1")
+	for i := 0; i < 2; i++ {
+		if _, err := hook.deliver(ctx, db, logf); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	letters, err := ListDead(db, WebhookConsumer("bot"), 0)
+	if err != nil {
+		t.Fatalf("list dead: %v", err)
+	}
+	if len(letters) != 1 || letters[0].Type != "event.deleted" || letters[0].LastError != "HTTP 503: try later" {
+		t.Fatalf("dead letters: %+v", letters)
+	}
+
+	statuses, err := ListConsumers(db)
+	if err != nil {
+		t.Fatalf("list consumers: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Lag != 0 || statuses[0].Dead != 1 {
+		t.Fatalf("consumer status: %+v", statuses)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	hook, err := NewWebhook("bot", config.WebhookConfig{URL: "https://example.com/hook", Backoff: "40m"})
+	if err != nil {
+		t.Fatalf("new webhook: %v", err)
+	}
+	if d := hook.retryDelay(1); d.Minutes() != 40 {
+		t.Fatalf("first delay = %s", d)
+	}
+	if d := hook.retryDelay(3); d != maxWebhookBackoff {
+		t.Fatalf("capped delay = %s", d)
+	}
+	if _, err := NewWebhook("bad", config.WebhookConfig{URL: "ftp://example.com"}); err == nil {
+		t.Fatalf("expected invalid url to fail")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Napageneral/mnemonic/internal/ingest"
 	"github.com/google/uuid"
 )
 
@@ -72,7 +73,7 @@ func (c *TimeGapChunker) Chunk(ctx context.Context, db *sql.DB, definitionID str
 			query = `
 				SELECT id, timestamp, thread_id, channel
 				FROM events
-				WHERE channel = ? AND thread_id IS NOT NULL
+				WHERE channel = ? AND thread_id IS NOT NULL AND ` + ingest.NotDeleted("events") + `
 				ORDER BY thread_id, timestamp ASC
 			`
 			args = []interface{}{channel}
@@ -80,7 +81,7 @@ func (c *TimeGapChunker) Chunk(ctx context.Context, db *sql.DB, definitionID str
 			query = `
 				SELECT id, timestamp, thread_id, channel
 				FROM events
-				WHERE thread_id IS NOT NULL
+				WHERE thread_id IS NOT NULL AND ` + ingest.NotDeleted("events") + `
 				ORDER BY thread_id, timestamp ASC
 			`
 		}
@@ -90,7 +91,7 @@ func (c *TimeGapChunker) Chunk(ctx context.Context, db *sql.DB, definitionID str
 			query = `
 				SELECT id, timestamp, thread_id, channel
 				FROM events
-				WHERE channel = ?
+				WHERE channel = ? AND ` + ingest.NotDeleted("events") + `
 				ORDER BY timestamp ASC
 			`
 			args = []interface{}{channel}
@@ -98,6 +99,7 @@ func (c *TimeGapChunker) Chunk(ctx context.Context, db *sql.DB, definitionID str
 			query = `
 				SELECT id, timestamp, thread_id, channel
 				FROM events
+				WHERE ` + ingest.NotDeleted("events") + `
 				ORDER BY timestamp ASC
 			`
 		}
@@ -344,7 +346,7 @@ func (c *ThreadChunker) Chunk(ctx context.Context, db *sql.DB, definitionID stri
 		query = `
 			SELECT id, timestamp, thread_id, channel
 			FROM events
-			WHERE channel = ? AND thread_id IS NOT NULL
+			WHERE channel = ? AND thread_id IS NOT NULL AND ` + ingest.NotDeleted("events") + `
 			ORDER BY thread_id, timestamp ASC
 		`
 		args = []interface{}{channel}
@@ -352,7 +354,7 @@ func (c *ThreadChunker) Chunk(ctx context.Context, db *sql.DB, definitionID stri
 		query = `
 			SELECT id, timestamp, thread_id, channel
 			FROM events
-			WHERE thread_id IS NOT NULL
+			WHERE thread_id IS NOT NULL AND ` + ingest.NotDeleted("events") + `
 			ORDER BY thread_id, timestamp ASC
 		`
 	}
@@ -530,7 +532,7 @@ func (c *SingleEventChunker) Chunk(ctx context.Context, db *sql.DB, definitionID
 		FROM events
 	`
 	args := []interface{}{}
-	clauses := []string{}
+	clauses := []string{ingest.NotDeleted("events")}
 	if channel != "" {
 		clauses = append(clauses, "channel = ?")
 		args = append(args, channel)
@@ -683,7 +685,7 @@ func (c *TurnPairChunker) Chunk(ctx context.Context, db *sql.DB, definitionID st
 	query := `
 		SELECT id, timestamp, thread_id, channel, direction, source_adapter
 		FROM events
-		WHERE thread_id IS NOT NULL
+		WHERE thread_id IS NOT NULL AND ` + ingest.NotDeleted("events") + `
 	`
 	args := []interface{}{}
 	if channel != "" {
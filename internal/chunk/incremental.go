@@ -0,0 +1,286 @@
+package chunk
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/google/uuid"
+)
+
+// ThreadsResult tracks the outcome of incremental chunking
+type ThreadsResult struct {
+	ThreadsProcessed int
+	EventsProcessed  int
+	EpisodesCreated  int
+	EpisodesExtended int
+	EpisodeIDs       []string // created or extended
+	Duration         time.Duration
+}
+
+// ChunkThreads chunks only the events in threadIDs that no episode of the
+// definition covers yet, so a live pipeline can keep episodes current without
+// re-running the whole definition.
+//
+// New events join the thread's latest episode when the strategy allows it
+// (always for "thread", within gap_seconds for "time_gap") and that episode
+// has not been analyzed or embedded; otherwise they start new episodes.
+// Existing episodes are never split or deleted.
+func ChunkThreads(ctx context.Context, db *sql.DB, definitionID string, threadIDs []string) (ThreadsResult, error) {
+	startTime := time.Now()
+	result := ThreadsResult{}
+
+	var channel sql.NullString
+	var strategy, configJSON string
+	err := db.QueryRowContext(ctx, `
+		SELECT channel, strategy, config_json FROM episode_definitions WHERE id = ?
+	`, definitionID).Scan(&channel, &strategy, &configJSON)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch definition: %w", err)
+	}
+
+	var split func([]Event) []episode
+	gap := int64(-1) // -1: new events always join the latest episode
+	switch strategy {
+	case "thread":
+		split = func(events []Event) []episode {
+			return []episode{newEpisode(events)}
+		}
+	case "time_gap":
+		var config TimeGapConfig
+		if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return result, fmt.Errorf("failed to unmarshal time_gap config: %w", err)
+		}
+		if config.Scope != "thread" {
+			return result, fmt.Errorf("time_gap definitions with scope %q cannot be chunked by thread", config.Scope)
+		}
+		split = NewTimeGapChunker(config).splitByTimeGap
+		gap = config.GapSeconds
+	default:
+		return result, fmt.Errorf("strategy %s does not support incremental chunking", strategy)
+	}
+
+	for _, threadID := range threadIDs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		events, err := loadUnchunkedThreadEvents(ctx, db, definitionID, channel.String, threadID)
+		if err != nil {
+			return result, err
+		}
+		if len(events) == 0 {
+			continue
+		}
+		result.ThreadsProcessed++
+		result.EventsProcessed += len(events)
+
+		episodes := split(events)
+
+		last, frozen, err := latestThreadEpisode(ctx, db, definitionID, threadID)
+		if err != nil {
+			return result, err
+		}
+		if last != nil && !frozen && (gap < 0 || episodes[0].startTime-last.endTime <= gap) {
+			if err := extendEpisode(ctx, db, last.id, episodes[0].events); err != nil {
+				return result, fmt.Errorf("failed to extend episode %s: %w", last.id, err)
+			}
+			result.EpisodesExtended++
+			result.EpisodeIDs = append(result.EpisodeIDs, last.id)
+			episodes = episodes[1:]
+		}
+
+		for _, ep := range episodes {
+			episodeID, err := createEpisode(ctx, db, definitionID, channel.String, ep)
+			if err != nil {
+				return result, fmt.Errorf("failed to insert episode: %w", err)
+			}
+			result.EpisodesCreated++
+			result.EpisodeIDs = append(result.EpisodeIDs, episodeID)
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+func newEpisode(events []Event) episode {
+	return episode{
+		events:    events,
+		startTime: events[0].Timestamp,
+		endTime:   events[len(events)-1].Timestamp,
+		threadID:  events[0].ThreadID,
+		channel:   events[0].Channel,
+	}
+}
+
+func loadUnchunkedThreadEvents(ctx context.Context, db *sql.DB, definitionID, channel, threadID string) ([]Event, error) {
+	query := `
+		SELECT id, timestamp, thread_id, channel
+		FROM events
+		WHERE thread_id = ? AND ` + ingest.NotDeleted("events") + `
+		AND NOT EXISTS (
+			SELECT 1 FROM episode_events ee
+			JOIN episodes ep ON ep.id = ee.episode_id
+			WHERE ee.event_id = events.id AND ep.definition_id = ?
+		)
+	`
+	args := []interface{}{threadID, definitionID}
+	if channel != "" {
+		query += ` AND channel = ?`
+		args = append(args, channel)
+	}
+	query += ` ORDER BY timestamp ASC`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.ThreadID, &e.Channel); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+	return events, nil
+}
+
+type episodeBounds struct {
+	id      string
+	endTime int64
+}
+
+// latestThreadEpisode returns the thread's most recent episode and whether
+// downstream work (analysis or embeddings) already consumed it.
+func latestThreadEpisode(ctx context.Context, db *sql.DB, definitionID, threadID string) (*episodeBounds, bool, error) {
+	var ep episodeBounds
+	var frozen bool
+	err := db.QueryRowContext(ctx, `
+		SELECT ep.id, ep.end_time,
+			EXISTS (SELECT 1 FROM analysis_runs ar WHERE ar.episode_id = ep.id)
+			OR EXISTS (SELECT 1 FROM embeddings em WHERE em.target_type = 'episode' AND em.target_id = ep.id)
+		FROM episodes ep
+		WHERE ep.definition_id = ? AND ep.thread_id = ?
+		ORDER BY ep.end_time DESC
+		LIMIT 1
+	`, definitionID, threadID).Scan(&ep.id, &ep.endTime, &frozen)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query latest episode: %w", err)
+	}
+	return &ep, frozen, nil
+}
+
+// extendEpisode adds events to an episode and renumbers its positions by
+// timestamp, since late-arriving events may predate ones already in it.
+func extendEpisode(ctx context.Context, db *sql.DB, episodeID string, events []Event) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.id, e.timestamp
+		FROM episode_events ee
+		JOIN events e ON e.id = ee.event_id
+		WHERE ee.episode_id = ?
+	`, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to load episode events: %w", err)
+	}
+	all := append([]Event(nil), events...)
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Timestamp); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan episode event: %w", err)
+		}
+		all = append(all, e)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating episode events: %w", err)
+	}
+	rows.Close()
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Timestamp < all[j].Timestamp })
+
+	for _, event := range events {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO episode_events (episode_id, event_id, position) VALUES (?, ?, 0)
+		`, episodeID, event.ID); err != nil {
+			return fmt.Errorf("failed to insert episode_event mapping: %w", err)
+		}
+	}
+	for position, event := range all {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE episode_events SET position = ? WHERE episode_id = ? AND event_id = ?
+		`, position+1, episodeID, event.ID); err != nil {
+			return fmt.Errorf("failed to renumber episode events: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE episodes
+		SET start_time = ?, end_time = ?, event_count = ?, first_event_id = ?, last_event_id = ?
+		WHERE id = ?
+	`, all[0].Timestamp, all[len(all)-1].Timestamp, len(all), all[0].ID, all[len(all)-1].ID, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to update episode: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// createEpisode inserts a thread-scoped episode and returns its id
+func createEpisode(ctx context.Context, db *sql.DB, definitionID, scopeChannel string, ep episode) (string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	episodeID := uuid.New().String()
+	channelValue := scopeChannel
+	if channelValue == "" {
+		channelValue = ep.channel
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO episodes (
+			id, definition_id, channel, thread_id,
+			start_time, end_time, event_count,
+			first_event_id, last_event_id, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, episodeID, definitionID, channelValue, ep.threadID,
+		ep.startTime, ep.endTime, len(ep.events),
+		ep.events[0].ID, ep.events[len(ep.events)-1].ID, time.Now().Unix())
+	if err != nil {
+		return "", err
+	}
+
+	for position, event := range ep.events {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO episode_events (episode_id, event_id, position)
+			VALUES (?, ?, ?)
+		`, episodeID, event.ID, position+1) // position is 1-indexed
+		if err != nil {
+			return "", fmt.Errorf("failed to insert episode_event mapping: %w", err)
+		}
+	}
+
+	return episodeID, tx.Commit()
+}
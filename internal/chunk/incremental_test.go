@@ -0,0 +1,131 @@
+package chunk
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestChunkThreads(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	defID, err := CreateDefinition(ctx, db, "imessage_1h", "imessage", "time_gap",
+		TimeGapConfig{GapSeconds: 3600, Scope: "thread"}, "")
+	if err != nil {
+		t.Fatalf("create definition: %v", err)
+	}
+	for _, thread := range []string{"t1", "t2"} {
+		if _, err := db.Exec(`
+			INSERT INTO threads (id, channel, source_adapter, source_id, created_at, updated_at)
+			VALUES (?, 'imessage', 'imessage', ?, 0, 0)
+		`, thread, thread); err != nil {
+			t.Fatalf("insert thread: %v", err)
+		}
+	}
+	addEvent := func(id, thread string, ts int64) {
+		t.Helper()
+		if _, err := db.Exec(`
+			INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, source_adapter, source_id)
+			VALUES (?, ?, 'imessage', '["text"]', 'hi', 'received', ?, 'imessage', ?)
+		`, id, ts, thread, id); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+	}
+
+	addEvent("e1", "t1", 1000)
+	addEvent("e2", "t1", 1100)
+	addEvent("other", "t2", 1000)
+
+	res, err := ChunkThreads(ctx, db, defID, []string{"t1"})
+	if err != nil {
+		t.Fatalf("chunk: %v", err)
+	}
+	if res.EpisodesCreated != 1 || res.EventsProcessed != 2 || len(res.EpisodeIDs) != 1 {
+		t.Fatalf("first pass: %+v", res)
+	}
+	episodeID := res.EpisodeIDs[0]
+
+	// An event inside the gap joins the episode; one that arrives late is
+	// ordered by timestamp.
+	addEvent("e3", "t1", 1200)
+	addEvent("e0", "t1", 900)
+	res, err = ChunkThreads(ctx, db, defID, []string{"t1"})
+	if err != nil {
+		t.Fatalf("chunk: %v", err)
+	}
+	if res.EpisodesExtended != 1 || res.EpisodesCreated != 0 || res.EpisodeIDs[0] != episodeID {
+		t.Fatalf("extend pass: %+v", res)
+	}
+	if got := episodeEventOrder(t, db, episodeID); got != "e0,e1,e2,e3" {
+		t.Fatalf("episode order = %s", got)
+	}
+	var start, end int64
+	var count int
+	if err := db.QueryRow(`SELECT start_time, end_time, event_count FROM episodes WHERE id = ?`, episodeID).Scan(&start, &end, &count); err != nil {
+		t.Fatalf("load episode: %v", err)
+	}
+	if start != 900 || end != 1200 || count != 4 {
+		t.Fatalf("episode bounds = %d-%d (%d events)", start, end, count)
+	}
+
+	// Once embedded, the episode is left alone and new events start new
+	// episodes, split by the gap.
+	if _, err := db.Exec(`
+		INSERT INTO embeddings (id, target_type, target_id, model, embedding_blob, dimension, created_at)
+		VALUES ('emb1', 'episode', ?, 'test', x'00', 1, 0)
+	`, episodeID); err != nil {
+		t.Fatalf("insert embedding: %v", err)
+	}
+	addEvent("e4", "t1", 1300)
+	addEvent("e5", "t1", 100000)
+	res, err = ChunkThreads(ctx, db, defID, []string{"t1"})
+	if err != nil {
+		t.Fatalf("chunk: %v", err)
+	}
+	if res.EpisodesCreated != 2 || res.EpisodesExtended != 0 {
+		t.Fatalf("frozen pass: %+v", res)
+	}
+	if got := episodeEventOrder(t, db, episodeID); got != "e0,e1,e2,e3" {
+		t.Fatalf("embedded episode changed: %s", got)
+	}
+
+	res, err = ChunkThreads(ctx, db, defID, []string{"t1"})
+	if err != nil {
+		t.Fatalf("chunk: %v", err)
+	}
+	if res.ThreadsProcessed != 0 || len(res.EpisodeIDs) != 0 {
+		t.Fatalf("rerun should be a no-op: %+v", res)
+	}
+
+	var t2Episodes int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM episodes WHERE thread_id = 't2'`).Scan(&t2Episodes); err != nil {
+		t.Fatalf("count t2 episodes: %v", err)
+	}
+	if t2Episodes != 0 {
+		t.Fatalf("untouched thread was chunked")
+	}
+}
+
+func episodeEventOrder(t *testing.T, db *sql.DB, episodeID string) string {
+	t.Helper()
+	rows, err := db.Query(`SELECT event_id FROM episode_events WHERE episode_id = ? ORDER BY position`, episodeID)
+	if err != nil {
+		t.Fatalf("query episode events: %v", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, ",")
+}
@@ -15,7 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Napageneral/mnemonic/internal/bus"
 	"github.com/Napageneral/mnemonic/internal/gemini"
+	"github.com/Napageneral/mnemonic/internal/ingest"
 	"github.com/Napageneral/taskengine/engine"
 	"github.com/Napageneral/taskengine/queue"
 	"github.com/google/uuid"
@@ -433,36 +435,40 @@ func (e *Engine) EnqueueAnalysis(ctx context.Context, analysisTypeName string, e
 	return count, nil
 }
 
-// EnqueueEmbeddings queues embedding jobs for all un-embedded episodes
-func (e *Engine) EnqueueEmbeddings(ctx context.Context) (int, error) {
-	// Find episodes without embeddings
-	// Collect IDs first, close rows, then enqueue (SQLite deadlock avoidance)
-	rows, err := e.db.QueryContext(ctx, `
-		SELECT ep.id FROM episodes ep
-		WHERE NOT EXISTS (
-			SELECT 1 FROM embeddings em
-			WHERE em.target_type = 'episode'
-			AND em.target_id = ep.id
-		)
-	`)
-	if err != nil {
-		return 0, fmt.Errorf("query episodes: %w", err)
-	}
+// EnqueueEmbeddings queues embedding jobs for the given episodes, or for all
+// un-embedded episodes when none are given
+func (e *Engine) EnqueueEmbeddings(ctx context.Context, episodeIDs ...string) (int, error) {
+	epIDs := episodeIDs
 
-	var epIDs []string
-	for rows.Next() {
-		var epID string
-		if err := rows.Scan(&epID); err != nil {
+	if len(epIDs) == 0 {
+		// Find episodes without embeddings
+		// Collect IDs first, close rows, then enqueue (SQLite deadlock avoidance)
+		rows, err := e.db.QueryContext(ctx, `
+			SELECT ep.id FROM episodes ep
+			WHERE NOT EXISTS (
+				SELECT 1 FROM embeddings em
+				WHERE em.target_type = 'episode'
+				AND em.target_id = ep.id
+			)
+		`)
+		if err != nil {
+			return 0, fmt.Errorf("query episodes: %w", err)
+		}
+
+		for rows.Next() {
+			var epID string
+			if err := rows.Scan(&epID); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			epIDs = append(epIDs, epID)
+		}
+		if err := rows.Err(); err != nil {
 			rows.Close()
 			return 0, err
 		}
-		epIDs = append(epIDs, epID)
-	}
-	if err := rows.Err(); err != nil {
 		rows.Close()
-		return 0, err
 	}
-	rows.Close()
 
 	// Now enqueue (rows is closed, no deadlock)
 	count := 0
@@ -805,6 +811,7 @@ func (e *Engine) handleAnalysisJob(ctx context.Context, job *queue.Job) error {
 
 		if err == nil {
 			outcome = "ok"
+			e.announceAnalysis(runID, analysisTypeName, episodeID)
 		}
 		return err
 	}
@@ -891,10 +898,23 @@ func (e *Engine) handleAnalysisJob(ctx context.Context, job *queue.Job) error {
 
 	if err == nil {
 		outcome = "ok"
+		e.announceAnalysis(runID, analysisTypeName, episodeID)
 	}
 	return err
 }
 
+// announceAnalysis emits analysis.completed. A failed emit is logged rather
+// than failing a run whose output is already stored.
+func (e *Engine) announceAnalysis(runID, analysisType, episodeID string) {
+	if err := bus.Emit(e.db, bus.TypeAnalysisCompleted, "", "", bus.AnalysisCompletedPayload{
+		RunID:        runID,
+		AnalysisType: analysisType,
+		EpisodeID:    episodeID,
+	}); err != nil {
+		log.Printf("warning: failed to emit %s: %v", bus.TypeAnalysisCompleted, err)
+	}
+}
+
 // handleEmbeddingJob processes an embedding job using the batch API
 func (e *Engine) handleEmbeddingJob(ctx context.Context, job *queue.Job) error {
 	overallStart := time.Now()
@@ -1072,6 +1092,7 @@ func (e *Engine) buildEpisodeText(ctx context.Context, episodeID string) (string
 			LIMIT 1
 		)
 		WHERE ee.episode_id = ?
+		  AND `+ingest.NotDeleted("e")+`
 		ORDER BY ee.position
 	`, episodeID)
 	if err != nil {
@@ -1132,6 +1153,11 @@ func (e *Engine) buildEpisodeText(ctx context.Context, episodeID string) (string
 			continue
 		}
 
+		if hasContentType(contentTypes, "call") {
+			sb.WriteString(fmt.Sprintf("[%s] %s\n", timestampStr, formatCallLine(name, metadataJSON)))
+			continue
+		}
+
 		isMembership := hasContentType(contentTypes, "membership")
 		if isMembership {
 			effectiveMetadata := metadataJSON
@@ -1210,6 +1236,8 @@ func (e *Engine) buildTurnQualityText(ctx context.Context, episodeID string) (st
 		  AND e.direction IN ('sent', 'received')
 		  AND e.content_types NOT LIKE '%"reaction"%'
 		  AND e.content_types NOT LIKE '%"membership"%'
+		  AND e.content_types NOT LIKE '%"call"%'
+		  AND `+ingest.NotDeleted("e")+`
 		ORDER BY ee.position
 	`, episodeID)
 	if err != nil {
@@ -1289,6 +1317,7 @@ func (e *Engine) buildEpisodeTextMasked(ctx context.Context, episodeID string) (
 			LIMIT 1
 		)
 		WHERE ee.episode_id = ?
+		  AND `+ingest.NotDeleted("e")+`
 		ORDER BY ee.position
 	`, episodeID)
 	if err != nil {
@@ -1368,6 +1397,11 @@ func (e *Engine) buildEpisodeTextMasked(ctx context.Context, episodeID string) (
 			continue
 		}
 
+		if hasContentType(contentTypes, "call") {
+			sb.WriteString(fmt.Sprintf("[%s] %s\n", timestampStr, formatCallLine(name, metadataJSON)))
+			continue
+		}
+
 		if hasContentType(contentTypes, "membership") {
 			effectiveMetadata := metadataJSON
 			if parseMembershipAction(metadataJSON) == "removed" {
@@ -1521,6 +1555,35 @@ func formatMembershipLineMasked(actor string, labelFor func(contactID string, is
 	}
 }
 
+// formatCallLine renders a call event from its call_type and
+// duration_seconds metadata. caller is the event's sender.
+func formatCallLine(caller string, metadataJSON sql.NullString) string {
+	var payload struct {
+		CallType        string `json:"call_type"`
+		DurationSeconds int64  `json:"duration_seconds"`
+	}
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		_ = json.Unmarshal([]byte(metadataJSON.String), &payload)
+	}
+	caller = strings.TrimSpace(caller)
+	if caller == "" {
+		caller = "Unknown"
+	}
+
+	switch payload.CallType {
+	case "missed":
+		return fmt.Sprintf("-> missed call from %s", caller)
+	case "voicemail":
+		return fmt.Sprintf("-> %s left a voicemail", caller)
+	case "rejected", "blocked":
+		return fmt.Sprintf("-> %s call from %s", payload.CallType, caller)
+	}
+	if payload.DurationSeconds > 0 {
+		return fmt.Sprintf("-> %s called (%s)", caller, time.Duration(payload.DurationSeconds)*time.Second)
+	}
+	return fmt.Sprintf("-> %s called", caller)
+}
+
 func parseMembershipAction(metadataJSON sql.NullString) string {
 	if !metadataJSON.Valid || metadataJSON.String == "" {
 		return "unknown"
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"path/filepath"
 	"strings"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
 )
 
 type terminalInvocation struct {
@@ -42,6 +44,7 @@ func (e *Engine) buildInvocationOutput(ctx context.Context, episodeID string, fi
 		FROM episode_events ee
 		JOIN events e ON ee.event_id = e.id
 		WHERE ee.episode_id = ?
+		  AND `+ingest.NotDeleted("e")+`
 		ORDER BY ee.position
 	`, episodeID)
 	if err != nil {
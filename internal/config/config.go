@@ -12,7 +12,21 @@ import (
 // Config represents the mnemonic configuration
 type Config struct {
 	Me       MeConfig                `yaml:"me"`
+	Sync     SyncConfig               `yaml:"sync,omitempty"`
 	Adapters map[string]AdapterConfig `yaml:"adapters"`
+	// Pipelines run downstream work in `mnemonic watch run` after syncs
+	// write new events.
+	Pipelines map[string]PipelineConfig `yaml:"pipelines,omitempty"`
+	// Bus configures `mnemonic bus serve`.
+	Bus BusConfig `yaml:"bus,omitempty"`
+}
+
+// SyncConfig controls how `mnemonic sync` schedules adapters.
+type SyncConfig struct {
+	// Concurrency caps how many adapters sync at once (default 4).
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// Timeout bounds each adapter's sync, as a Go duration such as "2h".
+	Timeout string `yaml:"timeout,omitempty"`
 }
 
 // MeConfig represents the user's identity
@@ -33,6 +47,11 @@ type AdapterConfig struct {
 	Enabled bool                   `yaml:"enabled"`
 	Live    *LiveConfig            `yaml:"live,omitempty"`
 	Options map[string]interface{} `yaml:"options,omitempty"`
+	// PurgeDeleted clears the content of events the source deleted instead of
+	// only tombstoning them.
+	PurgeDeleted bool `yaml:"purge_deleted,omitempty"`
+	// Timeout overrides sync.timeout for this adapter.
+	Timeout string `yaml:"timeout,omitempty"`
 }
 
 // LiveConfig controls live watching for an adapter.
@@ -41,6 +60,56 @@ type LiveConfig struct {
 	Options map[string]interface{} `yaml:"options,omitempty"`
 }
 
+// PipelineConfig describes a watch-mode pipeline: when syncs write events in
+// Channels, re-chunk the affected threads with Definition, then queue the
+// new or grown episodes for analysis and embedding.
+type PipelineConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Channels limits the trigger to events in these channels; empty means
+	// the definition's channel, or every channel if it has none.
+	Channels []string `yaml:"channels,omitempty"`
+	// Definition is the episode definition name (see `mnemonic chunk list`).
+	// It must use the thread strategy or time_gap with scope "thread".
+	Definition string `yaml:"definition"`
+	// Analysis lists analysis type names to enqueue for each episode.
+	Analysis []string `yaml:"analysis,omitempty"`
+	// Embed enqueues episode embeddings.
+	Embed bool `yaml:"embed,omitempty"`
+	// Compute runs the compute engine until the queue drains after each
+	// trigger. Requires GEMINI_API_KEY.
+	Compute bool `yaml:"compute,omitempty"`
+	// Debounce waits for syncs to go quiet, as a Go duration (default "30s").
+	Debounce string `yaml:"debounce,omitempty"`
+}
+
+// BusConfig configures `mnemonic bus serve`.
+type BusConfig struct {
+	// Webhooks receive bus events over HTTP, keyed by name.
+	Webhooks map[string]WebhookConfig `yaml:"webhooks,omitempty"`
+}
+
+// WebhookConfig describes an outbound webhook. Each one reads the bus as
+// durable consumer "webhook:<name>", so it resumes where it left off and
+// its failures show up in `mnemonic bus consumers` and `bus dead`.
+type WebhookConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+	// Secret signs each request with HMAC-SHA256 (X-Mnemonic-Signature).
+	Secret string `yaml:"secret,omitempty"`
+	// Types, Adapters and Channels filter the events sent; types may be
+	// glob patterns such as "event.*".
+	Types    []string `yaml:"types,omitempty"`
+	Adapters []string `yaml:"adapters,omitempty"`
+	Channels []string `yaml:"channels,omitempty"`
+	// MaxAttempts before an event is dead-lettered (default 5).
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// Timeout bounds each request, as a Go duration (default "10s").
+	Timeout string `yaml:"timeout,omitempty"`
+	// Backoff is the delay before the first retry, doubled for each later
+	// one up to an hour (default "10s").
+	Backoff string `yaml:"backoff,omitempty"`
+}
+
 // GetConfigDir returns the XDG-compliant config directory
 func GetConfigDir() (string, error) {
 	// Explicit override (useful for tests and portable installs)
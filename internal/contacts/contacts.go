@@ -69,7 +69,8 @@ func looksLikeEmail(value string) bool {
 	return strings.Contains(value, "@")
 }
 
-func looksLikePhone(value string) bool {
+// LooksLikePhone reports whether value is plausibly a phone number rather than a name.
+func LooksLikePhone(value string) bool {
 	normalized := normalizePhone(value)
 	if normalized == "" {
 		return false
@@ -114,7 +115,7 @@ func IsMeaningfulPersonName(name string) bool {
 	if lower == "unknown" || lower == "unknown contact" || lower == "me" {
 		return false
 	}
-	if looksLikeEmail(name) || looksLikePhone(name) {
+	if looksLikeEmail(name) || LooksLikePhone(name) {
 		return false
 	}
 	if strings.HasPrefix(strings.TrimSpace(name), "@") {
@@ -136,7 +137,7 @@ func isGenericName(name string) bool {
 	if lower == "unknown" || lower == "unknown contact" || lower == "me" {
 		return true
 	}
-	if looksLikeEmail(name) || looksLikePhone(name) {
+	if looksLikeEmail(name) || LooksLikePhone(name) {
 		return true
 	}
 	if strings.HasPrefix(strings.TrimSpace(name), "@") {
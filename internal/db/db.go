@@ -51,11 +51,24 @@ func Init() error {
 		return err
 	}
 
+	if err := dropLegacyFTS(db); err != nil {
+		return err
+	}
+	unindexed := missingFTS(db)
+
 	// Execute schema
 	if _, err := db.Exec(schemaSQL); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	// Newly created external-content indexes start empty; fill them from
+	// rows that already exist.
+	for _, idx := range unindexed {
+		if err := RebuildFTS(db, idx); err != nil {
+			return err
+		}
+	}
+
 	if err := migrateContactPersonSplit(db); err != nil {
 		return err
 	}
@@ -132,6 +145,32 @@ func ensureLegacyColumns(db *sql.DB) error {
 	if err := ensureColumn(db, "threads", "is_group", "INTEGER NOT NULL DEFAULT 0"); err != nil {
 		return err
 	}
+	// bus_events once named its event column cortex_event_id and had no
+	// payload schema version.
+	if err := renameColumn(db, "bus_events", "cortex_event_id", "mnemonic_event_id"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "bus_events", "version", "INTEGER"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func renameColumn(db *sql.DB, table, from, to string) error {
+	if !tableExists(db, table) {
+		return nil
+	}
+	has, err := columnExists(db, table, from)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return nil
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, from, to))
+	if err != nil {
+		return fmt.Errorf("rename column %s.%s: %w", table, from, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitMigratesLegacyBusEvents(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+
+	legacy, err := sql.Open("sqlite", filepath.Join(tmp, "cortex.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE bus_events (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			id TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			adapter TEXT,
+			cortex_event_id TEXT,
+			created_at INTEGER NOT NULL,
+			payload_json TEXT
+		)`,
+		`INSERT INTO bus_events (id, type, cortex_event_id, created_at) VALUES ('b1', 'event.created', 'e1', 1)`,
+	} {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("seed legacy db: %v", err)
+		}
+	}
+	legacy.Close()
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	d, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+
+	if ok, _ := columnExists(d, "bus_events", "version"); !ok {
+		t.Fatalf("expected bus_events.version to be added")
+	}
+	var eventID string
+	if err := d.QueryRow(`SELECT mnemonic_event_id FROM bus_events WHERE id = 'b1'`).Scan(&eventID); err != nil || eventID != "e1" {
+		t.Fatalf("expected cortex_event_id to be renamed, got %q (%v)", eventID, err)
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// FTSIndex is an external-content FTS5 table and the table it indexes.
+type FTSIndex struct {
+	Name   string // FTS5 table
+	Source string // content table
+	Column string // indexed column, named as in the source table
+}
+
+// FTSIndexes lists the full-text indexes defined in schema.sql.
+var FTSIndexes = []FTSIndex{
+	{Name: "events_fts", Source: "events", Column: "content"},
+	{Name: "threads_fts", Source: "threads", Column: "name"},
+	{Name: "attachments_fts", Source: "attachments", Column: "filename"},
+	{Name: "documents_fts", Source: "document_heads", Column: "title"},
+}
+
+// FTSStatus reports the health of one index.
+type FTSStatus struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	SourceRows  int64  `json:"source_rows"`
+	IndexedRows int64  `json:"indexed_rows"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+}
+
+// LookupFTSIndex finds an index by FTS table or source table name.
+func LookupFTSIndex(name string) (FTSIndex, bool) {
+	for _, idx := range FTSIndexes {
+		if idx.Name == name || idx.Source == name {
+			return idx, true
+		}
+	}
+	return FTSIndex{}, false
+}
+
+// CheckFTS runs FTS5's integrity check against each index's content table
+// and compares row counts.
+func CheckFTS(db *sql.DB) ([]FTSStatus, error) {
+	var out []FTSStatus
+	for _, idx := range FTSIndexes {
+		st := FTSStatus{Name: idx.Name, Source: idx.Source}
+		if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, idx.Source)).Scan(&st.SourceRows); err != nil {
+			return nil, fmt.Errorf("count %s: %w", idx.Source, err)
+		}
+		// The docsize shadow table holds one row per indexed document.
+		if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s_docsize`, idx.Name)).Scan(&st.IndexedRows); err != nil {
+			st.Error = fmt.Sprintf("index missing: %v", err)
+			out = append(out, st)
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`INSERT INTO %s(%s, rank) VALUES ('integrity-check', 1)`, idx.Name, idx.Name)); err != nil {
+			st.Error = err.Error()
+		} else if st.IndexedRows != st.SourceRows {
+			st.Error = fmt.Sprintf("%d of %d rows indexed", st.IndexedRows, st.SourceRows)
+		}
+		st.OK = st.Error == ""
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// RebuildFTS re-indexes idx from its content table. It runs as one
+// statement, so in WAL mode searches keep reading the old index until the
+// rebuild commits.
+func RebuildFTS(db *sql.DB, idx FTSIndex) error {
+	if _, err := db.Exec(fmt.Sprintf(`INSERT INTO %s(%s) VALUES ('rebuild')`, idx.Name, idx.Name)); err != nil {
+		return fmt.Errorf("rebuild %s: %w", idx.Name, err)
+	}
+	return nil
+}
+
+// OptimizeFTS merges an index's b-trees into one, which speeds up queries
+// after many incremental writes.
+func OptimizeFTS(db *sql.DB, idx FTSIndex) error {
+	if _, err := db.Exec(fmt.Sprintf(`INSERT INTO %s(%s) VALUES ('optimize')`, idx.Name, idx.Name)); err != nil {
+		return fmt.Errorf("optimize %s: %w", idx.Name, err)
+	}
+	return nil
+}
+
+// dropLegacyFTS removes the pre-external-content events_fts table (which
+// stored its own copy of event_id, channel and content) and its triggers so
+// schema.sql can recreate it.
+func dropLegacyFTS(db *sql.DB) error {
+	legacy, err := columnExists(db, "events_fts", "event_id")
+	if err != nil || !legacy {
+		return err
+	}
+	for _, stmt := range []string{
+		"DROP TRIGGER IF EXISTS events_fts_insert",
+		"DROP TRIGGER IF EXISTS events_fts_update",
+		"DROP TRIGGER IF EXISTS events_fts_delete",
+		"DROP TABLE IF EXISTS events_fts",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("drop legacy events_fts: %w", err)
+		}
+	}
+	return nil
+}
+
+// missingFTS returns the indexes whose FTS table does not exist yet.
+func missingFTS(db *sql.DB) []FTSIndex {
+	var out []FTSIndex
+	for _, idx := range FTSIndexes {
+		if !tableExists(db, idx.Name) {
+			out = append(out, idx)
+		}
+	}
+	return out
+}
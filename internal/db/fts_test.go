@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitMigratesLegacyEventsFTS(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmp)
+
+	// Lay down a database whose events_fts still carries its own columns.
+	legacy, err := sql.Open("sqlite", filepath.Join(tmp, "cortex.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for _, stmt := range []string{
+		schemaSQL,
+		"DROP TRIGGER events_fts_insert",
+		"DROP TRIGGER events_fts_update",
+		"DROP TRIGGER events_fts_delete",
+		"DROP TABLE events_fts",
+		"CREATE VIRTUAL TABLE events_fts USING fts5(event_id UNINDEXED, channel UNINDEXED, content, tokenize='porter unicode61')",
+		"CREATE TRIGGER events_fts_insert AFTER INSERT ON events BEGIN INSERT INTO events_fts(event_id, channel, content) VALUES (new.id, new.channel, COALESCE(new.content, '')); END",
+		`INSERT INTO events (id, timestamp, channel, content_types, content, direction, source_adapter, source_id)
+		 VALUES ('e1', 100, 'imessage', '["text"]', 'carpool on thursday', 'received', 'test', 'e1')`,
+	} {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("seed legacy db: %v", err)
+		}
+	}
+	legacy.Close()
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	d, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+
+	if ok, _ := columnExists(d, "events_fts", "event_id"); ok {
+		t.Fatalf("expected legacy events_fts to be replaced")
+	}
+	var id string
+	if err := d.QueryRow(`
+		SELECT e.id FROM events_fts JOIN events e ON e.rowid = events_fts.rowid
+		WHERE events_fts MATCH 'carpool'
+	`).Scan(&id); err != nil || id != "e1" {
+		t.Fatalf("expected existing event to be indexed, got %q (%v)", id, err)
+	}
+
+	statuses, err := CheckFTS(d)
+	if err != nil {
+		t.Fatalf("CheckFTS: %v", err)
+	}
+	for _, st := range statuses {
+		if !st.OK {
+			t.Fatalf("%s not healthy: %+v", st.Name, st)
+		}
+	}
+
+	// A row written behind the triggers' back is caught by check and fixed
+	// by rebuild.
+	if _, err := d.Exec(`INSERT INTO events_fts(events_fts, rowid, content) VALUES ('delete', (SELECT rowid FROM events WHERE id = 'e1'), 'carpool on thursday')`); err != nil {
+		t.Fatalf("corrupt index: %v", err)
+	}
+	statuses, _ = CheckFTS(d)
+	if statuses[0].OK {
+		t.Fatalf("expected events_fts to report a problem: %+v", statuses[0])
+	}
+	idx, _ := LookupFTSIndex("events")
+	if err := RebuildFTS(d, idx); err != nil {
+		t.Fatalf("RebuildFTS: %v", err)
+	}
+	statuses, _ = CheckFTS(d)
+	if !statuses[0].OK {
+		t.Fatalf("expected rebuild to repair events_fts: %+v", statuses[0])
+	}
+	if err := OptimizeFTS(d, idx); err != nil {
+		t.Fatalf("OptimizeFTS: %v", err)
+	}
+}
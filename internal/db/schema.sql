@@ -53,6 +53,16 @@ CREATE TABLE IF NOT EXISTS document_heads (
 CREATE INDEX IF NOT EXISTS idx_document_heads_channel ON document_heads(channel);
 CREATE INDEX IF NOT EXISTS idx_document_heads_event ON document_heads(current_event_id);
 
+-- Document links: References between documents (ex: Obsidian [[wikilinks]])
+CREATE TABLE IF NOT EXISTS document_links (
+    from_doc_key TEXT NOT NULL REFERENCES document_heads(doc_key) ON DELETE CASCADE,
+    target TEXT NOT NULL,               -- link text as written (ex: "Projects/Launch")
+    to_doc_key TEXT,                    -- resolved document; NULL while the target is missing
+    PRIMARY KEY (from_doc_key, target)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_links_to ON document_links(to_doc_key);
+
 -- Retrieval log: Optional per-query document retrieval tracking
 CREATE TABLE IF NOT EXISTS retrieval_log (
     id TEXT PRIMARY KEY,
@@ -156,6 +166,17 @@ CREATE INDEX IF NOT EXISTS idx_event_state_read_state ON event_state(read_state)
 CREATE INDEX IF NOT EXISTS idx_event_state_flagged ON event_state(flagged);
 CREATE INDEX IF NOT EXISTS idx_event_state_archived ON event_state(archived);
 
+-- Event revisions: Prior content of events the source edited or unsent
+CREATE TABLE IF NOT EXISTS event_revisions (
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,          -- 1 = original content
+    content TEXT,
+    content_types TEXT,
+    observed_at INTEGER NOT NULL,       -- when this content was first seen
+    replaced_at INTEGER NOT NULL,       -- when a sync saw it change
+    PRIMARY KEY (event_id, revision)
+);
+
 -- Event tags: Channel-agnostic tags (distinct from analysis tags table above)
 CREATE TABLE IF NOT EXISTS event_tags (
     event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
@@ -258,13 +279,38 @@ CREATE TABLE IF NOT EXISTS bus_events (
     adapter TEXT,
     mnemonic_event_id TEXT,
     created_at INTEGER NOT NULL,
-    payload_json TEXT
+    payload_json TEXT,
+    version INTEGER                      -- payload schema version (see 'bus types')
 );
 
 CREATE INDEX IF NOT EXISTS idx_bus_events_created_at ON bus_events(created_at);
 CREATE INDEX IF NOT EXISTS idx_bus_events_type ON bus_events(type);
 CREATE INDEX IF NOT EXISTS idx_bus_events_event ON bus_events(mnemonic_event_id);
 
+-- Bus consumers: named readers with a stored cursor. Every event handed to
+-- a consumer stays in bus_deliveries until it is acked; unacked deliveries
+-- are redelivered after ack_timeout and dead-lettered after max_attempts.
+CREATE TABLE IF NOT EXISTS bus_consumers (
+    name TEXT PRIMARY KEY,
+    cursor INTEGER NOT NULL DEFAULT 0,   -- highest seq delivered
+    ack_timeout INTEGER NOT NULL,        -- seconds
+    max_attempts INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bus_deliveries (
+    consumer TEXT NOT NULL REFERENCES bus_consumers(name) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    state TEXT NOT NULL,                 -- inflight, dead
+    attempts INTEGER NOT NULL,
+    visible_at INTEGER NOT NULL,         -- redeliver once now >= visible_at
+    last_error TEXT,
+    PRIMARY KEY (consumer, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bus_deliveries_state ON bus_deliveries(consumer, state, visible_at);
+
 -- Sync jobs: Track background/resumable sync progress per adapter
 CREATE TABLE IF NOT EXISTS sync_jobs (
     adapter TEXT PRIMARY KEY,
@@ -277,6 +323,66 @@ CREATE TABLE IF NOT EXISTS sync_jobs (
     progress_json TEXT         -- JSON blob with counters, ETA, etc.
 );
 
+-- Sync runs: One row per adapter sync, kept as history
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    adapter TEXT NOT NULL,          -- configured adapter name
+    source_adapter TEXT NOT NULL,   -- source_adapter of the rows it writes
+    full INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,           -- running, success, error, abandoned
+    started_at INTEGER NOT NULL,
+    finished_at INTEGER,
+    watermark_before INTEGER,       -- sync_watermarks.last_sync_at when the run started
+    watermark_after INTEGER,
+    result_json TEXT,               -- AdapterResult, including perf
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_adapter ON sync_runs(adapter, status);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_source ON sync_runs(source_adapter, status);
+
+-- Sync run changes: Events and threads written while a run was active,
+-- attributed by source_adapter through the triggers below
+CREATE TABLE IF NOT EXISTS sync_run_changes (
+    run_id INTEGER NOT NULL REFERENCES sync_runs(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,             -- event, thread
+    target_id TEXT NOT NULL,
+    action TEXT NOT NULL,           -- created, updated
+    PRIMARY KEY (run_id, kind, target_id)
+);
+
+CREATE TRIGGER IF NOT EXISTS sync_runs_event_insert AFTER INSERT ON events
+WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+BEGIN
+    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+    SELECT MAX(id), 'event', new.id, 'created' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_runs_event_update AFTER UPDATE ON events
+WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+  AND (old.content IS NOT new.content OR old.timestamp IS NOT new.timestamp
+    OR old.content_types IS NOT new.content_types OR old.direction IS NOT new.direction
+    OR old.thread_id IS NOT new.thread_id OR old.metadata_json IS NOT new.metadata_json)
+BEGIN
+    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+    SELECT MAX(id), 'event', new.id, 'updated' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_runs_thread_insert AFTER INSERT ON threads
+WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+BEGIN
+    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+    SELECT MAX(id), 'thread', new.id, 'created' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_runs_thread_update AFTER UPDATE ON threads
+WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+  AND (old.name IS NOT new.name OR old.is_group IS NOT new.is_group OR old.parent_thread_id IS NOT new.parent_thread_id)
+BEGIN
+    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+    SELECT MAX(id), 'thread', new.id, 'updated' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+END;
+
 -- Merge suggestions: Proposed identity merges for user review
 -- Generated from fuzzy evidence (name similarity, shared domains) rather than
 -- deterministic matches (exact email/phone overlap which auto-merge).
@@ -562,29 +668,84 @@ CREATE TABLE IF NOT EXISTS embeddings (
 CREATE INDEX IF NOT EXISTS idx_embeddings_target ON embeddings(target_type, target_id);
 CREATE INDEX IF NOT EXISTS idx_embeddings_model ON embeddings(model);
 
--- FTS5 full-text search index for events
--- Provides fast BM25-based lexical search over event content
+-- FTS5 full-text search indexes
+-- External-content tables: the text lives in the source table and the index
+-- is kept current by triggers, so it stays usable during full syncs. Rebuild
+-- with `mnemonic db fts rebuild` (rowids are the source tables' implicit rowids).
 CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(
-    event_id UNINDEXED,     -- Link to events table (not indexed)
-    channel UNINDEXED,      -- For filtering (not indexed)
-    content,                -- Main searchable content
+    content,
+    content='events', content_rowid='rowid',
     tokenize='porter unicode61'
 );
 
--- FTS5 triggers to keep index in sync with events table
 CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events BEGIN
-    INSERT INTO events_fts(event_id, channel, content)
-    VALUES (new.id, new.channel, COALESCE(new.content, ''));
+    INSERT INTO events_fts(rowid, content) VALUES (new.rowid, new.content);
 END;
 
-CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE ON events BEGIN
-    DELETE FROM events_fts WHERE event_id = old.id;
-    INSERT INTO events_fts(event_id, channel, content)
-    VALUES (new.id, new.channel, COALESCE(new.content, ''));
+CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE OF content ON events BEGIN
+    INSERT INTO events_fts(events_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+    INSERT INTO events_fts(rowid, content) VALUES (new.rowid, new.content);
 END;
 
 CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events BEGIN
-    DELETE FROM events_fts WHERE event_id = old.id;
+    INSERT INTO events_fts(events_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS threads_fts USING fts5(
+    name,
+    content='threads', content_rowid='rowid',
+    tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS threads_fts_insert AFTER INSERT ON threads BEGIN
+    INSERT INTO threads_fts(rowid, name) VALUES (new.rowid, new.name);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_fts_update AFTER UPDATE OF name ON threads BEGIN
+    INSERT INTO threads_fts(threads_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+    INSERT INTO threads_fts(rowid, name) VALUES (new.rowid, new.name);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_fts_delete AFTER DELETE ON threads BEGIN
+    INSERT INTO threads_fts(threads_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS attachments_fts USING fts5(
+    filename,
+    content='attachments', content_rowid='rowid',
+    tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS attachments_fts_insert AFTER INSERT ON attachments BEGIN
+    INSERT INTO attachments_fts(rowid, filename) VALUES (new.rowid, new.filename);
+END;
+
+CREATE TRIGGER IF NOT EXISTS attachments_fts_update AFTER UPDATE OF filename ON attachments BEGIN
+    INSERT INTO attachments_fts(attachments_fts, rowid, filename) VALUES ('delete', old.rowid, old.filename);
+    INSERT INTO attachments_fts(rowid, filename) VALUES (new.rowid, new.filename);
+END;
+
+CREATE TRIGGER IF NOT EXISTS attachments_fts_delete AFTER DELETE ON attachments BEGIN
+    INSERT INTO attachments_fts(attachments_fts, rowid, filename) VALUES ('delete', old.rowid, old.filename);
+END;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS documents_fts USING fts5(
+    title,
+    content='document_heads', content_rowid='rowid',
+    tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_insert AFTER INSERT ON document_heads BEGIN
+    INSERT INTO documents_fts(rowid, title) VALUES (new.rowid, new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_update AFTER UPDATE OF title ON document_heads BEGIN
+    INSERT INTO documents_fts(documents_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
+    INSERT INTO documents_fts(rowid, title) VALUES (new.rowid, new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_delete AFTER DELETE ON document_heads BEGIN
+    INSERT INTO documents_fts(documents_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
 END;
 
 -- ============================================
@@ -0,0 +1,62 @@
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Link is a reference from one document to another. ToDocKey is empty when
+// the target does not resolve to a known document.
+type Link struct {
+	Target   string
+	ToDocKey string
+}
+
+// ReplaceLinks sets the outgoing links of each document in links, dropping
+// any it had before. All documents are updated in one transaction.
+func ReplaceLinks(ctx context.Context, db *sql.DB, links map[string][]Link) error {
+	if db == nil {
+		return errors.New("documents: db is nil")
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS document_links (
+			from_doc_key TEXT NOT NULL REFERENCES document_heads(doc_key) ON DELETE CASCADE,
+			target TEXT NOT NULL,
+			to_doc_key TEXT,
+			PRIMARY KEY (from_doc_key, target)
+		)
+	`); err != nil {
+		return fmt.Errorf("documents: ensure document_links: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("documents: begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for from, out := range links {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM document_links WHERE from_doc_key = ?`, from); err != nil {
+			return fmt.Errorf("documents: clear links: %w", err)
+		}
+		for _, l := range out {
+			if l.Target == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO document_links (from_doc_key, target, to_doc_key)
+				VALUES (?, ?, ?)
+				ON CONFLICT(from_doc_key, target) DO UPDATE SET to_doc_key = excluded.to_doc_key
+			`, from, l.Target, nullIfEmpty(l.ToDocKey)); err != nil {
+				return fmt.Errorf("documents: insert link: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("documents: commit: %w", err)
+	}
+	return nil
+}
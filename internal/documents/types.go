@@ -21,6 +21,7 @@ type DocumentResult struct {
 	Created         bool
 	Updated         bool
 	Skipped         bool
+	MetadataUpdated bool // content unchanged, but title/description/metadata refreshed
 	PreviousEventID string
 	Reason          string
 }
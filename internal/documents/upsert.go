@@ -41,7 +41,7 @@ func UpsertDocument(ctx context.Context, db *sql.DB, input DocumentInput) (Docum
 		timestamp = time.Now().Unix()
 	}
 
-	contentHash := hashContent(input.Content)
+	contentHash := HashContent(input.Content)
 	metadataJSON, err := marshalMetadata(input.Metadata)
 	if err != nil {
 		return DocumentResult{}, fmt.Errorf("documents: marshal metadata: %w", err)
@@ -65,11 +65,31 @@ func UpsertDocument(ctx context.Context, db *sql.DB, input DocumentInput) (Docum
 	}
 
 	if previousHash.Valid && previousHash.String == contentHash {
+		// Title and metadata can change without the content (a renamed
+		// file); refresh the head in place rather than versioning.
+		res, err := tx.ExecContext(ctx, `
+			UPDATE document_heads
+			SET title = ?, description = ?, metadata_json = ?
+			WHERE doc_key = ?
+			  AND (title IS NOT ? OR description IS NOT ? OR metadata_json IS NOT ?)
+		`, nullIfEmpty(input.Title), nullIfEmpty(input.Description), metadataJSON, input.DocKey,
+			nullIfEmpty(input.Title), nullIfEmpty(input.Description), metadataJSON)
+		if err != nil {
+			return DocumentResult{}, fmt.Errorf("documents: refresh head: %w", err)
+		}
+		reason := "content unchanged"
+		if n, _ := res.RowsAffected(); n > 0 {
+			if err := tx.Commit(); err != nil {
+				return DocumentResult{}, fmt.Errorf("documents: commit: %w", err)
+			}
+			reason = "metadata updated"
+		}
 		return DocumentResult{
-			DocKey:      input.DocKey,
-			ContentHash: contentHash,
-			Skipped:     true,
-			Reason:      "content unchanged",
+			DocKey:          input.DocKey,
+			ContentHash:     contentHash,
+			Skipped:         true,
+			MetadataUpdated: reason == "metadata updated",
+			Reason:          reason,
 		}, nil
 	}
 
@@ -132,7 +152,8 @@ func UpsertDocument(ctx context.Context, db *sql.DB, input DocumentInput) (Docum
 	}, nil
 }
 
-func hashContent(content string) string {
+// HashContent returns the content hash stored in document_heads.
+func HashContent(content string) string {
 	sum := sha256.Sum256([]byte(content))
 	return hex.EncodeToString(sum[:])
 }
@@ -48,6 +48,15 @@ func TestUpsertDocument(t *testing.T) {
 		t.Fatalf("expected skip on unchanged content, got %+v", res2)
 	}
 
+	input.Title = "gog (renamed)"
+	resMeta, err := UpsertDocument(ctx, db, input)
+	if err != nil {
+		t.Fatalf("upsert metadata: %v", err)
+	}
+	if !resMeta.Skipped || !resMeta.MetadataUpdated {
+		t.Fatalf("expected metadata refresh without a new version, got %+v", resMeta)
+	}
+
 	input.Content = "Second version"
 	input.Timestamp = 2000
 	res3, err := UpsertDocument(ctx, db, input)
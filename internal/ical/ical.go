@@ -0,0 +1,426 @@
+// Package ical parses iCalendar (RFC 5545) files into VEVENTs and expands
+// their recurrence rules.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar is a parsed VCALENDAR.
+type Calendar struct {
+	Name   string // X-WR-CALNAME, when present
+	Events []Event
+}
+
+// Event is a VEVENT. Times are in the zone given by TZID (UTC for "Z" times
+// and all-day dates, local time for floating times).
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Status      string // CONFIRMED, TENTATIVE, CANCELLED
+	URL         string
+	Sequence    int
+
+	Start  time.Time
+	End    time.Time
+	AllDay bool
+
+	RRule   string
+	RDates  []time.Time
+	ExDates []time.Time
+	// RecurrenceID is set on instances that override one occurrence of a
+	// recurring event with the same UID.
+	RecurrenceID time.Time
+
+	Organizer *Person
+	Attendees []Person
+
+	duration time.Duration // DURATION, applied once DTSTART is known
+}
+
+// Person is an ORGANIZER or ATTENDEE.
+type Person struct {
+	Email    string
+	Name     string // CN
+	PartStat string // NEEDS-ACTION, ACCEPTED, DECLINED, TENTATIVE, DELEGATED
+	Role     string // REQ-PARTICIPANT, OPT-PARTICIPANT, CHAIR, NON-PARTICIPANT
+}
+
+// IsRecurring reports whether the event has a rule or extra dates to expand.
+func (e Event) IsRecurring() bool {
+	return e.RRule != "" || len(e.RDates) > 0
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads every VEVENT from an iCalendar stream. Unknown components
+// (VTODO, VALARM, VTIMEZONE) are skipped; TZIDs are resolved through the
+// system zone database.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	var cur *Event
+	var stack []string
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch p.name {
+		case "BEGIN":
+			comp := strings.ToUpper(p.value)
+			stack = append(stack, comp)
+			if comp == "VEVENT" && len(stack) >= 2 && stack[len(stack)-2] == "VCALENDAR" {
+				cur = &Event{}
+			}
+			continue
+		case "END":
+			comp := strings.ToUpper(p.value)
+			if len(stack) == 0 || stack[len(stack)-1] != comp {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, comp)
+			}
+			stack = stack[:len(stack)-1]
+			if comp == "VEVENT" && cur != nil {
+				if err := finishEvent(cur); err != nil {
+					return nil, fmt.Errorf("event %q: %w", cur.UID, err)
+				}
+				cal.Events = append(cal.Events, *cur)
+				cur = nil
+			}
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		switch stack[len(stack)-1] {
+		case "VCALENDAR":
+			if p.name == "X-WR-CALNAME" {
+				cal.Name = unescapeText(p.value)
+			}
+		case "VEVENT":
+			if cur != nil {
+				if err := setEventProperty(cur, p); err != nil {
+					return nil, fmt.Errorf("line %d: %s: %w", i+1, p.name, err)
+				}
+			}
+		}
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("unterminated VEVENT %q", cur.UID)
+	}
+	return cal, nil
+}
+
+// unfold joins continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read ics: %w", err)
+	}
+	return lines, nil
+}
+
+// parseProperty splits "NAME;PARAM=a;PARAM2=\"b:c\":value".
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+	inQuote := false
+	sep := -1
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ':' && !inQuote:
+			sep = i
+		}
+		if sep >= 0 {
+			break
+		}
+	}
+	if sep < 0 {
+		return p, fmt.Errorf("missing ':' in %q", line)
+	}
+	head, value := line[:sep], line[sep+1:]
+	p.value = value
+
+	parts := splitQuoted(head, ';')
+	p.name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	for _, param := range parts[1:] {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		p.params[strings.ToUpper(strings.TrimSpace(k))] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+func splitQuoted(s string, sep rune) []string {
+	var out []string
+	var b strings.Builder
+	inQuote := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case r == sep && !inQuote:
+			out = append(out, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(out, b.String())
+}
+
+var textUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+func setEventProperty(e *Event, p property) error {
+	switch p.name {
+	case "UID":
+		e.UID = strings.TrimSpace(p.value)
+	case "SUMMARY":
+		e.Summary = unescapeText(p.value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(p.value)
+	case "LOCATION":
+		e.Location = unescapeText(p.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(strings.TrimSpace(p.value))
+	case "URL":
+		e.URL = strings.TrimSpace(p.value)
+	case "SEQUENCE":
+		e.Sequence, _ = strconv.Atoi(strings.TrimSpace(p.value))
+	case "DTSTART":
+		t, allDay, err := parseTime(p.value, p.params)
+		if err != nil {
+			return err
+		}
+		e.Start, e.AllDay = t, allDay
+	case "DTEND":
+		t, _, err := parseTime(p.value, p.params)
+		if err != nil {
+			return err
+		}
+		e.End = t
+	case "DURATION":
+		d, err := parseDuration(p.value)
+		if err != nil {
+			return err
+		}
+		e.duration = d
+	case "RRULE":
+		e.RRule = strings.TrimSpace(p.value)
+	case "RDATE":
+		if strings.EqualFold(p.params["VALUE"], "PERIOD") {
+			return nil
+		}
+		ts, err := parseTimeList(p.value, p.params)
+		if err != nil {
+			return err
+		}
+		e.RDates = append(e.RDates, ts...)
+	case "EXDATE":
+		ts, err := parseTimeList(p.value, p.params)
+		if err != nil {
+			return err
+		}
+		e.ExDates = append(e.ExDates, ts...)
+	case "RECURRENCE-ID":
+		t, _, err := parseTime(p.value, p.params)
+		if err != nil {
+			return err
+		}
+		e.RecurrenceID = t
+	case "ORGANIZER":
+		person := parsePerson(p)
+		e.Organizer = &person
+	case "ATTENDEE":
+		e.Attendees = append(e.Attendees, parsePerson(p))
+	}
+	return nil
+}
+
+func finishEvent(e *Event) error {
+	if e.Start.IsZero() {
+		return fmt.Errorf("missing DTSTART")
+	}
+	if e.End.IsZero() && e.duration != 0 {
+		e.End = e.Start.Add(e.duration)
+	}
+	if e.End.IsZero() {
+		if e.AllDay {
+			e.End = e.Start.AddDate(0, 0, 1)
+		} else {
+			e.End = e.Start
+		}
+	}
+	return nil
+}
+
+func parsePerson(p property) Person {
+	email := strings.TrimSpace(p.value)
+	if len(email) >= 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+	return Person{
+		Email:    email,
+		Name:     p.params["CN"],
+		PartStat: strings.ToUpper(p.params["PARTSTAT"]),
+		Role:     strings.ToUpper(p.params["ROLE"]),
+	}
+}
+
+func parseTimeList(value string, params map[string]string) ([]time.Time, error) {
+	var out []time.Time
+	for _, v := range strings.Split(value, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		t, _, err := parseTime(v, params)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// parseTime parses DATE and DATE-TIME values. All-day dates are midnight UTC,
+// matching how the gogcli calendar adapter stores them.
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		loc = LoadLocation(tzid)
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// windowsZones maps the Windows zone names Outlook writes as TZIDs to IANA
+// names for the most common cases.
+var windowsZones = map[string]string{
+	"Pacific Standard Time":        "America/Los_Angeles",
+	"Mountain Standard Time":       "America/Denver",
+	"Central Standard Time":        "America/Chicago",
+	"Eastern Standard Time":        "America/New_York",
+	"GMT Standard Time":            "Europe/London",
+	"W. Europe Standard Time":      "Europe/Berlin",
+	"Romance Standard Time":        "Europe/Paris",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"India Standard Time":          "Asia/Kolkata",
+	"China Standard Time":          "Asia/Shanghai",
+	"Tokyo Standard Time":          "Asia/Tokyo",
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"UTC":                          "UTC",
+}
+
+// LoadLocation resolves a TZID, falling back to local time when the zone is
+// unknown. Prefixed ids such as "/mozilla.org/20050126_1/America/New_York"
+// are reduced to their IANA suffix.
+func LoadLocation(tzid string) *time.Location {
+	tzid = strings.Trim(strings.TrimSpace(tzid), `"`)
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+	parts := strings.Split(tzid, "/")
+	for i := 1; i < len(parts); i++ {
+		if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// parseDuration parses an RFC 5545 duration such as "PT1H30M" or "-P1D".
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	s = s[1:]
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			num += string(r)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			num = ""
+			switch {
+			case r == 'W':
+				d += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D':
+				d += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+		}
+	}
+	return sign * d, nil
+}
@@ -0,0 +1,176 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-CALNAME:Work\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/New_York\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"SUMMARY:Standup\\, daily\r\n" +
+	"DESCRIPTION:Line one\\nLine two that is folded\r\n" +
+	"  across lines\r\n" +
+	"DTSTART;TZID=America/New_York:20240102T090000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;COUNT=6\r\n" +
+	"EXDATE;TZID=America/New_York:20240104T090000\r\n" +
+	"ORGANIZER;CN=\"Boss, The\":mailto:boss@example.com\r\n" +
+	"ATTENDEE;CN=Alice;PARTSTAT=ACCEPTED;ROLE=REQ-PARTICIPANT:MAILTO:alice@example.com\r\n" +
+	"ATTENDEE;PARTSTAT=DECLINED:mailto:bob@example.com\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT5M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:offsite@example.com\r\n" +
+	"SUMMARY:Offsite\r\n" +
+	"DTSTART;VALUE=DATE:20240301\r\n" +
+	"STATUS:TENTATIVE\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	cal, err := Parse(strings.NewReader(sampleICS))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cal.Name != "Work" || len(cal.Events) != 2 {
+		t.Fatalf("unexpected calendar %q with %d events", cal.Name, len(cal.Events))
+	}
+
+	ev := cal.Events[0]
+	if ev.Summary != "Standup, daily" || ev.Description != "Line one\nLine two that is folded across lines" {
+		t.Fatalf("unexpected text summary=%q description=%q", ev.Summary, ev.Description)
+	}
+	if ev.Start.Location().String() != "America/New_York" || ev.End.Sub(ev.Start) != 15*time.Minute {
+		t.Fatalf("unexpected start=%s end=%s", ev.Start, ev.End)
+	}
+	if ev.Organizer == nil || ev.Organizer.Email != "boss@example.com" || ev.Organizer.Name != "Boss, The" {
+		t.Fatalf("unexpected organizer %+v", ev.Organizer)
+	}
+	if len(ev.Attendees) != 2 || ev.Attendees[0].Email != "alice@example.com" || ev.Attendees[0].PartStat != "ACCEPTED" || ev.Attendees[1].PartStat != "DECLINED" {
+		t.Fatalf("unexpected attendees %+v", ev.Attendees)
+	}
+
+	allDay := cal.Events[1]
+	if !allDay.AllDay || allDay.Start != time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) || allDay.End != time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("unexpected all-day event %+v", allDay)
+	}
+}
+
+func TestOccurrences_WeeklyCountExdate(t *testing.T) {
+	cal, err := Parse(strings.NewReader(sampleICS))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	got, err := cal.Events[0].Occurrences(from, to)
+	if err != nil {
+		t.Fatalf("Occurrences: %v", err)
+	}
+	// COUNT=6 covers Jan 2, 4, 9, 11, 16, 18; Jan 4 is excluded.
+	want := []string{"2024-01-02", "2024-01-09", "2024-01-11", "2024-01-16", "2024-01-18"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d occurrences, got %v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Format("2006-01-02 15:04") != w+" 09:00" {
+			t.Fatalf("occurrence %d = %s, want %s 09:00", i, got[i], w)
+		}
+	}
+}
+
+func TestOccurrences_Rules(t *testing.T) {
+	nyc := LoadLocation("America/New_York")
+	cases := []struct {
+		name  string
+		start time.Time
+		rule  string
+		from  time.Time
+		to    time.Time
+		want  []string
+	}{
+		{
+			name:  "monthly last friday",
+			start: time.Date(2024, 1, 26, 10, 0, 0, 0, time.UTC),
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			to:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-26", "2024-02-23", "2024-03-29", "2024-04-26"},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			start: time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+			rule:  "FREQ=MONTHLY",
+			to:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-31", "2024-03-31", "2024-05-31"},
+		},
+		{
+			name:  "daily interval until",
+			start: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			rule:  "FREQ=DAILY;INTERVAL=2;UNTIL=20240107T080000Z",
+			to:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-07"},
+		},
+		{
+			name:  "yearly thanksgiving",
+			start: time.Date(2023, 11, 23, 12, 0, 0, 0, time.UTC),
+			rule:  "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+			to:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2023-11-23", "2024-11-28", "2025-11-27"},
+		},
+		{
+			name:  "last weekday of month",
+			start: time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC),
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			to:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-01-31", "2024-02-29", "2024-03-29"},
+		},
+		{
+			name:  "weekly keeps wall clock across DST",
+			start: time.Date(2024, 3, 4, 9, 0, 0, 0, nyc),
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			to:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"2024-03-04", "2024-03-11", "2024-03-18"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ev := Event{Start: tc.start, RRule: tc.rule}
+			got, err := ev.Occurrences(tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Occurrences: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i, w := range tc.want {
+				if got[i].Format("2006-01-02") != w || got[i].Hour() != tc.start.Hour() {
+					t.Fatalf("occurrence %d = %s, want %s at %02d:00", i, got[i], w, tc.start.Hour())
+				}
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT5M":   -5 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+	} {
+		got, err := parseDuration(in)
+		if err != nil || got != want {
+			t.Fatalf("parseDuration(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrenceIterations bounds expansion of rules that never produce a
+// match (e.g. BYMONTHDAY=31 with FREQ=MONTHLY;BYMONTH=2).
+const maxRecurrenceIterations = 100000
+
+type weekdayNum struct {
+	n   int // 0 = every; 1/-1 = first/last within the period
+	day time.Weekday
+}
+
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []int
+	bySetPos   []int
+	wkst       time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRule(s string, loc *time.Location) (recurrenceRule, error) {
+	r := recurrenceRule{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.ToUpper(strings.TrimSpace(k)), strings.ToUpper(strings.TrimSpace(v))
+		switch k {
+		case "FREQ":
+			r.freq = v
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid INTERVAL %q", v)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return r, fmt.Errorf("invalid COUNT %q", v)
+			}
+			r.count = n
+		case "UNTIL":
+			t, _, err := parseTime(v, map[string]string{})
+			if err != nil {
+				return r, fmt.Errorf("invalid UNTIL %q", v)
+			}
+			if !strings.HasSuffix(v, "Z") && len(v) > 8 {
+				// Floating UNTIL is in DTSTART's zone.
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+			}
+			if len(v) == 8 {
+				// Date-only UNTIL includes the whole day.
+				t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+			}
+			r.until = t
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				d = strings.TrimSpace(d)
+				if len(d) < 2 {
+					return r, fmt.Errorf("invalid BYDAY %q", d)
+				}
+				wd, ok := weekdays[d[len(d)-2:]]
+				if !ok {
+					return r, fmt.Errorf("invalid BYDAY %q", d)
+				}
+				n := 0
+				if prefix := d[:len(d)-2]; prefix != "" {
+					var err error
+					if n, err = strconv.Atoi(prefix); err != nil {
+						return r, fmt.Errorf("invalid BYDAY %q", d)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, day: wd})
+			}
+		case "BYMONTHDAY":
+			ns, err := parseIntList(v)
+			if err != nil {
+				return r, fmt.Errorf("invalid BYMONTHDAY %q", v)
+			}
+			r.byMonthDay = ns
+		case "BYMONTH":
+			ns, err := parseIntList(v)
+			if err != nil {
+				return r, fmt.Errorf("invalid BYMONTH %q", v)
+			}
+			r.byMonth = ns
+		case "BYSETPOS":
+			ns, err := parseIntList(v)
+			if err != nil {
+				return r, fmt.Errorf("invalid BYSETPOS %q", v)
+			}
+			r.bySetPos = ns
+		case "WKST":
+			if wd, ok := weekdays[v]; ok {
+				r.wkst = wd
+			}
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	case "":
+		return r, fmt.Errorf("RRULE without FREQ")
+	default:
+		return r, fmt.Errorf("unsupported FREQ %q", r.freq)
+	}
+	return r, nil
+}
+
+func parseIntList(v string) ([]int, error) {
+	var out []int
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// Occurrences returns the start times of the event's occurrences that begin
+// in [from, to). DTSTART always counts as the first occurrence; RDATEs are
+// added and EXDATEs removed. A non-recurring event yields its own start when
+// it falls in the window.
+func (e Event) Occurrences(from, to time.Time) ([]time.Time, error) {
+	starts := []time.Time{e.Start}
+	if e.RRule != "" {
+		rule, err := parseRule(e.RRule, e.Start.Location())
+		if err != nil {
+			return nil, err
+		}
+		starts = rule.expand(e.Start, to)
+	}
+	starts = append(starts, e.RDates...)
+
+	excluded := map[int64]bool{}
+	for _, t := range e.ExDates {
+		excluded[t.Unix()] = true
+		if e.AllDay {
+			excluded[time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()] = true
+		}
+	}
+
+	seen := map[int64]bool{}
+	var out []time.Time
+	for _, t := range starts {
+		u := t.Unix()
+		if excluded[u] || seen[u] || t.Before(from) || !t.Before(to) {
+			continue
+		}
+		seen[u] = true
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out, nil
+}
+
+// expand generates occurrence starts from dtstart until the rule ends or the
+// next period starts at or after limit.
+func (r recurrenceRule) expand(dtstart, limit time.Time) []time.Time {
+	out := []time.Time{dtstart}
+	emitted := 1
+	loc := dtstart.Location()
+	h, m, s := dtstart.Clock()
+
+	for i := 0; i < maxRecurrenceIterations; i++ {
+		periodStart, days := r.period(dtstart, i)
+		if !periodStart.Before(limit) {
+			break
+		}
+		var candidates []time.Time
+		for _, d := range days {
+			candidates = append(candidates, time.Date(d.Year(), d.Month(), d.Day(), h, m, s, 0, loc))
+		}
+		candidates = applySetPos(candidates, r.bySetPos)
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && t.After(r.until) {
+				return out
+			}
+			if r.count > 0 && emitted >= r.count {
+				return out
+			}
+			if !t.Before(limit) {
+				return out
+			}
+			out = append(out, t)
+			emitted++
+		}
+	}
+	return out
+}
+
+// period returns the start of the i-th period and the sorted candidate days
+// in it (as midnight UTC dates) after applying the BY* filters.
+func (r recurrenceRule) period(dtstart time.Time, i int) (time.Time, []time.Time) {
+	base := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.UTC)
+	var days []time.Time
+	var start time.Time
+
+	switch r.freq {
+	case "DAILY":
+		start = base.AddDate(0, 0, i*r.interval)
+		if r.matchMonth(start) && r.matchMonthDay(start) && r.matchWeekday(start) {
+			days = []time.Time{start}
+		}
+	case "WEEKLY":
+		offset := (int(base.Weekday()) - int(r.wkst) + 7) % 7
+		weekStart := base.AddDate(0, 0, -offset+7*i*r.interval)
+		start = weekStart
+		byDay := r.byDay
+		if len(byDay) == 0 {
+			byDay = []weekdayNum{{day: dtstart.Weekday()}}
+		}
+		for d := 0; d < 7; d++ {
+			day := weekStart.AddDate(0, 0, d)
+			for _, wd := range byDay {
+				if day.Weekday() == wd.day && r.matchMonth(day) {
+					days = append(days, day)
+					break
+				}
+			}
+		}
+	case "MONTHLY":
+		start = time.Date(base.Year(), base.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, i*r.interval, 0)
+		if r.matchMonth(start) {
+			days = r.daysInMonth(start, dtstart.Day())
+		}
+	case "YEARLY":
+		start = time.Date(base.Year()+i*r.interval, 1, 1, 0, 0, 0, 0, time.UTC)
+		months := r.byMonth
+		switch {
+		case len(months) > 0:
+		case len(r.byDay) > 0 && len(r.byMonthDay) == 0:
+			// BYDAY without BYMONTH selects weekdays across the whole year.
+			days = yearWeekdays(start.Year(), r.byDay)
+		default:
+			months = []int{int(dtstart.Month())}
+		}
+		for _, mo := range months {
+			if mo < 1 || mo > 12 {
+				continue
+			}
+			days = append(days, r.daysInMonth(time.Date(start.Year(), time.Month(mo), 1, 0, 0, 0, 0, time.UTC), dtstart.Day())...)
+		}
+		sort.Slice(days, func(a, b int) bool { return days[a].Before(days[b]) })
+	}
+	return start, days
+}
+
+// daysInMonth expands BYMONTHDAY / BYDAY within one month, defaulting to
+// DTSTART's day of month (skipped in months that are too short).
+func (r recurrenceRule) daysInMonth(month time.Time, defaultDay int) []time.Time {
+	last := month.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = last + 1 + d
+			}
+			if d < 1 || d > last {
+				continue
+			}
+			day := month.AddDate(0, 0, d-1)
+			if r.matchWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case len(r.byDay) > 0:
+		for _, wd := range r.byDay {
+			var matches []time.Time
+			for d := 0; d < last; d++ {
+				day := month.AddDate(0, 0, d)
+				if day.Weekday() == wd.day {
+					matches = append(matches, day)
+				}
+			}
+			days = append(days, pickNth(matches, wd.n)...)
+		}
+	default:
+		if defaultDay <= last {
+			days = []time.Time{month.AddDate(0, 0, defaultDay-1)}
+		}
+	}
+	sort.Slice(days, func(a, b int) bool { return days[a].Before(days[b]) })
+	return days
+}
+
+func yearWeekdays(year int, byDay []weekdayNum) []time.Time {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	var days []time.Time
+	for _, wd := range byDay {
+		var matches []time.Time
+		for d := jan1; d.Year() == year; d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == wd.day {
+				matches = append(matches, d)
+			}
+		}
+		days = append(days, pickNth(matches, wd.n)...)
+	}
+	return days
+}
+
+func pickNth(matches []time.Time, n int) []time.Time {
+	switch {
+	case n == 0:
+		return matches
+	case n > 0 && n <= len(matches):
+		return []time.Time{matches[n-1]}
+	case n < 0 && -n <= len(matches):
+		return []time.Time{matches[len(matches)+n]}
+	}
+	return nil
+}
+
+func applySetPos(candidates []time.Time, setPos []int) []time.Time {
+	if len(setPos) == 0 {
+		return candidates
+	}
+	var out []time.Time
+	for _, p := range setPos {
+		out = append(out, pickNth(candidates, p)...)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Before(out[b]) })
+	return out
+}
+
+func (r recurrenceRule) matchMonth(t time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, m := range r.byMonth {
+		if int(t.Month()) == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r recurrenceRule) matchMonthDay(t time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	last := t.AddDate(0, 1, -t.Day()).Day()
+	for _, d := range r.byMonthDay {
+		if d == t.Day() || (d < 0 && last+1+d == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday applies BYDAY as a plain filter (ordinals are ignored), as
+// used by DAILY rules and BYMONTHDAY+BYDAY combinations.
+func (r recurrenceRule) matchWeekday(t time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if t.Weekday() == wd.day {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Napageneral/mnemonic/internal/bus"
 	"github.com/Napageneral/mnemonic/internal/contacts"
 )
 
@@ -219,6 +220,14 @@ func Merge(db *sql.DB, person1ID, person2ID string) error {
 		return fmt.Errorf("failed to update person1 timestamp: %w", err)
 	}
 
+	if err := bus.Emit(tx, bus.TypePersonMerged, "", "", bus.PersonMergedPayload{
+		PersonID:       person1ID,
+		MergedPersonID: person2ID,
+		Method:         "manual",
+	}); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
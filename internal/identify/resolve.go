@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Napageneral/mnemonic/internal/bus"
 	"github.com/Napageneral/mnemonic/internal/contacts"
 	"github.com/google/uuid"
 )
@@ -497,6 +498,15 @@ func ExecuteMerge(db *sql.DB, mergeEventID, sourcePersonID, targetPersonID strin
 		return fmt.Errorf("update merge event: %w", err)
 	}
 
+	if err := bus.Emit(tx, bus.TypePersonMerged, "", "", bus.PersonMergedPayload{
+		PersonID:       targetPersonID,
+		MergedPersonID: sourcePersonID,
+		MergeEventID:   mergeEventID,
+		Method:         "suggestion",
+	}); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -0,0 +1,757 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+)
+
+type AIChatImportOptions struct {
+	AdapterName string // defaults to "chatgpt" or "claude"
+	Path        string // conversations.json, an unpacked export directory, or the export .zip
+	DryRun      bool
+}
+
+type AIChatImportResult struct {
+	Conversations   int
+	SessionsCreated int
+	Messages        int
+	Turns           int
+	EventsCreated   int
+	EventsUpdated   int
+	ThreadsCreated  int
+	Duration        time.Duration
+}
+
+// aiChatConversation is a web chat export normalized to a message tree.
+type aiChatConversation struct {
+	ID       string
+	Title    string
+	Model    string
+	Created  int64 // unix ms
+	Messages []aiChatMessage
+}
+
+// aiChatMessage is one node of a conversation tree. Messages are ordered so
+// that every parent precedes its children.
+type aiChatMessage struct {
+	ID        string
+	ParentID  string
+	Role      string // user, assistant, tool
+	Content   string
+	Model     string
+	Timestamp int64 // unix ms
+
+	// Visible is set on assistant text shown to the user, as opposed to
+	// tool invocations; ToolCalls counts invocations carried by the message.
+	Visible   bool
+	ToolCalls int
+	Metadata  json.RawMessage
+}
+
+// aiChatTurn is a query/response exchange derived from the message tree.
+type aiChatTurn struct {
+	ID          string // response message id
+	ParentID    string
+	QueryIDs    []string
+	Query       string
+	Response    string
+	Model       string
+	Timestamp   int64 // unix ms of the response
+	QueryTS     int64 // unix ms of the last query message
+	ToolCalls   int
+	HasChildren bool
+}
+
+// ImportChatGPT imports an OpenAI data export (conversations.json) into the
+// agents ledger. Regenerated responses and edited prompts are branches of
+// the conversation tree and become sibling turns sharing a parent turn.
+func ImportChatGPT(ctx context.Context, db *sql.DB, opts AIChatImportOptions) (AIChatImportResult, error) {
+	return importAIChat(ctx, db, opts, "chatgpt", decodeChatGPTConversation)
+}
+
+// ImportClaude imports an Anthropic (claude.ai) data export into the agents
+// ledger. Exports that predate message branching are imported as a single
+// linear branch.
+func ImportClaude(ctx context.Context, db *sql.DB, opts AIChatImportOptions) (AIChatImportResult, error) {
+	return importAIChat(ctx, db, opts, "claude", decodeClaudeConversation)
+}
+
+func importAIChat(ctx context.Context, db *sql.DB, opts AIChatImportOptions, source string, decode func(json.RawMessage) (aiChatConversation, error)) (AIChatImportResult, error) {
+	start := time.Now()
+	var out AIChatImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	if strings.TrimSpace(opts.AdapterName) == "" {
+		opts.AdapterName = source
+	}
+	rc, err := openAIChatExport(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	defer rc.Close()
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+	var mePersonID string
+	if p, err := me.GetMePerson(db); err == nil && p != nil {
+		mePersonID = p.ID
+	}
+
+	// conversations.json is a single array that can run to hundreds of
+	// megabytes, so conversations are decoded one at a time.
+	dec := json.NewDecoder(rc)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return out, fmt.Errorf("conversations.json: expected a JSON array")
+	}
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return out, fmt.Errorf("conversations.json: %w", err)
+		}
+		conv, err := decode(raw)
+		if err != nil {
+			return out, err
+		}
+		if conv.ID == "" || len(conv.Messages) == 0 {
+			continue
+		}
+		if err := importAIChatConversation(db, opts, source, mePersonID, conv, &out); err != nil {
+			return out, fmt.Errorf("conversation %s: %w", conv.ID, err)
+		}
+		out.Conversations++
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// openAIChatExport returns a reader for conversations.json given the file
+// itself, an export directory or the export zip.
+func openAIChatExport(p string) (io.ReadCloser, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(p), ".json") {
+		return os.Open(p)
+	}
+	archive, err := openArchive(p)
+	if err != nil {
+		return nil, err
+	}
+	root, err := findArchiveRoot(archive, "conversations.json")
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	f, err := archive.Open(path.Join(root, "conversations.json"))
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return &archiveFile{ReadCloser: f, archive: archive}, nil
+}
+
+type archiveFile struct {
+	io.ReadCloser
+	archive *exportArchive
+}
+
+func (f *archiveFile) Close() error {
+	err := f.ReadCloser.Close()
+	if cerr := f.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func importAIChatConversation(db *sql.DB, opts AIChatImportOptions, source, mePersonID string, conv aiChatConversation, out *AIChatImportResult) error {
+	turns := buildAIChatTurns(conv)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT 1 FROM agent_sessions WHERE id = ?`, conv.ID).Scan(&exists); err == sql.ErrNoRows {
+		out.SessionsCreated++
+	} else if err != nil {
+		return fmt.Errorf("lookup session: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO agent_sessions (id, source, model, created_at, message_count, summary)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			model = excluded.model,
+			message_count = excluded.message_count,
+			summary = excluded.summary
+	`, conv.ID, source, nullIfBlank(conv.Model), conv.Created, len(conv.Messages), nullIfBlank(conv.Title)); err != nil {
+		return fmt.Errorf("upsert session: %w", err)
+	}
+
+	msgStmt, err := tx.Prepare(`
+		INSERT INTO agent_messages (id, session_id, role, content, sequence, timestamp, metadata_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			content = excluded.content,
+			metadata_json = excluded.metadata_json
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare message: %w", err)
+	}
+	defer msgStmt.Close()
+	for i, m := range conv.Messages {
+		var metadata any
+		if len(m.Metadata) > 0 && string(m.Metadata) != "null" {
+			metadata = string(m.Metadata)
+		}
+		if _, err := msgStmt.Exec(m.ID, conv.ID, m.Role, m.Content, i, m.Timestamp, metadata); err != nil {
+			return fmt.Errorf("upsert message: %w", err)
+		}
+		out.Messages++
+	}
+
+	turnStmt, err := tx.Prepare(`
+		INSERT INTO agent_turns (
+			id, session_id, parent_turn_id,
+			query_message_ids, response_message_id,
+			model, timestamp, has_children, tool_call_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			has_children = excluded.has_children,
+			tool_call_count = excluded.tool_call_count
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare turn: %w", err)
+	}
+	defer turnStmt.Close()
+	for _, t := range turns {
+		queryIDs, _ := json.Marshal(t.QueryIDs)
+		if _, err := turnStmt.Exec(t.ID, conv.ID, nullIfBlank(t.ParentID), string(queryIDs), t.ID,
+			nullIfBlank(t.Model), t.Timestamp, boolToInt(t.HasChildren), t.ToolCalls); err != nil {
+			return fmt.Errorf("upsert turn: %w", err)
+		}
+		out.Turns++
+	}
+
+	if err := writeAIChatEvents(tx, opts.AdapterName, source, mePersonID, conv, turns, out); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+// writeAIChatEvents mirrors the trimmed turns the AIX events adapter writes:
+// one thread per session, a "sent" event with the prompt and a "received"
+// event with the visible reply. User events are keyed by the prompt message
+// rather than the turn so regenerated replies do not repeat the prompt.
+func writeAIChatEvents(tx *sql.Tx, adapterName, source, mePersonID string, conv aiChatConversation, turns []aiChatTurn, out *AIChatImportResult) error {
+	if len(turns) == 0 {
+		return nil
+	}
+	w, err := ingest.NewWriter(tx, adapterName)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	threadName := firstNonEmpty(conv.Title, conv.Model, "AI Session")
+	threadID, err := w.UpsertThread(ingest.Thread{
+		ID:       "aix_turn_session:" + conv.ID,
+		SourceID: conv.ID,
+		Channel:  source,
+		Name:     threadName,
+	})
+	if err != nil {
+		return err
+	}
+
+	meContactID, _, err := contacts.GetOrCreateContact(tx, "human", fmt.Sprintf("ai-chat:%s:user", source), "", adapterName)
+	if err != nil {
+		return fmt.Errorf("upsert user contact: %w", err)
+	}
+	if mePersonID != "" {
+		_ = contacts.EnsurePersonContactLink(tx, mePersonID, meContactID, "deterministic", 1.0)
+	}
+	aiContacts := map[string]string{}
+
+	for _, t := range turns {
+		modelKey := firstNonEmpty(t.Model, "unknown")
+		aiContactID := aiContacts[modelKey]
+		if aiContactID == "" {
+			displayName := aiChatLabel(source)
+			if modelKey != "unknown" {
+				displayName = fmt.Sprintf("%s (%s)", displayName, modelKey)
+			}
+			aiContactID, _, err = contacts.GetOrCreateContact(tx, "ai", fmt.Sprintf("ai-chat:%s:model:%s", source, modelKey), displayName, adapterName)
+			if err != nil {
+				return fmt.Errorf("upsert ai contact: %w", err)
+			}
+			aiContacts[modelKey] = aiContactID
+		}
+
+		if strings.TrimSpace(t.Query) != "" {
+			id, _, err := w.UpsertEvent(ingest.Event{
+				SourceID:  t.QueryIDs[len(t.QueryIDs)-1] + ":user",
+				Timestamp: t.QueryTS / 1000,
+				Channel:   source,
+				Content:   t.Query,
+				Direction: "sent",
+				ThreadID:  threadID,
+			})
+			if err != nil {
+				return err
+			}
+			if err := w.AddParticipant(id, meContactID, "sender"); err != nil {
+				return err
+			}
+			if err := w.AddParticipant(id, aiContactID, "recipient"); err != nil {
+				return err
+			}
+		}
+
+		if strings.TrimSpace(t.Response) != "" {
+			id, _, err := w.UpsertEvent(ingest.Event{
+				SourceID:  t.ID + ":assistant",
+				Timestamp: t.Timestamp / 1000,
+				Channel:   source,
+				Content:   t.Response,
+				Direction: "received",
+				ThreadID:  threadID,
+				Metadata:  map[string]any{"model": modelKey, "turn_id": t.ID},
+			})
+			if err != nil {
+				return err
+			}
+			if err := w.AddParticipant(id, aiContactID, "sender"); err != nil {
+				return err
+			}
+			if err := w.AddParticipant(id, meContactID, "recipient"); err != nil {
+				return err
+			}
+		}
+	}
+
+	out.EventsCreated += w.Stats.EventsCreated
+	out.EventsUpdated += w.Stats.EventsUpdated
+	out.ThreadsCreated += w.Stats.ThreadsCreated
+	return nil
+}
+
+func aiChatLabel(source string) string {
+	switch source {
+	case "chatgpt":
+		return "ChatGPT"
+	case "claude":
+		return "Claude"
+	}
+	return source
+}
+
+// buildAIChatTurns splits a message tree into turns. A turn ends at visible
+// assistant text that is not followed by more assistant or tool output; it
+// covers everything back to the end of the previous turn on the same
+// branch, which becomes its parent.
+func buildAIChatTurns(conv aiChatConversation) []aiChatTurn {
+	byID := make(map[string]int, len(conv.Messages))
+	children := map[string][]int{}
+	for i, m := range conv.Messages {
+		byID[m.ID] = i
+		children[m.ParentID] = append(children[m.ParentID], i)
+	}
+	isEnd := func(i int) bool {
+		m := conv.Messages[i]
+		if m.Role != "assistant" || !m.Visible {
+			return false
+		}
+		for _, c := range children[m.ID] {
+			if conv.Messages[c].Role != "user" {
+				return false
+			}
+		}
+		return true
+	}
+
+	var turns []aiChatTurn
+	index := map[string]int{}
+	for i, m := range conv.Messages {
+		if !isEnd(i) {
+			continue
+		}
+		t := aiChatTurn{ID: m.ID, Model: firstNonEmpty(m.Model, conv.Model), Timestamp: m.Timestamp}
+		var responses, queries []string
+		for cur := i; ; {
+			msg := conv.Messages[cur]
+			switch {
+			case msg.Role == "user":
+				t.QueryIDs = append([]string{msg.ID}, t.QueryIDs...)
+				queries = append([]string{msg.Content}, queries...)
+				if t.QueryTS == 0 {
+					t.QueryTS = msg.Timestamp
+				}
+			case msg.Role == "assistant" && msg.Visible:
+				responses = append([]string{msg.Content}, responses...)
+			}
+			t.ToolCalls += msg.ToolCalls
+			p, ok := byID[msg.ParentID]
+			if !ok {
+				break
+			}
+			if isEnd(p) {
+				t.ParentID = conv.Messages[p].ID
+				break
+			}
+			cur = p
+		}
+		t.Query = strings.TrimSpace(strings.Join(queries, "\n\n"))
+		t.Response = strings.TrimSpace(strings.Join(responses, "\n\n"))
+		if len(t.QueryIDs) == 0 {
+			t.QueryIDs = []string{}
+		}
+		index[t.ID] = len(turns)
+		turns = append(turns, t)
+	}
+	for _, t := range turns {
+		if j, ok := index[t.ParentID]; ok {
+			turns[j].HasChildren = true
+		}
+	}
+	return turns
+}
+
+// --- ChatGPT ---
+
+type chatGPTConversation struct {
+	ID               string                 `json:"id"`
+	ConversationID   string                 `json:"conversation_id"`
+	Title            string                 `json:"title"`
+	CreateTime       float64                `json:"create_time"`
+	DefaultModelSlug string                 `json:"default_model_slug"`
+	Mapping          map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Message  *chatGPTMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatGPTMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+		Name string `json:"name"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+		Result      string            `json:"result"`
+	} `json:"content"`
+	Recipient string          `json:"recipient"`
+	Metadata  json.RawMessage `json:"metadata"`
+}
+
+func decodeChatGPTConversation(raw json.RawMessage) (aiChatConversation, error) {
+	var c chatGPTConversation
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return aiChatConversation{}, fmt.Errorf("decode conversation: %w", err)
+	}
+	conv := aiChatConversation{
+		ID:      firstNonEmpty(c.ConversationID, c.ID),
+		Title:   strings.TrimSpace(c.Title),
+		Model:   c.DefaultModelSlug,
+		Created: unixMillis(c.CreateTime),
+	}
+
+	var roots []string
+	for id, n := range c.Mapping {
+		if _, ok := c.Mapping[n.Parent]; n.Parent == "" || !ok {
+			roots = append(roots, id)
+		}
+	}
+	sort.Strings(roots)
+
+	// Walk the tree depth first, dropping system prompts, hidden context and
+	// empty nodes and attaching their children to the nearest kept ancestor.
+	var walk func(id, parent string, parentTS int64)
+	walk = func(id, parent string, parentTS int64) {
+		n, ok := c.Mapping[id]
+		if !ok {
+			return
+		}
+		childParent, childTS := parent, parentTS
+		if m, keep := chatGPTToMessage(n.Message); keep {
+			m.ID = firstNonEmpty(m.ID, id)
+			m.ParentID = parent
+			if m.Timestamp == 0 {
+				m.Timestamp = firstNonZero(parentTS, conv.Created)
+			}
+			if m.Role == "assistant" && m.Model == "" {
+				m.Model = conv.Model
+			}
+			if m.Role == "assistant" && m.Model != "" && conv.Model == "" {
+				conv.Model = m.Model
+			}
+			conv.Messages = append(conv.Messages, m)
+			childParent, childTS = m.ID, m.Timestamp
+		}
+		for _, child := range n.Children {
+			walk(child, childParent, childTS)
+		}
+	}
+	for _, r := range roots {
+		walk(r, "", conv.Created)
+	}
+	return conv, nil
+}
+
+func chatGPTToMessage(msg *chatGPTMessage) (aiChatMessage, bool) {
+	if msg == nil {
+		return aiChatMessage{}, false
+	}
+	var meta struct {
+		ModelSlug string `json:"model_slug"`
+		Hidden    bool   `json:"is_visually_hidden_from_conversation"`
+	}
+	_ = json.Unmarshal(msg.Metadata, &meta)
+	if meta.Hidden {
+		return aiChatMessage{}, false
+	}
+
+	role := msg.Author.Role
+	if role != "user" && role != "assistant" && role != "tool" {
+		return aiChatMessage{}, false
+	}
+	content := chatGPTContent(msg)
+	if strings.TrimSpace(content) == "" {
+		return aiChatMessage{}, false
+	}
+	m := aiChatMessage{
+		ID:        msg.ID,
+		Role:      role,
+		Content:   content,
+		Model:     meta.ModelSlug,
+		Timestamp: unixMillis(msg.CreateTime),
+		Metadata:  msg.Metadata,
+	}
+	if role == "assistant" {
+		toUser := msg.Recipient == "" || msg.Recipient == "all"
+		switch msg.Content.ContentType {
+		case "text", "multimodal_text":
+			m.Visible = toUser
+		}
+		if !toUser {
+			m.ToolCalls = 1
+		}
+	}
+	return m, true
+}
+
+func chatGPTContent(msg *chatGPTMessage) string {
+	switch msg.Content.ContentType {
+	case "text", "multimodal_text":
+		var parts []string
+		for _, raw := range msg.Content.Parts {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				if strings.TrimSpace(s) != "" {
+					parts = append(parts, s)
+				}
+				continue
+			}
+			var obj struct {
+				ContentType string `json:"content_type"`
+				Text        string `json:"text"`
+			}
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				continue
+			}
+			switch {
+			case obj.Text != "":
+				parts = append(parts, obj.Text)
+			case strings.Contains(obj.ContentType, "image"):
+				parts = append(parts, "[image]")
+			}
+		}
+		return strings.Join(parts, "\n")
+	case "code", "execution_output":
+		return msg.Content.Text
+	case "tether_browsing_display", "tether_quote":
+		return firstNonEmpty(msg.Content.Result, msg.Content.Text)
+	}
+	// Reasoning ("thoughts", "reasoning_recap") and user/model editable
+	// context are not part of the visible conversation.
+	return ""
+}
+
+// --- Claude ---
+
+type claudeConversation struct {
+	UUID         string          `json:"uuid"`
+	Name         string          `json:"name"`
+	Model        string          `json:"model"`
+	CreatedAt    string          `json:"created_at"`
+	ChatMessages []claudeMessage `json:"chat_messages"`
+}
+
+type claudeMessage struct {
+	UUID              string  `json:"uuid"`
+	Text              string  `json:"text"`
+	Sender            string  `json:"sender"`
+	CreatedAt         string  `json:"created_at"`
+	ParentMessageUUID *string `json:"parent_message_uuid"`
+	Content           []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+		Name string `json:"name"`
+	} `json:"content"`
+	Attachments []struct {
+		FileName string `json:"file_name"`
+	} `json:"attachments"`
+	Files []struct {
+		FileName string `json:"file_name"`
+	} `json:"files"`
+}
+
+func decodeClaudeConversation(raw json.RawMessage) (aiChatConversation, error) {
+	var c claudeConversation
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return aiChatConversation{}, fmt.Errorf("decode conversation: %w", err)
+	}
+	conv := aiChatConversation{
+		ID:      c.UUID,
+		Title:   strings.TrimSpace(c.Name),
+		Model:   c.Model,
+		Created: parseClaudeTime(c.CreatedAt),
+	}
+
+	// kept maps every message id to the nearest message that was imported,
+	// so children of skipped messages stay on their branch.
+	kept := make(map[string]string, len(c.ChatMessages))
+	prev := ""
+	for _, cm := range c.ChatMessages {
+		if cm.UUID == "" {
+			continue
+		}
+		// Older exports carry no parent pointers and are a single branch;
+		// newer ones point the first message at a sentinel root.
+		parent := prev
+		if cm.ParentMessageUUID != nil {
+			parent = kept[*cm.ParentMessageUUID]
+		}
+		kept[cm.UUID] = parent
+
+		m := aiChatMessage{
+			ID:        cm.UUID,
+			ParentID:  parent,
+			Timestamp: firstNonZero(parseClaudeTime(cm.CreatedAt), conv.Created),
+		}
+		var texts []string
+		for _, block := range cm.Content {
+			switch block.Type {
+			case "text":
+				if strings.TrimSpace(block.Text) != "" {
+					texts = append(texts, block.Text)
+				}
+			case "tool_use":
+				m.ToolCalls++
+			}
+		}
+		if len(texts) == 0 && strings.TrimSpace(cm.Text) != "" {
+			texts = append(texts, cm.Text)
+		}
+		m.Content = strings.Join(texts, "\n\n")
+
+		switch cm.Sender {
+		case "human":
+			m.Role = "user"
+		case "assistant":
+			m.Role = "assistant"
+			m.Model = c.Model
+			m.Visible = m.Content != ""
+		default:
+			continue
+		}
+		var files []string
+		for _, a := range cm.Attachments {
+			files = append(files, a.FileName)
+		}
+		for _, f := range cm.Files {
+			files = append(files, f.FileName)
+		}
+		if len(files) > 0 {
+			m.Metadata, _ = json.Marshal(map[string]any{"files": files})
+		}
+		if m.Content == "" && len(files) == 0 {
+			continue
+		}
+
+		conv.Messages = append(conv.Messages, m)
+		kept[m.ID] = m.ID
+		prev = m.ID
+	}
+	return conv, nil
+}
+
+func parseClaudeTime(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// unixMillis converts the fractional unix seconds ChatGPT exports use.
+func unixMillis(sec float64) int64 {
+	return int64(math.Round(sec * 1000))
+}
+
+func firstNonZero(vals ...int64) int64 {
+	for _, v := range vals {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func nullIfBlank(s string) any {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const chatGPTExport = `[{
+  "title": "Arithmetic",
+  "create_time": 1700000000.5,
+  "conversation_id": "conv-1",
+  "default_model_slug": "gpt-4o",
+  "current_node": "a3",
+  "mapping": {
+    "root": {"id": "root", "message": null, "parent": null, "children": ["sys"]},
+    "sys": {"id": "sys", "parent": "root", "children": ["u1"], "message": {"id": "sys", "author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "u1": {"id": "u1", "parent": "sys", "children": ["a1"], "message": {"id": "u1", "author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "text", "parts": ["Hi"]}, "metadata": {}}},
+    "a1": {"id": "a1", "parent": "u1", "children": ["u2"], "message": {"id": "a1", "author": {"role": "assistant"}, "create_time": 1700000002, "recipient": "all", "content": {"content_type": "text", "parts": ["Hello!"]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "u2": {"id": "u2", "parent": "a1", "children": ["call", "a3"], "message": {"id": "u2", "author": {"role": "user"}, "create_time": 1700000010, "content": {"content_type": "multimodal_text", "parts": [{"content_type": "image_asset_pointer", "asset_pointer": "file-service://x"}, "What is 2+2?"]}, "metadata": {}}},
+    "call": {"id": "call", "parent": "u2", "children": ["out"], "message": {"id": "call", "author": {"role": "assistant"}, "create_time": 1700000011, "recipient": "python", "content": {"content_type": "code", "text": "2+2"}, "metadata": {"model_slug": "gpt-4o"}}},
+    "out": {"id": "out", "parent": "call", "children": ["a2"], "message": {"id": "out", "author": {"role": "tool", "name": "python"}, "create_time": 1700000012, "content": {"content_type": "execution_output", "text": "4"}, "metadata": {}}},
+    "a2": {"id": "a2", "parent": "out", "children": [], "message": {"id": "a2", "author": {"role": "assistant"}, "create_time": 1700000013, "recipient": "all", "content": {"content_type": "text", "parts": ["It's 4."]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "a3": {"id": "a3", "parent": "u2", "children": [], "message": {"id": "a3", "author": {"role": "assistant"}, "create_time": 1700000020, "recipient": "all", "content": {"content_type": "text", "parts": ["Four."]}, "metadata": {"model_slug": "o1"}}}
+  }
+}]`
+
+const claudeExport = `[{
+  "uuid": "claude-1",
+  "name": "Naming help",
+  "created_at": "2024-03-01T10:00:00.000000Z",
+  "chat_messages": [
+    {"uuid": "h1", "sender": "human", "text": "Name my cat", "created_at": "2024-03-01T10:00:01.000000Z", "content": [{"type": "text", "text": "Name my cat"}], "attachments": [], "files": [{"file_name": "cat.jpg"}]},
+    {"uuid": "r1", "sender": "assistant", "text": "", "created_at": "2024-03-01T10:00:05.000000Z", "content": [
+      {"type": "thinking", "thinking": "hmm"},
+      {"type": "text", "text": "Let me search."},
+      {"type": "tool_use", "name": "web_search"},
+      {"type": "tool_result", "name": "web_search"},
+      {"type": "text", "text": "How about Miso?"}
+    ]}
+  ]
+}]`
+
+func TestImportChatGPT(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	path := filepath.Join(tmpDir, "conversations.json")
+	if err := os.WriteFile(path, []byte(chatGPTExport), 0o644); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+	res, err := ImportChatGPT(context.Background(), d, AIChatImportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("ImportChatGPT: %v", err)
+	}
+	if res.Conversations != 1 || res.SessionsCreated != 1 || res.Messages != 7 || res.Turns != 3 || res.EventsCreated != 5 || res.ThreadsCreated != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	type turn struct {
+		parent, query string
+		hasChildren   int
+		tools         int
+	}
+	turns := map[string]turn{}
+	rows, err := d.Query(`SELECT id, COALESCE(parent_turn_id, ''), query_message_ids, has_children, tool_call_count FROM agent_turns WHERE session_id = 'conv-1'`)
+	if err != nil {
+		t.Fatalf("query turns: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		var tr turn
+		if err := rows.Scan(&id, &tr.parent, &tr.query, &tr.hasChildren, &tr.tools); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		turns[id] = tr
+	}
+	rows.Close()
+	if turns["a1"] != (turn{"", `["u1"]`, 1, 0}) || turns["a2"] != (turn{"a1", `["u2"]`, 0, 1}) || turns["a3"] != (turn{"a1", `["u2"]`, 0, 0}) {
+		t.Fatalf("unexpected turns %+v", turns)
+	}
+
+	var content, direction, threadID string
+	if err := d.QueryRow(`SELECT content, direction, thread_id FROM events WHERE id = 'chatgpt:u2:user'`).Scan(&content, &direction, &threadID); err != nil {
+		t.Fatalf("user event: %v", err)
+	}
+	if content != "[image]\nWhat is 2+2?" || direction != "sent" || threadID != "aix_turn_session:conv-1" {
+		t.Fatalf("unexpected user event %q %s %s", content, direction, threadID)
+	}
+	if err := d.QueryRow(`SELECT content FROM events WHERE id = 'chatgpt:a2:assistant'`).Scan(&content); err != nil || content != "It's 4." {
+		t.Fatalf("unexpected assistant event %q (%v)", content, err)
+	}
+	var aiName string
+	if err := d.QueryRow(`
+		SELECT c.display_name FROM event_participants p JOIN contacts c ON c.id = p.contact_id
+		WHERE p.event_id = 'chatgpt:a3:assistant' AND p.role = 'sender'
+	`).Scan(&aiName); err != nil || aiName != "ChatGPT (o1)" {
+		t.Fatalf("unexpected ai sender %q (%v)", aiName, err)
+	}
+
+	res, err = ImportChatGPT(context.Background(), d, AIChatImportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("second ImportChatGPT: %v", err)
+	}
+	if res.SessionsCreated != 0 || res.EventsCreated != 0 || res.EventsUpdated != 0 {
+		t.Fatalf("expected idempotent re-import, got %+v", res)
+	}
+}
+
+func TestImportClaude(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	dir := filepath.Join(tmpDir, "data-export", "claude")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "conversations.json"), []byte(claudeExport), 0o644); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+	res, err := ImportClaude(context.Background(), d, AIChatImportOptions{Path: filepath.Join(tmpDir, "data-export")})
+	if err != nil {
+		t.Fatalf("ImportClaude: %v", err)
+	}
+	if res.Conversations != 1 || res.Messages != 2 || res.Turns != 1 || res.EventsCreated != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	var parent, queryIDs string
+	var tools int
+	if err := d.QueryRow(`SELECT COALESCE(parent_turn_id, ''), query_message_ids, tool_call_count FROM agent_turns WHERE id = 'r1'`).Scan(&parent, &queryIDs, &tools); err != nil {
+		t.Fatalf("turn: %v", err)
+	}
+	if parent != "" || queryIDs != `["h1"]` || tools != 1 {
+		t.Fatalf("unexpected turn parent=%q query=%s tools=%d", parent, queryIDs, tools)
+	}
+	var content, channel string
+	if err := d.QueryRow(`SELECT content, channel FROM events WHERE id = 'claude:r1:assistant'`).Scan(&content, &channel); err != nil {
+		t.Fatalf("assistant event: %v", err)
+	}
+	if content != "Let me search.\n\nHow about Miso?" || channel != "claude" {
+		t.Fatalf("unexpected assistant event %q on %s", content, channel)
+	}
+	var summary string
+	if err := d.QueryRow(`SELECT summary FROM agent_sessions WHERE id = 'claude-1' AND source = 'claude'`).Scan(&summary); err != nil || summary != "Naming help" {
+		t.Fatalf("unexpected session summary %q (%v)", summary, err)
+	}
+}
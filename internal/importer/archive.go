@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// exportArchive is an export opened from a directory or a .zip file. It
+// remembers where it came from so attachments can point back at their files.
+type exportArchive struct {
+	fs.FS
+	close func() error
+
+	dir     string // absolute directory, when opened from a directory
+	zipPath string // absolute zip path, when opened from a zip
+}
+
+// openArchive opens an export that may be a directory or a .zip file.
+func openArchive(p string) (*exportArchive, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	if info.IsDir() {
+		return &exportArchive{FS: os.DirFS(abs), close: func() error { return nil }, dir: abs}, nil
+	}
+	zr, err := zip.OpenReader(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export zip: %w", err)
+	}
+	return &exportArchive{FS: zr, close: zr.Close, zipPath: abs}, nil
+}
+
+func (a *exportArchive) Close() error {
+	return a.close()
+}
+
+// storage returns the attachments.storage_type and storage_uri for a file
+// inside the export: a local path, or "<zip>#<member>" for zipped exports.
+func (a *exportArchive) storage(member string) (string, string) {
+	if a.zipPath != "" {
+		return "archive", a.zipPath + "#" + member
+	}
+	return "local", filepath.Join(a.dir, filepath.FromSlash(member))
+}
+
+// hashFile returns the size and sha256 content hash of a file in the export.
+func (a *exportArchive) hashFile(member string) (int64, string, error) {
+	f, err := a.Open(member)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("hash %s: %w", member, err)
+	}
+	return size, "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findArchiveRoot returns the directory inside fsys that contains marker.
+// Exports are often zipped with a single top-level folder, so the root and
+// one level below it are checked.
+func findArchiveRoot(fsys fs.FS, marker string) (string, error) {
+	if _, err := fs.Stat(fsys, marker); err == nil {
+		return ".", nil
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), "__MACOSX") {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(e.Name(), marker)); err == nil {
+			return e.Name(), nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in export", marker)
+}
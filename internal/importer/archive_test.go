@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "export")
+	if err := os.MkdirAll(filepath.Join(dir, "Export", "media"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := map[string]string{
+		"Export/result.json":     "{}",
+		"Export/media/photo.jpg": "jpeg",
+	}
+	zipPath := filepath.Join(tmpDir, "export.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(zf)
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	zf.Close()
+
+	const member = "Export/media/photo.jpg"
+	for _, tc := range []struct {
+		path, storageType, storageURI string
+	}{
+		{dir, "local", filepath.Join(dir, "Export", "media", "photo.jpg")},
+		{zipPath, "archive", zipPath + "#" + member},
+	} {
+		archive, err := openArchive(tc.path)
+		if err != nil {
+			t.Fatalf("open %s: %v", tc.path, err)
+		}
+		if root, err := findArchiveRoot(archive, "result.json"); err != nil || root != "Export" {
+			t.Fatalf("%s: root = %q, %v", tc.path, root, err)
+		}
+		if typ, uri := archive.storage(member); typ != tc.storageType || uri != tc.storageURI {
+			t.Fatalf("%s: storage = %q %q", tc.path, typ, uri)
+		}
+		size, hash, err := archive.hashFile(member)
+		if err != nil {
+			t.Fatalf("%s: hash: %v", tc.path, err)
+		}
+		if size != 4 || hash != "sha256:41e5787e9f28562d07b891b1816b492309d646c0f2829743fa4963a9f9cc1d61" {
+			t.Fatalf("%s: size=%d hash=%q", tc.path, size, hash)
+		}
+		if err := archive.Close(); err != nil {
+			t.Fatalf("%s: close: %v", tc.path, err)
+		}
+	}
+}
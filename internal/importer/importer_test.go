@@ -0,0 +1,25 @@
+package importer
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/db"
+)
+
+// openImporterTestDB initializes a fresh data dir and returns its database
+// along with the temp dir, for importers that need the full schema.
+func openImporterTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("COMMS_DATA_DIR", tmpDir)
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	d, err := db.Open()
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d, tmpDir
+}
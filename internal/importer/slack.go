@@ -0,0 +1,691 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+)
+
+type SlackImportOptions struct {
+	AdapterName   string // defaults to "slack"
+	Path          string // workspace export .zip or extracted directory
+	Me            string // Slack user id or email of the exporting user (drives direction)
+	LimitMessages int    // 0 = no limit
+	DryRun        bool
+}
+
+type SlackImportResult struct {
+	Conversations      int
+	MessagesSeen       int
+	EventsCreated      int
+	EventsUpdated      int
+	PersonsCreated     int
+	ThreadsCreated     int
+	ThreadsUpdated     int
+	AttachmentsCreated int
+	ReactionsCreated   int
+	Duration           time.Duration
+}
+
+func (o SlackImportOptions) withDefaults() SlackImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "slack"
+	}
+	return o
+}
+
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	IsBot    bool   `json:"is_bot"`
+	Profile  struct {
+		Email       string `json:"email"`
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+func (u slackUser) displayName() string {
+	for _, s := range []string{u.Profile.RealName, u.RealName, u.Profile.DisplayName, u.Name} {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return u.ID
+}
+
+type slackConversation struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+
+	kind string // channel, group, mpim, dm
+	dir  string
+}
+
+type slackMessage struct {
+	Type       string `json:"type"`
+	Subtype    string `json:"subtype"`
+	User       string `json:"user"`
+	BotID      string `json:"bot_id"`
+	Username   string `json:"username"`
+	Inviter    string `json:"inviter"`
+	Text       string `json:"text"`
+	TS         string `json:"ts"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+	Edited     *struct {
+		User string `json:"user"`
+		TS   string `json:"ts"`
+	} `json:"edited"`
+	Reactions []struct {
+		Name  string   `json:"name"`
+		Users []string `json:"users"`
+	} `json:"reactions"`
+	Files []struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Title      string `json:"title"`
+		Mimetype   string `json:"mimetype"`
+		Size       int64  `json:"size"`
+		URLPrivate string `json:"url_private"`
+		Created    int64  `json:"created"`
+		Mode       string `json:"mode"`
+	} `json:"files"`
+}
+
+// Subtypes that carry user-authored content. Everything else not handled
+// explicitly (topic changes, pins, bot noise) is skipped.
+var slackContentSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"file_share":       true,
+	"me_message":       true,
+	"bot_message":      true,
+}
+
+var slackMembershipSubtypes = map[string]string{
+	"channel_join":  "added",
+	"group_join":    "added",
+	"channel_leave": "removed",
+	"group_leave":   "removed",
+}
+
+// ImportSlack imports a Slack workspace export (the zip produced by
+// "Export data", or its extracted directory). Channels, private groups,
+// multi-party DMs and DMs become threads; thread replies become child threads.
+func ImportSlack(ctx context.Context, db *sql.DB, opts SlackImportOptions) (SlackImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out SlackImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	fsys, err := openArchive(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	defer fsys.Close()
+
+	root, err := findArchiveRoot(fsys, "users.json")
+	if err != nil {
+		return out, fmt.Errorf("not a Slack export: %w", err)
+	}
+
+	var userList []slackUser
+	if err := readSlackJSON(fsys, path.Join(root, "users.json"), &userList); err != nil {
+		return out, err
+	}
+	users := make(map[string]slackUser, len(userList))
+	me := ""
+	for _, u := range userList {
+		users[u.ID] = u
+		if opts.Me != "" && (u.ID == opts.Me || strings.EqualFold(u.Profile.Email, opts.Me)) {
+			me = u.ID
+		}
+	}
+	if opts.Me != "" && me == "" {
+		return out, fmt.Errorf("--me %q does not match any user in users.json", opts.Me)
+	}
+
+	var convs []slackConversation
+	for _, src := range []struct{ file, kind string }{
+		{"channels.json", "channel"},
+		{"groups.json", "group"},
+		{"mpims.json", "mpim"},
+		{"dms.json", "dm"},
+	} {
+		var list []slackConversation
+		if err := readSlackJSON(fsys, path.Join(root, src.file), &list); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return out, err
+		}
+		for _, c := range list {
+			c.kind = src.kind
+			c.dir = c.Name
+			if src.kind == "dm" {
+				c.dir = c.ID
+			}
+			if c.ID == "" || c.dir == "" {
+				continue
+			}
+			convs = append(convs, c)
+		}
+	}
+
+	for _, c := range convs {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if opts.LimitMessages > 0 && out.MessagesSeen >= opts.LimitMessages {
+			break
+		}
+		stats, err := importSlackConversation(ctx, db, fsys, root, c, users, me, opts, &out.MessagesSeen)
+		if err != nil {
+			return out, fmt.Errorf("conversation %s: %w", c.dir, err)
+		}
+		out.Conversations++
+		out.EventsCreated += stats.EventsCreated
+		out.EventsUpdated += stats.EventsUpdated
+		out.PersonsCreated += stats.PersonsCreated
+		out.ThreadsCreated += stats.ThreadsCreated
+		out.ThreadsUpdated += stats.ThreadsUpdated
+		out.AttachmentsCreated += stats.AttachmentsCreated
+		out.ReactionsCreated += stats.ReactionsCreated
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// importSlackConversation writes one conversation in its own transaction.
+func importSlackConversation(ctx context.Context, db *sql.DB, fsys fs.FS, root string, c slackConversation, users map[string]slackUser, me string, opts SlackImportOptions, seen *int) (ingest.Stats, error) {
+	entries, err := fs.ReadDir(fsys, path.Join(root, c.dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ingest.Stats{}, nil
+		}
+		return ingest.Stats{}, err
+	}
+	var days []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			days = append(days, e.Name())
+		}
+	}
+	sort.Strings(days)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ingest.Stats{}, fmt.Errorf("failed to begin import tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, err := ingest.NewWriter(tx, opts.AdapterName)
+	if err != nil {
+		return ingest.Stats{}, err
+	}
+	defer w.Close()
+
+	s := &slackSession{w: w, users: users, me: me, contacts: map[string]string{}, childThreads: map[string]bool{}, threadNames: map[string]string{}}
+
+	threadID, err := w.UpsertThread(ingest.Thread{
+		SourceID: c.ID,
+		Channel:  "slack",
+		Name:     s.conversationName(c),
+		IsGroup:  c.kind != "dm",
+	})
+	if err != nil {
+		return w.Stats, err
+	}
+	s.conv = c
+	s.threadID = threadID
+
+	for _, day := range days {
+		var msgs []slackMessage
+		if err := readSlackJSON(fsys, path.Join(root, c.dir, day), &msgs); err != nil {
+			return w.Stats, err
+		}
+		for _, m := range msgs {
+			if opts.LimitMessages > 0 && *seen >= opts.LimitMessages {
+				break
+			}
+			handled, err := s.importMessage(m)
+			if err != nil {
+				return w.Stats, fmt.Errorf("%s ts %s: %w", day, m.TS, err)
+			}
+			if handled {
+				*seen++
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return w.Stats, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return w.Stats, fmt.Errorf("commit import tx: %w", err)
+	}
+	return w.Stats, nil
+}
+
+type slackSession struct {
+	w        *ingest.Writer
+	users    map[string]slackUser
+	me       string
+	conv     slackConversation
+	threadID string
+
+	contacts     map[string]string // slack user id -> contact id
+	childThreads map[string]bool   // thread_ts already upserted
+	threadNames  map[string]string // thread_ts -> parent text
+}
+
+func (s *slackSession) conversationName(c slackConversation) string {
+	if c.kind != "dm" && c.kind != "mpim" {
+		return c.Name
+	}
+	var names []string
+	for _, id := range c.Members {
+		if id == s.me && len(c.Members) > 1 {
+			continue
+		}
+		names = append(names, s.userName(id))
+	}
+	if len(names) == 0 {
+		return c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (s *slackSession) userName(id string) string {
+	if u, ok := s.users[id]; ok {
+		return u.displayName()
+	}
+	return id
+}
+
+// contact resolves a Slack user to a contact. Users with a profile email are
+// keyed by email so they merge with mail and calendar data; the Slack user id
+// is always attached as a handle.
+func (s *slackSession) contact(userID, fallbackName string) (string, error) {
+	if id, ok := s.contacts[userID]; ok {
+		return id, nil
+	}
+	name := fallbackName
+	email := ""
+	if u, ok := s.users[userID]; ok {
+		name = u.displayName()
+		email = strings.TrimSpace(u.Profile.Email)
+	}
+	var contactID string
+	var err error
+	if email != "" {
+		if contactID, err = s.w.Contact("email", email, name); err != nil {
+			return "", err
+		}
+		if err := s.w.LinkIdentifier(contactID, "handle", userID); err != nil {
+			return "", err
+		}
+	} else if contactID, err = s.w.Contact("handle", userID, name); err != nil {
+		return "", err
+	}
+	s.contacts[userID] = contactID
+	return contactID, nil
+}
+
+func (s *slackSession) direction(userID string) string {
+	if s.me != "" && userID == s.me {
+		return "sent"
+	}
+	return "received"
+}
+
+func (s *slackSession) importMessage(m slackMessage) (bool, error) {
+	if m.Type != "" && m.Type != "message" {
+		return false, nil
+	}
+	if m.TS == "" {
+		return false, nil
+	}
+	if action, ok := slackMembershipSubtypes[m.Subtype]; ok {
+		return true, s.importMembership(m, action)
+	}
+	if !slackContentSubtypes[m.Subtype] {
+		return false, nil
+	}
+
+	senderID := m.User
+	senderName := ""
+	if senderID == "" && m.BotID != "" {
+		senderID = m.BotID
+		senderName = m.Username
+	}
+
+	sourceID := s.conv.ID + ":" + m.TS
+	threadID := s.threadID
+	replyTo := ""
+	content := s.cleanText(m.Text)
+
+	isReply := m.ThreadTS != "" && m.ThreadTS != m.TS
+	if m.ThreadTS == m.TS || m.ReplyCount > 0 {
+		s.threadNames[m.TS] = truncateRunes(content, 80)
+		if err := s.ensureChildThread(m.TS); err != nil {
+			return true, err
+		}
+	}
+	if isReply {
+		if err := s.ensureChildThread(m.ThreadTS); err != nil {
+			return true, err
+		}
+		threadID = s.w.ID(s.conv.ID + ":" + m.ThreadTS)
+		replyTo = s.w.ID(s.conv.ID + ":" + m.ThreadTS)
+	}
+
+	contentTypes := []string{"text"}
+	var files []int
+	for i, f := range m.Files {
+		if f.ID != "" && f.Mode != "tombstone" && f.Mode != "hidden_by_limit" {
+			files = append(files, i)
+		}
+	}
+	if len(files) > 0 {
+		if content == "" {
+			contentTypes = []string{"attachment"}
+		} else {
+			contentTypes = append(contentTypes, "attachment")
+		}
+	}
+
+	metadata := map[string]any{"slack_ts": m.TS}
+	if m.Subtype != "" {
+		metadata["subtype"] = m.Subtype
+	}
+	if m.ThreadTS != "" {
+		metadata["thread_ts"] = m.ThreadTS
+	}
+	if m.ReplyCount > 0 {
+		metadata["reply_count"] = m.ReplyCount
+	}
+	if m.Edited != nil && m.Edited.TS != "" {
+		metadata["edited_ts"] = m.Edited.TS
+		if ts := slackTimestamp(m.Edited.TS); ts > 0 {
+			metadata["edited_at"] = ts
+		}
+	}
+
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     sourceID,
+		Timestamp:    slackTimestamp(m.TS),
+		Channel:      "slack",
+		ContentTypes: contentTypes,
+		Content:      content,
+		Direction:    s.direction(senderID),
+		ThreadID:     threadID,
+		ReplyTo:      replyTo,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	if senderID != "" {
+		contactID, err := s.contact(senderID, senderName)
+		if err != nil {
+			return true, err
+		}
+		if err := s.w.AddParticipant(eventID, contactID, "sender"); err != nil {
+			return true, err
+		}
+	}
+	if s.conv.kind == "dm" || s.conv.kind == "mpim" {
+		for _, member := range s.conv.Members {
+			if member == senderID {
+				continue
+			}
+			contactID, err := s.contact(member, "")
+			if err != nil {
+				return true, err
+			}
+			if err := s.w.AddParticipant(eventID, contactID, "recipient"); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	for _, i := range files {
+		f := m.Files[i]
+		filename := f.Name
+		if filename == "" {
+			filename = f.Title
+		}
+		createdAt := f.Created
+		if createdAt == 0 {
+			createdAt = slackTimestamp(m.TS)
+		}
+		storageType := ""
+		if f.URLPrivate != "" {
+			storageType = "url"
+		}
+		if _, err := s.w.UpsertAttachment(ingest.Attachment{
+			SourceID:    f.ID,
+			EventID:     eventID,
+			Filename:    filename,
+			MimeType:    f.Mimetype,
+			SizeBytes:   f.Size,
+			StorageURI:  f.URLPrivate,
+			StorageType: storageType,
+			CreatedAt:   createdAt,
+		}); err != nil {
+			return true, err
+		}
+	}
+
+	for _, r := range m.Reactions {
+		for _, userID := range r.Users {
+			if err := s.importReaction(m, eventID, threadID, r.Name, userID); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// ensureChildThread upserts the reply thread rooted at threadTS once per run.
+func (s *slackSession) ensureChildThread(threadTS string) error {
+	if s.childThreads[threadTS] {
+		return nil
+	}
+	name := s.threadNames[threadTS]
+	if name == "" {
+		name = s.conv.Name
+	}
+	if _, err := s.w.UpsertThread(ingest.Thread{
+		SourceID: s.conv.ID + ":" + threadTS,
+		Channel:  "slack",
+		Name:     name,
+		IsGroup:  s.conv.kind != "dm",
+		ParentID: s.threadID,
+	}); err != nil {
+		return err
+	}
+	s.childThreads[threadTS] = true
+	return nil
+}
+
+// importReaction stores a reaction as its own event pointing at the target,
+// matching how Eve records iMessage tapbacks.
+func (s *slackSession) importReaction(m slackMessage, targetID, threadID, name, userID string) error {
+	reactionID, _, err := s.w.UpsertReaction(ingest.Event{
+		SourceID:     fmt.Sprintf("%s:%s:reaction:%s:%s", s.conv.ID, m.TS, userID, name),
+		Timestamp:    slackTimestamp(m.TS),
+		Channel:      "slack",
+		ContentTypes: []string{"reaction"},
+		Content:      slackEmoji(name),
+		Direction:    s.direction(userID),
+		ThreadID:     threadID,
+		ReplyTo:      targetID,
+		Metadata:     map[string]any{"emoji_name": name},
+	})
+	if err != nil {
+		return err
+	}
+	contactID, err := s.contact(userID, "")
+	if err != nil {
+		return err
+	}
+	return s.w.AddParticipant(reactionID, contactID, "sender")
+}
+
+func (s *slackSession) importMembership(m slackMessage, action string) error {
+	metadata := map[string]any{
+		"action":   action,
+		"subtype":  m.Subtype,
+		"slack_ts": m.TS,
+	}
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     s.conv.ID + ":" + m.TS,
+		Timestamp:    slackTimestamp(m.TS),
+		Channel:      "slack",
+		ContentTypes: []string{"membership"},
+		Content:      action,
+		Direction:    s.direction(m.User),
+		ThreadID:     s.threadID,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return err
+	}
+	if m.User == "" {
+		return nil
+	}
+	memberID, err := s.contact(m.User, "")
+	if err != nil {
+		return err
+	}
+	if err := s.w.AddParticipant(eventID, memberID, "member"); err != nil {
+		return err
+	}
+	actor := m.User
+	if m.Inviter != "" {
+		actor = m.Inviter
+	}
+	actorID, err := s.contact(actor, "")
+	if err != nil {
+		return err
+	}
+	return s.w.AddParticipant(eventID, actorID, "sender")
+}
+
+var (
+	slackUserRef    = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|([^>]*))?>`)
+	slackChannelRef = regexp.MustCompile(`<#[A-Z0-9]+\|([^>]*)>`)
+	slackSpecialRef = regexp.MustCompile(`<!([a-z]+)(?:\|[^>]*)?>`)
+	slackLinkRef    = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+)
+
+// cleanText rewrites Slack's mrkdwn escapes into readable text.
+func (s *slackSession) cleanText(text string) string {
+	text = slackUserRef.ReplaceAllStringFunc(text, func(ref string) string {
+		sub := slackUserRef.FindStringSubmatch(ref)
+		if sub[2] != "" {
+			return "@" + sub[2]
+		}
+		return "@" + s.userName(sub[1])
+	})
+	text = slackChannelRef.ReplaceAllString(text, "#$1")
+	text = slackSpecialRef.ReplaceAllString(text, "@$1")
+	text = slackLinkRef.ReplaceAllStringFunc(text, func(ref string) string {
+		sub := slackLinkRef.FindStringSubmatch(ref)
+		if sub[2] != "" {
+			return sub[2]
+		}
+		return sub[1]
+	})
+	text = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text)
+	return strings.TrimSpace(text)
+}
+
+var slackEmojiNames = map[string]string{
+	"+1":               "👍",
+	"thumbsup":         "👍",
+	"-1":               "👎",
+	"thumbsdown":       "👎",
+	"heart":            "❤️",
+	"joy":              "😂",
+	"laughing":         "😆",
+	"smile":            "😄",
+	"tada":             "🎉",
+	"fire":             "🔥",
+	"eyes":             "👀",
+	"pray":             "🙏",
+	"clap":             "👏",
+	"raised_hands":     "🙌",
+	"white_check_mark": "✅",
+	"heavy_check_mark": "✔️",
+	"rocket":           "🚀",
+	"100":              "💯",
+	"thinking_face":    "🤔",
+	"ok_hand":          "👌",
+}
+
+// slackEmoji maps common reaction names to unicode; custom and skin-toned
+// names are kept in Slack's :name: form.
+func slackEmoji(name string) string {
+	if e, ok := slackEmojiNames[name]; ok {
+		return e
+	}
+	return ":" + name + ":"
+}
+
+// slackTimestamp converts a Slack ts ("1700000000.000100") to unix seconds.
+func slackTimestamp(ts string) int64 {
+	secs, _, _ := strings.Cut(ts, ".")
+	n, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func readSlackJSON(fsys fs.FS, name string, v any) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func truncateRunes(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
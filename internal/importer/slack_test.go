@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var slackExportFiles = map[string]string{
+	"users.json": `[
+		{"id":"U1","name":"alice","real_name":"Alice Smith","profile":{"email":"alice@example.com","real_name":"Alice Smith"}},
+		{"id":"U2","name":"bob","profile":{"real_name":"Bob Jones"}}
+	]`,
+	"channels.json": `[{"id":"C1","name":"general","members":["U1","U2"]}]`,
+	"dms.json":      `[{"id":"D1","members":["U1","U2"]}]`,
+	"general/2023-11-14.json": `[
+		{"type":"message","subtype":"channel_join","user":"U2","text":"<@U2> has joined the channel","ts":"1699999000.000100"},
+		{"type":"message","user":"U1","text":"hello <@U2>, see <https://example.com|the doc>","ts":"1700000000.000100","thread_ts":"1700000000.000100","reply_count":1,
+		 "reactions":[{"name":"+1","users":["U2"],"count":1}],
+		 "files":[{"id":"F1","name":"notes.pdf","mimetype":"application/pdf","size":42,"url_private":"https://files.slack.com/F1"}]},
+		{"type":"message","user":"U2","text":"thanks","ts":"1700000100.000200","thread_ts":"1700000000.000100","parent_user_id":"U1","edited":{"user":"U2","ts":"1700000200.000000"}},
+		{"type":"message","subtype":"channel_topic","user":"U1","text":"set the topic","ts":"1700000300.000100"}
+	]`,
+	"D1/2023-11-15.json": `[{"type":"message","user":"U2","text":"psst","ts":"1700090000.000100"}]`,
+}
+
+func writeSlackExport(t *testing.T, dir string) {
+	t.Helper()
+	for name, body := range slackExportFiles {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func TestImportSlack_Directory(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	exportDir := filepath.Join(tmpDir, "export")
+	writeSlackExport(t, exportDir)
+
+	res, err := ImportSlack(context.Background(), d, SlackImportOptions{Path: exportDir, Me: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("ImportSlack: %v", err)
+	}
+	// join + parent + reply + DM; the topic change is skipped.
+	if res.MessagesSeen != 4 || res.EventsCreated != 4 || res.ReactionsCreated != 1 || res.AttachmentsCreated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// general, D1 and the reply thread.
+	if res.ThreadsCreated != 3 {
+		t.Fatalf("expected 3 threads, got %d", res.ThreadsCreated)
+	}
+
+	var parentThread, replyTo, direction string
+	if err := d.QueryRow(`
+		SELECT t.parent_thread_id, e.reply_to, e.direction
+		FROM events e JOIN threads t ON t.id = e.thread_id
+		WHERE e.id = 'slack:C1:1700000100.000200'
+	`).Scan(&parentThread, &replyTo, &direction); err != nil {
+		t.Fatalf("query reply: %v", err)
+	}
+	if parentThread != "slack:C1" || replyTo != "slack:C1:1700000000.000100" || direction != "received" {
+		t.Fatalf("unexpected reply parent=%q reply_to=%q direction=%q", parentThread, replyTo, direction)
+	}
+
+	var content, contentTypes, parentDirection string
+	if err := d.QueryRow(`SELECT content, content_types, direction FROM events WHERE id = 'slack:C1:1700000000.000100'`).Scan(&content, &contentTypes, &parentDirection); err != nil {
+		t.Fatalf("query parent: %v", err)
+	}
+	if content != "hello @Bob Jones, see the doc" || contentTypes != `["text","attachment"]` || parentDirection != "sent" {
+		t.Fatalf("unexpected parent content=%q types=%s direction=%q", content, contentTypes, parentDirection)
+	}
+
+	var reaction string
+	if err := d.QueryRow(`SELECT content FROM events WHERE reply_to = 'slack:C1:1700000000.000100' AND content_types = '["reaction"]'`).Scan(&reaction); err != nil || reaction != "👍" {
+		t.Fatalf("expected 👍 reaction, got %q (%v)", reaction, err)
+	}
+
+	var isGroup int
+	var dmName string
+	if err := d.QueryRow(`SELECT is_group, name FROM threads WHERE id = 'slack:D1'`).Scan(&isGroup, &dmName); err != nil {
+		t.Fatalf("query dm: %v", err)
+	}
+	if isGroup != 0 || dmName != "Bob Jones" {
+		t.Fatalf("unexpected DM thread is_group=%d name=%q", isGroup, dmName)
+	}
+
+	var membership string
+	if err := d.QueryRow(`SELECT content FROM events WHERE content_types = '["membership"]'`).Scan(&membership); err != nil || membership != "added" {
+		t.Fatalf("expected membership added event, got %q (%v)", membership, err)
+	}
+
+	// Alice resolves by email and carries her Slack id as a handle.
+	var handles int
+	if err := d.QueryRow(`
+		SELECT COUNT(*) FROM contact_identifiers h
+		JOIN contact_identifiers e ON e.contact_id = h.contact_id
+		WHERE h.type = 'handle' AND h.normalized = '@u1' AND e.type = 'email' AND e.normalized = 'alice@example.com'
+	`).Scan(&handles); err != nil || handles != 1 {
+		t.Fatalf("expected alice's handle linked to her email contact, got %d (%v)", handles, err)
+	}
+
+	// Re-import is idempotent.
+	res, err = ImportSlack(context.Background(), d, SlackImportOptions{Path: exportDir, Me: "U1"})
+	if err != nil {
+		t.Fatalf("second ImportSlack: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.ReactionsCreated != 0 || res.ThreadsCreated != 0 {
+		t.Fatalf("expected no changes on re-import, got %+v", res)
+	}
+}
+
+func TestImportSlack_ZipDryRun(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	zipPath := filepath.Join(tmpDir, "export.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range slackExportFiles {
+		fw, err := zw.Create("Acme Slack export/" + name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := fw.Write([]byte(body)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	f.Close()
+
+	res, err := ImportSlack(context.Background(), d, SlackImportOptions{Path: zipPath, DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportSlack: %v", err)
+	}
+	if res.EventsCreated != 4 {
+		t.Fatalf("expected 4 events counted, got %+v", res)
+	}
+	var n int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("dry run wrote %d events (%v)", n, err)
+	}
+}
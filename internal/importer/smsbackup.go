@@ -0,0 +1,814 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+)
+
+type SMSBackupImportOptions struct {
+	AdapterName   string // defaults to "android-sms"
+	Path          string // sms-*.xml / calls-*.xml file, or a directory holding them
+	Me            string // your phone number; detected from sent MMS when empty
+	MediaDir      string // where MMS parts are written; defaults to <data dir>/attachments/<adapter>
+	SkipMedia     bool   // record attachments without writing their bytes
+	LimitMessages int    // 0 = no limit
+	DryRun        bool
+}
+
+type SMSBackupImportResult struct {
+	Files              int
+	SMSSeen            int
+	MMSSeen            int
+	CallsSeen          int
+	EventsCreated      int
+	EventsUpdated      int
+	PersonsCreated     int
+	ThreadsCreated     int
+	AttachmentsCreated int
+	Duration           time.Duration
+}
+
+func (o SMSBackupImportOptions) withDefaults() SMSBackupImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "android-sms"
+	}
+	return o
+}
+
+type smsRecord struct {
+	Address     string `xml:"address,attr"`
+	Date        string `xml:"date,attr"`
+	Type        string `xml:"type,attr"`
+	Subject     string `xml:"subject,attr"`
+	Body        string `xml:"body,attr"`
+	Read        string `xml:"read,attr"`
+	ContactName string `xml:"contact_name,attr"`
+}
+
+type mmsRecord struct {
+	Date        string `xml:"date,attr"`
+	MsgBox      string `xml:"msg_box,attr"`
+	Address     string `xml:"address,attr"`
+	MID         string `xml:"m_id,attr"`
+	Sub         string `xml:"sub,attr"`
+	Read        string `xml:"read,attr"`
+	ContactName string `xml:"contact_name,attr"`
+	Parts       []struct {
+		Seq  string `xml:"seq,attr"`
+		CT   string `xml:"ct,attr"`
+		Name string `xml:"name,attr"`
+		FN   string `xml:"fn,attr"`
+		CL   string `xml:"cl,attr"`
+		Text string `xml:"text,attr"`
+		Data string `xml:"data,attr"`
+	} `xml:"parts>part"`
+	Addrs []struct {
+		Address string `xml:"address,attr"`
+		Type    string `xml:"type,attr"`
+	} `xml:"addrs>addr"`
+}
+
+type callRecord struct {
+	Number      string `xml:"number,attr"`
+	Duration    string `xml:"duration,attr"`
+	Date        string `xml:"date,attr"`
+	Type        string `xml:"type,attr"`
+	ContactName string `xml:"contact_name,attr"`
+}
+
+// PDU address types in <addrs>.
+const (
+	mmsAddrFrom = "137"
+	mmsAddrTo   = "151"
+	mmsAddrCC   = "130"
+	mmsAddrBCC  = "129"
+)
+
+// Android call log types.
+var callTypes = map[string]string{
+	"1": "incoming",
+	"2": "outgoing",
+	"3": "missed",
+	"4": "voicemail",
+	"5": "rejected",
+	"6": "blocked",
+}
+
+// ImportSMSBackup imports the XML files written by SMS Backup & Restore.
+// SMS and MMS become events in per-conversation threads keyed by the other
+// parties' normalized numbers; group MMS become group threads. Call log
+// entries become "call" events in the matching 1:1 thread.
+func ImportSMSBackup(ctx context.Context, db *sql.DB, opts SMSBackupImportOptions) (SMSBackupImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out SMSBackupImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	files, err := smsBackupFiles(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	if len(files) == 0 {
+		return out, fmt.Errorf("no sms-*.xml or calls-*.xml files found in %s", opts.Path)
+	}
+	if opts.MediaDir == "" && !opts.SkipMedia {
+		dataDir, err := config.GetDataDir()
+		if err != nil {
+			return out, err
+		}
+		opts.MediaDir = filepath.Join(dataDir, "attachments", opts.AdapterName)
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	base := &smsBackupBase{opts: opts, meNumbers: map[string]bool{}}
+	if n := contacts.NormalizeIdentifier(opts.Me, "phone"); n != "" {
+		base.meNumbers[n] = true
+		base.meRaw = opts.Me
+	}
+	for _, f := range files {
+		if err := base.detectMe(f); err != nil {
+			return out, err
+		}
+	}
+	if p, err := me.GetMePerson(db); err == nil && p != nil {
+		base.mePersonID = p.ID
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if opts.LimitMessages > 0 && out.SMSSeen+out.MMSSeen+out.CallsSeen >= opts.LimitMessages {
+			break
+		}
+		stats, err := importSMSBackupFile(ctx, db, base, f, &out)
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		out.Files++
+		out.EventsCreated += stats.EventsCreated
+		out.EventsUpdated += stats.EventsUpdated
+		out.PersonsCreated += stats.PersonsCreated
+		out.ThreadsCreated += stats.ThreadsCreated
+		out.AttachmentsCreated += stats.AttachmentsCreated
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+func smsBackupFiles(p string) ([]string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{p}, nil
+	}
+	var files []string
+	for _, pattern := range []string{"sms-*.xml", "calls-*.xml"} {
+		matches, err := filepath.Glob(filepath.Join(p, pattern))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+type smsBackupBase struct {
+	opts       SMSBackupImportOptions
+	meNumbers  map[string]bool // normalized
+	meRaw      string
+	mePersonID string
+}
+
+// detectMe learns the phone's own number from the sender address of sent MMS,
+// which is needed to key group threads by the other participants only.
+func (b *smsBackupBase) detectMe(file string) error {
+	if len(b.meNumbers) > 0 {
+		return nil
+	}
+	return scanSMSBackup(file, func(dec *xml.Decoder, el xml.StartElement) error {
+		if el.Name.Local != "mms" {
+			return dec.Skip()
+		}
+		var m mmsRecord
+		if err := dec.DecodeElement(&m, &el); err != nil {
+			return err
+		}
+		if smsAttr(m.MsgBox) != "2" {
+			return nil
+		}
+		for _, a := range m.Addrs {
+			if a.Type == mmsAddrFrom && !isAddressToken(a.Address) {
+				if typ, n := smsAddress(a.Address); typ == "phone" && n != "" {
+					b.meNumbers[n] = true
+					if b.meRaw == "" {
+						b.meRaw = a.Address
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// scanSMSBackup streams the top-level records of a backup file to fn.
+func scanSMSBackup(file string, fn func(*xml.Decoder, xml.StartElement) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				depth--
+				if err := fn(dec, t); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+func importSMSBackupFile(ctx context.Context, db *sql.DB, base *smsBackupBase, file string, out *SMSBackupImportResult) (ingest.Stats, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ingest.Stats{}, fmt.Errorf("failed to begin import tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, err := ingest.NewWriter(tx, base.opts.AdapterName)
+	if err != nil {
+		return ingest.Stats{}, err
+	}
+	defer w.Close()
+
+	s := &smsBackupSession{smsBackupBase: base, w: w, threads: map[string]string{}, seen: map[string]int{}}
+	limit := base.opts.LimitMessages
+	errLimit := fmt.Errorf("limit reached")
+
+	err = scanSMSBackup(file, func(dec *xml.Decoder, el xml.StartElement) error {
+		if limit > 0 && out.SMSSeen+out.MMSSeen+out.CallsSeen >= limit {
+			return errLimit
+		}
+		switch el.Name.Local {
+		case "sms":
+			var r smsRecord
+			if err := dec.DecodeElement(&r, &el); err != nil {
+				return err
+			}
+			out.SMSSeen++
+			return s.importSMS(r)
+		case "mms":
+			var r mmsRecord
+			if err := dec.DecodeElement(&r, &el); err != nil {
+				return err
+			}
+			out.MMSSeen++
+			return s.importMMS(r)
+		case "call":
+			var r callRecord
+			if err := dec.DecodeElement(&r, &el); err != nil {
+				return err
+			}
+			out.CallsSeen++
+			return s.importCall(r)
+		default:
+			return dec.Skip()
+		}
+	})
+	if err != nil && err != errLimit {
+		return w.Stats, err
+	}
+
+	if base.opts.DryRun {
+		return w.Stats, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return w.Stats, fmt.Errorf("commit import tx: %w", err)
+	}
+	return w.Stats, nil
+}
+
+type smsBackupSession struct {
+	*smsBackupBase
+	w *ingest.Writer
+
+	threads map[string]string // thread key -> thread id
+	seen    map[string]int    // source id -> occurrences in this file
+}
+
+// smsAddress classifies an address so it normalizes like the rest of the
+// ledger: phone numbers and short codes through contacts.NormalizeIdentifier,
+// email gateways as email, alphanumeric sender ids (even ones with digits,
+// like "AMZN2FA") as handles.
+func smsAddress(raw string) (identifierType, normalized string) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "@") {
+		return "email", contacts.NormalizeIdentifier(raw, "email")
+	}
+	if contacts.LooksLikePhone(raw) || isShortCode(raw) {
+		return "phone", contacts.NormalizeIdentifier(raw, "phone")
+	}
+	return "handle", contacts.NormalizeIdentifier(raw, "handle")
+}
+
+// isShortCode reports whether raw is a bare run of digits too short to be a
+// full number, as used by automated senders.
+func isShortCode(raw string) bool {
+	return raw != "" && strings.Trim(raw, "0123456789") == ""
+}
+
+func (s *smsBackupSession) isMe(raw string) bool {
+	if isAddressToken(raw) {
+		return true
+	}
+	typ, n := smsAddress(raw)
+	return typ == "phone" && s.meNumbers[n]
+}
+
+func (s *smsBackupSession) contact(raw, name string) (string, error) {
+	if s.isMe(raw) {
+		return s.meContact()
+	}
+	typ, _ := smsAddress(raw)
+	return s.w.Contact(typ, raw, smsContactName(name))
+}
+
+// meContact returns the contact for the phone's own number, linked to the
+// "me" person when one is set. Empty when the number is unknown.
+func (s *smsBackupSession) meContact() (string, error) {
+	if s.meRaw == "" {
+		return "", nil
+	}
+	if s.mePersonID != "" {
+		contactID, _, err := contacts.GetOrCreateContact(s.w.Tx(), "phone", s.meRaw, "", s.w.Adapter())
+		if err != nil {
+			return "", err
+		}
+		if err := contacts.EnsurePersonContactLink(s.w.Tx(), s.mePersonID, contactID, "deterministic", 1.0); err != nil {
+			return "", err
+		}
+	}
+	return s.w.Contact("phone", s.meRaw, "")
+}
+
+// thread upserts the conversation with the given other parties.
+func (s *smsBackupSession) thread(others []string, name string) (string, error) {
+	keys := make([]string, 0, len(others))
+	seen := map[string]bool{}
+	for _, raw := range others {
+		_, n := smsAddress(raw)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		keys = append(keys, n)
+	}
+	if len(keys) == 0 {
+		keys = []string{"unknown"}
+	}
+	sort.Strings(keys)
+	key := strings.Join(keys, ",")
+	if id, ok := s.threads[key]; ok {
+		return id, nil
+	}
+	if name = smsContactName(name); name == "" {
+		name = strings.Join(others, ", ")
+	}
+	id, err := s.w.UpsertThread(ingest.Thread{
+		SourceID: key,
+		Channel:  "sms",
+		Name:     name,
+		IsGroup:  len(keys) > 1,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.threads[key] = id
+	return id, nil
+}
+
+// sourceID disambiguates records that share a key within one file (two
+// messages to the same number in the same millisecond).
+func (s *smsBackupSession) sourceID(id string) string {
+	s.seen[id]++
+	if n := s.seen[id]; n > 1 {
+		return id + "#" + strconv.Itoa(n)
+	}
+	return id
+}
+
+func (s *smsBackupSession) importSMS(r smsRecord) error {
+	address := smsAttr(r.Address)
+	if address == "" {
+		return nil
+	}
+	ts := smsMillis(r.Date)
+	threadID, err := s.thread([]string{address}, r.ContactName)
+	if err != nil {
+		return err
+	}
+	direction, status := smsBoxState(smsAttr(r.Type))
+	_, key := smsAddress(address)
+
+	metadata := map[string]any{}
+	if sub := smsAttr(r.Subject); sub != "" {
+		metadata["subject"] = sub
+	}
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:  s.sourceID(fmt.Sprintf("sms:%s:%s:%s", key, smsAttr(r.Date), smsAttr(r.Type))),
+		Timestamp: ts,
+		Channel:   "sms",
+		Content:   smsAttr(r.Body),
+		Direction: direction,
+		ThreadID:  threadID,
+		Metadata:  metadata,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.w.SetState(eventID, ingest.EventState{ReadState: smsReadState(r.Read), Status: status}); err != nil {
+		return err
+	}
+
+	other, err := s.contact(address, r.ContactName)
+	if err != nil {
+		return err
+	}
+	self, err := s.meContact()
+	if err != nil {
+		return err
+	}
+	sender, recipient := other, self
+	if direction == "sent" {
+		sender, recipient = self, other
+	}
+	return s.addParticipants(eventID, sender, recipient)
+}
+
+func (s *smsBackupSession) addParticipants(eventID, sender string, recipients ...string) error {
+	if sender != "" {
+		if err := s.w.AddParticipant(eventID, sender, "sender"); err != nil {
+			return err
+		}
+	}
+	for _, r := range recipients {
+		if r == "" || r == sender {
+			continue
+		}
+		if err := s.w.AddParticipant(eventID, r, "recipient"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *smsBackupSession) importMMS(r mmsRecord) error {
+	ts := smsMillis(r.Date)
+	direction, status := smsBoxState(smsAttr(r.MsgBox))
+
+	// Participants come from <addrs>; fall back to the "~"-joined address
+	// attribute for backups that omit them.
+	var from string
+	var to []string
+	for _, a := range r.Addrs {
+		addr := smsAttr(a.Address)
+		if addr == "" {
+			continue
+		}
+		switch a.Type {
+		case mmsAddrFrom:
+			from = addr
+		case mmsAddrTo, mmsAddrCC, mmsAddrBCC:
+			to = append(to, addr)
+		}
+	}
+	if len(r.Addrs) == 0 {
+		to = strings.Split(smsAttr(r.Address), "~")
+	}
+	var others []string
+	for _, addr := range append([]string{from}, to...) {
+		if addr != "" && !s.isMe(addr) {
+			others = append(others, addr)
+		}
+	}
+	threadName := ""
+	if len(others) == 1 {
+		threadName = r.ContactName
+	}
+	threadID, err := s.thread(others, threadName)
+	if err != nil {
+		return err
+	}
+
+	var texts []string
+	type mediaPart struct {
+		filename, mimeType string
+		data               []byte
+		seq                string
+	}
+	var media []mediaPart
+	for _, p := range r.Parts {
+		ct := strings.ToLower(smsAttr(p.CT))
+		switch {
+		case ct == "application/smil":
+			continue
+		case ct == "text/plain" && smsAttr(p.Data) == "":
+			if t := smsAttr(p.Text); t != "" {
+				texts = append(texts, t)
+			}
+		default:
+			filename := firstNonEmpty(smsAttr(p.CL), smsAttr(p.FN), smsAttr(p.Name))
+			var data []byte
+			if raw := smsAttr(p.Data); raw != "" {
+				if data, err = base64.StdEncoding.DecodeString(raw); err != nil {
+					return fmt.Errorf("decode mms part %s: %w", filename, err)
+				}
+			}
+			media = append(media, mediaPart{filename: filename, mimeType: ct, data: data, seq: smsAttr(p.Seq)})
+		}
+	}
+	content := strings.Join(texts, "\n")
+	contentTypes := []string{"text"}
+	if len(media) > 0 {
+		contentTypes = []string{"attachment"}
+		if content != "" {
+			contentTypes = []string{"text", "attachment"}
+		}
+	}
+
+	sourceID := "mms:" + smsAttr(r.MID)
+	if smsAttr(r.MID) == "" {
+		sourceID = fmt.Sprintf("mms:%s:%s:%s", strings.Join(others, ","), smsAttr(r.Date), smsAttr(r.MsgBox))
+	}
+	sourceID = s.sourceID(sourceID)
+	metadata := map[string]any{}
+	if sub := smsAttr(r.Sub); sub != "" {
+		metadata["subject"] = sub
+	}
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     sourceID,
+		Timestamp:    ts,
+		Channel:      "sms",
+		ContentTypes: contentTypes,
+		Content:      content,
+		Direction:    direction,
+		ThreadID:     threadID,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.w.SetState(eventID, ingest.EventState{ReadState: smsReadState(r.Read), Status: status}); err != nil {
+		return err
+	}
+
+	var sender string
+	if direction == "sent" || from == "" {
+		sender, err = s.meContact()
+	} else {
+		sender, err = s.contact(from, "")
+	}
+	if err != nil {
+		return err
+	}
+	var recipients []string
+	for _, addr := range to {
+		id, err := s.contact(addr, "")
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, id)
+	}
+	if err := s.addParticipants(eventID, sender, recipients...); err != nil {
+		return err
+	}
+
+	for i, m := range media {
+		if err := s.importPart(eventID, sourceID, i, m.filename, m.mimeType, m.data, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importPart records an MMS part and, unless disabled, writes its bytes to
+// the media directory under their content hash.
+func (s *smsBackupSession) importPart(eventID, sourceID string, index int, filename, mimeType string, data []byte, ts int64) error {
+	a := ingest.Attachment{
+		SourceID:  fmt.Sprintf("%s:part:%d", sourceID, index),
+		EventID:   eventID,
+		Filename:  filename,
+		MimeType:  mimeType,
+		SizeBytes: int64(len(data)),
+		CreatedAt: ts,
+	}
+	if len(data) > 0 {
+		sum := sha256.Sum256(data)
+		digest := hex.EncodeToString(sum[:])
+		a.ContentHash = "sha256:" + digest
+		if !s.opts.SkipMedia {
+			ext := strings.ToLower(path.Ext(filename))
+			if ext == "" {
+				if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+					ext = exts[0]
+				}
+			}
+			dest := filepath.Join(s.opts.MediaDir, digest[:2], digest+ext)
+			if !s.opts.DryRun {
+				if err := writeFileIfMissing(dest, data); err != nil {
+					return err
+				}
+			}
+			a.StorageType, a.StorageURI = "local", dest
+		}
+	}
+	_, err := s.w.UpsertAttachment(a)
+	return err
+}
+
+func (s *smsBackupSession) importCall(r callRecord) error {
+	number := smsAttr(r.Number)
+	if number == "" {
+		number = "unknown"
+	}
+	ts := smsMillis(r.Date)
+	callType := callTypes[smsAttr(r.Type)]
+	if callType == "" {
+		callType = "unknown"
+	}
+	duration, _ := strconv.ParseInt(smsAttr(r.Duration), 10, 64)
+
+	threadID, err := s.thread([]string{number}, r.ContactName)
+	if err != nil {
+		return err
+	}
+	direction := "received"
+	if callType == "outgoing" {
+		direction = "sent"
+	}
+	_, key := smsAddress(number)
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     s.sourceID(fmt.Sprintf("call:%s:%s", key, smsAttr(r.Date))),
+		Timestamp:    ts,
+		Channel:      "sms",
+		ContentTypes: []string{"call"},
+		Content:      callSummary(callType, duration),
+		Direction:    direction,
+		ThreadID:     threadID,
+		Metadata:     map[string]any{"call_type": callType, "duration_seconds": duration},
+	})
+	if err != nil {
+		return err
+	}
+
+	if number == "unknown" {
+		return nil
+	}
+	other, err := s.contact(number, r.ContactName)
+	if err != nil {
+		return err
+	}
+	self, err := s.meContact()
+	if err != nil {
+		return err
+	}
+	if direction == "sent" {
+		return s.addParticipants(eventID, self, other)
+	}
+	return s.addParticipants(eventID, other, self)
+}
+
+func callSummary(callType string, duration int64) string {
+	label := strings.ToUpper(callType[:1]) + callType[1:] + " call"
+	if callType == "voicemail" {
+		label = "Voicemail"
+	}
+	if duration > 0 {
+		return fmt.Sprintf("%s (%s)", label, time.Duration(duration)*time.Second)
+	}
+	return label
+}
+
+// smsBoxState maps SMS type / MMS msg_box to direction and event_state status.
+func smsBoxState(box string) (direction, status string) {
+	switch box {
+	case "1":
+		return "received", "received"
+	case "2":
+		return "sent", "sent"
+	case "3":
+		return "sent", "draft"
+	case "4", "6":
+		return "sent", "unknown"
+	case "5":
+		return "sent", "failed"
+	default:
+		return "received", "unknown"
+	}
+}
+
+func smsReadState(read string) string {
+	switch smsAttr(read) {
+	case "1":
+		return "read"
+	case "0":
+		return "unread"
+	default:
+		return "unknown"
+	}
+}
+
+// smsAttr treats the literal "null" the app writes for missing values as empty.
+func smsAttr(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "null" {
+		return ""
+	}
+	return v
+}
+
+func smsContactName(name string) string {
+	name = smsAttr(name)
+	if name == "(Unknown)" {
+		return ""
+	}
+	return name
+}
+
+func smsMillis(v string) int64 {
+	n, _ := strconv.ParseInt(smsAttr(v), 10, 64)
+	return n / 1000
+}
+
+func isAddressToken(addr string) bool {
+	return strings.EqualFold(strings.TrimSpace(addr), "insert-address-token")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func writeFileIfMissing(dest string, data []byte) error {
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
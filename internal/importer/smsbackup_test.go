@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var smsBackupXML = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<smses count="5" backup_set="b1" backup_date="1700100000000" type="full">
+  <sms protocol="0" address="+1 (555) 123-4567" date="1700000000000" type="1" subject="null" body="hey there" read="1" status="-1" contact_name="Alice Smith" />
+  <sms protocol="0" address="5551234567" date="1700000100000" type="2" subject="null" body="hi alice" read="1" status="-1" contact_name="Alice Smith" />
+  <sms protocol="0" address="AMZN2FA" date="1700000200000" type="1" subject="null" body="Your code is 1234" read="0" status="-1" contact_name="(Unknown)" />
+  <mms date="1700000300000" msg_box="1" address="+15551234567~+15559876543~+15550001111" m_id="mid-1" sub="null" read="1" contact_name="Alice Smith, Bob Jones">
+    <parts>
+      <part seq="-1" ct="application/smil" name="null" chset="null" cl="smil.xml" text="&lt;smil/&gt;" />
+      <part seq="0" ct="image/jpeg" name="null" chset="null" cl="IMG_0001.jpg" text="null" data="` + base64.StdEncoding.EncodeToString([]byte("\xff\xd8\xffjpeg")) + `" />
+      <part seq="0" ct="text/plain" name="null" chset="106" cl="text_0.txt" text="group photo" />
+    </parts>
+    <addrs>
+      <addr address="+15551234567" type="137" charset="106" />
+      <addr address="+15559876543" type="151" charset="106" />
+      <addr address="+15550001111" type="151" charset="106" />
+    </addrs>
+  </mms>
+  <mms date="1700000400000" msg_box="2" address="+15551234567~+15559876543" m_id="mid-2" sub="null" read="1" contact_name="null">
+    <parts>
+      <part seq="0" ct="text/plain" name="null" chset="106" cl="text_0.txt" text="thanks both" />
+    </parts>
+    <addrs>
+      <addr address="+15550001111" type="137" charset="106" />
+      <addr address="+15551234567" type="151" charset="106" />
+      <addr address="+15559876543" type="151" charset="106" />
+    </addrs>
+  </mms>
+</smses>
+`
+
+var callsBackupXML = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<calls count="2">
+  <call number="+15551234567" duration="62" date="1700000500000" type="2" presentation="1" contact_name="Alice Smith" />
+  <call number="(555) 123-4567" duration="0" date="1700000600000" type="3" presentation="1" contact_name="Alice Smith" />
+</calls>
+`
+
+func TestImportSMSBackup(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	backupDir := filepath.Join(tmpDir, "SMSBackupRestore")
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "sms-20231116.xml"), []byte(smsBackupXML), 0o644); err != nil {
+		t.Fatalf("write sms: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "calls-20231116.xml"), []byte(callsBackupXML), 0o644); err != nil {
+		t.Fatalf("write calls: %v", err)
+	}
+	mediaDir := filepath.Join(tmpDir, "media")
+
+	res, err := ImportSMSBackup(context.Background(), d, SMSBackupImportOptions{Path: backupDir, MediaDir: mediaDir})
+	if err != nil {
+		t.Fatalf("ImportSMSBackup: %v", err)
+	}
+	if res.Files != 2 || res.SMSSeen != 3 || res.MMSSeen != 2 || res.CallsSeen != 2 || res.EventsCreated != 7 || res.AttachmentsCreated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// Alice, AMZN2FA and the group (my number is detected from the sent MMS).
+	if res.ThreadsCreated != 3 {
+		t.Fatalf("expected 3 threads, got %d", res.ThreadsCreated)
+	}
+
+	// Both spellings of Alice's number land in the same 1:1 thread, calls included.
+	var aliceEvents int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM events WHERE thread_id = 'android-sms:5551234567'`).Scan(&aliceEvents); err != nil || aliceEvents != 4 {
+		t.Fatalf("expected 4 events in alice's thread, got %d (%v)", aliceEvents, err)
+	}
+
+	var isGroup int
+	if err := d.QueryRow(`SELECT is_group FROM threads WHERE id = 'android-sms:5551234567,5559876543'`).Scan(&isGroup); err != nil || isGroup != 1 {
+		t.Fatalf("expected group thread, got is_group=%d (%v)", isGroup, err)
+	}
+
+	var content, contentTypes, direction string
+	if err := d.QueryRow(`SELECT content, content_types, direction FROM events WHERE id = 'android-sms:mms:mid-1'`).Scan(&content, &contentTypes, &direction); err != nil {
+		t.Fatalf("query mms: %v", err)
+	}
+	if content != "group photo" || contentTypes != `["text","attachment"]` || direction != "received" {
+		t.Fatalf("unexpected mms content=%q types=%s direction=%q", content, contentTypes, direction)
+	}
+	var sentDirection string
+	if err := d.QueryRow(`SELECT direction FROM events WHERE id = 'android-sms:mms:mid-2'`).Scan(&sentDirection); err != nil || sentDirection != "sent" {
+		t.Fatalf("expected sent mms, got %q (%v)", sentDirection, err)
+	}
+
+	var storageURI, mediaType, hash string
+	if err := d.QueryRow(`SELECT storage_uri, media_type, content_hash FROM attachments WHERE event_id = 'android-sms:mms:mid-1'`).Scan(&storageURI, &mediaType, &hash); err != nil {
+		t.Fatalf("query attachment: %v", err)
+	}
+	if mediaType != "image" || hash == "" {
+		t.Fatalf("unexpected attachment media=%q hash=%q", mediaType, hash)
+	}
+	if data, err := os.ReadFile(storageURI); err != nil || string(data) != "\xff\xd8\xffjpeg" {
+		t.Fatalf("expected mms part written to %s (%v)", storageURI, err)
+	}
+
+	var readState, status string
+	if err := d.QueryRow(`
+		SELECT s.read_state, s.status FROM event_state s JOIN events e ON e.id = s.event_id
+		WHERE e.content = 'Your code is 1234'
+	`).Scan(&readState, &status); err != nil {
+		t.Fatalf("query state: %v", err)
+	}
+	if readState != "unread" || status != "received" {
+		t.Fatalf("unexpected state read=%q status=%q", readState, status)
+	}
+
+	var callContent, callMeta, callChannel string
+	if err := d.QueryRow(`
+		SELECT content, metadata_json, channel FROM events
+		WHERE content_types = '["call"]' AND direction = 'received'
+	`).Scan(&callContent, &callMeta, &callChannel); err != nil {
+		t.Fatalf("query call: %v", err)
+	}
+	if callContent != "Missed call" || callMeta != `{"call_type":"missed","duration_seconds":0}` || callChannel != "sms" {
+		t.Fatalf("unexpected call content=%q metadata=%s channel=%q", callContent, callMeta, callChannel)
+	}
+
+	// Alphanumeric sender ids stay handles even when they contain digits.
+	var senderType string
+	if err := d.QueryRow(`SELECT type FROM contact_identifiers WHERE normalized = '@amzn2fa'`).Scan(&senderType); err != nil || senderType != "handle" {
+		t.Fatalf("expected AMZN2FA as a handle, got %q (%v)", senderType, err)
+	}
+
+	// Alice's contact is keyed by her normalized phone, so it merges with
+	// any other source that knows the same number.
+	var aliceContacts int
+	if err := d.QueryRow(`SELECT COUNT(DISTINCT contact_id) FROM contact_identifiers WHERE type = 'phone' AND normalized = '5551234567'`).Scan(&aliceContacts); err != nil || aliceContacts != 1 {
+		t.Fatalf("expected one contact for alice's number, got %d (%v)", aliceContacts, err)
+	}
+
+	// Re-import is idempotent.
+	res, err = ImportSMSBackup(context.Background(), d, SMSBackupImportOptions{Path: backupDir, MediaDir: mediaDir})
+	if err != nil {
+		t.Fatalf("second ImportSMSBackup: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.AttachmentsCreated != 0 || res.ThreadsCreated != 0 {
+		t.Fatalf("expected no changes on re-import, got %+v", res)
+	}
+}
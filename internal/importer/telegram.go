@@ -0,0 +1,736 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+)
+
+type TelegramImportOptions struct {
+	AdapterName   string // defaults to "telegram"
+	Path          string // result.json, the export directory, or a zip of it
+	Me            string // your Telegram user id; read from personal_information when present
+	LimitMessages int    // 0 = no limit
+	DryRun        bool
+}
+
+type TelegramImportResult struct {
+	Chats              int
+	MessagesSeen       int
+	ServiceMessages    int
+	EventsCreated      int
+	EventsUpdated      int
+	PersonsCreated     int
+	ThreadsCreated     int
+	ThreadsUpdated     int
+	AttachmentsCreated int
+	ReactionsCreated   int
+	Duration           time.Duration
+}
+
+func (o TelegramImportOptions) withDefaults() TelegramImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "telegram"
+	}
+	return o
+}
+
+// telegramExport covers both export shapes: a full account export
+// ("chats.list") and a single-chat export (the chat object at the top level).
+type telegramExport struct {
+	PersonalInformation *struct {
+		UserID      json.Number `json:"user_id"`
+		FirstName   string      `json:"first_name"`
+		LastName    string      `json:"last_name"`
+		PhoneNumber string      `json:"phone_number"`
+		Username    string      `json:"username"`
+	} `json:"personal_information"`
+	Contacts *struct {
+		List []struct {
+			UserID      json.Number `json:"user_id"`
+			FirstName   string      `json:"first_name"`
+			LastName    string      `json:"last_name"`
+			PhoneNumber string      `json:"phone_number"`
+		} `json:"list"`
+	} `json:"contacts"`
+	Chats *struct {
+		List []telegramChat `json:"list"`
+	} `json:"chats"`
+	telegramChat
+}
+
+type telegramChat struct {
+	ID       json.Number       `json:"id"`
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Messages []telegramMessage `json:"messages"`
+}
+
+type telegramMessage struct {
+	ID               json.Number     `json:"id"`
+	Type             string          `json:"type"`
+	Date             string          `json:"date"`
+	DateUnix         string          `json:"date_unixtime"`
+	Edited           string          `json:"edited"`
+	EditedUnix       string          `json:"edited_unixtime"`
+	From             *string         `json:"from"`
+	FromID           string          `json:"from_id"`
+	Actor            *string         `json:"actor"`
+	ActorID          string          `json:"actor_id"`
+	Action           string          `json:"action"`
+	Members          []*string       `json:"members"`
+	Title            string          `json:"title"`
+	ForwardedFrom    *string         `json:"forwarded_from"`
+	SavedFrom        string          `json:"saved_from"`
+	ReplyToMessageID json.Number     `json:"reply_to_message_id"`
+	Text             json.RawMessage `json:"text"`
+	Photo            string          `json:"photo"`
+	File             string          `json:"file"`
+	FileName         string          `json:"file_name"`
+	MimeType         string          `json:"mime_type"`
+	MediaType        string          `json:"media_type"`
+	StickerEmoji     string          `json:"sticker_emoji"`
+	DurationSeconds  int64           `json:"duration_seconds"`
+	DiscardReason    string          `json:"discard_reason"`
+	Reactions        []struct {
+		Type   string `json:"type"`
+		Count  int    `json:"count"`
+		Emoji  string `json:"emoji"`
+		Recent []struct {
+			From   string `json:"from"`
+			FromID string `json:"from_id"`
+			Date   string `json:"date"`
+		} `json:"recent"`
+	} `json:"reactions"`
+}
+
+// ImportTelegram imports a Telegram Desktop "Export chat history" result.json.
+// Chats become threads, messages become events keyed by chat and message id,
+// and Telegram user ids become handle identifiers on contacts.
+func ImportTelegram(ctx context.Context, db *sql.DB, opts TelegramImportOptions) (TelegramImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out TelegramImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	// Accept result.json itself, the export directory, or a zip of it.
+	archivePath, root, resultName := opts.Path, "", "result.json"
+	if strings.EqualFold(filepath.Ext(opts.Path), ".json") {
+		archivePath, root, resultName = filepath.Dir(opts.Path), ".", filepath.Base(opts.Path)
+	}
+	archive, err := openArchive(archivePath)
+	if err != nil {
+		return out, err
+	}
+	defer archive.Close()
+	if root == "" {
+		if root, err = findArchiveRoot(archive, resultName); err != nil {
+			return out, fmt.Errorf("not a Telegram export: %w", err)
+		}
+	}
+	b, err := fs.ReadFile(archive, path.Join(root, resultName))
+	if err != nil {
+		return out, err
+	}
+	var export telegramExport
+	if err := json.Unmarshal(b, &export); err != nil {
+		return out, fmt.Errorf("parse %s: %w", resultName, err)
+	}
+
+	var chats []telegramChat
+	if export.Chats != nil {
+		chats = export.Chats.List
+	} else if export.telegramChat.ID != "" {
+		chats = []telegramChat{export.telegramChat}
+	}
+
+	base := telegramBase{
+		archive: archive,
+		root:    root,
+		me:      telegramUserID(opts.Me),
+		names:   map[string]string{},
+		phones:  map[string]string{},
+	}
+	if pi := export.PersonalInformation; pi != nil && pi.UserID != "" {
+		base.me = "user" + pi.UserID.String()
+		base.meName = strings.TrimSpace(pi.FirstName + " " + pi.LastName)
+		base.meUsername = pi.Username
+		base.phones[base.me] = pi.PhoneNumber
+	}
+	if export.Contacts != nil {
+		for _, c := range export.Contacts.List {
+			if c.UserID == "" || c.UserID.String() == "0" {
+				continue
+			}
+			id := "user" + c.UserID.String()
+			base.names[id] = strings.TrimSpace(c.FirstName + " " + c.LastName)
+			base.phones[id] = c.PhoneNumber
+		}
+	}
+	// Service messages name members without ids, so learn name -> id from
+	// every attributed message first.
+	base.idsByName = map[string]string{}
+	for _, c := range chats {
+		for _, m := range c.Messages {
+			if m.From != nil && m.FromID != "" {
+				base.idsByName[*m.From] = m.FromID
+				if base.names[m.FromID] == "" {
+					base.names[m.FromID] = *m.From
+				}
+			}
+		}
+	}
+	if base.me != "" {
+		if p, err := me.GetMePerson(db); err == nil && p != nil {
+			base.mePersonID = p.ID
+		}
+	}
+
+	for _, c := range chats {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if opts.LimitMessages > 0 && out.MessagesSeen+out.ServiceMessages >= opts.LimitMessages {
+			break
+		}
+		if c.ID == "" {
+			continue
+		}
+		stats, err := importTelegramChat(ctx, db, &base, c, opts, &out)
+		if err != nil {
+			return out, fmt.Errorf("chat %s: %w", c.ID, err)
+		}
+		out.Chats++
+		out.EventsCreated += stats.EventsCreated
+		out.EventsUpdated += stats.EventsUpdated
+		out.PersonsCreated += stats.PersonsCreated
+		out.ThreadsCreated += stats.ThreadsCreated
+		out.ThreadsUpdated += stats.ThreadsUpdated
+		out.AttachmentsCreated += stats.AttachmentsCreated
+		out.ReactionsCreated += stats.ReactionsCreated
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// telegramBase is the export-wide context shared across chats.
+type telegramBase struct {
+	archive    *exportArchive
+	root       string
+	me         string
+	meName     string
+	meUsername string
+	mePersonID string
+	names      map[string]string // from_id -> display name
+	phones     map[string]string // from_id -> phone (address book entries)
+	idsByName  map[string]string // display name -> from_id
+}
+
+func importTelegramChat(ctx context.Context, db *sql.DB, base *telegramBase, c telegramChat, opts TelegramImportOptions, out *TelegramImportResult) (ingest.Stats, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ingest.Stats{}, fmt.Errorf("failed to begin import tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, err := ingest.NewWriter(tx, opts.AdapterName)
+	if err != nil {
+		return ingest.Stats{}, err
+	}
+	defer w.Close()
+
+	s := &telegramSession{telegramBase: base, w: w, chat: c, contacts: map[string]string{}}
+	s.isGroup = c.Type != "personal_chat" && c.Type != "saved_messages" && c.Type != "bot_chat"
+
+	name := c.Name
+	if c.Type == "saved_messages" && name == "" {
+		name = "Saved Messages"
+	}
+	if s.threadID, err = w.UpsertThread(ingest.Thread{
+		SourceID: c.ID.String(),
+		Channel:  "telegram",
+		Name:     name,
+		IsGroup:  s.isGroup,
+	}); err != nil {
+		return w.Stats, err
+	}
+
+	for _, m := range c.Messages {
+		if opts.LimitMessages > 0 && out.MessagesSeen+out.ServiceMessages >= opts.LimitMessages {
+			break
+		}
+		switch m.Type {
+		case "message":
+			out.MessagesSeen++
+			err = s.importMessage(m)
+		case "service":
+			out.ServiceMessages++
+			err = s.importService(m)
+		default:
+			continue
+		}
+		if err != nil {
+			return w.Stats, fmt.Errorf("message %s: %w", m.ID, err)
+		}
+	}
+
+	if opts.DryRun {
+		return w.Stats, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return w.Stats, fmt.Errorf("commit import tx: %w", err)
+	}
+	return w.Stats, nil
+}
+
+type telegramSession struct {
+	*telegramBase
+	w        *ingest.Writer
+	chat     telegramChat
+	threadID string
+	isGroup  bool
+
+	contacts map[string]string // from_id -> contact id
+}
+
+// contact resolves a Telegram peer id ("user123", "channel456") to a contact
+// keyed by a handle identifier; address-book phones and the account's own
+// username are attached as extra identifiers.
+func (s *telegramSession) contact(fromID, name string) (string, error) {
+	if fromID == "" {
+		if name == "" {
+			return "", fmt.Errorf("message has neither from_id nor name")
+		}
+		if id := s.idsByName[name]; id != "" {
+			fromID = id
+		} else {
+			return s.w.Contact("human", "telegram:"+name, name)
+		}
+	}
+	if id, ok := s.contacts[fromID]; ok {
+		return id, nil
+	}
+	if name == "" {
+		name = s.names[fromID]
+	}
+	if fromID == s.me && s.meName != "" {
+		name = s.meName
+	}
+
+	if fromID == s.me && s.mePersonID != "" {
+		contactID, _, err := contacts.GetOrCreateContact(s.w.Tx(), "handle", fromID, name, s.w.Adapter())
+		if err != nil {
+			return "", err
+		}
+		if err := contacts.EnsurePersonContactLink(s.w.Tx(), s.mePersonID, contactID, "deterministic", 1.0); err != nil {
+			return "", err
+		}
+	}
+	contactID, err := s.w.Contact("handle", fromID, name)
+	if err != nil {
+		return "", err
+	}
+	if phone := s.phones[fromID]; phone != "" {
+		if err := s.w.LinkIdentifier(contactID, "phone", phone); err != nil {
+			return "", err
+		}
+	}
+	if fromID == s.me && s.meUsername != "" {
+		if err := s.w.LinkIdentifier(contactID, "handle", s.meUsername); err != nil {
+			return "", err
+		}
+	}
+	s.contacts[fromID] = contactID
+	return contactID, nil
+}
+
+func (s *telegramSession) direction(fromID string) string {
+	if s.me != "" && fromID == s.me {
+		return "sent"
+	}
+	return "received"
+}
+
+func (s *telegramSession) sourceID(messageID json.Number) string {
+	return s.chat.ID.String() + ":" + messageID.String()
+}
+
+func (s *telegramSession) importMessage(m telegramMessage) error {
+	ts := telegramTime(m.DateUnix, m.Date)
+	content := telegramText(m.Text)
+	metadata := map[string]any{}
+	if m.Edited != "" || m.EditedUnix != "" {
+		metadata["edited_at"] = telegramTime(m.EditedUnix, m.Edited)
+	}
+	if m.ForwardedFrom != nil {
+		metadata["forwarded"] = true
+		if *m.ForwardedFrom != "" {
+			metadata["forwarded_from"] = *m.ForwardedFrom
+		}
+	}
+	if m.SavedFrom != "" {
+		metadata["saved_from"] = m.SavedFrom
+	}
+	if m.MediaType != "" {
+		metadata["media_type"] = m.MediaType
+	}
+	if m.StickerEmoji != "" {
+		metadata["sticker_emoji"] = m.StickerEmoji
+		if content == "" {
+			content = m.StickerEmoji
+		}
+	}
+	if m.DurationSeconds > 0 {
+		metadata["duration_seconds"] = m.DurationSeconds
+	}
+
+	media := m.Photo
+	if media == "" {
+		media = m.File
+	}
+	if strings.HasPrefix(media, "(") {
+		// "(File not included. Change data exporting settings to download.)"
+		metadata["media_not_included"] = true
+		media = ""
+	}
+	contentTypes := []string{"text"}
+	if media != "" || metadata["media_not_included"] == true {
+		if content == "" {
+			contentTypes = []string{"attachment"}
+		} else {
+			contentTypes = []string{"text", "attachment"}
+		}
+	}
+
+	replyTo := ""
+	if m.ReplyToMessageID != "" {
+		replyTo = s.w.ID(s.sourceID(m.ReplyToMessageID))
+	}
+
+	fromName := ""
+	if m.From != nil {
+		fromName = *m.From
+	}
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     s.sourceID(m.ID),
+		Timestamp:    ts,
+		Channel:      "telegram",
+		ContentTypes: contentTypes,
+		Content:      content,
+		Direction:    s.direction(m.FromID),
+		ThreadID:     s.threadID,
+		ReplyTo:      replyTo,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	if m.FromID != "" || fromName != "" {
+		senderID, err := s.contact(m.FromID, fromName)
+		if err != nil {
+			return err
+		}
+		if err := s.w.AddParticipant(eventID, senderID, "sender"); err != nil {
+			return err
+		}
+	}
+	// In a personal chat the chat id is the other user's id.
+	if !s.isGroup && s.chat.Type == "personal_chat" && s.me != "" {
+		other := "user" + s.chat.ID.String()
+		recipient := other
+		if m.FromID == other {
+			recipient = s.me
+		}
+		if recipient != m.FromID {
+			contactID, err := s.contact(recipient, "")
+			if err != nil {
+				return err
+			}
+			if err := s.w.AddParticipant(eventID, contactID, "recipient"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if media != "" {
+		if err := s.importMedia(eventID, m, media, ts); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range m.Reactions {
+		emoji := r.Emoji
+		if emoji == "" {
+			emoji = ":" + r.Type + ":"
+		}
+		for _, recent := range r.Recent {
+			if recent.FromID == "" && recent.From == "" {
+				continue
+			}
+			reactor := recent.FromID
+			if reactor == "" {
+				reactor = recent.From
+			}
+			reactionTS := ts
+			if recent.Date != "" {
+				reactionTS = telegramTime("", recent.Date)
+			}
+			reactionID, _, err := s.w.UpsertReaction(ingest.Event{
+				SourceID:     fmt.Sprintf("%s:reaction:%s:%s", s.sourceID(m.ID), reactor, emoji),
+				Timestamp:    reactionTS,
+				Channel:      "telegram",
+				ContentTypes: []string{"reaction"},
+				Content:      emoji,
+				Direction:    s.direction(recent.FromID),
+				ThreadID:     s.threadID,
+				ReplyTo:      eventID,
+			})
+			if err != nil {
+				return err
+			}
+			contactID, err := s.contact(recent.FromID, recent.From)
+			if err != nil {
+				return err
+			}
+			if err := s.w.AddParticipant(reactionID, contactID, "sender"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *telegramSession) importMedia(eventID string, m telegramMessage, rel string, ts int64) error {
+	member := path.Join(s.root, filepath.ToSlash(rel))
+	kind := "file"
+	if m.Photo != "" {
+		kind = "photo"
+	}
+	mimeType := m.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(strings.ToLower(path.Ext(rel)))
+	}
+	mediaType := ""
+	switch m.MediaType {
+	case "voice_message", "audio_file":
+		mediaType = "audio"
+	case "video_file", "video_message", "animation":
+		mediaType = "video"
+	case "sticker":
+		mediaType = "image"
+	}
+	if m.Photo != "" {
+		mediaType = "image"
+	}
+	filename := m.FileName
+	if filename == "" {
+		filename = path.Base(rel)
+	}
+
+	a := ingest.Attachment{
+		SourceID:  s.sourceID(m.ID) + ":" + kind,
+		EventID:   eventID,
+		Filename:  filename,
+		MimeType:  mimeType,
+		MediaType: mediaType,
+		CreatedAt: ts,
+	}
+	if size, hash, err := s.archive.hashFile(member); err == nil {
+		a.SizeBytes, a.ContentHash = size, hash
+		a.StorageType, a.StorageURI = s.archive.storage(member)
+	}
+	_, err := s.w.UpsertAttachment(a)
+	return err
+}
+
+// importService maps service messages: member changes become membership
+// events shaped like Eve's, phone calls become call events; the rest (pins,
+// title and photo changes) are skipped.
+func (s *telegramSession) importService(m telegramMessage) error {
+	ts := telegramTime(m.DateUnix, m.Date)
+	actorName := ""
+	if m.Actor != nil {
+		actorName = *m.Actor
+	}
+
+	switch m.Action {
+	case "phone_call", "group_call":
+		direction := s.direction(m.ActorID)
+		callType := "incoming"
+		switch {
+		case m.DiscardReason == "missed":
+			callType = "missed"
+		case direction == "sent":
+			callType = "outgoing"
+		}
+		metadata := map[string]any{"action": m.Action, "call_type": callType}
+		if m.DurationSeconds > 0 {
+			metadata["duration_seconds"] = m.DurationSeconds
+		}
+		if m.DiscardReason != "" {
+			metadata["discard_reason"] = m.DiscardReason
+		}
+		eventID, _, err := s.w.UpsertEvent(ingest.Event{
+			SourceID:     s.sourceID(m.ID),
+			Timestamp:    ts,
+			Channel:      "telegram",
+			ContentTypes: []string{"call"},
+			Content:      callSummary(callType, m.DurationSeconds),
+			Direction:    direction,
+			ThreadID:     s.threadID,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			return err
+		}
+		if m.ActorID == "" && actorName == "" {
+			return nil
+		}
+		actorID, err := s.contact(m.ActorID, actorName)
+		if err != nil {
+			return err
+		}
+		return s.w.AddParticipant(eventID, actorID, "sender")
+	}
+
+	var action string
+	var members []string
+	switch m.Action {
+	case "invite_members", "create_group":
+		action = "added"
+	case "remove_members":
+		action = "removed"
+	case "join_group_by_link", "join_group_by_request":
+		action = "added"
+		members = []string{actorName}
+	default:
+		return nil
+	}
+	for _, name := range m.Members {
+		if name != nil && *name != "" {
+			members = append(members, *name)
+		}
+	}
+
+	for i, member := range members {
+		metadata := map[string]any{"action": action, "telegram_action": m.Action, "member": member}
+		if actorName != "" {
+			metadata["actor"] = actorName
+		}
+		if m.Title != "" {
+			metadata["group_title"] = m.Title
+		}
+		sourceID := s.sourceID(m.ID)
+		if len(members) > 1 {
+			sourceID += ":" + strconv.Itoa(i)
+		}
+		eventID, _, err := s.w.UpsertEvent(ingest.Event{
+			SourceID:     sourceID,
+			Timestamp:    ts,
+			Channel:      "telegram",
+			ContentTypes: []string{"membership"},
+			Content:      action,
+			Direction:    s.direction(m.ActorID),
+			ThreadID:     s.threadID,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			return err
+		}
+		memberID, err := s.contact(s.idsByName[member], member)
+		if err != nil {
+			return err
+		}
+		if err := s.w.AddParticipant(eventID, memberID, "member"); err != nil {
+			return err
+		}
+		if m.ActorID == "" && actorName == "" {
+			continue
+		}
+		actorID, err := s.contact(m.ActorID, actorName)
+		if err != nil {
+			return err
+		}
+		if err := s.w.AddParticipant(eventID, actorID, "sender"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// telegramText flattens "text", which is a string or an array mixing strings
+// and entity objects ({"type":"link","text":"..."}).
+func telegramText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		var str string
+		if err := json.Unmarshal(p, &str); err == nil {
+			b.WriteString(str)
+			continue
+		}
+		var entity struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(p, &entity); err == nil {
+			b.WriteString(entity.Text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// telegramTime prefers the *_unixtime field (newer exports) and falls back to
+// the local-time ISO string older exports carry.
+func telegramTime(unix, local string) int64 {
+	if unix != "" {
+		if n, err := strconv.ParseInt(unix, 10, 64); err == nil {
+			return n
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", local, time.Local); err == nil {
+		return t.Unix()
+	}
+	return 0
+}
+
+// telegramUserID accepts "123" or "user123" and returns the from_id form.
+func telegramUserID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.HasPrefix(id, "user") {
+		return id
+	}
+	return "user" + id
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const telegramResultJSON = `{
+ "personal_information": {"user_id": 100, "first_name": "Tyler", "last_name": "Brandt", "phone_number": "+1 555 000 1111", "username": "@tyler"},
+ "contacts": {"list": [{"user_id": 200, "first_name": "Alice", "last_name": "Smith", "phone_number": "+1 (555) 123-4567"}]},
+ "chats": {"list": [
+  {"name": "Alice Smith", "type": "personal_chat", "id": 200, "messages": [
+   {"id": 1, "type": "message", "date": "2023-11-14T22:13:20", "date_unixtime": "1700000000", "from": "Alice Smith", "from_id": "user200",
+    "text": ["see ", {"type": "link", "text": "https://example.com"}, " ok?"],
+    "reactions": [{"type": "emoji", "count": 1, "emoji": "👍", "recent": [{"from": "Tyler Brandt", "from_id": "user100", "date": "2023-11-14T22:14:00"}]}]},
+   {"id": 2, "type": "message", "date_unixtime": "1700000100", "edited_unixtime": "1700000200", "edited": "2023-11-14T22:16:40",
+    "from": "Tyler Brandt", "from_id": "user100", "reply_to_message_id": 1, "text": "yes"},
+   {"id": 3, "type": "message", "date_unixtime": "1700000300", "from": "Alice Smith", "from_id": "user200",
+    "forwarded_from": "News Channel", "photo": "photos/photo_1.jpg", "width": 10, "height": 10, "text": ""},
+   {"id": 4, "type": "message", "date_unixtime": "1700000400", "from": "Alice Smith", "from_id": "user200",
+    "file": "(File not included. Change data exporting settings to download.)", "media_type": "voice_message", "mime_type": "audio/ogg", "duration_seconds": 5, "text": ""},
+   {"id": 5, "type": "service", "date_unixtime": "1700000500", "actor": "Tyler Brandt", "actor_id": "user100", "action": "phone_call", "duration_seconds": 62, "text": ""}
+  ]},
+  {"name": "Book Club", "type": "private_supergroup", "id": 300, "messages": [
+   {"id": 10, "type": "service", "date_unixtime": "1700001000", "actor": "Tyler Brandt", "actor_id": "user100", "action": "invite_members", "members": ["Alice Smith", "Carol"], "text": ""},
+   {"id": 11, "type": "message", "date_unixtime": "1700001100", "from": "Carol", "from_id": "user400", "text": "hi all"}
+  ]}
+ ]}
+}`
+
+func TestImportTelegram(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	exportDir := filepath.Join(tmpDir, "DataExport_2023-11-20")
+	if err := os.MkdirAll(filepath.Join(exportDir, "photos"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(exportDir, "result.json"), []byte(telegramResultJSON), 0o644); err != nil {
+		t.Fatalf("write result.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(exportDir, "photos", "photo_1.jpg"), []byte("\xff\xd8\xff"), 0o644); err != nil {
+		t.Fatalf("write photo: %v", err)
+	}
+
+	res, err := ImportTelegram(context.Background(), d, TelegramImportOptions{Path: exportDir})
+	if err != nil {
+		t.Fatalf("ImportTelegram: %v", err)
+	}
+	// 5 messages + call + 2 membership events; the reaction is counted separately.
+	if res.Chats != 2 || res.EventsCreated != 8 || res.ReactionsCreated != 1 || res.AttachmentsCreated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	var replyTo, direction, metadata string
+	if err := d.QueryRow(`SELECT reply_to, direction, metadata_json FROM events WHERE id = 'telegram:200:2'`).Scan(&replyTo, &direction, &metadata); err != nil {
+		t.Fatalf("query reply: %v", err)
+	}
+	if replyTo != "telegram:200:1" || direction != "sent" || metadata != `{"edited_at":1700000200}` {
+		t.Fatalf("unexpected reply_to=%q direction=%q metadata=%s", replyTo, direction, metadata)
+	}
+
+	var content string
+	if err := d.QueryRow(`SELECT content FROM events WHERE id = 'telegram:200:1'`).Scan(&content); err != nil || content != "see https://example.com ok?" {
+		t.Fatalf("expected flattened text, got %q (%v)", content, err)
+	}
+
+	var fwdMeta string
+	if err := d.QueryRow(`SELECT metadata_json FROM events WHERE id = 'telegram:200:3'`).Scan(&fwdMeta); err != nil {
+		t.Fatalf("query forwarded: %v", err)
+	}
+	var fwd map[string]any
+	_ = json.Unmarshal([]byte(fwdMeta), &fwd)
+	if fwd["forwarded"] != true || fwd["forwarded_from"] != "News Channel" {
+		t.Fatalf("expected forwarded metadata, got %s", fwdMeta)
+	}
+
+	var storageType, mediaType string
+	if err := d.QueryRow(`SELECT storage_type, media_type FROM attachments WHERE event_id = 'telegram:200:3'`).Scan(&storageType, &mediaType); err != nil {
+		t.Fatalf("query photo: %v", err)
+	}
+	if storageType != "local" || mediaType != "image" {
+		t.Fatalf("unexpected photo attachment storage=%q media=%q", storageType, mediaType)
+	}
+
+	var callTypes string
+	if err := d.QueryRow(`SELECT content_types FROM events WHERE id = 'telegram:200:5'`).Scan(&callTypes); err != nil || callTypes != `["call"]` {
+		t.Fatalf("expected call event, got %q (%v)", callTypes, err)
+	}
+
+	// Alice is keyed by her user id handle and carries her address-book phone.
+	var aliceIdentifiers int
+	if err := d.QueryRow(`
+		SELECT COUNT(*) FROM contact_identifiers h
+		JOIN contact_identifiers p ON p.contact_id = h.contact_id
+		WHERE h.type = 'handle' AND h.normalized = '@user200' AND p.type = 'phone' AND p.normalized = '5551234567'
+	`).Scan(&aliceIdentifiers); err != nil || aliceIdentifiers != 1 {
+		t.Fatalf("expected alice's phone linked to her handle contact, got %d (%v)", aliceIdentifiers, err)
+	}
+
+	// Membership events resolve member names to ids seen elsewhere in the export.
+	var carolMember int
+	if err := d.QueryRow(`
+		SELECT COUNT(*) FROM events e
+		JOIN event_participants ep ON ep.event_id = e.id AND ep.role = 'member'
+		JOIN contact_identifiers ci ON ci.contact_id = ep.contact_id
+		WHERE e.content_types = '["membership"]' AND e.content = 'added' AND e.direction = 'sent'
+		  AND ci.type = 'handle' AND ci.normalized = '@user400'
+	`).Scan(&carolMember); err != nil || carolMember != 1 {
+		t.Fatalf("expected Carol's membership event, got %d (%v)", carolMember, err)
+	}
+
+	// Re-import is idempotent.
+	res, err = ImportTelegram(context.Background(), d, TelegramImportOptions{Path: filepath.Join(exportDir, "result.json")})
+	if err != nil {
+		t.Fatalf("second ImportTelegram: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.ReactionsCreated != 0 || res.AttachmentsCreated != 0 {
+		t.Fatalf("expected no changes on re-import, got %+v", res)
+	}
+}
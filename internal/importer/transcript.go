@@ -0,0 +1,405 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+	"github.com/Napageneral/mnemonic/internal/transcript"
+)
+
+type TranscriptImportOptions struct {
+	AdapterName string    // defaults to "transcript"
+	Path        string    // .vtt, .srt or speaker-labelled .txt
+	Title       string    // thread name; defaults to the linked calendar event or the file name
+	Start       time.Time // when the recording began; defaults to the file's mtime minus its length
+	Me          string    // the speaker label that is you, when it differs from your name
+	DryRun      bool
+}
+
+type TranscriptImportResult struct {
+	Format          string
+	Utterances      int
+	Speakers        int
+	EventsCreated   int
+	EventsUpdated   int
+	PersonsCreated  int
+	ThreadID        string
+	CalendarEventID string // the overlapping calendar event the meeting was linked to
+	Duration        time.Duration
+}
+
+func (o TranscriptImportOptions) withDefaults() TranscriptImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "transcript"
+	}
+	return o
+}
+
+// meetingCalendarEvent is a calendar event that overlaps a transcript.
+type meetingCalendarEvent struct {
+	ID        string
+	Summary   string
+	Start     int64
+	End       int64
+	Attendees []meetingAttendee
+}
+
+type meetingAttendee struct {
+	ContactID string
+	Names     []string
+}
+
+// ImportTranscript imports a meeting transcript as a "meeting" thread with
+// one event per utterance. Speakers resolve to contacts, preferring the
+// attendees of an overlapping calendar event, then persons with the same
+// name. The meeting is linked to the calendar event that overlaps it in time
+// and shares the most speakers with its attendee list.
+func ImportTranscript(ctx context.Context, db *sql.DB, opts TranscriptImportOptions) (TranscriptImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out TranscriptImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	data, err := os.ReadFile(opts.Path)
+	if err != nil {
+		return out, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	utterances, format, err := transcript.Parse(bytes.NewReader(data))
+	if err != nil {
+		return out, fmt.Errorf("parse transcript: %w", err)
+	}
+	if len(utterances) == 0 {
+		return out, fmt.Errorf("no utterances found in %s", opts.Path)
+	}
+	out.Format = string(format)
+
+	length := utterances[len(utterances)-1].End
+	for _, u := range utterances {
+		if u.End > length {
+			length = u.End
+		}
+	}
+	meetingStart := opts.Start
+	if meetingStart.IsZero() {
+		// Recorders write the transcript when the meeting ends.
+		info, err := os.Stat(opts.Path)
+		if err != nil {
+			return out, err
+		}
+		meetingStart = info.ModTime().Add(-length)
+	}
+	meetingEnd := meetingStart.Add(length)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	var speakers []string
+	seen := map[string]bool{}
+	for _, u := range utterances {
+		if u.Speaker != "" && !seen[u.Speaker] {
+			seen[u.Speaker] = true
+			speakers = append(speakers, u.Speaker)
+		}
+	}
+	out.Speakers = len(speakers)
+
+	cal, err := findMeetingCalendarEvent(ctx, db, meetingStart.Unix(), meetingEnd.Unix(), speakers)
+	if err != nil {
+		return out, err
+	}
+	if cal != nil {
+		out.CalendarEventID = cal.ID
+	}
+
+	var meName string
+	var mePersonID string
+	if p, err := me.GetMePerson(db); err == nil && p != nil {
+		mePersonID = p.ID
+		meName = p.CanonicalName
+		if p.DisplayName != nil && strings.TrimSpace(*p.DisplayName) != "" {
+			meName = *p.DisplayName
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return out, err
+	}
+	defer tx.Rollback()
+	w, err := ingest.NewWriter(tx, opts.AdapterName)
+	if err != nil {
+		return out, err
+	}
+	defer w.Close()
+
+	sum := sha256.Sum256(data)
+	meetingKey := hex.EncodeToString(sum[:8])
+	name := strings.TrimSpace(opts.Title)
+	if name == "" && cal != nil {
+		name = cal.Summary
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(opts.Path), filepath.Ext(opts.Path))
+	}
+	threadID, err := w.UpsertThread(ingest.Thread{
+		SourceID: "meeting:" + meetingKey,
+		Channel:  "meeting",
+		Name:     name,
+		IsGroup:  len(speakers) > 2,
+	})
+	if err != nil {
+		return out, err
+	}
+	out.ThreadID = threadID
+
+	speakerContacts := map[string]string{}
+	isMe := map[string]bool{}
+	for _, speaker := range speakers {
+		if (opts.Me != "" && strings.EqualFold(speaker, opts.Me)) || (meName != "" && speakerMatchesName(speaker, meName)) {
+			isMe[speaker] = true
+		}
+		contactID, err := resolveSpeaker(w, speaker, isMe[speaker], mePersonID, cal)
+		if err != nil {
+			return out, fmt.Errorf("resolve speaker %q: %w", speaker, err)
+		}
+		speakerContacts[speaker] = contactID
+	}
+
+	for i, u := range utterances {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		direction := "observed"
+		switch {
+		case isMe[u.Speaker]:
+			direction = "sent"
+		case u.Speaker != "":
+			direction = "received"
+		}
+		metadata := map[string]any{
+			"offset_ms":   u.Start.Milliseconds(),
+			"duration_ms": (u.End - u.Start).Milliseconds(),
+		}
+		if u.Speaker != "" {
+			metadata["speaker"] = u.Speaker
+		}
+		if cal != nil {
+			metadata["calendar_event_id"] = cal.ID
+		}
+		eventID, _, err := w.UpsertEvent(ingest.Event{
+			SourceID:  fmt.Sprintf("%s:%d", meetingKey, i),
+			Timestamp: meetingStart.Add(u.Start).Unix(),
+			Channel:   "meeting",
+			Content:   u.Text,
+			Direction: direction,
+			ThreadID:  threadID,
+			Metadata:  metadata,
+		})
+		if err != nil {
+			return out, err
+		}
+		if contactID := speakerContacts[u.Speaker]; contactID != "" {
+			if err := w.AddParticipant(eventID, contactID, "sender"); err != nil {
+				return out, err
+			}
+		}
+		out.Utterances++
+	}
+
+	out.EventsCreated = w.Stats.EventsCreated
+	out.EventsUpdated = w.Stats.EventsUpdated
+	out.PersonsCreated = w.Stats.PersonsCreated
+	if opts.DryRun {
+		_ = tx.Rollback()
+	} else if err := tx.Commit(); err != nil {
+		return out, err
+	}
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// resolveSpeaker maps a speaker label to a contact: the matching attendee
+// of the linked calendar event, else a "human" contact keyed by the label
+// and attached to you or to the one existing person with that name.
+func resolveSpeaker(w *ingest.Writer, speaker string, isMe bool, mePersonID string, cal *meetingCalendarEvent) (string, error) {
+	if cal != nil && !isMe {
+		for _, a := range cal.Attendees {
+			for _, n := range a.Names {
+				if speakerMatchesName(speaker, n) {
+					return a.ContactID, nil
+				}
+			}
+		}
+	}
+
+	identifier := "transcript:" + speaker
+	personID := ""
+	if isMe {
+		personID = mePersonID
+	} else {
+		rows, err := w.Tx().Query(`
+			SELECT id FROM persons
+			WHERE LOWER(canonical_name) = LOWER(?) OR LOWER(COALESCE(display_name, '')) = LOWER(?)
+			LIMIT 2
+		`, speaker, speaker)
+		if err != nil {
+			return "", err
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return "", err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if len(ids) == 1 {
+			personID = ids[0]
+		}
+	}
+	if personID == "" {
+		return w.Contact("human", identifier, speaker)
+	}
+	contactID, _, err := contacts.GetOrCreateContact(w.Tx(), "human", identifier, speaker, w.Adapter())
+	if err != nil {
+		return "", err
+	}
+	if err := contacts.EnsurePersonContactLink(w.Tx(), personID, contactID, "deterministic", 0.9); err != nil {
+		return "", err
+	}
+	return contactID, nil
+}
+
+// findMeetingCalendarEvent returns the non-cancelled, timed calendar event
+// overlapping [start, end] whose attendees match the most speakers, breaking
+// ties by the length of the overlap.
+func findMeetingCalendarEvent(ctx context.Context, db *sql.DB, start, end int64, speakers []string) (*meetingCalendarEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.timestamp, COALESCE(json_extract(e.metadata_json, '$.end'), e.timestamp + 3600), COALESCE(e.content, '')
+		FROM events e
+		LEFT JOIN event_state s ON s.event_id = e.id
+		WHERE e.channel = 'calendar'
+		  AND e.timestamp < ? AND e.timestamp > ?
+		  AND COALESCE(s.status, '') != 'cancelled'
+	`, end, start-24*3600)
+	if err != nil {
+		return nil, fmt.Errorf("query calendar events: %w", err)
+	}
+	var candidates []meetingCalendarEvent
+	for rows.Next() {
+		var c meetingCalendarEvent
+		var content string
+		if err := rows.Scan(&c.ID, &c.Start, &c.End, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		// All-day events overlap everything that day.
+		if c.End <= start || c.End-c.Start >= 20*3600 {
+			continue
+		}
+		c.Summary = strings.TrimSpace(strings.TrimPrefix(strings.SplitN(content, "\n", 2)[0], "Summary:"))
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var best *meetingCalendarEvent
+	bestMatches, bestOverlap := -1, int64(-1)
+	for i := range candidates {
+		c := &candidates[i]
+		attendees, err := meetingAttendees(ctx, db, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		c.Attendees = attendees
+		matches := 0
+		for _, speaker := range speakers {
+		attendee:
+			for _, a := range attendees {
+				for _, n := range a.Names {
+					if speakerMatchesName(speaker, n) {
+						matches++
+						break attendee
+					}
+				}
+			}
+		}
+		overlap := min(end, c.End) - max(start, c.Start)
+		if matches > bestMatches || (matches == bestMatches && overlap > bestOverlap) {
+			best, bestMatches, bestOverlap = c, matches, overlap
+		}
+	}
+	return best, nil
+}
+
+func meetingAttendees(ctx context.Context, db *sql.DB, eventID string) ([]meetingAttendee, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.contact_id, COALESCE(c.display_name, ''), COALESCE(pe.display_name, ''), COALESCE(pe.canonical_name, '')
+		FROM event_participants p
+		JOIN contacts c ON c.id = p.contact_id
+		LEFT JOIN person_contact_links l ON l.contact_id = p.contact_id
+		LEFT JOIN persons pe ON pe.id = l.person_id
+		WHERE p.event_id = ? AND p.role IN ('organizer', 'attendee')
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("query attendees: %w", err)
+	}
+	defer rows.Close()
+
+	var out []meetingAttendee
+	index := map[string]int{}
+	for rows.Next() {
+		var contactID string
+		var names [3]string
+		if err := rows.Scan(&contactID, &names[0], &names[1], &names[2]); err != nil {
+			return nil, err
+		}
+		i, ok := index[contactID]
+		if !ok {
+			i = len(out)
+			index[contactID] = i
+			out = append(out, meetingAttendee{ContactID: contactID})
+		}
+		for _, n := range names {
+			if strings.TrimSpace(n) != "" {
+				out[i].Names = append(out[i].Names, n)
+			}
+		}
+	}
+	return out, rows.Err()
+}
+
+// speakerMatchesName compares a transcript label with a known name. A
+// single-word label ("Alice") matches the first name of "Alice Smith".
+func speakerMatchesName(speaker, name string) bool {
+	s := strings.Fields(strings.ToLower(speaker))
+	n := strings.Fields(strings.ToLower(name))
+	if len(s) == 0 || len(n) == 0 {
+		return false
+	}
+	if strings.Join(s, " ") == strings.Join(n, " ") {
+		return true
+	}
+	return len(s) == 1 && s[0] == n[0]
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
+)
+
+const meetingVTT = `WEBVTT
+
+00:00:01.000 --> 00:00:04.000
+<v Alice>Let's review the launch plan.
+
+00:00:04.000 --> 00:00:06.500
+<v Alice>Starting with the timeline.
+
+00:00:07.000 --> 00:00:09.000
+<v Bob Jones>Sounds good.
+`
+
+func TestImportTranscript(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+	meetingStart := time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)
+
+	// Two calendar events overlap the recording; only one has Alice on it.
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	w, err := ingest.NewWriter(tx, "cal")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, ev := range []struct {
+		id, summary string
+		attendee    string
+	}{
+		{"focus", "Focus time", ""},
+		{"launch", "Launch review", "alice@example.com"},
+	} {
+		eventID, _, err := w.UpsertEvent(ingest.Event{
+			SourceID:     ev.id,
+			Timestamp:    meetingStart.Add(-5 * time.Minute).Unix(),
+			Channel:      "calendar",
+			ContentTypes: []string{"calendar_event"},
+			Content:      "Summary: " + ev.summary + "\nCalendar: primary",
+			Direction:    "observed",
+			Metadata:     map[string]any{"end": meetingStart.Add(time.Hour).Unix()},
+		})
+		if err != nil {
+			t.Fatalf("UpsertEvent: %v", err)
+		}
+		if ev.attendee == "" {
+			continue
+		}
+		contactID, err := w.Contact("email", ev.attendee, "Alice Smith")
+		if err != nil {
+			t.Fatalf("Contact: %v", err)
+		}
+		if err := w.AddParticipant(eventID, contactID, "attendee"); err != nil {
+			t.Fatalf("AddParticipant: %v", err)
+		}
+	}
+	w.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	path := filepath.Join(tmpDir, "standup.vtt")
+	if err := os.WriteFile(path, []byte(meetingVTT), 0o644); err != nil {
+		t.Fatalf("write transcript: %v", err)
+	}
+	opts := TranscriptImportOptions{Path: path, Start: meetingStart}
+	res, err := ImportTranscript(context.Background(), d, opts)
+	if err != nil {
+		t.Fatalf("ImportTranscript: %v", err)
+	}
+	if res.Format != "vtt" || res.Utterances != 2 || res.Speakers != 2 || res.EventsCreated != 2 || res.CalendarEventID != "cal:launch" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	var threadName, channel string
+	if err := d.QueryRow(`SELECT name, channel FROM threads WHERE id = ?`, res.ThreadID).Scan(&threadName, &channel); err != nil {
+		t.Fatalf("thread: %v", err)
+	}
+	if threadName != "Launch review" || channel != "meeting" {
+		t.Fatalf("unexpected thread %q on %s", threadName, channel)
+	}
+
+	var content, sender string
+	var ts int64
+	if err := d.QueryRow(`
+		SELECT e.content, e.timestamp, ci.normalized FROM events e
+		JOIN event_participants p ON p.event_id = e.id AND p.role = 'sender'
+		JOIN contact_identifiers ci ON ci.contact_id = p.contact_id AND ci.type = 'email'
+		WHERE e.thread_id = ? ORDER BY e.timestamp LIMIT 1
+	`, res.ThreadID).Scan(&content, &ts, &sender); err != nil {
+		t.Fatalf("first utterance: %v", err)
+	}
+	if content != "Let's review the launch plan. Starting with the timeline." || ts != meetingStart.Add(time.Second).Unix() || sender != "alice@example.com" {
+		t.Fatalf("unexpected first utterance %q at %d from %s", content, ts, sender)
+	}
+
+	res, err = ImportTranscript(context.Background(), d, opts)
+	if err != nil {
+		t.Fatalf("second ImportTranscript: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.PersonsCreated != 0 {
+		t.Fatalf("expected idempotent re-import, got %+v", res)
+	}
+}
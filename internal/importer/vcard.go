@@ -0,0 +1,535 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/identify"
+	"github.com/Napageneral/mnemonic/internal/vcard"
+)
+
+type VCardImportOptions struct {
+	AdapterName string // defaults to "vcard"; recorded as the contact source
+	Path        string // .vcf file, or a directory of them
+	PhotoDir    string // where PHOTO images are written; defaults to <data dir>/photos
+	SkipPhotos  bool
+	DryRun      bool
+}
+
+type VCardImportResult struct {
+	Files           int
+	Cards           int
+	Skipped         int // cards without a phone number or email address
+	ContactsCreated int
+	PersonsCreated  int
+	FactsWritten    int
+	PhotosSaved     int
+	Duration        time.Duration
+}
+
+func (o VCardImportOptions) withDefaults() VCardImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "vcard"
+	}
+	return o
+}
+
+// Person facts recorded from vCards that have no constant in identify.
+const (
+	vcardFactTypePhoto = "photo"
+	vcardSourceType    = "vcard"
+	vcardConfidence    = 0.9
+)
+
+// ImportVCard imports address book exports (.vcf) from iCloud, Outlook,
+// Google or a phone. Every TEL and EMAIL becomes a contact identifier; the
+// contacts of one card are linked to a single person, reusing a person an
+// earlier source already linked. ORG, TITLE, BDAY, typed TEL/EMAIL entries and
+// PHOTO are recorded as person facts.
+func ImportVCard(ctx context.Context, db *sql.DB, opts VCardImportOptions) (VCardImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out VCardImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	files, err := vcardFiles(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	if len(files) == 0 {
+		return out, fmt.Errorf("no .vcf files found in %s", opts.Path)
+	}
+	if opts.PhotoDir == "" && !opts.SkipPhotos {
+		dataDir, err := config.GetDataDir()
+		if err != nil {
+			return out, err
+		}
+		opts.PhotoDir = filepath.Join(dataDir, "photos")
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if err := importVCardFile(ctx, db, f, opts, &out); err != nil {
+			return out, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		out.Files++
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+func vcardFiles(p string) ([]string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vCard file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{p}, nil
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".vcf") {
+			files = append(files, filepath.Join(p, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func importVCardFile(ctx context.Context, db *sql.DB, file string, opts VCardImportOptions, out *VCardImportResult) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	cards, err := vcard.Parse(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, card := range cards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		out.Cards++
+		if err := importVCardCard(tx, card, opts, out); err != nil {
+			return fmt.Errorf("card %q: %w", card.Name(), err)
+		}
+	}
+
+	if opts.DryRun {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+func importVCardCard(tx *sql.Tx, card vcard.Card, opts VCardImportOptions, out *VCardImportResult) error {
+	name := card.Name()
+
+	var contactIDs []string
+	addContact := func(identifierType, raw string) error {
+		if contacts.NormalizeIdentifier(raw, identifierType) == "" {
+			return nil
+		}
+		cid, created, err := contacts.GetOrCreateContact(tx, identifierType, raw, name, opts.AdapterName)
+		if err != nil {
+			return err
+		}
+		if created {
+			out.ContactsCreated++
+		}
+		contactIDs = append(contactIDs, cid)
+		return nil
+	}
+	for _, e := range card.Emails {
+		if err := addContact("email", e.Value); err != nil {
+			return err
+		}
+	}
+	for _, t := range card.Tels {
+		if err := addContact("phone", t.Value); err != nil {
+			return err
+		}
+	}
+	if len(contactIDs) == 0 {
+		out.Skipped++
+		return nil
+	}
+
+	for _, h := range card.IMPPs {
+		if err := contacts.EnsureContactIdentifier(tx, contactIDs[0], "handle", vcardHandle(h.Value)); err != nil {
+			return err
+		}
+	}
+
+	var personID string
+	for _, cid := range contactIDs {
+		pid, err := contacts.GetLinkedPersonID(tx, cid)
+		if err != nil {
+			return err
+		}
+		if pid != "" {
+			personID = pid
+			break
+		}
+	}
+	if personID == "" {
+		pid, created, err := contacts.EnsurePersonForContact(tx, contactIDs[0], name, "deterministic", 1.0)
+		if err != nil {
+			return err
+		}
+		if created {
+			out.PersonsCreated++
+		}
+		personID = pid
+	}
+	if personID == "" {
+		return nil
+	}
+	for _, cid := range contactIDs {
+		if err := contacts.EnsurePersonContactLink(tx, personID, cid, "deterministic", 1.0); err != nil {
+			return err
+		}
+	}
+
+	type fact struct {
+		category, factType, value string
+		identifier                bool
+	}
+	var facts []fact
+	if card.Org != "" {
+		facts = append(facts, fact{identify.CategoryProfessional, identify.FactTypeEmployerCurrent, card.Org, false})
+	}
+	if card.Title != "" {
+		facts = append(facts, fact{identify.CategoryProfessional, identify.FactTypeBusinessRole, card.Title, false})
+	}
+	if card.BDay != "" {
+		facts = append(facts, fact{identify.CategoryCoreIdentity, identify.FactTypeBirthdate, card.BDay, false})
+	}
+	for _, t := range card.Tels {
+		if ft := vcardPhoneFactType(t); ft != "" {
+			facts = append(facts, fact{identify.CategoryContactInfo, ft, t.Value, true})
+		}
+	}
+	for _, e := range card.Emails {
+		if ft := vcardEmailFactType(e); ft != "" {
+			facts = append(facts, fact{identify.CategoryContactInfo, ft, strings.ToLower(e.Value), true})
+		}
+	}
+	if card.Photo != nil && !opts.SkipPhotos {
+		value := card.Photo.URI
+		if len(card.Photo.Data) > 0 {
+			dest, err := saveVCardPhoto(opts.PhotoDir, card.Photo)
+			if err != nil {
+				return err
+			}
+			out.PhotosSaved++
+			value = dest
+		}
+		if value != "" {
+			facts = append(facts, fact{identify.CategoryPhysical, vcardFactTypePhoto, value, false})
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, f := range facts {
+		if err := upsertVCardFact(tx, personID, f.category, f.factType, f.value, f.identifier, now); err != nil {
+			return err
+		}
+		out.FactsWritten++
+	}
+	return nil
+}
+
+func upsertVCardFact(tx *sql.Tx, personID, category, factType, value string, identifier bool, now int64) error {
+	hard := false
+	for _, t := range identify.HardIdentifiers {
+		if t == factType {
+			hard = true
+		}
+	}
+	_, err := tx.Exec(`
+		INSERT INTO person_facts (
+			id, person_id, category, fact_type, fact_value, confidence,
+			source_type, source_channel, is_identifier, is_hard_identifier,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(person_id, category, fact_type, fact_value) DO UPDATE SET
+			confidence = MAX(confidence, excluded.confidence),
+			updated_at = excluded.updated_at
+	`, uuid.New().String(), personID, category, factType, value, vcardConfidence,
+		vcardSourceType, vcardSourceType, boolToInt(identifier), boolToInt(hard), now, now)
+	if err != nil {
+		return fmt.Errorf("insert person fact: %w", err)
+	}
+	return nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// vcardPhoneFactType maps TEL TYPE parameters onto identify's phone facts.
+// Untyped numbers are still identifiers but carry no fact.
+func vcardPhoneFactType(t vcard.Typed) string {
+	switch {
+	case t.HasType("cell") || t.HasType("mobile") || t.HasType("iphone"):
+		return identify.FactTypePhoneMobile
+	case t.HasType("work"):
+		return identify.FactTypePhoneWork
+	case t.HasType("home"):
+		return identify.FactTypePhoneHome
+	}
+	return ""
+}
+
+func vcardEmailFactType(t vcard.Typed) string {
+	switch {
+	case t.HasType("work"):
+		return identify.FactTypeEmailWork
+	case t.HasType("home") || t.HasType("personal"):
+		return identify.FactTypeEmailPersonal
+	}
+	return ""
+}
+
+// vcardHandle drops the URI scheme from IMPP values such as xmpp:bob@jabber.org.
+func vcardHandle(v string) string {
+	if i := strings.Index(v, ":"); i > 0 && !strings.ContainsAny(v[:i], "@/") {
+		return v[i+1:]
+	}
+	return v
+}
+
+func saveVCardPhoto(dir string, p *vcard.Photo) (string, error) {
+	sum := sha256.Sum256(p.Data)
+	name := hex.EncodeToString(sum[:]) + vcardPhotoExt(p.MediaType)
+	dest := filepath.Join(dir, name)
+	if err := writeFileIfMissing(dest, p.Data); err != nil {
+		return "", fmt.Errorf("write photo: %w", err)
+	}
+	return dest, nil
+}
+
+var vcardPhotoExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/heic": ".heic",
+	"image/webp": ".webp",
+}
+
+func vcardPhotoExt(mediaType string) string {
+	if ext, ok := vcardPhotoExts[mediaType]; ok {
+		return ext
+	}
+	return ".img"
+}
+
+func vcardPhotoMediaType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for mt, e := range vcardPhotoExts {
+		if e == ext {
+			return mt
+		}
+	}
+	return "image/jpeg"
+}
+
+type VCardExportOptions struct {
+	SkipPhotos bool
+}
+
+type VCardExportResult struct {
+	Persons int
+}
+
+// ExportVCard writes one vCard per person that has at least one phone, email
+// or handle, after identity merges have folded duplicates together. Each card
+// carries every identifier linked to the person plus the employer, role,
+// birthday and photo facts recorded for them.
+func ExportVCard(ctx context.Context, db *sql.DB, w io.Writer, opts VCardExportOptions) (VCardExportResult, error) {
+	var out VCardExportResult
+
+	rows, err := db.Query(`
+		SELECT p.id, COALESCE(NULLIF(TRIM(p.display_name), ''), p.canonical_name)
+		FROM persons p
+		WHERE EXISTS (
+			SELECT 1 FROM person_contact_links l
+			JOIN contact_identifiers ci ON ci.contact_id = l.contact_id
+			WHERE l.person_id = p.id AND ci.type IN ('phone', 'email', 'handle')
+		)
+		ORDER BY 2 COLLATE NOCASE, p.id
+	`)
+	if err != nil {
+		return out, fmt.Errorf("query persons: %w", err)
+	}
+	type person struct{ id, name string }
+	var persons []person
+	for rows.Next() {
+		var p person
+		if err := rows.Scan(&p.id, &p.name); err != nil {
+			rows.Close()
+			return out, err
+		}
+		persons = append(persons, p)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return out, err
+	}
+	rows.Close()
+
+	for _, p := range persons {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		card, err := buildVCard(db, p.id, p.name, opts)
+		if err != nil {
+			return out, fmt.Errorf("person %s: %w", p.id, err)
+		}
+		if err := vcard.Write(w, card); err != nil {
+			return out, err
+		}
+		out.Persons++
+	}
+	return out, nil
+}
+
+func buildVCard(db *sql.DB, personID, name string, opts VCardExportOptions) (vcard.Card, error) {
+	card := vcard.Card{UID: personID, FN: name}
+	if fields := strings.Fields(name); len(fields) > 1 {
+		card.Given = strings.Join(fields[:len(fields)-1], " ")
+		card.Family = fields[len(fields)-1]
+	} else {
+		card.Given = name
+	}
+
+	phoneTypes := map[string][]string{}
+	emailTypes := map[string][]string{}
+	rows, err := db.Query(`
+		SELECT category, fact_type, fact_value FROM person_facts
+		WHERE person_id = ?
+		ORDER BY confidence DESC, updated_at DESC
+	`, personID)
+	if err != nil {
+		return card, fmt.Errorf("query facts: %w", err)
+	}
+	for rows.Next() {
+		var category, factType, value string
+		if err := rows.Scan(&category, &factType, &value); err != nil {
+			rows.Close()
+			return card, err
+		}
+		switch factType {
+		case identify.FactTypeEmployerCurrent:
+			card.Org = firstNonEmpty(card.Org, value)
+		case identify.FactTypeBusinessRole:
+			card.Title = firstNonEmpty(card.Title, value)
+		case identify.FactTypeBirthdate:
+			card.BDay = firstNonEmpty(card.BDay, value)
+		case identify.FactTypePhoneMobile:
+			n := contacts.NormalizeIdentifier(value, "phone")
+			phoneTypes[n] = append(phoneTypes[n], "cell")
+		case identify.FactTypePhoneHome:
+			n := contacts.NormalizeIdentifier(value, "phone")
+			phoneTypes[n] = append(phoneTypes[n], "home")
+		case identify.FactTypePhoneWork:
+			n := contacts.NormalizeIdentifier(value, "phone")
+			phoneTypes[n] = append(phoneTypes[n], "work")
+		case identify.FactTypeEmailWork:
+			n := contacts.NormalizeIdentifier(value, "email")
+			emailTypes[n] = append(emailTypes[n], "work")
+		case identify.FactTypeEmailPersonal:
+			n := contacts.NormalizeIdentifier(value, "email")
+			emailTypes[n] = append(emailTypes[n], "home")
+		case vcardFactTypePhoto:
+			if card.Photo == nil && category == identify.CategoryPhysical && !opts.SkipPhotos {
+				card.Photo = vcardExportPhoto(value)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return card, err
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT ci.type, ci.value, ci.normalized
+		FROM person_contact_links l
+		JOIN contact_identifiers ci ON ci.contact_id = l.contact_id
+		WHERE l.person_id = ? AND ci.type IN ('phone', 'email', 'handle')
+		ORDER BY l.confidence DESC, ci.created_at, ci.normalized
+	`, personID)
+	if err != nil {
+		return card, fmt.Errorf("query identifiers: %w", err)
+	}
+	defer rows.Close()
+	seen := map[string]bool{}
+	for rows.Next() {
+		var typ, value, normalized string
+		if err := rows.Scan(&typ, &value, &normalized); err != nil {
+			return card, err
+		}
+		if seen[typ+":"+normalized] {
+			continue
+		}
+		seen[typ+":"+normalized] = true
+		switch typ {
+		case "phone":
+			card.Tels = append(card.Tels, vcard.Typed{Value: value, Types: phoneTypes[normalized]})
+		case "email":
+			card.Emails = append(card.Emails, vcard.Typed{Value: value, Types: emailTypes[normalized]})
+		case "handle":
+			card.IMPPs = append(card.IMPPs, vcard.Typed{Value: value})
+		}
+	}
+	return card, rows.Err()
+}
+
+// vcardExportPhoto embeds a saved photo, falling back to a URI reference
+// when the value is not a readable local file.
+func vcardExportPhoto(value string) *vcard.Photo {
+	if data, err := os.ReadFile(value); err == nil && len(data) > 0 {
+		return &vcard.Photo{Data: data, MediaType: vcardPhotoMediaType(value)}
+	}
+	if strings.Contains(value, "://") {
+		return &vcard.Photo{URI: value}
+	}
+	return nil
+}
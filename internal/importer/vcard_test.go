@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/vcard"
+)
+
+const vcardExport = "BEGIN:VCARD\r\n" +
+	"VERSION:3.0\r\n" +
+	"N:Smith;Alice;;;\r\n" +
+	"FN:Alice Smith\r\n" +
+	"ORG:Acme Inc.;Research\r\n" +
+	"TITLE:Principal Engineer\r\n" +
+	"TEL;type=CELL;type=pref:+1 (555) 123-4567\r\n" +
+	"EMAIL;type=INTERNET;type=WORK:Alice@Acme.example\r\n" +
+	"IMPP;X-SERVICE-TYPE=Jabber:xmpp:alice@jabber.example\r\n" +
+	"BDAY:1985-04-12\r\n" +
+	"PHOTO;ENCODING=b;TYPE=JPEG:/9j/4AAQSkZJRgABAQAAAQABAAD\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Front Desk\r\n" +
+	"ADR:;;1 Main St;Springfield;;;\r\n" +
+	"END:VCARD\r\n"
+
+func TestImportVCard(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	// Alice is already known from messaging under her phone number.
+	cid, _, err := contacts.GetOrCreateContact(d, "phone", "5551234567", "Alice", "imessage")
+	if err != nil {
+		t.Fatalf("GetOrCreateContact: %v", err)
+	}
+	existing, _, err := contacts.EnsurePersonForContact(d, cid, "Alice", "deterministic", 1.0)
+	if err != nil {
+		t.Fatalf("EnsurePersonForContact: %v", err)
+	}
+
+	path := filepath.Join(tmpDir, "contacts.vcf")
+	if err := os.WriteFile(path, []byte(vcardExport), 0o644); err != nil {
+		t.Fatalf("write vcf: %v", err)
+	}
+
+	res, err := ImportVCard(context.Background(), d, VCardImportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("ImportVCard: %v", err)
+	}
+	if res.Cards != 2 || res.Skipped != 1 || res.ContactsCreated != 1 || res.PersonsCreated != 0 || res.PhotosSaved != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	var emailPerson string
+	if err := d.QueryRow(`
+		SELECT l.person_id FROM contact_identifiers ci
+		JOIN person_contact_links l ON l.contact_id = ci.contact_id
+		WHERE ci.type = 'email' AND ci.normalized = 'alice@acme.example'
+	`).Scan(&emailPerson); err != nil || emailPerson != existing {
+		t.Fatalf("email not linked to existing person: %q (%v)", emailPerson, err)
+	}
+	var handles int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM contact_identifiers WHERE type = 'handle' AND normalized = '@alice@jabber.example'`).Scan(&handles)
+	if handles != 1 {
+		t.Fatalf("expected IMPP handle identifier")
+	}
+
+	facts := map[string]string{}
+	rows, err := d.Query(`SELECT fact_type, fact_value FROM person_facts WHERE person_id = ? AND source_type = 'vcard'`, existing)
+	if err != nil {
+		t.Fatalf("query facts: %v", err)
+	}
+	for rows.Next() {
+		var ft, v string
+		if err := rows.Scan(&ft, &v); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		facts[ft] = v
+	}
+	rows.Close()
+	if facts["employer_current"] != "Acme Inc." || facts["business_role"] != "Principal Engineer" || facts["birthdate"] != "1985-04-12" ||
+		facts["phone_mobile"] != "+1 (555) 123-4567" || facts["email_work"] != "alice@acme.example" {
+		t.Fatalf("unexpected facts %v", facts)
+	}
+	if _, err := os.Stat(facts["photo"]); err != nil {
+		t.Fatalf("photo not saved: %v", err)
+	}
+
+	// Re-importing is idempotent.
+	res, err = ImportVCard(context.Background(), d, VCardImportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("second ImportVCard: %v", err)
+	}
+	if res.ContactsCreated != 0 || res.PersonsCreated != 0 {
+		t.Fatalf("expected no new contacts, got %+v", res)
+	}
+
+	var buf bytes.Buffer
+	exp, err := ExportVCard(context.Background(), d, &buf, VCardExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportVCard: %v", err)
+	}
+	cards, err := vcard.Parse(&buf)
+	if err != nil {
+		t.Fatalf("parse export: %v", err)
+	}
+	if exp.Persons != 1 || len(cards) != 1 {
+		t.Fatalf("expected one exported person, got %d (%d cards)", exp.Persons, len(cards))
+	}
+	card := cards[0]
+	if card.UID != existing || card.Org != "Acme Inc." || card.Title != "Principal Engineer" || card.BDay != "1985-04-12" {
+		t.Fatalf("unexpected exported card %+v", card)
+	}
+	if len(card.Tels) != 1 || !card.Tels[0].HasType("cell") || len(card.Emails) != 1 || !card.Emails[0].HasType("work") || len(card.IMPPs) != 1 {
+		t.Fatalf("unexpected exported identifiers %+v %+v %+v", card.Tels, card.Emails, card.IMPPs)
+	}
+	if card.Photo == nil || card.Photo.MediaType != "image/jpeg" || len(card.Photo.Data) == 0 {
+		t.Fatalf("expected embedded photo, got %+v", card.Photo)
+	}
+}
@@ -0,0 +1,767 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+)
+
+type WhatsAppImportOptions struct {
+	AdapterName   string         // defaults to "whatsapp"
+	Path          string         // exported .txt, the "with media" .zip, or an extracted directory
+	Chat          string         // thread name; defaults to the name in the export file name
+	ThreadKey     string         // stable key for the chat's thread and event ids; defaults to Chat
+	Me            string         // your name as it appears in the export (drives direction, maps "You")
+	DateOrder     string         // auto (default), dmy, mdy, ymd
+	Location      *time.Location // exports carry local wall-clock times; defaults to time.Local
+	LimitMessages int            // 0 = no limit
+	DryRun        bool
+}
+
+type WhatsAppImportResult struct {
+	MessagesSeen       int
+	SystemMessages     int
+	EventsCreated      int
+	EventsUpdated      int
+	PersonsCreated     int
+	ThreadsCreated     int
+	AttachmentsCreated int
+	MediaOmitted       int
+	InvalidTimestamps  int // lines skipped because their date did not parse in the date order
+	Duration           time.Duration
+}
+
+func (o WhatsAppImportOptions) withDefaults() WhatsAppImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "whatsapp"
+	}
+	if o.DateOrder == "" {
+		o.DateOrder = "auto"
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	return o
+}
+
+// whatsappHeader matches the start of a message in both export styles:
+//
+//	iOS:     [14/11/2023, 22:13:20] Alice: hi
+//	Android: 14/11/2023, 22:13 - Alice: hi
+//	         11/14/23, 10:13 PM - Alice: hi
+var whatsappHeader = regexp.MustCompile(`^[\x{200E}\x{200F}]?\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?[\s\x{202F}\x{00A0}]*([AaPp]\.?\s?[Mm]\.?)?(?:\]\s|\s[-–]\s)(.*)$`)
+
+var (
+	whatsappCreated     = regexp.MustCompile(`^(.+?) created group "(.*)"$`)
+	whatsappSubject     = regexp.MustCompile(`^(.+?) changed (?:the subject|the group name) (?:from ".*" )?to "(.*)"$`)
+	whatsappJoinedLink  = regexp.MustCompile(`^(.+?) joined(?: using this (?:group|community)'s invite link)?$`)
+	whatsappLeft        = regexp.MustCompile(`^(.+?) left$`)
+	whatsappWasAdded    = regexp.MustCompile(`^(.+?) (?:was|were) added$`)
+	whatsappWasRemoved  = regexp.MustCompile(`^(.+?) (?:was|were) removed$`)
+	whatsappAdded       = regexp.MustCompile(`^(.+?) added (.+)$`)
+	whatsappRemoved     = regexp.MustCompile(`^(.+?) removed (.+)$`)
+	whatsappMemberSplit = regexp.MustCompile(`,\s*|\s+and\s+|\s*&\s*`)
+
+	whatsappOmitted  = regexp.MustCompile(`(?i)^(image|video|audio|sticker|gif|document|contact card) omitted$`)
+	whatsappAttached = regexp.MustCompile(`^<attached: ([^>]+)>\s*(.*)$`)
+	whatsappFileNote = regexp.MustCompile(`^(\S[^\n]*?\.[A-Za-z0-9]{2,5}) \([^)\n]+\)(?:\n([\s\S]*))?$`)
+)
+
+const whatsappEditedMarker = "<This message was edited>"
+
+type whatsappLine struct {
+	parts [3]int
+	hour  int
+	min   int
+	sec   int
+	ampm  string
+	body  string
+}
+
+type whatsappSystem struct {
+	action  string // added, removed, subject, encryption, other
+	actor   string
+	members []string
+	subject string
+}
+
+// ImportWhatsApp imports a single chat from WhatsApp's "Export chat" feature.
+// The chat becomes a thread; messages become events; join/leave lines become
+// membership events shaped like Eve's so episode encoders treat them alike.
+//
+// Exports carry no chat id, so the thread and its events are keyed on the
+// chat name. A chat renamed between exports would import as a second thread;
+// pass the original name as ThreadKey to keep importing into the first one.
+func ImportWhatsApp(ctx context.Context, db *sql.DB, opts WhatsAppImportOptions) (WhatsAppImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out WhatsAppImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	switch opts.DateOrder {
+	case "auto", "dmy", "mdy", "ymd":
+	default:
+		return out, fmt.Errorf("invalid date order %q (use auto, dmy, mdy or ymd)", opts.DateOrder)
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	export, err := openWhatsAppExport(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	defer export.Close()
+
+	chat := strings.TrimSpace(opts.Chat)
+	if chat == "" {
+		chat = export.chatName
+	}
+	if chat == "" {
+		return out, fmt.Errorf("could not infer chat name from %s; pass --chat", opts.Path)
+	}
+	key := strings.TrimSpace(opts.ThreadKey)
+	if key == "" {
+		key = chat
+	}
+
+	f, err := export.Open(export.chatFile)
+	if err != nil {
+		return out, fmt.Errorf("failed to open chat file: %w", err)
+	}
+	lines, err := parseWhatsAppLines(f)
+	f.Close()
+	if err != nil {
+		return out, err
+	}
+	order := opts.DateOrder
+	if order == "auto" {
+		order = detectWhatsAppDateOrder(lines)
+	}
+
+	mePersonID := ""
+	if opts.Me != "" {
+		if p, err := me.GetMePerson(db); err == nil && p != nil {
+			mePersonID = p.ID
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return out, fmt.Errorf("failed to begin import tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, err := ingest.NewWriter(tx, opts.AdapterName)
+	if err != nil {
+		return out, err
+	}
+	defer w.Close()
+
+	s := &whatsappSession{
+		w:          w,
+		export:     export,
+		key:        key,
+		me:         strings.TrimSpace(opts.Me),
+		mePersonID: mePersonID,
+		contacts:   map[string]string{},
+		seenIDs:    map[string]int{},
+	}
+
+	// Classify once up front so group detection and 1:1 recipients can see
+	// every sender before the first event is written.
+	type classified struct {
+		ts     int64
+		sender string
+		text   string
+		system *whatsappSystem
+	}
+	var entries []classified
+	senders := map[string]bool{}
+	hasMembership := false
+	for _, l := range lines {
+		ts, err := l.timestamp(order, opts.Location)
+		if err != nil {
+			out.InvalidTimestamps++
+			continue
+		}
+		sender, text, system := classifyWhatsAppBody(l.body)
+		if system != nil {
+			if system.action == "added" || system.action == "removed" || system.action == "subject" {
+				hasMembership = true
+			}
+		} else {
+			senders[sender] = true
+		}
+		entries = append(entries, classified{ts: ts, sender: sender, text: text, system: system})
+	}
+	for name := range senders {
+		s.senders = append(s.senders, name)
+	}
+	sort.Strings(s.senders)
+	others := 0
+	for _, name := range s.senders {
+		if name != s.me {
+			others++
+		}
+	}
+	s.isGroup = hasMembership || others > 1
+
+	threadID, err := w.UpsertThread(ingest.Thread{SourceID: key, Channel: "whatsapp", Name: chat, IsGroup: s.isGroup})
+	if err != nil {
+		return out, err
+	}
+	s.threadID = threadID
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if opts.LimitMessages > 0 && out.MessagesSeen+out.SystemMessages >= opts.LimitMessages {
+			break
+		}
+		if e.system != nil {
+			out.SystemMessages++
+			if err := s.importSystem(e.ts, *e.system); err != nil {
+				return out, err
+			}
+			continue
+		}
+		out.MessagesSeen++
+		omitted, err := s.importMessage(e.ts, e.sender, e.text)
+		if err != nil {
+			return out, err
+		}
+		if omitted {
+			out.MediaOmitted++
+		}
+	}
+
+	out.EventsCreated = w.Stats.EventsCreated
+	out.EventsUpdated = w.Stats.EventsUpdated
+	out.PersonsCreated = w.Stats.PersonsCreated
+	out.ThreadsCreated = w.Stats.ThreadsCreated
+	out.AttachmentsCreated = w.Stats.AttachmentsCreated
+
+	if opts.DryRun {
+		out.Duration = time.Since(start)
+		return out, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return out, fmt.Errorf("commit import tx: %w", err)
+	}
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// whatsappExport locates the chat transcript and any media next to it.
+type whatsappExport struct {
+	*exportArchive
+	chatFile string
+	chatName string
+}
+
+func openWhatsAppExport(p string) (*whatsappExport, error) {
+	if strings.EqualFold(filepath.Ext(p), ".txt") {
+		archive, err := openArchive(filepath.Dir(p))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fs.Stat(archive, filepath.Base(p)); err != nil {
+			archive.Close()
+			return nil, fmt.Errorf("failed to open chat file: %w", err)
+		}
+		return &whatsappExport{
+			exportArchive: archive,
+			chatFile:      filepath.Base(p),
+			chatName:      whatsappChatName(filepath.Base(p)),
+		}, nil
+	}
+
+	archive, err := openArchive(p)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(archive, ".")
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	chatFile := ""
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(path.Ext(e.Name()), ".txt") {
+			continue
+		}
+		if e.Name() == "_chat.txt" || chatFile == "" {
+			chatFile = e.Name()
+		}
+	}
+	if chatFile == "" {
+		archive.Close()
+		return nil, fmt.Errorf("no chat .txt found in %s", p)
+	}
+	ex := &whatsappExport{exportArchive: archive, chatFile: chatFile, chatName: whatsappChatName(chatFile)}
+	// iOS names the transcript _chat.txt, so the archive name carries the chat.
+	if chatFile == "_chat.txt" {
+		ex.chatName = whatsappChatName(filepath.Base(filepath.Clean(p)))
+	}
+	return ex, nil
+}
+
+// whatsappChatName strips the "WhatsApp Chat with"/"WhatsApp Chat - " prefix
+// and extension from an export file name.
+func whatsappChatName(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	for _, prefix := range []string{"WhatsApp Chat with ", "WhatsApp Chat - ", "WhatsApp Chat "} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(name, prefix))
+		}
+	}
+	if name == "_chat" {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+func parseWhatsAppLines(r io.Reader) ([]whatsappLine, error) {
+	var lines []whatsappLine
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	first := true
+	for sc.Scan() {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if first {
+			text = strings.TrimPrefix(text, "\ufeff")
+			first = false
+		}
+		m := whatsappHeader.FindStringSubmatch(text)
+		if m == nil {
+			// Continuation of a multi-line message.
+			if len(lines) > 0 {
+				lines[len(lines)-1].body += "\n" + text
+			}
+			continue
+		}
+		var l whatsappLine
+		for i := 0; i < 3; i++ {
+			l.parts[i], _ = strconv.Atoi(m[i+1])
+		}
+		if len(m[1]) == 4 {
+			// Keep the four-digit marker so detection can spot y-m-d.
+			l.parts[0] += 10000
+		}
+		l.hour, _ = strconv.Atoi(m[4])
+		l.min, _ = strconv.Atoi(m[5])
+		if m[6] != "" {
+			l.sec, _ = strconv.Atoi(m[6])
+		}
+		l.ampm = strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(m[7]))
+		l.body = m[8]
+		lines = append(lines, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read chat: %w", err)
+	}
+	return lines, nil
+}
+
+// detectWhatsAppDateOrder picks the date order that is consistent with every
+// line. Ambiguous exports fall back to m/d/y for 12-hour clocks (US locale)
+// and d/m/y otherwise.
+func detectWhatsAppDateOrder(lines []whatsappLine) string {
+	twelveHour := false
+	for _, l := range lines {
+		if l.parts[0] >= 10000 {
+			return "ymd"
+		}
+		if l.parts[0] > 12 {
+			return "dmy"
+		}
+		if l.parts[1] > 12 {
+			return "mdy"
+		}
+		if l.ampm != "" {
+			twelveHour = true
+		}
+	}
+	if twelveHour {
+		return "mdy"
+	}
+	return "dmy"
+}
+
+func (l whatsappLine) timestamp(order string, loc *time.Location) (int64, error) {
+	var y, m, d int
+	switch order {
+	case "ymd":
+		y, m, d = l.parts[0]%10000, l.parts[1], l.parts[2]
+	case "mdy":
+		m, d, y = l.parts[0], l.parts[1], l.parts[2]
+	default:
+		d, m, y = l.parts[0], l.parts[1], l.parts[2]
+	}
+	if y < 100 {
+		y += 2000
+	}
+	hour := l.hour
+	switch l.ampm {
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	}
+	if m < 1 || m > 12 || d < 1 || d > 31 || hour > 23 {
+		return 0, fmt.Errorf("invalid date")
+	}
+	return time.Date(y, time.Month(m), d, hour, l.min, l.sec, 0, loc).Unix(), nil
+}
+
+// classifyWhatsAppBody splits "Sender: text" and recognises system lines.
+// Android writes system lines without a sender; iOS attributes them to the
+// chat and marks the text with a left-to-right mark.
+func classifyWhatsAppBody(body string) (sender, text string, system *whatsappSystem) {
+	body = strings.TrimLeft(body, "\u200e")
+	idx := strings.Index(body, ": ")
+	if idx < 0 || strings.Contains(body[:idx], "\n") {
+		sys := parseWhatsAppSystem(body)
+		return "", "", &sys
+	}
+	sender = strings.TrimSpace(strings.TrimLeft(body[:idx], "\u200e~\u202f\u00a0 "))
+	text = body[idx+2:]
+	if strings.HasPrefix(text, "\u200e") {
+		if sys := parseWhatsAppSystem(strings.TrimLeft(text, "\u200e")); sys.action != "other" {
+			return "", "", &sys
+		}
+	}
+	return sender, strings.TrimLeft(text, "\u200e"), nil
+}
+
+func parseWhatsAppSystem(text string) whatsappSystem {
+	text = strings.TrimSpace(text)
+	if strings.Contains(text, "end-to-end encrypted") {
+		return whatsappSystem{action: "encryption"}
+	}
+	if m := whatsappCreated.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "subject", actor: m[1], subject: m[2]}
+	}
+	if m := whatsappSubject.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "subject", actor: m[1], subject: m[2]}
+	}
+	if m := whatsappWasAdded.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "added", members: splitWhatsAppMembers(m[1])}
+	}
+	if m := whatsappWasRemoved.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "removed", members: splitWhatsAppMembers(m[1])}
+	}
+	if m := whatsappJoinedLink.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "added", actor: m[1], members: []string{m[1]}}
+	}
+	if m := whatsappLeft.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "removed", actor: m[1], members: []string{m[1]}}
+	}
+	if m := whatsappAdded.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "added", actor: m[1], members: splitWhatsAppMembers(m[2])}
+	}
+	if m := whatsappRemoved.FindStringSubmatch(text); m != nil {
+		return whatsappSystem{action: "removed", actor: m[1], members: splitWhatsAppMembers(m[2])}
+	}
+	return whatsappSystem{action: "other"}
+}
+
+func splitWhatsAppMembers(s string) []string {
+	var out []string
+	for _, part := range whatsappMemberSplit.Split(s, -1) {
+		if part = strings.TrimSpace(strings.TrimLeft(part, "\u200e~\u202f ")); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+type whatsappSession struct {
+	w          *ingest.Writer
+	export     *whatsappExport
+	key        string // thread source id and event id prefix
+	me         string
+	mePersonID string
+	threadID   string
+	isGroup    bool
+	senders    []string
+
+	contacts map[string]string
+	seenIDs  map[string]int
+}
+
+// contact resolves a display name (or phone number, for senders missing from
+// the exporter's address book) to a contact.
+func (s *whatsappSession) contact(name string) (string, error) {
+	name = s.resolveYou(name)
+	if id, ok := s.contacts[name]; ok {
+		return id, nil
+	}
+	identifierType, identifier, displayName := "human", "whatsapp:"+name, name
+	if contacts.LooksLikePhone(name) {
+		identifierType, identifier, displayName = "phone", name, ""
+	}
+	if name == s.me && s.mePersonID != "" {
+		contactID, _, err := contacts.GetOrCreateContact(s.w.Tx(), identifierType, identifier, displayName, s.w.Adapter())
+		if err != nil {
+			return "", err
+		}
+		if err := contacts.EnsurePersonContactLink(s.w.Tx(), s.mePersonID, contactID, "deterministic", 1.0); err != nil {
+			return "", err
+		}
+	}
+	contactID, err := s.w.Contact(identifierType, identifier, displayName)
+	if err != nil {
+		return "", err
+	}
+	s.contacts[name] = contactID
+	return contactID, nil
+}
+
+func (s *whatsappSession) resolveYou(name string) string {
+	if s.me != "" && (name == "You" || name == "you") {
+		return s.me
+	}
+	return name
+}
+
+// unnamedMe reports whether name is the exporter with no --me to map it to.
+// There is no identity to record for them, so they get no participant row.
+func (s *whatsappSession) unnamedMe(name string) bool {
+	return s.me == "" && s.isMe(name)
+}
+
+func (s *whatsappSession) isMe(name string) bool {
+	if name == "You" || name == "you" {
+		return true
+	}
+	return s.me != "" && name == s.me
+}
+
+// sourceID is a content hash: exports carry no message ids, and hashing keeps
+// re-imports of a longer export of the same chat idempotent.
+func (s *whatsappSession) sourceID(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(append([]string{s.key}, parts...), "\x00")))
+	id := s.key + ":" + hex.EncodeToString(h[:8])
+	s.seenIDs[id]++
+	if n := s.seenIDs[id]; n > 1 {
+		id += "#" + strconv.Itoa(n)
+	}
+	return id
+}
+
+func (s *whatsappSession) importMessage(ts int64, sender, text string) (omitted bool, err error) {
+	metadata := map[string]any{}
+	if strings.HasSuffix(strings.TrimSpace(text), whatsappEditedMarker) {
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), whatsappEditedMarker))
+		metadata["edited"] = true
+	}
+	sourceID := s.sourceID(strconv.FormatInt(ts, 10), sender, text)
+
+	content := text
+	contentTypes := []string{"text"}
+	mediaFile := ""
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case trimmed == "<Media omitted>":
+		content, contentTypes, omitted = "", []string{"attachment"}, true
+		metadata["media_omitted"] = true
+	case whatsappOmitted.MatchString(trimmed):
+		kind := strings.ToLower(whatsappOmitted.FindStringSubmatch(trimmed)[1])
+		content, contentTypes, omitted = "", []string{"attachment"}, true
+		metadata["media_omitted"] = true
+		metadata["media_kind"] = kind
+	default:
+		if m := whatsappAttached.FindStringSubmatch(trimmed); m != nil {
+			mediaFile, content = m[1], strings.TrimSpace(m[2])
+		} else if m := whatsappFileNote.FindStringSubmatch(trimmed); m != nil && s.hasMedia(m[1]) {
+			mediaFile, content = m[1], strings.TrimSpace(m[2])
+		}
+		if mediaFile != "" {
+			contentTypes = []string{"attachment"}
+			if content != "" {
+				contentTypes = []string{"text", "attachment"}
+			}
+		}
+	}
+
+	direction := "received"
+	if s.isMe(sender) {
+		direction = "sent"
+	}
+	eventID, _, err := s.w.UpsertEvent(ingest.Event{
+		SourceID:     sourceID,
+		Timestamp:    ts,
+		Channel:      "whatsapp",
+		ContentTypes: contentTypes,
+		Content:      content,
+		Direction:    direction,
+		ThreadID:     s.threadID,
+		Metadata:     metadata,
+	})
+	if err != nil {
+		return omitted, err
+	}
+
+	if !s.unnamedMe(sender) {
+		senderID, err := s.contact(sender)
+		if err != nil {
+			return omitted, err
+		}
+		if err := s.w.AddParticipant(eventID, senderID, "sender"); err != nil {
+			return omitted, err
+		}
+	}
+	if !s.isGroup {
+		for _, other := range s.recipients(sender) {
+			contactID, err := s.contact(other)
+			if err != nil {
+				return omitted, err
+			}
+			if err := s.w.AddParticipant(eventID, contactID, "recipient"); err != nil {
+				return omitted, err
+			}
+		}
+	}
+
+	if mediaFile != "" && s.hasMedia(mediaFile) {
+		if err := s.importMedia(eventID, mediaFile, ts); err != nil {
+			return omitted, err
+		}
+	}
+	return omitted, nil
+}
+
+// recipients lists the other side of a 1:1 chat.
+func (s *whatsappSession) recipients(sender string) []string {
+	sender = s.resolveYou(sender)
+	var out []string
+	seen := map[string]bool{sender: true}
+	candidates := append([]string{}, s.senders...)
+	if s.me != "" {
+		candidates = append(candidates, s.me)
+	}
+	for _, name := range candidates {
+		name = s.resolveYou(name)
+		if seen[name] || s.unnamedMe(name) {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+func (s *whatsappSession) hasMedia(name string) bool {
+	if name == "" || strings.Contains(name, "/") {
+		return false
+	}
+	_, err := fs.Stat(s.export, path.Join(path.Dir(s.export.chatFile), name))
+	return err == nil
+}
+
+func (s *whatsappSession) importMedia(eventID, name string, ts int64) error {
+	member := path.Join(path.Dir(s.export.chatFile), name)
+	size, hash, err := s.export.hashFile(member)
+	if err != nil {
+		return err
+	}
+	storageType, storageURI := s.export.storage(member)
+	_, err = s.w.UpsertAttachment(ingest.Attachment{
+		SourceID:    s.key + ":" + name,
+		EventID:     eventID,
+		Filename:    name,
+		MimeType:    mime.TypeByExtension(strings.ToLower(path.Ext(name))),
+		SizeBytes:   size,
+		StorageURI:  storageURI,
+		StorageType: storageType,
+		ContentHash: hash,
+		CreatedAt:   ts,
+	})
+	return err
+}
+
+// importSystem writes one membership event per affected member, mirroring
+// EveAdapter.syncMembershipEvents. Subject changes only mark the chat as a
+// group; encryption notices and other housekeeping lines are dropped.
+func (s *whatsappSession) importSystem(ts int64, sys whatsappSystem) error {
+	switch sys.action {
+	case "added", "removed":
+	default:
+		return nil
+	}
+
+	direction := "received"
+	if sys.actor != "" && s.isMe(sys.actor) {
+		direction = "sent"
+	}
+	for _, member := range sys.members {
+		metadata := map[string]any{"action": sys.action}
+		if sys.actor != "" {
+			metadata["actor"] = s.resolveYou(sys.actor)
+		}
+		metadata["member"] = s.resolveYou(member)
+		eventID, _, err := s.w.UpsertEvent(ingest.Event{
+			SourceID:     s.sourceID(strconv.FormatInt(ts, 10), "membership", sys.action, sys.actor, member),
+			Timestamp:    ts,
+			Channel:      "whatsapp",
+			ContentTypes: []string{"membership"},
+			Content:      sys.action,
+			Direction:    direction,
+			ThreadID:     s.threadID,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			return err
+		}
+		if s.unnamedMe(member) {
+			continue
+		}
+		memberID, err := s.contact(member)
+		if err != nil {
+			return err
+		}
+		if err := s.w.AddParticipant(eventID, memberID, "member"); err != nil {
+			return err
+		}
+		if sys.actor == "" || s.unnamedMe(sys.actor) {
+			continue
+		}
+		actorID, err := s.contact(sys.actor)
+		if err != nil {
+			return err
+		}
+		if err := s.w.AddParticipant(eventID, actorID, "sender"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
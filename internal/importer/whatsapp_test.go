@@ -0,0 +1,297 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const whatsappAndroidGroup = "11/14/23, 10:00 PM - Messages and calls are end-to-end encrypted. No one outside of this chat, not even WhatsApp, can read or listen to them. Tap to learn more.\n" +
+	"11/14/23, 10:01 PM - Tyler created group \"Hiking\"\n" +
+	"11/14/23, 10:02 PM - Tyler added Alice Smith and +1 (555) 123-4567\n" +
+	"11/14/23, 10:13 PM - Alice Smith: first line\n" +
+	"second line\n" +
+	"11/14/23, 10:14 PM - Tyler: <Media omitted>\n" +
+	"11/14/23, 10:15 PM - +1 (555) 123-4567: see you at 9:30 <This message was edited>\n" +
+	"11/15/23, 1:05 AM - Alice Smith left\n"
+
+func TestImportWhatsApp_AndroidGroup(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	chatPath := filepath.Join(tmpDir, "WhatsApp Chat with Hiking.txt")
+	if err := os.WriteFile(chatPath, []byte(whatsappAndroidGroup), 0o644); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+
+	res, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: chatPath, Me: "Tyler", Location: time.UTC})
+	if err != nil {
+		t.Fatalf("ImportWhatsApp: %v", err)
+	}
+	// 3 messages + 2 "added" + 1 "removed" membership events.
+	if res.MessagesSeen != 3 || res.SystemMessages != 4 || res.EventsCreated != 6 || res.MediaOmitted != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	var isGroup int
+	if err := d.QueryRow(`SELECT is_group FROM threads WHERE id = 'whatsapp:Hiking'`).Scan(&isGroup); err != nil || isGroup != 1 {
+		t.Fatalf("expected group thread, got is_group=%d (%v)", isGroup, err)
+	}
+
+	var content string
+	var ts int64
+	if err := d.QueryRow(`
+		SELECT e.content, e.timestamp FROM events e
+		JOIN event_participants ep ON ep.event_id = e.id AND ep.role = 'sender'
+		JOIN contacts c ON c.id = ep.contact_id
+		WHERE c.display_name = 'Alice Smith' AND e.content_types = '["text"]'
+	`).Scan(&content, &ts); err != nil {
+		t.Fatalf("query alice message: %v", err)
+	}
+	if content != "first line\nsecond line" {
+		t.Fatalf("expected multi-line content, got %q", content)
+	}
+	if want := time.Date(2023, 11, 14, 22, 13, 0, 0, time.UTC).Unix(); ts != want {
+		t.Fatalf("expected timestamp %d, got %d", want, ts)
+	}
+
+	var phoneMsg, metadata string
+	if err := d.QueryRow(`
+		SELECT e.content, e.metadata_json FROM events e
+		JOIN event_participants ep ON ep.event_id = e.id AND ep.role = 'sender'
+		JOIN contact_identifiers ci ON ci.contact_id = ep.contact_id
+		WHERE ci.type = 'phone' AND ci.normalized = '5551234567' AND e.content_types = '["text"]'
+	`).Scan(&phoneMsg, &metadata); err != nil {
+		t.Fatalf("query phone sender: %v", err)
+	}
+	if phoneMsg != "see you at 9:30" || metadata != `{"edited":true}` {
+		t.Fatalf("unexpected phone message %q metadata %s", phoneMsg, metadata)
+	}
+
+	var sent int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM events WHERE direction = 'sent' AND content_types = '["attachment"]'`).Scan(&sent); err != nil || sent != 1 {
+		t.Fatalf("expected Tyler's omitted media as a sent attachment event, got %d (%v)", sent, err)
+	}
+
+	rows, err := d.Query(`
+		SELECT e.content, e.direction, e.metadata_json,
+			(SELECT COUNT(*) FROM event_participants ep WHERE ep.event_id = e.id AND ep.role = 'member'),
+			(SELECT COUNT(*) FROM event_participants ep WHERE ep.event_id = e.id AND ep.role = 'sender')
+		FROM events e WHERE e.content_types = '["membership"]'
+		ORDER BY e.timestamp, e.metadata_json
+	`)
+	if err != nil {
+		t.Fatalf("query membership: %v", err)
+	}
+	defer rows.Close()
+	type membership struct {
+		content, direction, metadata string
+		members, senders             int
+	}
+	var got []membership
+	for rows.Next() {
+		var m membership
+		if err := rows.Scan(&m.content, &m.direction, &m.metadata, &m.members, &m.senders); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, m)
+	}
+	want := []membership{
+		{"added", "sent", `{"action":"added","actor":"Tyler","member":"+1 (555) 123-4567"}`, 1, 1},
+		{"added", "sent", `{"action":"added","actor":"Tyler","member":"Alice Smith"}`, 1, 1},
+		{"removed", "received", `{"action":"removed","actor":"Alice Smith","member":"Alice Smith"}`, 1, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d membership events, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("membership %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	// Re-import is idempotent.
+	res, err = ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: chatPath, Me: "Tyler", Location: time.UTC})
+	if err != nil {
+		t.Fatalf("second ImportWhatsApp: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 {
+		t.Fatalf("expected no changes on re-import, got %+v", res)
+	}
+}
+
+func TestImportWhatsApp_IOSZipWithMedia(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	chat := "[14/11/2023, 22:13:20] Alice Smith: ‎Messages and calls are end-to-end encrypted.\n" +
+		"[14/11/2023, 22:13:25] Alice Smith: look\n" +
+		"[14/11/2023, 22:13:30] Alice Smith: ‎<attached: 00000012-PHOTO-2023-11-14-22-13-30.jpg>\n" +
+		"[15/11/2023, 08:00:00] Tyler: nice\n"
+
+	zipPath := filepath.Join(tmpDir, "WhatsApp Chat - Alice Smith.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{
+		"_chat.txt":                              chat,
+		"00000012-PHOTO-2023-11-14-22-13-30.jpg": "\xff\xd8\xff fake jpeg",
+	} {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := fw.Write([]byte(body)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	f.Close()
+
+	res, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: zipPath, Me: "Tyler", Location: time.UTC})
+	if err != nil {
+		t.Fatalf("ImportWhatsApp: %v", err)
+	}
+	if res.MessagesSeen != 3 || res.EventsCreated != 3 || res.AttachmentsCreated != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	var isGroup int
+	if err := d.QueryRow(`SELECT is_group FROM threads WHERE id = 'whatsapp:Alice Smith'`).Scan(&isGroup); err != nil || isGroup != 0 {
+		t.Fatalf("expected 1:1 thread named after the archive, got is_group=%d (%v)", isGroup, err)
+	}
+
+	var filename, mimeType, mediaType, storageType, storageURI string
+	if err := d.QueryRow(`SELECT filename, mime_type, media_type, storage_type, storage_uri FROM attachments`).Scan(&filename, &mimeType, &mediaType, &storageType, &storageURI); err != nil {
+		t.Fatalf("query attachment: %v", err)
+	}
+	if filename != "00000012-PHOTO-2023-11-14-22-13-30.jpg" || mimeType != "image/jpeg" || mediaType != "image" || storageType != "archive" {
+		t.Fatalf("unexpected attachment %q %q %q %q", filename, mimeType, mediaType, storageType)
+	}
+	if storageURI != zipPath+"#00000012-PHOTO-2023-11-14-22-13-30.jpg" {
+		t.Fatalf("unexpected storage uri %q", storageURI)
+	}
+
+	// 1:1 messages carry the other side as recipient.
+	var recipients int
+	if err := d.QueryRow(`
+		SELECT COUNT(*) FROM events e
+		JOIN event_participants ep ON ep.event_id = e.id AND ep.role = 'recipient'
+		WHERE e.direction = 'sent'
+	`).Scan(&recipients); err != nil || recipients != 1 {
+		t.Fatalf("expected 1 recipient on the sent message, got %d (%v)", recipients, err)
+	}
+}
+
+func TestImportWhatsApp_InvalidTimestamps(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	chatPath := filepath.Join(tmpDir, "WhatsApp Chat with Alice.txt")
+	chat := "14/11/2023, 22:13 - Alice: ok\n" +
+		"11/14/2023, 22:14 - Alice: month 14 under d/m/y\n"
+	if err := os.WriteFile(chatPath, []byte(chat), 0o644); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+
+	res, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: chatPath, DateOrder: "dmy", DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportWhatsApp: %v", err)
+	}
+	if res.MessagesSeen != 1 || res.InvalidTimestamps != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestImportWhatsApp_YouWithoutMe(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	chatPath := filepath.Join(tmpDir, "WhatsApp Chat with Alice.txt")
+	chat := "14/11/2023, 22:13 - Alice: hi\n" +
+		"14/11/2023, 22:14 - You: hey\n"
+	if err := os.WriteFile(chatPath, []byte(chat), 0o644); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+
+	res, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: chatPath, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("ImportWhatsApp: %v", err)
+	}
+	if res.EventsCreated != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	// Without --me the exporter's own messages are sent but unattributed.
+	var direction string
+	var senders int
+	if err := d.QueryRow(`
+		SELECT e.direction, (SELECT COUNT(*) FROM event_participants ep WHERE ep.event_id = e.id)
+		FROM events e WHERE e.content = 'hey'
+	`).Scan(&direction, &senders); err != nil || direction != "sent" || senders != 0 {
+		t.Fatalf("expected an unattributed sent message, got %q with %d participants (%v)", direction, senders, err)
+	}
+	var yous int
+	if err := d.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM contact_identifiers WHERE value = 'whatsapp:You')
+		     + (SELECT COUNT(*) FROM persons WHERE canonical_name = 'You')
+	`).Scan(&yous); err != nil || yous != 0 {
+		t.Fatalf("expected no contact or person for \"You\", got %d (%v)", yous, err)
+	}
+}
+
+func TestImportWhatsApp_RenamedChatThreadKey(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	chat := "14/11/2023, 22:13 - Alice: hi\n14/11/2023, 22:14 - Bob: hey\n"
+	first := filepath.Join(tmpDir, "WhatsApp Chat with Hiking.txt")
+	renamed := filepath.Join(tmpDir, "WhatsApp Chat with Hiking 2024.txt")
+	for _, p := range []string{first, renamed} {
+		if err := os.WriteFile(p, []byte(chat), 0o644); err != nil {
+			t.Fatalf("write chat: %v", err)
+		}
+	}
+
+	if _, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: first, Location: time.UTC}); err != nil {
+		t.Fatalf("ImportWhatsApp: %v", err)
+	}
+	res, err := ImportWhatsApp(context.Background(), d, WhatsAppImportOptions{Path: renamed, ThreadKey: "Hiking", Location: time.UTC})
+	if err != nil {
+		t.Fatalf("ImportWhatsApp renamed: %v", err)
+	}
+	if res.EventsCreated != 0 || res.ThreadsCreated != 0 {
+		t.Fatalf("expected the renamed export to reuse the thread and events: %+v", res)
+	}
+	var threads int
+	var name string
+	if err := d.QueryRow(`SELECT COUNT(*), MAX(name) FROM threads`).Scan(&threads, &name); err != nil || threads != 1 || name != "Hiking 2024" {
+		t.Fatalf("expected one renamed thread, got %d %q (%v)", threads, name, err)
+	}
+}
+
+func TestDetectWhatsAppDateOrder(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"14/11/2023, 22:13 - A: x\n", "dmy"},
+		{"11/14/23, 10:13 PM - A: x\n", "mdy"},
+		{"03/04/2023, 10:13 - A: x\n", "dmy"},
+		{"03/04/23, 10:13 PM - A: x\n", "mdy"},
+		{"2023-11-14 22:13 - A: x\n", "ymd"},
+		{"14.11.23, 22:13 - A: x\n", "dmy"},
+	}
+	for _, tc := range cases {
+		lines, err := parseWhatsAppLines(strings.NewReader(tc.text))
+		if err != nil || len(lines) != 1 {
+			t.Fatalf("%q: parsed %d lines (%v)", tc.text, len(lines), err)
+		}
+		if got := detectWhatsAppDateOrder(lines); got != tc.want {
+			t.Fatalf("%q: expected %s, got %s", tc.text, tc.want, got)
+		}
+	}
+}
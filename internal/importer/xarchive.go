@@ -0,0 +1,545 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/contacts"
+	"github.com/Napageneral/mnemonic/internal/ingest"
+	"github.com/Napageneral/mnemonic/internal/me"
+)
+
+type XArchiveImportOptions struct {
+	AdapterName string // defaults to "x", the bird adapter's name, so tweets and likes dedupe
+	Path        string // the archive zip or its unpacked directory
+	DryRun      bool
+}
+
+type XArchiveImportResult struct {
+	Tweets         int
+	Likes          int
+	LikesExisting  int // already synced by the bird adapter
+	DMs            int
+	Conversations  int
+	EventsCreated  int
+	EventsUpdated  int
+	PersonsCreated int
+	ThreadsCreated int
+	Duration       time.Duration
+}
+
+func (o XArchiveImportOptions) withDefaults() XArchiveImportOptions {
+	if strings.TrimSpace(o.AdapterName) == "" {
+		o.AdapterName = "x"
+	}
+	return o
+}
+
+type xAccount struct {
+	Account struct {
+		AccountID          string `json:"accountId"`
+		Username           string `json:"username"`
+		AccountDisplayName string `json:"accountDisplayName"`
+	} `json:"account"`
+}
+
+type xTweet struct {
+	Tweet struct {
+		IDStr               string `json:"id_str"`
+		FullText            string `json:"full_text"`
+		CreatedAt           string `json:"created_at"`
+		InReplyToStatusID   string `json:"in_reply_to_status_id_str"`
+		InReplyToUserID     string `json:"in_reply_to_user_id_str"`
+		InReplyToScreenName string `json:"in_reply_to_screen_name"`
+		Entities            struct {
+			UserMentions []xUser `json:"user_mentions"`
+			Media        []struct {
+				Type string `json:"type"`
+			} `json:"media"`
+		} `json:"entities"`
+	} `json:"tweet"`
+}
+
+type xUser struct {
+	IDStr      string `json:"id_str"`
+	ScreenName string `json:"screen_name"`
+	Name       string `json:"name"`
+}
+
+type xLike struct {
+	Like struct {
+		TweetID     string `json:"tweetId"`
+		FullText    string `json:"fullText"`
+		ExpandedURL string `json:"expandedUrl"`
+	} `json:"like"`
+}
+
+type xDMConversation struct {
+	DMConversation struct {
+		ConversationID string `json:"conversationId"`
+		Name           string `json:"name"`
+		Messages       []struct {
+			MessageCreate *struct {
+				ID          string   `json:"id"`
+				SenderID    string   `json:"senderId"`
+				RecipientID string   `json:"recipientId"`
+				Text        string   `json:"text"`
+				MediaURLs   []string `json:"mediaUrls"`
+				CreatedAt   string   `json:"createdAt"`
+			} `json:"messageCreate"`
+		} `json:"messages"`
+	} `json:"dmConversation"`
+}
+
+// xSession carries what every section of the archive needs: the owner's
+// account and the handles learned from tweet mentions.
+type xSession struct {
+	opts       XArchiveImportOptions
+	archive    fs.FS
+	root       string
+	owner      xUser
+	mePersonID string
+	users      map[string]xUser  // user id -> handle and name
+	contacts   map[string]string // user id -> contact id
+}
+
+// ImportXArchive imports the official X (Twitter) data archive. Tweets and
+// likes are written with the same source ids and channel as BirdAdapter so
+// the two sources dedupe; DMs become threads keyed by conversation id with
+// direction taken from the account owner's id.
+func ImportXArchive(ctx context.Context, db *sql.DB, opts XArchiveImportOptions) (XArchiveImportResult, error) {
+	start := time.Now()
+	opts = opts.withDefaults()
+	var out XArchiveImportResult
+
+	if strings.TrimSpace(opts.Path) == "" {
+		return out, fmt.Errorf("Path is required")
+	}
+	archive, err := openArchive(opts.Path)
+	if err != nil {
+		return out, err
+	}
+	defer archive.Close()
+	root, err := findArchiveRoot(archive, "data/account.js")
+	if err != nil {
+		return out, err
+	}
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return out, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	if err := ensureImportTables(db); err != nil {
+		return out, err
+	}
+
+	s := &xSession{opts: opts, archive: archive, root: root, users: map[string]xUser{}, contacts: map[string]string{}}
+	accounts, err := readXParts[xAccount](s, "account")
+	if err != nil {
+		return out, err
+	}
+	if len(accounts) == 0 || accounts[0].Account.AccountID == "" {
+		return out, fmt.Errorf("account.js has no account")
+	}
+	acct := accounts[0].Account
+	s.owner = xUser{IDStr: acct.AccountID, ScreenName: acct.Username, Name: acct.AccountDisplayName}
+	s.users[s.owner.IDStr] = s.owner
+	if p, err := me.GetMePerson(db); err == nil && p != nil {
+		s.mePersonID = p.ID
+	}
+
+	tweets, err := readXParts[xTweet](s, "tweets")
+	if err != nil {
+		return out, err
+	}
+	if len(tweets) == 0 {
+		// Archives from before 2019 name the file tweet.js.
+		if tweets, err = readXParts[xTweet](s, "tweet"); err != nil {
+			return out, err
+		}
+	}
+	for _, t := range tweets {
+		for _, u := range t.Tweet.Entities.UserMentions {
+			s.learnUser(u)
+		}
+		if t.Tweet.InReplyToUserID != "" {
+			s.learnUser(xUser{IDStr: t.Tweet.InReplyToUserID, ScreenName: t.Tweet.InReplyToScreenName})
+		}
+	}
+
+	likes, err := readXParts[xLike](s, "like")
+	if err != nil {
+		return out, err
+	}
+	dms, err := readXParts[xDMConversation](s, "direct-messages")
+	if err != nil {
+		return out, err
+	}
+	groupDMs, err := readXParts[xDMConversation](s, "direct-messages-group")
+	if err != nil {
+		return out, err
+	}
+	dms = append(dms, groupDMs...)
+
+	sections := []func(*ingest.Writer, *XArchiveImportResult) error{
+		func(w *ingest.Writer, out *XArchiveImportResult) error { return s.importTweets(w, tweets, out) },
+		func(w *ingest.Writer, out *XArchiveImportResult) error { return s.importLikes(w, likes, out) },
+		func(w *ingest.Writer, out *XArchiveImportResult) error { return s.importDMs(w, dms, out) },
+	}
+	for _, section := range sections {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if err := s.inTx(db, section, &out); err != nil {
+			return out, err
+		}
+	}
+
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+func (s *xSession) inTx(db *sql.DB, fn func(*ingest.Writer, *XArchiveImportResult) error, out *XArchiveImportResult) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	w, err := ingest.NewWriter(tx, s.opts.AdapterName)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := fn(w, out); err != nil {
+		return err
+	}
+	out.EventsCreated += w.Stats.EventsCreated
+	out.EventsUpdated += w.Stats.EventsUpdated
+	out.PersonsCreated += w.Stats.PersonsCreated
+	out.ThreadsCreated += w.Stats.ThreadsCreated
+	if s.opts.DryRun {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+// readXParts decodes data/<name>.js and its data/<name>-part<N>.js
+// continuations. Each file is a JSON array assigned to a window.YTD global.
+func readXParts[T any](s *xSession, name string) ([]T, error) {
+	files := []string{path.Join(s.root, "data", name+".js")}
+	more, err := fs.Glob(s.archive, path.Join(s.root, "data", name+"-part*.js"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(more)
+	files = append(files, more...)
+
+	var items []T
+	for _, f := range files {
+		data, err := fs.ReadFile(s.archive, f)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f, err)
+		}
+		if i := bytes.IndexByte(data, '='); i >= 0 && bytes.HasPrefix(bytes.TrimSpace(data), []byte("window.")) {
+			data = data[i+1:]
+		}
+		var part []T
+		if err := json.Unmarshal(data, &part); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path.Base(f), err)
+		}
+		items = append(items, part...)
+	}
+	return items, nil
+}
+
+func (s *xSession) learnUser(u xUser) {
+	if u.IDStr == "" || u.ScreenName == "" {
+		return
+	}
+	if _, ok := s.users[u.IDStr]; !ok || s.users[u.IDStr].Name == "" {
+		s.users[u.IDStr] = u
+	}
+}
+
+// contact resolves an X user id. Users whose handle is known are keyed by
+// handle, like BirdAdapter's authors, with the numeric id linked as
+// "x:<id>"; users only seen in DMs are keyed by that id alone.
+func (s *xSession) contact(w *ingest.Writer, userID string) (string, error) {
+	if id, ok := s.contacts[userID]; ok {
+		return id, nil
+	}
+	u, known := s.users[userID]
+	if userID == s.owner.IDStr && s.mePersonID != "" {
+		contactID, _, err := contacts.GetOrCreateContact(w.Tx(), "handle", firstNonEmpty(u.ScreenName, "x:"+userID), u.Name, w.Adapter())
+		if err != nil {
+			return "", err
+		}
+		if err := contacts.EnsurePersonContactLink(w.Tx(), s.mePersonID, contactID, "deterministic", 1.0); err != nil {
+			return "", err
+		}
+	}
+	if !known || u.ScreenName == "" {
+		contactID, err := w.Contact("handle", "x:"+userID, "")
+		if err != nil {
+			return "", err
+		}
+		s.contacts[userID] = contactID
+		return contactID, nil
+	}
+	contactID, err := w.Contact("handle", u.ScreenName, u.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := w.LinkIdentifier(contactID, "handle", "x:"+userID); err != nil {
+		return "", err
+	}
+	s.contacts[userID] = contactID
+	return contactID, nil
+}
+
+func (s *xSession) importTweets(w *ingest.Writer, tweets []xTweet, out *XArchiveImportResult) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+	ownerID, err := s.contact(w, s.owner.IDStr)
+	if err != nil {
+		return err
+	}
+	parents := make(map[string]string, len(tweets))
+	for _, t := range tweets {
+		parents[t.Tweet.IDStr] = t.Tweet.InReplyToStatusID
+	}
+
+	for _, t := range tweets {
+		tw := t.Tweet
+		if tw.IDStr == "" {
+			continue
+		}
+		ts := time.Now().Unix()
+		if parsed, err := time.Parse(time.RubyDate, tw.CreatedAt); err == nil {
+			ts = parsed.Unix()
+		}
+		contentTypes := []string{"text"}
+		if len(tw.Entities.Media) > 0 {
+			contentTypes = append(contentTypes, "image")
+		}
+		replyTo := ""
+		if _, ok := parents[tw.InReplyToStatusID]; ok {
+			replyTo = w.ID("tweet:" + tw.InReplyToStatusID)
+		}
+
+		eventID, _, err := w.UpsertEvent(ingest.Event{
+			SourceID:     "tweet:" + tw.IDStr,
+			Timestamp:    ts,
+			Channel:      "x",
+			ContentTypes: contentTypes,
+			Content:      tw.FullText,
+			Direction:    "sent",
+			ThreadID:     xConversationRoot(tw.IDStr, parents),
+			ReplyTo:      replyTo,
+		})
+		if err != nil {
+			return err
+		}
+		if err := w.AddParticipant(eventID, ownerID, "sender"); err != nil {
+			return err
+		}
+		for _, u := range tw.Entities.UserMentions {
+			if u.IDStr == "" || u.IDStr == s.owner.IDStr {
+				continue
+			}
+			contactID, err := s.contact(w, u.IDStr)
+			if err != nil {
+				return err
+			}
+			if err := w.AddParticipant(eventID, contactID, "recipient"); err != nil {
+				return err
+			}
+		}
+		out.Tweets++
+	}
+	return nil
+}
+
+// xConversationRoot follows reply links through the archive's own tweets to
+// approximate the conversation id BirdAdapter stores as thread_id. Replies
+// to other people's tweets stop at the first tweet outside the archive.
+func xConversationRoot(id string, parents map[string]string) string {
+	root := id
+	for i := 0; i < 1000; i++ {
+		parent := parents[root]
+		if parent == "" {
+			break
+		}
+		root = parent
+		if _, ok := parents[parent]; !ok {
+			break
+		}
+	}
+	return root
+}
+
+func (s *xSession) importLikes(w *ingest.Writer, likes []xLike, out *XArchiveImportResult) error {
+	for _, l := range likes {
+		like := l.Like
+		if like.TweetID == "" {
+			continue
+		}
+		sourceID := "like:" + like.TweetID
+		// BirdAdapter records the author and conversation the archive
+		// lacks, so its rows are left as they are.
+		var exists int
+		if err := w.Tx().QueryRow(`SELECT 1 FROM events WHERE source_adapter = ? AND source_id = ?`, w.Adapter(), sourceID).Scan(&exists); err == nil {
+			out.LikesExisting++
+			continue
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+		var metadata map[string]any
+		if like.ExpandedURL != "" {
+			metadata = map[string]any{"url": like.ExpandedURL}
+		}
+		if _, _, err := w.UpsertEvent(ingest.Event{
+			SourceID:  sourceID,
+			Timestamp: xSnowflakeTime(like.TweetID),
+			Channel:   "x",
+			Content:   like.FullText,
+			Direction: "observed",
+			ThreadID:  like.TweetID,
+			Metadata:  metadata,
+		}); err != nil {
+			return err
+		}
+		out.Likes++
+	}
+	return nil
+}
+
+// xSnowflakeTime returns the creation time encoded in a tweet id. Ids from
+// before November 2010 carry no timestamp and fall back to now, as
+// BirdAdapter does for unparseable dates.
+func xSnowflakeTime(id string) int64 {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < 1<<32 {
+		return time.Now().Unix()
+	}
+	const twitterEpochMs = 1288834974657
+	return ((n >> 22) + twitterEpochMs) / 1000
+}
+
+func (s *xSession) importDMs(w *ingest.Writer, convs []xDMConversation, out *XArchiveImportResult) error {
+	for _, c := range convs {
+		conv := c.DMConversation
+		if conv.ConversationID == "" {
+			continue
+		}
+		var members []string
+		seen := map[string]bool{}
+		for _, m := range conv.Messages {
+			if mc := m.MessageCreate; mc != nil {
+				for _, id := range []string{mc.SenderID, mc.RecipientID} {
+					if id != "" && !seen[id] {
+						seen[id] = true
+						members = append(members, id)
+					}
+				}
+			}
+		}
+		// One-to-one conversation ids are "<user id>-<user id>"; group
+		// conversations have a plain numeric id.
+		isGroup := !strings.Contains(conv.ConversationID, "-")
+		if !isGroup {
+			for _, id := range strings.Split(conv.ConversationID, "-") {
+				if id != "" && !seen[id] {
+					seen[id] = true
+					members = append(members, id)
+				}
+			}
+		}
+
+		name := conv.Name
+		if name == "" {
+			var others []string
+			for _, id := range members {
+				if id == s.owner.IDStr {
+					continue
+				}
+				if u, ok := s.users[id]; ok && u.ScreenName != "" {
+					others = append(others, "@"+u.ScreenName)
+				} else {
+					others = append(others, id)
+				}
+			}
+			name = strings.Join(others, ", ")
+		}
+		threadID, err := w.UpsertThread(ingest.Thread{
+			SourceID: "dm:" + conv.ConversationID,
+			Channel:  "x",
+			Name:     name,
+			IsGroup:  isGroup,
+		})
+		if err != nil {
+			return err
+		}
+		out.Conversations++
+
+		for _, m := range conv.Messages {
+			mc := m.MessageCreate
+			if mc == nil || mc.ID == "" {
+				continue
+			}
+			ts := time.Now().Unix()
+			if parsed, err := time.Parse(time.RFC3339Nano, mc.CreatedAt); err == nil {
+				ts = parsed.Unix()
+			}
+			contentTypes := []string{"text"}
+			if len(mc.MediaURLs) > 0 {
+				contentTypes = append(contentTypes, "image")
+			}
+			direction := "received"
+			if mc.SenderID == s.owner.IDStr {
+				direction = "sent"
+			}
+			eventID, _, err := w.UpsertEvent(ingest.Event{
+				SourceID:     "dm:" + mc.ID,
+				Timestamp:    ts,
+				Channel:      "x",
+				ContentTypes: contentTypes,
+				Content:      mc.Text,
+				Direction:    direction,
+				ThreadID:     threadID,
+			})
+			if err != nil {
+				return err
+			}
+			for _, id := range members {
+				role := "recipient"
+				if id == mc.SenderID {
+					role = "sender"
+				}
+				contactID, err := s.contact(w, id)
+				if err != nil {
+					return err
+				}
+				if err := w.AddParticipant(eventID, contactID, role); err != nil {
+					return err
+				}
+			}
+			out.DMs++
+		}
+	}
+	return nil
+}
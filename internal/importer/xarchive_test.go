@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var xArchiveFiles = map[string]string{
+	"account.js": `window.YTD.account.part0 = [{"account": {"accountId": "100", "username": "me_on_x", "accountDisplayName": "Me"}}]`,
+	"tweets.js": `window.YTD.tweets.part0 = [
+  {"tweet": {"id_str": "1500000000000000001", "full_text": "Shipping today @alice", "created_at": "Sun Mar 06 12:00:00 +0000 2022",
+    "entities": {"user_mentions": [{"id_str": "200", "screen_name": "alice", "name": "Alice"}]}}}
+]`,
+	"tweets-part1.js": `window.YTD.tweets.part1 = [
+  {"tweet": {"id_str": "1500000000000000002", "full_text": "Follow-up", "created_at": "Sun Mar 06 12:05:00 +0000 2022",
+    "in_reply_to_status_id_str": "1500000000000000001", "in_reply_to_user_id_str": "100", "in_reply_to_screen_name": "me_on_x"}}
+]`,
+	"like.js": `window.YTD.like.part0 = [
+  {"like": {"tweetId": "1600000000000000000", "fullText": "A liked tweet", "expandedUrl": "https://twitter.com/i/web/status/1600000000000000000"}},
+  {"like": {"tweetId": "1600000000000000001", "fullText": "Already synced"}}
+]`,
+	"direct-messages.js": `window.YTD.direct_messages.part0 = [
+  {"dmConversation": {"conversationId": "100-200", "messages": [
+    {"messageCreate": {"id": "900", "senderId": "200", "recipientId": "100", "text": "hey", "mediaUrls": [], "createdAt": "2022-03-06T13:00:00.000Z"}},
+    {"messageCreate": {"id": "901", "senderId": "100", "recipientId": "200", "text": "hi Alice", "mediaUrls": [], "createdAt": "2022-03-06T13:01:00.000Z"}}
+  ]}}
+]`,
+}
+
+func TestImportXArchive(t *testing.T) {
+	d, tmpDir := openImporterTestDB(t)
+
+	dataDir := filepath.Join(tmpDir, "twitter-2024", "data")
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, body := range xArchiveFiles {
+		if err := os.WriteFile(filepath.Join(dataDir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	// A like the bird adapter already synced, with its random event id.
+	if _, err := d.Exec(`
+		INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, reply_to, source_adapter, source_id)
+		VALUES ('bird-uuid', 1669000000, 'x', '["text"]', 'Already synced', 'observed', '1599999999999999999', '', 'x', 'like:1600000000000000001')
+	`); err != nil {
+		t.Fatalf("seed bird like: %v", err)
+	}
+
+	res, err := ImportXArchive(context.Background(), d, XArchiveImportOptions{Path: tmpDir})
+	if err != nil {
+		t.Fatalf("ImportXArchive: %v", err)
+	}
+	if res.Tweets != 2 || res.Likes != 1 || res.LikesExisting != 1 || res.DMs != 2 || res.Conversations != 1 || res.EventsCreated != 5 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	var threadID, replyTo, direction string
+	if err := d.QueryRow(`SELECT thread_id, reply_to, direction FROM events WHERE source_adapter = 'x' AND source_id = 'tweet:1500000000000000002'`).Scan(&threadID, &replyTo, &direction); err != nil {
+		t.Fatalf("reply tweet: %v", err)
+	}
+	if threadID != "1500000000000000001" || replyTo != "x:tweet:1500000000000000001" || direction != "sent" {
+		t.Fatalf("unexpected reply tweet thread=%s reply_to=%s direction=%s", threadID, replyTo, direction)
+	}
+
+	var likeTS int64
+	if err := d.QueryRow(`SELECT timestamp FROM events WHERE source_adapter = 'x' AND source_id = 'like:1600000000000000000'`).Scan(&likeTS); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+	if likeTS != 1670304701 {
+		t.Fatalf("expected snowflake timestamp, got %d", likeTS)
+	}
+	var likes int
+	_ = d.QueryRow(`SELECT COUNT(*) FROM events WHERE source_id = 'like:1600000000000000001'`).Scan(&likes)
+	if likes != 1 {
+		t.Fatalf("expected bird like to dedupe, got %d rows", likes)
+	}
+
+	dirs := map[string]string{}
+	rows, err := d.Query(`SELECT source_id, direction FROM events WHERE thread_id = 'x:dm:100-200'`)
+	if err != nil {
+		t.Fatalf("query dms: %v", err)
+	}
+	for rows.Next() {
+		var id, dir string
+		if err := rows.Scan(&id, &dir); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		dirs[id] = dir
+	}
+	rows.Close()
+	if dirs["dm:900"] != "received" || dirs["dm:901"] != "sent" {
+		t.Fatalf("unexpected dm directions %v", dirs)
+	}
+	var threadName string
+	if err := d.QueryRow(`SELECT name FROM threads WHERE id = 'x:dm:100-200'`).Scan(&threadName); err != nil || threadName != "@alice" {
+		t.Fatalf("unexpected dm thread name %q (%v)", threadName, err)
+	}
+
+	// The DM sender and the mentioned user resolve to the same contact.
+	var contactsForAlice int
+	_ = d.QueryRow(`
+		SELECT COUNT(DISTINCT p.contact_id) FROM event_participants p
+		JOIN contact_identifiers ci ON ci.contact_id = p.contact_id
+		WHERE ci.type = 'handle' AND ci.normalized = '@alice'
+	`).Scan(&contactsForAlice)
+	var aliceByID int
+	_ = d.QueryRow(`
+		SELECT COUNT(*) FROM contact_identifiers a JOIN contact_identifiers b ON a.contact_id = b.contact_id
+		WHERE a.normalized = '@alice' AND b.normalized = '@x:200'
+	`).Scan(&aliceByID)
+	if contactsForAlice != 1 || aliceByID != 1 {
+		t.Fatalf("expected one alice contact with her user id linked, got %d/%d", contactsForAlice, aliceByID)
+	}
+
+	res, err = ImportXArchive(context.Background(), d, XArchiveImportOptions{Path: tmpDir})
+	if err != nil {
+		t.Fatalf("second ImportXArchive: %v", err)
+	}
+	if res.EventsCreated != 0 || res.EventsUpdated != 0 || res.ThreadsCreated != 0 {
+		t.Fatalf("expected idempotent re-import, got %+v", res)
+	}
+}
@@ -0,0 +1,63 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/bus"
+)
+
+// RecordRevision keeps the current content of an event in event_revisions
+// before an adapter overwrites it with content, and emits an event.edited
+// bus event. It returns false when the event is new or its content is
+// unchanged. Call it before the UPDATE, inside the same transaction.
+func RecordRevision(db DBTX, adapter, sourceID, content string) (bool, error) {
+	var eventID, channel string
+	var prior, contentTypes sql.NullString
+	var timestamp int64
+	err := db.QueryRow(`
+		SELECT id, channel, content, content_types, timestamp
+		FROM events WHERE source_adapter = ? AND source_id = ?
+	`, adapter, sourceID).Scan(&eventID, &channel, &prior, &contentTypes, &timestamp)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load event %s: %w", sourceID, err)
+	}
+	if prior.String == content {
+		return false, nil
+	}
+
+	// The original content counts from the event's own timestamp; later
+	// versions from when the previous edit was seen.
+	var revision int
+	var observedAt sql.NullInt64
+	if err := db.QueryRow(`
+		SELECT COUNT(*), MAX(replaced_at) FROM event_revisions WHERE event_id = ?
+	`, eventID).Scan(&revision, &observedAt); err != nil {
+		return false, fmt.Errorf("load revisions for %s: %w", eventID, err)
+	}
+	revision++
+	observed := timestamp
+	if observedAt.Valid {
+		observed = observedAt.Int64
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec(`
+		INSERT INTO event_revisions (event_id, revision, content, content_types, observed_at, replaced_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, eventID, revision, prior, contentTypes, observed, now); err != nil {
+		return false, fmt.Errorf("record revision for %s: %w", eventID, err)
+	}
+	if err := bus.Emit(db, bus.TypeEventEdited, adapter, eventID, bus.EventEditedPayload{
+		Channel:       channel,
+		SourceAdapter: adapter,
+		SourceID:      sourceID,
+		Revision:      revision,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
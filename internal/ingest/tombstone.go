@@ -0,0 +1,117 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/bus"
+)
+
+// DeletedStatus is the event_state.status value for events the source
+// reported as deleted.
+const DeletedStatus = "deleted"
+
+// DBTX is the subset of *sql.DB and *sql.Tx the tombstone and revision
+// helpers need.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NotDeleted returns a WHERE fragment excluding tombstoned events, where
+// alias is the events table alias (or "events").
+func NotDeleted(alias string) string {
+	return alias + ".id NOT IN (SELECT event_id FROM event_state WHERE status = '" + DeletedStatus + "')"
+}
+
+// MarkDeleted tombstones an event and emits an event.deleted bus event. It
+// returns false when the event does not exist or is already tombstoned.
+func MarkDeleted(db DBTX, adapter, eventID string) (bool, error) {
+	var sourceAdapter, sourceID, channel string
+	var status sql.NullString
+	err := db.QueryRow(`
+		SELECT e.source_adapter, e.source_id, e.channel, s.status
+		FROM events e
+		LEFT JOIN event_state s ON s.event_id = e.id
+		WHERE e.id = ?
+	`, eventID).Scan(&sourceAdapter, &sourceID, &channel, &status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load event %s: %w", eventID, err)
+	}
+	if status.String == DeletedStatus {
+		return false, nil
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO event_state (event_id, read_state, flagged, archived, status, updated_at)
+		VALUES (?, 'unknown', 0, 0, ?, ?)
+		ON CONFLICT(event_id) DO UPDATE SET
+			status = excluded.status,
+			updated_at = excluded.updated_at
+	`, eventID, DeletedStatus, time.Now().Unix()); err != nil {
+		return false, fmt.Errorf("tombstone event %s: %w", eventID, err)
+	}
+	if err := bus.Emit(db, bus.TypeEventDeleted, adapter, eventID, bus.EventDeletedPayload{
+		Channel:       channel,
+		SourceAdapter: sourceAdapter,
+		SourceID:      sourceID,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Tombstone marks the event with the given source id as deleted. Unknown
+// source ids are ignored.
+func (w *Writer) Tombstone(sourceID string) (bool, error) {
+	var id string
+	err := w.tx.QueryRow(`SELECT id FROM events WHERE source_adapter = ? AND source_id = ?`, w.adapter, sourceID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lookup event %s: %w", sourceID, err)
+	}
+	return w.TombstoneEvent(id)
+}
+
+// TombstoneEvent is Tombstone for callers that already hold the event id.
+func (w *Writer) TombstoneEvent(eventID string) (bool, error) {
+	deleted, err := MarkDeleted(w.tx, w.adapter, eventID)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		w.Stats.EventsDeleted++
+	}
+	return deleted, nil
+}
+
+// PurgeDeleted clears content, metadata, earlier revisions and attachments
+// of tombstoned events from one adapter. The event rows and their state are
+// kept so the deletion is not re-imported. Pass a transaction so no purged
+// event is left with part of its content.
+func PurgeDeleted(db DBTX, adapter string) (int, error) {
+	deleted := `SELECT e.id FROM events e JOIN event_state s ON s.event_id = e.id
+		WHERE e.source_adapter = ? AND s.status = '` + DeletedStatus + `'`
+	if _, err := db.Exec(`DELETE FROM event_revisions WHERE event_id IN (`+deleted+`)`, adapter); err != nil {
+		return 0, fmt.Errorf("purge revisions: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM attachments WHERE event_id IN (`+deleted+`)`, adapter); err != nil {
+		return 0, fmt.Errorf("purge attachments: %w", err)
+	}
+	res, err := db.Exec(`
+		UPDATE events SET content = '', metadata_json = NULL
+		WHERE id IN (`+deleted+`)
+		  AND (COALESCE(content, '') != '' OR metadata_json IS NOT NULL)
+	`, adapter)
+	if err != nil {
+		return 0, fmt.Errorf("purge events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -0,0 +1,499 @@
+// Package ingest holds the shared write path for adapters and importers that
+// produce threads, events, participants and attachments from an external source.
+package ingest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/bus"
+	"github.com/Napageneral/mnemonic/internal/contacts"
+)
+
+// Thread is a grouping container (chat, channel, email thread).
+type Thread struct {
+	ID       string // full thread id; defaults to "<adapter>:<source_id>"
+	SourceID string
+	Channel  string
+	Name     string
+	IsGroup  bool
+	ParentID string // full id of the parent thread, if any
+}
+
+// Event is a single ledger entry. ID defaults to "<adapter>:<source_id>".
+type Event struct {
+	ID           string
+	SourceID     string
+	Timestamp    int64
+	Channel      string
+	ContentTypes []string
+	Content      string
+	Direction    string // sent, received, observed
+	ThreadID     string
+	ReplyTo      string
+	Metadata     map[string]any
+}
+
+// Attachment is file/media metadata linked to an event.
+type Attachment struct {
+	ID          string // defaults to "<adapter>:<source_id>"
+	SourceID    string
+	EventID     string
+	Filename    string
+	MimeType    string
+	SizeBytes   int64
+	MediaType   string // derived from MimeType when empty
+	StorageURI  string
+	StorageType string
+	ContentHash string
+	Metadata    map[string]any
+	CreatedAt   int64
+}
+
+// Stats counts what a Writer changed.
+type Stats struct {
+	EventsCreated      int
+	EventsUpdated      int
+	PersonsCreated     int
+	ThreadsCreated     int
+	ThreadsUpdated     int
+	AttachmentsCreated int
+	AttachmentsUpdated int
+	EventsDeleted      int
+	ReactionsCreated   int
+	ReactionsUpdated   int
+}
+
+// Writer upserts records for one source adapter inside a transaction.
+// Identifiers are deterministic ("<adapter>:<source_id>") so re-running an
+// import or sync updates rows in place instead of duplicating them.
+type Writer struct {
+	tx      *sql.Tx
+	adapter string
+	Stats   Stats
+
+	contactCache map[string]string
+
+	insThread   *sql.Stmt
+	updThread   *sql.Stmt
+	insEvent    *sql.Stmt
+	updEvent    *sql.Stmt
+	insPart     *sql.Stmt
+	insAttach   *sql.Stmt
+	updAttach   *sql.Stmt
+	insTag      *sql.Stmt
+	upsertState *sql.Stmt
+}
+
+// NewWriter prepares statements on tx for the given source adapter.
+func NewWriter(tx *sql.Tx, adapter string) (*Writer, error) {
+	if strings.TrimSpace(adapter) == "" {
+		return nil, fmt.Errorf("adapter name is required")
+	}
+	w := &Writer{adapter: adapter, contactCache: map[string]string{}}
+	if err := w.prepare(tx); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Rebind moves the writer onto a new transaction, keeping its stats and
+// contact cache. Callers that commit in batches use it after each commit.
+func (w *Writer) Rebind(tx *sql.Tx) error {
+	w.Close()
+	return w.prepare(tx)
+}
+
+func (w *Writer) prepare(tx *sql.Tx) error {
+	w.tx = tx
+	var err error
+	prepare := func(dst **sql.Stmt, query string) {
+		if err != nil {
+			return
+		}
+		*dst, err = tx.Prepare(query)
+	}
+	prepare(&w.insThread, `
+		INSERT OR IGNORE INTO threads (id, channel, name, is_group, source_adapter, source_id, parent_thread_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	prepare(&w.updThread, `
+		UPDATE threads
+		SET name = ?, is_group = ?, parent_thread_id = ?, updated_at = ?
+		WHERE id = ?
+		  AND (name IS NOT ? OR is_group IS NOT ? OR parent_thread_id IS NOT ?)
+	`)
+	prepare(&w.insEvent, `
+		INSERT OR IGNORE INTO events (
+			id, timestamp, channel, content_types, content,
+			direction, thread_id, reply_to, source_adapter, source_id, metadata_json
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	prepare(&w.updEvent, `
+		UPDATE events
+		SET timestamp = ?, channel = ?, content_types = ?, content = ?,
+			direction = ?, thread_id = ?, reply_to = ?, metadata_json = ?
+		WHERE source_adapter = ? AND source_id = ?
+		  AND (
+		    timestamp IS NOT ? OR channel IS NOT ? OR content_types IS NOT ? OR content IS NOT ?
+		    OR direction IS NOT ? OR thread_id IS NOT ? OR reply_to IS NOT ? OR metadata_json IS NOT ?
+		  )
+	`)
+	prepare(&w.insPart, `
+		INSERT OR IGNORE INTO event_participants (event_id, contact_id, role)
+		VALUES (?, ?, ?)
+	`)
+	prepare(&w.insAttach, `
+		INSERT OR IGNORE INTO attachments (
+			id, event_id, filename, mime_type, size_bytes,
+			media_type, storage_uri, storage_type, content_hash,
+			source_id, metadata_json, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	prepare(&w.updAttach, `
+		UPDATE attachments
+		SET event_id = ?, filename = ?, mime_type = ?, size_bytes = ?, media_type = ?,
+			storage_uri = ?, storage_type = ?, content_hash = ?, metadata_json = ?
+		WHERE id = ?
+		  AND (
+		    event_id IS NOT ? OR filename IS NOT ? OR mime_type IS NOT ? OR size_bytes IS NOT ? OR media_type IS NOT ?
+		    OR storage_uri IS NOT ? OR storage_type IS NOT ? OR content_hash IS NOT ? OR metadata_json IS NOT ?
+		  )
+	`)
+	prepare(&w.insTag, `
+		INSERT INTO event_tags (event_id, tag, source, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_id, tag, source) DO NOTHING
+	`)
+	prepare(&w.upsertState, `
+		INSERT INTO event_state (event_id, read_state, flagged, archived, status, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id) DO UPDATE SET
+			read_state = excluded.read_state,
+			flagged = excluded.flagged,
+			archived = excluded.archived,
+			status = excluded.status,
+			updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("prepare ingest statements: %w", err)
+	}
+	return nil
+}
+
+// Close releases prepared statements. The transaction is left to the caller.
+func (w *Writer) Close() {
+	for _, stmt := range []*sql.Stmt{
+		w.insThread, w.updThread, w.insEvent, w.updEvent, w.insPart,
+		w.insAttach, w.updAttach, w.insTag, w.upsertState,
+	} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+}
+
+// Tx returns the transaction the writer is bound to.
+func (w *Writer) Tx() *sql.Tx {
+	return w.tx
+}
+
+// Adapter returns the source_adapter value the writer stamps on rows.
+func (w *Writer) Adapter() string {
+	return w.adapter
+}
+
+// ID builds the deterministic row id for a source id.
+func (w *Writer) ID(sourceID string) string {
+	return w.adapter + ":" + sourceID
+}
+
+// UpsertThread inserts or updates a thread and returns its id.
+func (w *Writer) UpsertThread(t Thread) (string, error) {
+	if strings.TrimSpace(t.SourceID) == "" {
+		return "", fmt.Errorf("thread source id is required")
+	}
+	id := t.ID
+	if id == "" {
+		id = w.ID(t.SourceID)
+	}
+	isGroup := 0
+	if t.IsGroup {
+		isGroup = 1
+	}
+	parent := nullIfEmpty(t.ParentID)
+	now := time.Now().Unix()
+
+	res, err := w.insThread.Exec(id, t.Channel, t.Name, isGroup, w.adapter, t.SourceID, parent, now, now)
+	if err != nil {
+		return "", fmt.Errorf("insert thread: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		w.Stats.ThreadsCreated++
+		return id, nil
+	}
+	res, err = w.updThread.Exec(t.Name, isGroup, parent, now, id, t.Name, isGroup, parent)
+	if err != nil {
+		return "", fmt.Errorf("update thread: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		w.Stats.ThreadsUpdated++
+		if err := bus.Emit(w.tx, bus.TypeThreadUpdated, w.adapter, "", bus.ThreadUpdatedPayload{
+			ThreadID:       id,
+			Channel:        t.Channel,
+			Name:           t.Name,
+			IsGroup:        t.IsGroup,
+			ParentThreadID: t.ParentID,
+			SourceAdapter:  w.adapter,
+			SourceID:       t.SourceID,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// UpsertEvent inserts or updates an event and returns its id along with
+// whether it was newly created. Changes are announced on the bus as
+// event.created or event.updated.
+func (w *Writer) UpsertEvent(e Event) (string, bool, error) {
+	return w.upsertEvent(e, &w.Stats.EventsCreated, &w.Stats.EventsUpdated)
+}
+
+// UpsertReaction is UpsertEvent for reaction events, which are counted in
+// ReactionsCreated/ReactionsUpdated rather than with messages.
+func (w *Writer) UpsertReaction(e Event) (string, bool, error) {
+	if len(e.ContentTypes) == 0 {
+		e.ContentTypes = []string{"reaction"}
+	}
+	return w.upsertEvent(e, &w.Stats.ReactionsCreated, &w.Stats.ReactionsUpdated)
+}
+
+func (w *Writer) upsertEvent(e Event, created, updated *int) (string, bool, error) {
+	if strings.TrimSpace(e.SourceID) == "" {
+		return "", false, fmt.Errorf("event source id is required")
+	}
+	id := e.ID
+	if id == "" {
+		id = w.ID(e.SourceID)
+	}
+	contentTypes := e.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"text"}
+	}
+	ctJSON, err := json.Marshal(contentTypes)
+	if err != nil {
+		return "", false, err
+	}
+	direction := e.Direction
+	if direction == "" {
+		direction = "observed"
+	}
+	var metadata any
+	if len(e.Metadata) > 0 {
+		b, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return "", false, fmt.Errorf("marshal event metadata: %w", err)
+		}
+		metadata = string(b)
+	}
+	threadID := nullIfEmpty(e.ThreadID)
+	replyTo := nullIfEmpty(e.ReplyTo)
+
+	res, err := w.insEvent.Exec(id, e.Timestamp, e.Channel, string(ctJSON), e.Content,
+		direction, threadID, replyTo, w.adapter, e.SourceID, metadata)
+	if err != nil {
+		return "", false, fmt.Errorf("insert event: %w", err)
+	}
+	payload := bus.EventPayload{
+		Channel:       e.Channel,
+		Direction:     direction,
+		Timestamp:     e.Timestamp,
+		ThreadID:      e.ThreadID,
+		SourceAdapter: w.adapter,
+		SourceID:      e.SourceID,
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		*created++
+		if err := bus.Emit(w.tx, bus.TypeEventCreated, w.adapter, id, payload); err != nil {
+			return "", false, err
+		}
+		return id, true, nil
+	}
+	if _, err := RecordRevision(w.tx, w.adapter, e.SourceID, e.Content); err != nil {
+		return "", false, err
+	}
+	res, err = w.updEvent.Exec(
+		e.Timestamp, e.Channel, string(ctJSON), e.Content, direction, threadID, replyTo, metadata,
+		w.adapter, e.SourceID,
+		e.Timestamp, e.Channel, string(ctJSON), e.Content, direction, threadID, replyTo, metadata,
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("update event: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		*updated++
+		if err := bus.Emit(w.tx, bus.TypeEventUpdated, w.adapter, id, payload); err != nil {
+			return "", false, err
+		}
+	}
+	return id, false, nil
+}
+
+// Contact resolves an identifier to a contact (creating it if needed) and
+// ensures a person exists when the display name is meaningful.
+func (w *Writer) Contact(identifierType, value, displayName string) (string, error) {
+	normalized := contacts.NormalizeIdentifier(value, identifierType)
+	if normalized == "" {
+		return "", fmt.Errorf("empty %s identifier", identifierType)
+	}
+	key := identifierType + ":" + normalized
+	if id, ok := w.contactCache[key]; ok {
+		return id, nil
+	}
+	contactID, _, err := contacts.GetOrCreateContact(w.tx, identifierType, value, displayName, w.adapter)
+	if err != nil {
+		return "", err
+	}
+	if _, created, err := contacts.EnsurePersonForContact(w.tx, contactID, displayName, "deterministic", 0.8); err == nil && created {
+		w.Stats.PersonsCreated++
+	}
+	w.contactCache[key] = contactID
+	return contactID, nil
+}
+
+// LinkIdentifier attaches an additional identifier (e.g. a profile email) to a contact.
+func (w *Writer) LinkIdentifier(contactID, identifierType, value string) error {
+	return contacts.EnsureContactIdentifier(w.tx, contactID, identifierType, value)
+}
+
+// AddParticipant links a contact to an event with a role (sender, recipient, member, ...).
+func (w *Writer) AddParticipant(eventID, contactID, role string) error {
+	if _, err := w.insPart.Exec(eventID, contactID, role); err != nil {
+		return fmt.Errorf("insert participant: %w", err)
+	}
+	return nil
+}
+
+// UpsertAttachment inserts or updates an attachment and returns its id.
+func (w *Writer) UpsertAttachment(a Attachment) (string, error) {
+	if strings.TrimSpace(a.SourceID) == "" {
+		return "", fmt.Errorf("attachment source id is required")
+	}
+	if a.EventID == "" {
+		return "", fmt.Errorf("attachment event id is required")
+	}
+	id := a.ID
+	if id == "" {
+		id = w.ID(a.SourceID)
+	}
+	mediaType := a.MediaType
+	if mediaType == "" {
+		mediaType = MediaType(a.MimeType)
+	}
+	var metadata any
+	if len(a.Metadata) > 0 {
+		b, err := json.Marshal(a.Metadata)
+		if err != nil {
+			return "", fmt.Errorf("marshal attachment metadata: %w", err)
+		}
+		metadata = string(b)
+	}
+	createdAt := a.CreatedAt
+	if createdAt == 0 {
+		createdAt = time.Now().Unix()
+	}
+	filename := nullIfEmpty(a.Filename)
+	mimeType := nullIfEmpty(a.MimeType)
+	storageURI := nullIfEmpty(a.StorageURI)
+	storageType := nullIfEmpty(a.StorageType)
+	contentHash := nullIfEmpty(a.ContentHash)
+
+	res, err := w.insAttach.Exec(id, a.EventID, filename, mimeType, a.SizeBytes, mediaType,
+		storageURI, storageType, contentHash, a.SourceID, metadata, createdAt)
+	if err != nil {
+		return "", fmt.Errorf("insert attachment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		w.Stats.AttachmentsCreated++
+		return id, nil
+	}
+	res, err = w.updAttach.Exec(
+		a.EventID, filename, mimeType, a.SizeBytes, mediaType, storageURI, storageType, contentHash, metadata,
+		id,
+		a.EventID, filename, mimeType, a.SizeBytes, mediaType, storageURI, storageType, contentHash, metadata,
+	)
+	if err != nil {
+		return "", fmt.Errorf("update attachment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		w.Stats.AttachmentsUpdated++
+	}
+	return id, nil
+}
+
+// AddTag attaches a channel-agnostic tag to an event.
+func (w *Writer) AddTag(eventID, tag, source string) error {
+	if source == "" {
+		source = "system"
+	}
+	if _, err := w.insTag.Exec(eventID, tag, source, time.Now().Unix()); err != nil {
+		return fmt.Errorf("insert tag: %w", err)
+	}
+	return nil
+}
+
+// EventState mirrors a row of event_state.
+type EventState struct {
+	ReadState string // unknown|read|unread
+	Flagged   bool
+	Archived  bool
+	Status    string // draft|sent|received|failed|deleted|unknown
+}
+
+// SetState upserts the mutable state for an event.
+func (w *Writer) SetState(eventID string, s EventState) error {
+	if s.ReadState == "" {
+		s.ReadState = "unknown"
+	}
+	if s.Status == "" {
+		s.Status = "unknown"
+	}
+	if _, err := w.upsertState.Exec(eventID, s.ReadState, boolInt(s.Flagged), boolInt(s.Archived), s.Status, time.Now().Unix()); err != nil {
+		return fmt.Errorf("upsert event state: %w", err)
+	}
+	return nil
+}
+
+// MediaType maps a MIME type onto the attachments.media_type categories.
+func MediaType(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "image"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	default:
+		return "document"
+	}
+}
+
+func nullIfEmpty(s string) any {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return s
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
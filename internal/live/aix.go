@@ -11,6 +11,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/sync"
 )
 
 func NewAixWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
@@ -89,6 +90,7 @@ func NewAixWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbea
 					logf("[%s] Sync error: %v", time.Now().Format("15:04:05"), err)
 					return
 				}
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
 				if result.EventsCreated > 0 || result.EventsUpdated > 0 {
 					logf("[%s] Synced %d events (%d new, %d updated)",
 						time.Now().Format("15:04:05"),
@@ -12,6 +12,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/sync"
 )
 
 func NewEveWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
@@ -92,6 +93,7 @@ func NewEveWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbea
 					logf("watch sync error (eve): %v", err)
 					return
 				}
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
 				totalNew := result.EventsCreated + result.ReactionsCreated
 				if totalNew > 0 {
 					logf("[%s] Synced %d new events (%d messages, %d reactions, %d attachments)",
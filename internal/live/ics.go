@@ -0,0 +1,119 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/sync"
+)
+
+func NewICSWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	debounceSec := getIntOption(opts, "debounce_seconds", 2)
+
+	return WatcherSpec{
+		Name:     adapterName,
+		Adapters: []string{adapterName},
+		Run: func(ctx context.Context, beat func()) error {
+			reg, _ := adapters.Lookup("ics")
+			decoded, err := reg.DecodeOptions(opts)
+			if err != nil {
+				return err
+			}
+			adapter, err := adapters.NewICSAdapter(adapterName, *decoded.(*adapters.ICSAdapterOptions))
+			if err != nil {
+				return fmt.Errorf("create ics adapter: %w", err)
+			}
+
+			// Editors and exporters replace files rather than writing in
+			// place, so a single file is watched through its directory.
+			watchDir := adapter.Path()
+			watchFile := ""
+			info, err := os.Stat(watchDir)
+			if err != nil {
+				return fmt.Errorf("stat %s: %w", watchDir, err)
+			}
+			if !info.IsDir() {
+				watchFile = filepath.Clean(watchDir)
+				watchDir = filepath.Dir(watchDir)
+			}
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("create watcher: %w", err)
+			}
+			defer watcher.Close()
+
+			if err := watcher.Add(watchDir); err != nil {
+				return fmt.Errorf("watch %s: %w", watchDir, err)
+			}
+
+			logf("Watching for calendar changes in %s (debounce: %ds)", adapter.Path(), debounceSec)
+
+			stopHeartbeat := startHeartbeat(heartbeatInterval, beat)
+			defer stopHeartbeat()
+
+			runSync := func() {
+				beat()
+				result, err := adapter.Sync(ctx, db, false)
+				if err != nil {
+					logf("[%s] ICS sync error: %v", time.Now().Format("15:04:05"), err)
+					return
+				}
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
+				if result.EventsCreated > 0 || result.EventsUpdated > 0 {
+					logf("[%s] Synced %d calendar events (%d new, %d updated)",
+						time.Now().Format("15:04:05"),
+						result.EventsCreated+result.EventsUpdated,
+						result.EventsCreated,
+						result.EventsUpdated,
+					)
+				}
+			}
+
+			logf("[%s] Running initial sync...", time.Now().Format("15:04:05"))
+			runSync()
+
+			debounceDelay := time.Duration(debounceSec) * time.Second
+			var debounceTimer *time.Timer
+			triggerSync := func() {
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.AfterFunc(debounceDelay, runSync)
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case event, ok := <-watcher.Events:
+					if !ok {
+						return nil
+					}
+					if watchFile != "" && filepath.Clean(event.Name) != watchFile {
+						continue
+					}
+					if strings.EqualFold(filepath.Ext(event.Name), ".ics") {
+						triggerSync()
+					}
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return nil
+					}
+					logf("[%s] Watch error: %v", time.Now().Format("15:04:05"), err)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/sync"
+)
+
+func NewMaildirWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	debounceSec := getIntOption(opts, "debounce_seconds", 2)
+
+	return WatcherSpec{
+		Name:     adapterName,
+		Adapters: []string{adapterName},
+		Run: func(ctx context.Context, beat func()) error {
+			reg, _ := adapters.Lookup("maildir")
+			decoded, err := reg.DecodeOptions(opts)
+			if err != nil {
+				return err
+			}
+			adapter, err := adapters.NewMaildirAdapter(adapterName, *decoded.(*adapters.MaildirAdapterOptions))
+			if err != nil {
+				return fmt.Errorf("create maildir adapter: %w", err)
+			}
+			folders, err := adapter.Folders()
+			if err != nil {
+				return fmt.Errorf("list maildir folders: %w", err)
+			}
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("create watcher: %w", err)
+			}
+			defer watcher.Close()
+
+			// Deliveries land in new/; flag changes and moves rename files
+			// within or into cur/.
+			for _, f := range folders {
+				for _, sub := range []string{"new", "cur"} {
+					dir := filepath.Join(f.Dir, sub)
+					if err := watcher.Add(dir); err != nil {
+						return fmt.Errorf("watch %s: %w", dir, err)
+					}
+				}
+			}
+
+			logf("Watching %d maildir folders in %s (debounce: %ds)", len(folders), adapter.Path(), debounceSec)
+
+			stopHeartbeat := startHeartbeat(heartbeatInterval, beat)
+			defer stopHeartbeat()
+
+			runSync := func() {
+				beat()
+				result, err := adapter.Sync(ctx, db, false)
+				if err != nil {
+					logf("[%s] Maildir sync error: %v", time.Now().Format("15:04:05"), err)
+					return
+				}
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
+				if result.EventsCreated > 0 || result.Perf["files_flags_changed"] != "0" {
+					logf("[%s] Synced %d new messages (%s flag changes)",
+						time.Now().Format("15:04:05"),
+						result.EventsCreated,
+						result.Perf["files_flags_changed"],
+					)
+				}
+			}
+
+			logf("[%s] Running initial sync...", time.Now().Format("15:04:05"))
+			runSync()
+
+			debounceDelay := time.Duration(debounceSec) * time.Second
+			var debounceTimer *time.Timer
+			triggerSync := func() {
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.AfterFunc(debounceDelay, runSync)
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case _, ok := <-watcher.Events:
+					if !ok {
+						return nil
+					}
+					triggerSync()
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return nil
+					}
+					logf("[%s] Watch error: %v", time.Now().Format("15:04:05"), err)
+				}
+			}
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/Napageneral/mnemonic/internal/adapters"
 	"github.com/Napageneral/mnemonic/internal/config"
 )
 
@@ -131,6 +132,15 @@ func (m *Manager) BuildSpecs() ([]WatcherSpec, error) {
 			continue
 		}
 
+		reg, ok := adapters.Lookup(adapterCfg.Type)
+		if !ok || !reg.Live {
+			m.Logf("live not supported for adapter %s (type=%s)", name, adapterCfg.Type)
+			continue
+		}
+		if err := adapters.ValidateConfig(adapterCfg); err != nil {
+			return nil, fmt.Errorf("adapter %s: %w", name, err)
+		}
+
 		opts := mergeOptions(adapterCfg.Live.Options, adapterCfg.Options)
 
 		switch adapterCfg.Type {
@@ -138,13 +148,21 @@ func (m *Manager) BuildSpecs() ([]WatcherSpec, error) {
 			specs = append(specs, NewEveWatcher(m.DB, name, opts, m.HeartbeatInterval, m.Logf))
 		case "aix":
 			specs = append(specs, NewAixWatcher(m.DB, name, opts, m.HeartbeatInterval, m.Logf))
+		case "ics":
+			specs = append(specs, NewICSWatcher(m.DB, name, opts, m.HeartbeatInterval, m.Logf))
+		case "maildir":
+			specs = append(specs, NewMaildirWatcher(m.DB, name, opts, m.HeartbeatInterval, m.Logf))
+		case "nexus":
+			specs = append(specs, NewNexusWatcher(m.DB, name, opts, m.HeartbeatInterval, m.Logf))
+		case "gogcli_calendar", "gogcli_contacts":
+			specs = append(specs, NewPollWatcher(m.DB, name, adapterCfg.Type, opts, m.HeartbeatInterval, m.Logf))
 		case "gogcli":
 			gmailAdapters = append(gmailAdapters, name)
 			if gmailOptions == nil {
 				gmailOptions = opts
 			}
 		default:
-			m.Logf("live not supported for adapter %s (type=%s)", name, adapterCfg.Type)
+			return nil, fmt.Errorf("adapter type %s is registered as live but has no watcher", adapterCfg.Type)
 		}
 	}
 
@@ -152,14 +170,20 @@ func (m *Manager) BuildSpecs() ([]WatcherSpec, error) {
 		specs = append(specs, NewGmailWatcher(m.DB, m.Config, gmailAdapters, gmailOptions, m.HeartbeatInterval, m.Logf))
 	}
 
+	for name, pipelineCfg := range m.Config.Pipelines {
+		if !pipelineCfg.Enabled {
+			continue
+		}
+		if err := validatePipeline(pipelineCfg); err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", name, err)
+		}
+		specs = append(specs, NewPipelineWatcher(m.DB, name, pipelineCfg, m.HeartbeatInterval, m.Logf))
+	}
+
 	return specs, nil
 }
 
 func LiveSupported(adapterType string) bool {
-	switch adapterType {
-	case "eve", "aix", "gogcli":
-		return true
-	default:
-		return false
-	}
+	reg, ok := adapters.Lookup(adapterType)
+	return ok && reg.Live
 }
@@ -0,0 +1,178 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/sync"
+)
+
+func NewNexusWatcher(db *sql.DB, adapterName string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	debounceSec := getIntOption(opts, "debounce_seconds", 1)
+
+	return WatcherSpec{
+		Name:     adapterName,
+		Adapters: []string{adapterName},
+		Run: func(ctx context.Context, beat func()) error {
+			reg, _ := adapters.Lookup("nexus")
+			decoded, err := reg.DecodeOptions(opts)
+			if err != nil {
+				return err
+			}
+			adapter, err := adapters.NewNexusAdapter(*decoded.(*adapters.NexusAdapterOptions))
+			if err != nil {
+				return fmt.Errorf("create nexus adapter: %w", err)
+			}
+			eventsDir := adapter.EventsDir()
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("create watcher: %w", err)
+			}
+			defer watcher.Close()
+
+			if err := watcher.Add(eventsDir); err != nil {
+				return fmt.Errorf("watch %s: %w", eventsDir, err)
+			}
+
+			// Logs are append-only, so after the catch-up sync each file is
+			// tailed from the byte offset where its last complete line ended.
+			// Offsets are taken before the catch-up so nothing written in
+			// between is missed; lines read twice are idempotent upserts.
+			offsets := map[string]int64{}
+			files, _ := filepath.Glob(filepath.Join(eventsDir, "*.jsonl"))
+			for _, f := range files {
+				if off, err := completeLineOffset(f); err == nil {
+					offsets[f] = off
+				}
+			}
+
+			logf("Tailing Nexus event logs in %s (debounce: %ds)", eventsDir, debounceSec)
+
+			stopHeartbeat := startHeartbeat(heartbeatInterval, beat)
+			defer stopHeartbeat()
+
+			logf("[%s] Running initial sync...", time.Now().Format("15:04:05"))
+			beat()
+			if result, err := adapter.Sync(ctx, db, false); err != nil {
+				logf("[%s] Nexus sync error: %v", time.Now().Format("15:04:05"), err)
+			} else if result.EventsCreated > 0 {
+				logf("[%s] Synced %d new nexus events", time.Now().Format("15:04:05"), result.EventsCreated)
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
+			}
+
+			// Only the watch loop touches offsets and dirty; the debounce
+			// timer just signals it.
+			dirty := map[string]bool{}
+			flush := make(chan struct{}, 1)
+			tail := func() {
+				beat()
+				created := 0
+				for path := range dirty {
+					result, next, err := adapter.SyncAppended(ctx, db, path, offsets[path])
+					if err != nil {
+						logf("[%s] Nexus tail error (%s): %v", time.Now().Format("15:04:05"), filepath.Base(path), err)
+						continue
+					}
+					offsets[path] = next
+					created += result.EventsCreated
+				}
+				dirty = map[string]bool{}
+				if created > 0 {
+					logf("[%s] Synced %d new nexus events", time.Now().Format("15:04:05"), created)
+					_ = sync.Announce(db, adapterName, adapter.Name(), created, 0)
+				}
+			}
+
+			debounceDelay := time.Duration(debounceSec) * time.Second
+			var debounceTimer *time.Timer
+			triggerTail := func() {
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.AfterFunc(debounceDelay, func() {
+					select {
+					case flush <- struct{}{}:
+					default:
+					}
+				})
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-flush:
+					tail()
+				case event, ok := <-watcher.Events:
+					if !ok {
+						return nil
+					}
+					if !strings.HasSuffix(event.Name, ".jsonl") {
+						continue
+					}
+					if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+						delete(offsets, event.Name)
+						delete(dirty, event.Name)
+						continue
+					}
+					if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+						dirty[event.Name] = true
+						triggerTail()
+					}
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return nil
+					}
+					logf("[%s] Watch error: %v", time.Now().Format("15:04:05"), err)
+				}
+			}
+		},
+	}
+}
+
+// completeLineOffset returns the offset just past the last newline in path,
+// reading backwards from the end so large logs are not scanned.
+func completeLineOffset(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	const block = 64 * 1024
+	end := info.Size()
+	buf := make([]byte, block)
+	for end > 0 {
+		start := end - block
+		if start < 0 {
+			start = 0
+		}
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] == '\n' {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,78 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+// waitFor polls cond until it holds or a few seconds pass.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func hasEvent(db *sql.DB, sourceID string) bool {
+	var n int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM events WHERE source_adapter = 'nexus' AND source_id = ?`, sourceID).Scan(&n)
+	return n == 1
+}
+
+func TestNexusWatcherTailsLogs(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2024-01-01.jsonl")
+	write := func(flag int, lines ...string) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatalf("open log: %v", err)
+		}
+		for _, l := range lines {
+			if _, err := f.WriteString(l + "\n"); err != nil {
+				t.Fatalf("write log: %v", err)
+			}
+		}
+		f.Close()
+	}
+	write(os.O_TRUNC, `{"id":"n1","ts":1704067200000,"session_id":"s1","source":"cli","event_type":"command"}`)
+
+	spec := NewNexusWatcher(db, "nexus", map[string]any{"events_dir": dir, "debounce_seconds": 0}, time.Hour, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- spec.Run(ctx, func() {}) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitFor(t, "initial sync", func() bool { return hasEvent(db, "n1") })
+
+	// Appended lines are picked up.
+	write(os.O_APPEND, `{"id":"n2","ts":1704067260000,"session_id":"s1","source":"cli","event_type":"command"}`)
+	waitFor(t, "appended line", func() bool { return hasEvent(db, "n2") })
+
+	// A truncated log is re-read from the start.
+	write(os.O_TRUNC, `{"id":"n3","ts":1704067320000,"session_id":"s2","source":"cli","event_type":"turn"}`)
+	waitFor(t, "line after truncate", func() bool { return hasEvent(db, "n3") })
+
+	// So is a new log written in place of a rotated one.
+	if err := os.Rename(path, filepath.Join(dir, "2024-01-01.log.1")); err != nil {
+		t.Fatalf("rotate log: %v", err)
+	}
+	write(os.O_TRUNC, `{"id":"n4","ts":1704067380000,"session_id":"s2","source":"cli","event_type":"turn"}`)
+	waitFor(t, "line after rotate", func() bool { return hasEvent(db, "n4") })
+}
@@ -0,0 +1,423 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/bus"
+	"github.com/Napageneral/mnemonic/internal/chunk"
+	"github.com/Napageneral/mnemonic/internal/compute"
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/gemini"
+	"github.com/Napageneral/mnemonic/internal/state"
+	"github.com/Napageneral/mnemonic/internal/sync"
+)
+
+const defaultPipelineDebounce = 30 * time.Second
+
+// busPollInterval is how often pipeline watchers read the bus.
+var busPollInterval = 2 * time.Second
+
+// PipelineRun is the outcome of one pipeline pass.
+type PipelineRun struct {
+	FinishedAt       int64  `json:"finished_at"`
+	Duration         string `json:"duration"`
+	Threads          int    `json:"threads"`
+	EventsChunked    int    `json:"events_chunked"`
+	EpisodesCreated  int    `json:"episodes_created"`
+	EpisodesExtended int    `json:"episodes_extended"`
+	AnalysisQueued   int    `json:"analysis_queued"`
+	EmbeddingsQueued int    `json:"embeddings_queued"`
+	ComputeSucceeded int    `json:"compute_succeeded,omitempty"`
+	ComputeFailed    int    `json:"compute_failed,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+func pipelineScope(name string) string {
+	return "pipeline:" + name
+}
+
+func pipelineDebounce(cfg config.PipelineConfig) (time.Duration, error) {
+	if cfg.Debounce == "" {
+		return defaultPipelineDebounce, nil
+	}
+	d, err := time.ParseDuration(cfg.Debounce)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid debounce %q", cfg.Debounce)
+	}
+	return d, nil
+}
+
+func validatePipeline(cfg config.PipelineConfig) error {
+	if cfg.Definition == "" {
+		return fmt.Errorf("definition is required")
+	}
+	if _, err := pipelineDebounce(cfg); err != nil {
+		return err
+	}
+	if cfg.Compute && os.Getenv("GEMINI_API_KEY") == "" {
+		return fmt.Errorf("compute requires GEMINI_API_KEY")
+	}
+	return nil
+}
+
+// NewPipelineWatcher runs a configured pipeline in watch mode. It reads
+// sync.completed from the bus as consumer "pipeline:<name>", waits for syncs
+// that wrote events in its channels to go quiet, then chunks the threads that
+// received events since its last pass and queues the resulting episodes for
+// analysis and embedding.
+//
+// Progress is tracked by events rowid, so a pipeline first started against
+// an existing database only handles events written from then on; use
+// `chunk run` and `compute enqueue` to backfill.
+func NewPipelineWatcher(db *sql.DB, name string, cfg config.PipelineConfig, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	scope := pipelineScope(name)
+	debounce, _ := pipelineDebounce(cfg)
+	// Steady traffic keeps resetting the debounce; never hold work longer
+	// than this.
+	maxWait := 10 * debounce
+
+	return WatcherSpec{
+		Name:     scope,
+		Adapters: []string{scope},
+		Run: func(ctx context.Context, beat func()) error {
+			var definitionID string
+			var definitionChannel sql.NullString
+			err := db.QueryRowContext(ctx, `
+				SELECT id, channel FROM episode_definitions WHERE name = ?
+			`, cfg.Definition).Scan(&definitionID, &definitionChannel)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("episode definition %q not found (see 'mnemonic chunk list')", cfg.Definition)
+			}
+			if err != nil {
+				return fmt.Errorf("load definition: %w", err)
+			}
+			channels := cfg.Channels
+			if len(channels) == 0 && definitionChannel.String != "" {
+				channels = []string{definitionChannel.String}
+			}
+
+			cursor, ok, err := readPipelineCursor(db, scope)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(rowid), 0) FROM events`).Scan(&cursor); err != nil {
+					return fmt.Errorf("read events cursor: %w", err)
+				}
+				if err := writePipelineCursor(db, scope, cursor); err != nil {
+					return err
+				}
+			}
+			// The bus only signals that something changed; progress lives in
+			// the events cursor, so deliveries are acked as soon as they are
+			// read.
+			if _, err := bus.EnsureConsumer(db, scope, bus.ConsumerOptions{}); err != nil {
+				return err
+			}
+
+			desc := cfg.Definition
+			if len(channels) > 0 {
+				desc += " on " + strings.Join(channels, ", ")
+			}
+			logf("Pipeline %s: %s (debounce: %s)", name, desc, debounce)
+
+			stopHeartbeat := startHeartbeat(heartbeatInterval, beat)
+			defer stopHeartbeat()
+
+			runPass := func() {
+				beat()
+				run, next := runPipeline(ctx, db, scope, cfg, definitionID, channels, cursor)
+				cursor = next
+				if b, err := json.Marshal(run); err == nil {
+					_ = state.Set(db, scope, keyPipelineLastRun, string(b))
+				}
+				_ = state.Set(db, scope, keyPipelinePending, "0")
+				if run.Error != "" {
+					logf("[%s] Pipeline %s error: %s", time.Now().Format("15:04:05"), name, run.Error)
+					return
+				}
+				if run.Threads > 0 {
+					logf("[%s] Pipeline %s: %d threads, %d new and %d extended episodes, queued %d analysis and %d embedding jobs",
+						time.Now().Format("15:04:05"),
+						name,
+						run.Threads,
+						run.EpisodesCreated,
+						run.EpisodesExtended,
+						run.AnalysisQueued,
+						run.EmbeddingsQueued,
+					)
+				}
+			}
+
+			// Pick up events written while watch was not running, and
+			// episodes a failed pass left unqueued.
+			var latest int64
+			unqueued, _ := readPipelineQueue(db, scope)
+			if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(rowid), 0) FROM events`).Scan(&latest); err == nil && (latest > cursor || len(unqueued) > 0) {
+				logf("[%s] Running initial pass...", time.Now().Format("15:04:05"))
+				runPass()
+			}
+
+			ticker := time.NewTicker(busPollInterval)
+			defer ticker.Stop()
+
+			var timer *time.Timer
+			var fire <-chan time.Time
+			var firstTrigger time.Time
+			pending := 0
+			for {
+				select {
+				case <-ctx.Done():
+					if timer != nil {
+						timer.Stop()
+					}
+					return nil
+				case <-ticker.C:
+					deliveries, err := bus.Receive(db, scope, 500)
+					if err != nil {
+						return err
+					}
+					var synced []string
+					seqs := make([]int64, 0, len(deliveries))
+					for _, d := range deliveries {
+						seqs = append(seqs, d.Seq)
+						if d.Type == sync.BusSyncCompleted {
+							adapter := ""
+							if d.Adapter != nil {
+								adapter = *d.Adapter
+							}
+							synced = append(synced, adapter)
+						}
+					}
+					if _, err := bus.Ack(db, scope, seqs...); err != nil {
+						return err
+					}
+					triggered, err := matchingSyncs(ctx, db, synced, channels, cursor)
+					if err != nil {
+						return err
+					}
+					if triggered == 0 {
+						continue
+					}
+					if pending == 0 {
+						firstTrigger = time.Now()
+					}
+					pending += triggered
+					_ = state.Set(db, scope, keyPipelinePending, fmt.Sprintf("%d", pending))
+
+					wait := debounce
+					if remaining := maxWait - time.Since(firstTrigger); remaining < wait {
+						wait = remaining
+					}
+					if timer != nil {
+						timer.Stop()
+					}
+					timer = time.NewTimer(wait)
+					fire = timer.C
+				case <-fire:
+					fire = nil
+					pending = 0
+					runPass()
+				}
+			}
+		},
+	}
+}
+
+// runPipeline does one pass over events written after cursor and returns the
+// cursor to resume from. The cursor only advances once the new events are
+// chunked, and the episodes they produced are kept with it until they are
+// queued, so a failed pass is retried on the next trigger without dropping
+// either.
+func runPipeline(ctx context.Context, db *sql.DB, scope string, cfg config.PipelineConfig, definitionID string, channels []string, cursor int64) (PipelineRun, int64) {
+	start := time.Now()
+	run := PipelineRun{}
+	finish := func() (PipelineRun, int64) {
+		run.FinishedAt = time.Now().Unix()
+		run.Duration = time.Since(start).String()
+		return run, cursor
+	}
+	queues := len(cfg.Analysis) > 0 || cfg.Embed
+	chunkedEpisodes := 0
+
+	unqueued, err := readPipelineQueue(db, scope)
+	if err != nil {
+		run.Error = err.Error()
+		return finish()
+	}
+
+	var latest int64
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(rowid), 0) FROM events`).Scan(&latest); err != nil {
+		run.Error = fmt.Sprintf("read events cursor: %v", err)
+		return finish()
+	}
+	if latest <= cursor && len(unqueued) == 0 {
+		return finish()
+	}
+
+	if latest > cursor {
+		threadIDs, err := changedThreads(ctx, db, cursor, latest, channels)
+		if err != nil {
+			run.Error = err.Error()
+			return finish()
+		}
+		chunked, err := chunk.ChunkThreads(ctx, db, definitionID, threadIDs)
+		run.Threads = chunked.ThreadsProcessed
+		run.EventsChunked = chunked.EventsProcessed
+		run.EpisodesCreated = chunked.EpisodesCreated
+		run.EpisodesExtended = chunked.EpisodesExtended
+		chunkedEpisodes = len(chunked.EpisodeIDs)
+		if queues {
+			unqueued = mergeEpisodeIDs(unqueued, chunked.EpisodeIDs)
+		}
+		next := latest
+		if err != nil {
+			// Events left unchunked are picked up again from the old cursor;
+			// episodes already written would not be.
+			run.Error = fmt.Sprintf("chunk: %v", err)
+			next = cursor
+		}
+		if werr := writePipelineProgress(db, scope, next, unqueued); werr != nil {
+			if run.Error == "" {
+				run.Error = werr.Error()
+			}
+			return finish()
+		}
+		cursor = next
+		if run.Error != "" {
+			return finish()
+		}
+	}
+
+	if len(unqueued) == 0 && (!cfg.Compute || chunkedEpisodes == 0) {
+		return finish()
+	}
+
+	engine, err := compute.NewEngine(db, gemini.NewClient(os.Getenv("GEMINI_API_KEY")), compute.DefaultConfig())
+	if err != nil {
+		run.Error = fmt.Sprintf("compute: %v", err)
+		return finish()
+	}
+	defer engine.Close()
+
+	if len(unqueued) > 0 {
+		for _, analysisType := range cfg.Analysis {
+			n, err := engine.EnqueueAnalysis(ctx, analysisType, unqueued...)
+			if err != nil {
+				run.Error = fmt.Sprintf("enqueue %s: %v", analysisType, err)
+				return finish()
+			}
+			run.AnalysisQueued += n
+		}
+		if cfg.Embed {
+			n, err := engine.EnqueueEmbeddings(ctx, unqueued...)
+			if err != nil {
+				run.Error = fmt.Sprintf("enqueue embeddings: %v", err)
+				return finish()
+			}
+			run.EmbeddingsQueued = n
+		}
+		if err := writePipelineProgress(db, scope, cursor, nil); err != nil {
+			run.Error = err.Error()
+			return finish()
+		}
+	}
+	if cfg.Compute {
+		stats, err := engine.Run(ctx)
+		if err != nil {
+			run.Error = fmt.Sprintf("compute: %v", err)
+			return finish()
+		}
+		run.ComputeSucceeded = stats.Succeeded
+		run.ComputeFailed = stats.Failed
+	}
+	return finish()
+}
+
+// mergeEpisodeIDs appends the ids in add that ids does not already hold.
+func mergeEpisodeIDs(ids, add []string) []string {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range add {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// matchingSyncs counts the syncs, given by source adapter, that wrote events
+// in channels after cursor. Without channels every sync counts.
+func matchingSyncs(ctx context.Context, db *sql.DB, adapters []string, channels []string, cursor int64) (int, error) {
+	if len(channels) == 0 {
+		return len(adapters), nil
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM events
+			WHERE rowid > ? AND source_adapter = ? AND channel IN (?` + strings.Repeat(", ?", len(channels)-1) + `)
+		)
+	`
+	matched := map[string]bool{}
+	count := 0
+	for _, adapter := range adapters {
+		match, ok := matched[adapter]
+		if !ok {
+			args := []any{cursor, adapter}
+			for _, c := range channels {
+				args = append(args, c)
+			}
+			if err := db.QueryRowContext(ctx, query, args...).Scan(&match); err != nil {
+				return 0, fmt.Errorf("match sync to channels: %w", err)
+			}
+			matched[adapter] = match
+		}
+		if match {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// changedThreads lists threads with events in the rowid range (after, upTo],
+// limited to channels when given.
+func changedThreads(ctx context.Context, db *sql.DB, after, upTo int64, channels []string) ([]string, error) {
+	query := `
+		SELECT DISTINCT thread_id FROM events
+		WHERE rowid > ? AND rowid <= ? AND thread_id IS NOT NULL AND thread_id != ''
+	`
+	args := []any{after, upTo}
+	if len(channels) > 0 {
+		query += ` AND channel IN (?` + strings.Repeat(", ?", len(channels)-1) + `)`
+		for _, c := range channels {
+			args = append(args, c)
+		}
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query changed threads: %w", err)
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan changed thread: %w", err)
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
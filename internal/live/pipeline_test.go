@@ -0,0 +1,156 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/bus"
+	"github.com/Napageneral/mnemonic/internal/chunk"
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/state"
+	"github.com/Napageneral/mnemonic/internal/sync"
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func TestPipelineWatcherDebouncesMatchingSyncs(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	defer func(d time.Duration) { busPollInterval = d }(busPollInterval)
+	busPollInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	if _, err := chunk.CreateDefinition(ctx, db, "imessage_1h", "imessage", "time_gap",
+		chunk.TimeGapConfig{GapSeconds: 3600, Scope: "thread"}, ""); err != nil {
+		t.Fatalf("create definition: %v", err)
+	}
+	for _, thread := range []string{"imessage", "gmail"} {
+		if _, err := db.Exec(`
+			INSERT INTO threads (id, channel, source_adapter, source_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, 0, 0)
+		`, "t-"+thread, thread, thread, thread); err != nil {
+			t.Fatalf("insert thread: %v", err)
+		}
+	}
+	// syncEvent writes an event the way an adapter sync would and announces it.
+	syncEvent := func(id, channel string, ts int64) {
+		t.Helper()
+		if _, err := db.Exec(`
+			INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, source_adapter, source_id)
+			VALUES (?, ?, ?, '["text"]', 'hi', 'received', ?, ?, ?)
+		`, id, ts, channel, "t-"+channel, channel, id); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+		if err := sync.Announce(db, channel, channel, 1, 0); err != nil {
+			t.Fatalf("announce: %v", err)
+		}
+	}
+
+	var passes atomic.Int32
+	logf := func(format string, args ...any) {
+		if strings.HasPrefix(format, "[%s] Pipeline %s") {
+			passes.Add(1)
+		}
+	}
+	spec := NewPipelineWatcher(db, "p", config.PipelineConfig{Enabled: true, Definition: "imessage_1h", Debounce: "300ms"}, time.Hour, logf)
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- spec.Run(runCtx, func() {}) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitFor(t, "pipeline consumer", func() bool {
+		_, err := bus.GetConsumer(db, "pipeline:p")
+		return err == nil
+	})
+
+	// A sync that wrote nothing in the pipeline's channels is ignored.
+	syncEvent("g1", "gmail", 1000)
+	time.Sleep(600 * time.Millisecond)
+	if n := passes.Load(); n != 0 {
+		t.Fatalf("expected no pass for a gmail sync, got %d", n)
+	}
+	if pending, _, _ := state.Get(db, "pipeline:p", keyPipelinePending); pending != "" {
+		t.Fatalf("expected no pending triggers, got %q", pending)
+	}
+
+	// A burst of matching syncs runs one pass over all of them.
+	for i := int64(1); i <= 3; i++ {
+		syncEvent(fmt.Sprintf("m%d", i), "imessage", 1000+i*60)
+		time.Sleep(30 * time.Millisecond)
+	}
+	waitFor(t, "pipeline pass", func() bool { return passes.Load() > 0 })
+	time.Sleep(600 * time.Millisecond)
+	if n := passes.Load(); n != 1 {
+		t.Fatalf("expected one pass for the burst, got %d", n)
+	}
+	raw, _, err := state.Get(db, "pipeline:p", keyPipelineLastRun)
+	if err != nil {
+		t.Fatalf("read last run: %v", err)
+	}
+	var run PipelineRun
+	if err := json.Unmarshal([]byte(raw), &run); err != nil {
+		t.Fatalf("decode last run %q: %v", raw, err)
+	}
+	if run.Error != "" || run.Threads != 1 || run.EventsChunked != 3 || run.EpisodesCreated != 1 {
+		t.Fatalf("unexpected pass %+v", run)
+	}
+}
+
+func TestRunPipelineKeepsEpisodesUntilQueued(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	defID, err := chunk.CreateDefinition(ctx, db, "imessage_1h", "imessage", "time_gap",
+		chunk.TimeGapConfig{GapSeconds: 3600, Scope: "thread"}, "")
+	if err != nil {
+		t.Fatalf("create definition: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO threads (id, channel, source_adapter, source_id, created_at, updated_at)
+		 VALUES ('t1', 'imessage', 'imessage', 't1', 0, 0)`,
+		`INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, source_adapter, source_id)
+		 VALUES ('m1', 1000, 'imessage', '["text"]', 'hi', 'received', 't1', 'imessage', 'm1')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	// The analysis type does not exist yet, so queueing fails after the
+	// events are chunked.
+	cfg := config.PipelineConfig{Enabled: true, Definition: "imessage_1h", Analysis: []string{"summary_v1"}}
+	run, cursor := runPipeline(ctx, db, "pipeline:p", cfg, defID, []string{"imessage"}, 0)
+	if run.Error == "" || run.EpisodesCreated != 1 {
+		t.Fatalf("expected enqueue to fail after chunking, got %+v", run)
+	}
+	if saved, _, _ := readPipelineCursor(db, "pipeline:p"); cursor == 0 || saved != cursor {
+		t.Fatalf("expected cursor to advance past the chunked events, got %d (saved %d)", cursor, saved)
+	}
+	if unqueued, _ := readPipelineQueue(db, "pipeline:p"); len(unqueued) != 1 {
+		t.Fatalf("expected the episode to wait for queueing, got %v", unqueued)
+	}
+
+	// The next pass queues it even though no new events arrived.
+	if _, err := db.Exec(`
+		INSERT INTO analysis_types (id, name, version, output_type, prompt_template, created_at, updated_at)
+		VALUES ('at1', 'summary_v1', '1.0.0', 'freeform', '{segment_text}', 0, 0)
+	`); err != nil {
+		t.Fatalf("insert analysis type: %v", err)
+	}
+	run, _ = runPipeline(ctx, db, "pipeline:p", cfg, defID, []string{"imessage"}, cursor)
+	if run.Error != "" || run.AnalysisQueued != 1 {
+		t.Fatalf("expected the waiting episode to be queued, got %+v", run)
+	}
+	if unqueued, _ := readPipelineQueue(db, "pipeline:p"); len(unqueued) != 0 {
+		t.Fatalf("expected nothing left to queue, got %v", unqueued)
+	}
+}
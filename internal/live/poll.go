@@ -0,0 +1,78 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/sync"
+)
+
+// NewPollWatcher keeps an adapter without change notifications current by
+// running an incremental sync every poll_seconds. Up to jitter_seconds of
+// random delay is added to each wait so adapters sharing an upstream account
+// don't hit its API in lockstep.
+func NewPollWatcher(db *sql.DB, adapterName string, adapterType string, opts map[string]any, heartbeatInterval time.Duration, logf func(format string, args ...any)) WatcherSpec {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	interval := time.Duration(getIntOption(opts, "poll_seconds", 300)) * time.Second
+	if interval <= 0 {
+		interval = 300 * time.Second
+	}
+	jitter := time.Duration(getIntOption(opts, "jitter_seconds", int(interval/time.Second)/10)) * time.Second
+
+	return WatcherSpec{
+		Name:     adapterName,
+		Adapters: []string{adapterName},
+		Run: func(ctx context.Context, beat func()) error {
+			adapter, err := adapters.Build(adapterName, config.AdapterConfig{Type: adapterType, Options: opts})
+			if err != nil {
+				return fmt.Errorf("create %s adapter: %w", adapterType, err)
+			}
+
+			logf("Polling %s every %s (jitter: %s)", adapterName, interval, jitter)
+
+			stopHeartbeat := startHeartbeat(heartbeatInterval, beat)
+			defer stopHeartbeat()
+
+			runSync := func() {
+				beat()
+				result, err := adapter.Sync(ctx, db, false)
+				if err != nil {
+					logf("[%s] %s sync error: %v", time.Now().Format("15:04:05"), adapterName, err)
+					return
+				}
+				_ = sync.Announce(db, adapterName, adapter.Name(), result.EventsCreated, result.EventsUpdated)
+				if result.EventsCreated > 0 || result.EventsUpdated > 0 {
+					logf("[%s] Synced %s: %d new, %d updated",
+						time.Now().Format("15:04:05"),
+						adapterName,
+						result.EventsCreated,
+						result.EventsUpdated,
+					)
+				}
+			}
+
+			logf("[%s] Running initial sync...", time.Now().Format("15:04:05"))
+			runSync()
+
+			for {
+				wait := interval
+				if jitter > 0 {
+					wait += time.Duration(rand.Int63n(int64(jitter)))
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(wait):
+					runSync()
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package live
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+// cannedAdapter reports the next of its canned results on each sync.
+type cannedAdapter struct {
+	results chan adapters.SyncResult
+	synced  chan struct{}
+}
+
+func (a *cannedAdapter) Name() string { return "polled" }
+
+func (a *cannedAdapter) Sync(ctx context.Context, db *sql.DB, full bool) (adapters.SyncResult, error) {
+	var res adapters.SyncResult
+	select {
+	case res = <-a.results:
+	default:
+	}
+	defer func() { a.synced <- struct{}{} }()
+	return res, nil
+}
+
+// polled is what the "test-poll" adapter type builds.
+var polled *cannedAdapter
+
+func init() {
+	adapters.Register(adapters.Registration{
+		Type:        "test-poll",
+		LiveOptions: []string{"poll_seconds", "jitter_seconds"},
+		Factory:     func(string, any) (adapters.Adapter, error) { return polled, nil },
+	})
+}
+
+func TestPollWatcherAnnouncesOnlyChanges(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	polled = &cannedAdapter{results: make(chan adapters.SyncResult, 3), synced: make(chan struct{}, 3)}
+	polled.results <- adapters.SyncResult{EventsCreated: 2}
+	polled.results <- adapters.SyncResult{}
+	polled.results <- adapters.SyncResult{EventsUpdated: 1}
+
+	spec := NewPollWatcher(db, "polled", "test-poll", map[string]any{"poll_seconds": 1, "jitter_seconds": 0}, time.Hour, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- spec.Run(ctx, func() {}) }()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-polled.synced:
+		case err := <-done:
+			t.Fatalf("watcher stopped: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for poll %d", i+1)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// The empty poll in the middle announces nothing.
+	var announced int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM bus_events WHERE type = 'sync.completed' AND adapter = 'polled'`).Scan(&announced)
+	if announced != 2 {
+		t.Fatalf("expected 2 sync.completed events, got %d", announced)
+	}
+}
@@ -2,6 +2,7 @@ package live
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
@@ -14,6 +15,11 @@ const (
 	keyLiveLastHeartbeat = "live_last_heartbeat"
 	keyLiveLastError     = "live_last_error"
 	keyLiveRestarts      = "live_restarts"
+
+	keyPipelineCursor  = "pipeline_events_cursor" // events.rowid already chunked
+	keyPipelinePending = "pipeline_pending"       // triggers waiting on the debounce
+	keyPipelineLastRun = "pipeline_last_run"      // JSON PipelineRun
+	keyPipelineQueue   = "pipeline_unqueued"      // JSON episode ids chunked but not yet queued
 )
 
 func setLiveStatus(db *sql.DB, adapter string, status string) {
@@ -65,3 +71,56 @@ func readLiveStatus(db *sql.DB, adapter string) (status string, lastHeartbeat *i
 	}
 	return status, lastHeartbeat, lastError, restarts
 }
+
+func readPipelineCursor(db *sql.DB, scope string) (int64, bool, error) {
+	v, ok, err := state.Get(db, scope, keyPipelineCursor)
+	if err != nil || !ok || v == "" {
+		return 0, false, err
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid pipeline cursor %q", v)
+	}
+	return n, true, nil
+}
+
+func writePipelineCursor(db *sql.DB, scope string, cursor int64) error {
+	return state.Set(db, scope, keyPipelineCursor, fmt.Sprintf("%d", cursor))
+}
+
+func readPipelineQueue(db *sql.DB, scope string) ([]string, error) {
+	v, ok, err := state.Get(db, scope, keyPipelineQueue)
+	if err != nil || !ok || v == "" {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(v), &ids); err != nil {
+		return nil, fmt.Errorf("invalid pipeline queue %q", v)
+	}
+	return ids, nil
+}
+
+// writePipelineProgress saves the events cursor together with the episodes
+// chunked up to it that still have to be queued, so neither is lost if the
+// pass stops in between.
+func writePipelineProgress(db *sql.DB, scope string, cursor int64, unqueued []string) error {
+	b, err := json.Marshal(unqueued)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin pipeline state tx: %w", err)
+	}
+	defer tx.Rollback()
+	if err := state.Set(tx, scope, keyPipelineCursor, fmt.Sprintf("%d", cursor)); err != nil {
+		return err
+	}
+	if err := state.Set(tx, scope, keyPipelineQueue, string(b)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit pipeline state tx: %w", err)
+	}
+	return nil
+}
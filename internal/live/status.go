@@ -2,9 +2,14 @@ package live
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/state"
 )
 
 type AdapterLiveStatus struct {
@@ -43,3 +48,82 @@ func GetStatuses(db *sql.DB, cfg *config.Config) ([]AdapterLiveStatus, error) {
 	}
 	return out, nil
 }
+
+// PipelineLiveStatus reports a watch-mode pipeline. Backlog counts events in
+// the pipeline's channels written since its last pass.
+type PipelineLiveStatus struct {
+	Pipeline      string       `json:"pipeline"`
+	Definition    string       `json:"definition"`
+	Enabled       bool         `json:"enabled"`
+	Status        string       `json:"status,omitempty"`
+	LastHeartbeat *int64       `json:"last_heartbeat,omitempty"`
+	LastError     string       `json:"last_error,omitempty"`
+	Restarts      int          `json:"restarts,omitempty"`
+	Pending       int          `json:"pending"`
+	Backlog       int64        `json:"backlog"`
+	LastRun       *PipelineRun `json:"last_run,omitempty"`
+}
+
+func GetPipelineStatuses(db *sql.DB, cfg *config.Config) ([]PipelineLiveStatus, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+
+	var names []string
+	for name := range cfg.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []PipelineLiveStatus
+	for _, name := range names {
+		pipelineCfg := cfg.Pipelines[name]
+		scope := pipelineScope(name)
+		status, lastHeartbeat, lastError, restarts := readLiveStatus(db, scope)
+		st := PipelineLiveStatus{
+			Pipeline:      name,
+			Definition:    pipelineCfg.Definition,
+			Enabled:       pipelineCfg.Enabled,
+			Status:        status,
+			LastHeartbeat: lastHeartbeat,
+			LastError:     lastError,
+			Restarts:      restarts,
+		}
+		if v, ok, _ := state.Get(db, scope, keyPipelinePending); ok {
+			st.Pending, _ = strconv.Atoi(v)
+		}
+		if v, ok, _ := state.Get(db, scope, keyPipelineLastRun); ok && v != "" {
+			var run PipelineRun
+			if err := json.Unmarshal([]byte(v), &run); err == nil {
+				st.LastRun = &run
+			}
+		}
+		if cursor, ok, _ := readPipelineCursor(db, scope); ok {
+			st.Backlog = pipelineBacklog(db, pipelineCfg, cursor)
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func pipelineBacklog(db *sql.DB, cfg config.PipelineConfig, cursor int64) int64 {
+	channels := cfg.Channels
+	if len(channels) == 0 {
+		var channel sql.NullString
+		_ = db.QueryRow(`SELECT channel FROM episode_definitions WHERE name = ?`, cfg.Definition).Scan(&channel)
+		if channel.String != "" {
+			channels = []string{channel.String}
+		}
+	}
+	query := `SELECT COUNT(*) FROM events WHERE rowid > ?`
+	args := []any{cursor}
+	if len(channels) > 0 {
+		query += ` AND channel IN (?` + strings.Repeat(", ?", len(channels)-1) + `)`
+		for _, c := range channels {
+			args = append(args, c)
+		}
+	}
+	var n int64
+	_ = db.QueryRow(query, args...).Scan(&n)
+	return n
+}
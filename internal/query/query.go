@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
 )
 
 // EventFilters holds all possible filters for querying events
@@ -15,6 +17,9 @@ type EventFilters struct {
 	Until      time.Time // Filter by end date
 	Direction  string    // Filter by direction (sent, received, observed)
 	Limit      int       // Limit number of results (default 100)
+
+	IncludeDeleted bool // Include events tombstoned by their source
+	ShowRevisions  bool // Load prior content of edited events
 }
 
 // Event represents a communication event with participant info
@@ -28,6 +33,15 @@ type Event struct {
 	ThreadID     *string
 	ReplyTo      *string
 	Participants []Participant
+	Revisions    []Revision
+}
+
+// Revision is an earlier version of an event's content.
+type Revision struct {
+	Revision   int
+	Content    string
+	ObservedAt int64
+	ReplacedAt int64
 }
 
 // Participant represents a contact involved in an event.
@@ -101,6 +115,10 @@ func QueryEvents(db *sql.DB, filters EventFilters) ([]Event, error) {
 		argCount++
 	}
 
+	if !filters.IncludeDeleted {
+		conditions = append(conditions, ingest.NotDeleted("e"))
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -163,11 +181,47 @@ func QueryEvents(db *sql.DB, filters EventFilters) ([]Event, error) {
 			return nil, fmt.Errorf("failed to get participants for event %s: %w", events[i].ID, err)
 		}
 		events[i].Participants = participants
+
+		if filters.ShowRevisions {
+			revisions, err := getEventRevisions(db, events[i].ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get revisions for event %s: %w", events[i].ID, err)
+			}
+			events[i].Revisions = revisions
+		}
 	}
 
 	return events, nil
 }
 
+// getEventRevisions retrieves prior content versions of an event, oldest first
+func getEventRevisions(db *sql.DB, eventID string) ([]Revision, error) {
+	rows, err := db.Query(`
+		SELECT revision, COALESCE(content, ''), observed_at, replaced_at
+		FROM event_revisions
+		WHERE event_id = ?
+		ORDER BY revision
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		if err := rows.Scan(&r.Revision, &r.Content, &r.ObservedAt, &r.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
 // getEventParticipants retrieves all participants for a given event
 func getEventParticipants(db *sql.DB, eventID string) ([]Participant, error) {
 	query := `
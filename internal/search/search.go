@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Napageneral/mnemonic/internal/ingest"
 )
 
 const (
@@ -91,6 +93,12 @@ func (s *Searcher) SearchEpisodes(ctx context.Context, req EpisodeSearchRequest)
 		querySQL += " AND d.name = ?"
 		args = append(args, req.DefinitionName)
 	}
+	if !req.IncludeDeleted {
+		// Skip episodes whose events were all deleted at the source.
+		querySQL += ` AND EXISTS (
+			SELECT 1 FROM episode_events ee JOIN events ev ON ev.id = ee.event_id
+			WHERE ee.episode_id = ep.id AND ` + ingest.NotDeleted("ev") + `)`
+	}
 
 	rows, err := s.db.QueryContext(ctx, querySQL, args...)
 	if err != nil {
@@ -199,7 +207,7 @@ func (s *Searcher) SearchDocuments(ctx context.Context, req DocumentSearchReques
 		useLexical = true
 	}
 
-	docs, err := loadDocuments(ctx, s.db, req.Channels)
+	docs, err := loadDocuments(ctx, s.db, req.Channels, req.IncludeDeleted)
 	if err != nil {
 		return DocumentSearchResponse{}, err
 	}
@@ -324,20 +332,27 @@ type documentRow struct {
 	Snippet     string
 }
 
-func loadDocuments(ctx context.Context, db *sql.DB, channels []string) ([]documentRow, error) {
+func loadDocuments(ctx context.Context, db *sql.DB, channels []string, includeDeleted bool) ([]documentRow, error) {
 	query := `
 		SELECT d.doc_key, d.channel, d.title, d.description, d.metadata_json, d.current_event_id, e.content
 		FROM document_heads d
 		JOIN events e ON e.id = d.current_event_id
 	`
 	args := []any{}
+	var where []string
 	if len(channels) > 0 {
 		placeholders := make([]string, len(channels))
 		for i, ch := range channels {
 			placeholders[i] = "?"
 			args = append(args, ch)
 		}
-		query += " WHERE d.channel IN (" + strings.Join(placeholders, ",") + ")"
+		where = append(where, "d.channel IN ("+strings.Join(placeholders, ",")+")")
+	}
+	if !includeDeleted {
+		where = append(where, ingest.NotDeleted("e"))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
 	}
 
 	rows, err := db.QueryContext(ctx, query, args...)
@@ -546,6 +561,7 @@ type EventSearchRequest struct {
 	UseFTS        bool      // Use FTS5 full-text search
 	Model         string    // Embedding model (default: gemini-embedding-001)
 	QueryEmbedding []float64 // Pre-computed query embedding (optional)
+	IncludeDeleted bool      // Include events tombstoned by their source
 }
 
 // EventSearchResult represents a single event search result
@@ -600,7 +616,7 @@ func (s *Searcher) SearchEvents(ctx context.Context, req EventSearchRequest) (Ev
 	ftsResults := map[string]float64{}
 	ftsSnippets := map[string]string{}
 	if useFTS {
-		ftsResults, ftsSnippets = s.searchEventsFTS(ctx, query, req.Channels, req.ThreadID, req.Since, req.Until, limit*2)
+		ftsResults, ftsSnippets = s.searchEventsFTS(ctx, query, req.Channels, req.ThreadID, req.Since, req.Until, req.IncludeDeleted, limit*2)
 	}
 
 	// Vector search
@@ -616,7 +632,7 @@ func (s *Searcher) SearchEvents(ctx context.Context, req EventSearchRequest) (Ev
 		}
 		if len(queryEmbedding) > 0 {
 			embeddingUsed = true
-			vectorResults = s.searchEventsVector(ctx, queryEmbedding, model, req.Channels, req.ThreadID, req.Since, req.Until, limit*2)
+			vectorResults = s.searchEventsVector(ctx, queryEmbedding, model, req.Channels, req.ThreadID, req.Since, req.Until, req.IncludeDeleted, limit*2)
 		}
 	}
 
@@ -766,42 +782,80 @@ func (s *Searcher) loadEventMeta(ctx context.Context, eventIDs map[string]bool)
 	return result
 }
 
-func (s *Searcher) searchEventsFTS(ctx context.Context, query string, channels []string, threadID string, since, until int64, limit int) (map[string]float64, map[string]string) {
+func (s *Searcher) searchEventsFTS(ctx context.Context, query string, channels []string, threadID string, since, until int64, includeDeleted bool, limit int) (map[string]float64, map[string]string) {
 	// Escape query for FTS5 safety
 	safeQuery := escapeFTS5Query(query)
 	if safeQuery == "" {
 		return nil, nil
 	}
 
-	ftsQuery := `
-		SELECT fts.event_id, bm25(events_fts) as score, snippet(events_fts, 2, '<mark>', '</mark>', '...', 64)
-		FROM events_fts fts
-		JOIN events e ON e.id = fts.event_id
-		WHERE events_fts MATCH ?`
-	args := []any{safeQuery}
-
-	if len(channels) > 0 {
-		placeholders := make([]string, len(channels))
-		for i, ch := range channels {
-			placeholders[i] = "?"
-			args = append(args, ch)
+	// eventFilter narrows events aliased as alias to the request's scope.
+	eventFilter := func(alias string) (string, []any) {
+		var clause string
+		var args []any
+		if len(channels) > 0 {
+			placeholders := make([]string, len(channels))
+			for i, ch := range channels {
+				placeholders[i] = "?"
+				args = append(args, ch)
+			}
+			clause += " AND " + alias + ".channel IN (" + strings.Join(placeholders, ",") + ")"
 		}
-		ftsQuery += " AND e.channel IN (" + strings.Join(placeholders, ",") + ")"
-	}
-	if threadID != "" {
-		ftsQuery += " AND e.thread_id = ?"
-		args = append(args, threadID)
-	}
-	if since > 0 {
-		ftsQuery += " AND e.timestamp >= ?"
-		args = append(args, since)
-	}
-	if until > 0 {
-		ftsQuery += " AND e.timestamp <= ?"
-		args = append(args, until)
+		if threadID != "" {
+			clause += " AND " + alias + ".thread_id = ?"
+			args = append(args, threadID)
+		}
+		if since > 0 {
+			clause += " AND " + alias + ".timestamp >= ?"
+			args = append(args, since)
+		}
+		if until > 0 {
+			clause += " AND " + alias + ".timestamp <= ?"
+			args = append(args, until)
+		}
+		if !includeDeleted {
+			clause += " AND " + ingest.NotDeleted(alias)
+		}
+		return clause, args
 	}
+	threadFilter, threadArgs := eventFilter("te")
+	outerFilter, outerArgs := eventFilter("e")
+
+	// Hits on a thread name, attachment filename or document title count
+	// for the events they belong to. A thread name stands in for the latest
+	// in-scope event of the thread only, and ranks below direct content
+	// hits. SQLite fills the bare snippet column from the row that produced
+	// MIN(score).
+	ftsQuery := `
+		SELECT h.event_id, MIN(h.score) as score, h.snippet
+		FROM (
+			SELECT e.id as event_id, bm25(events_fts) as score, snippet(events_fts, 0, '<mark>', '</mark>', '...', 64) as snippet
+			FROM events_fts JOIN events e ON e.rowid = events_fts.rowid
+			WHERE events_fts MATCH ?
+			UNION ALL
+			SELECT (
+				SELECT te.id FROM events te WHERE te.thread_id = t.id` + threadFilter + `
+				ORDER BY te.timestamp DESC LIMIT 1
+			), 0.5 * bm25(threads_fts), snippet(threads_fts, 0, '<mark>', '</mark>', '...', 16)
+			FROM threads_fts JOIN threads t ON t.rowid = threads_fts.rowid
+			WHERE threads_fts MATCH ?
+			UNION ALL
+			SELECT a.event_id, bm25(attachments_fts), snippet(attachments_fts, 0, '<mark>', '</mark>', '...', 16)
+			FROM attachments_fts JOIN attachments a ON a.rowid = attachments_fts.rowid
+			WHERE attachments_fts MATCH ?
+			UNION ALL
+			SELECT d.current_event_id, bm25(documents_fts), snippet(documents_fts, 0, '<mark>', '</mark>', '...', 16)
+			FROM documents_fts JOIN document_heads d ON d.rowid = documents_fts.rowid
+			WHERE documents_fts MATCH ?
+		) h
+		JOIN events e ON e.id = h.event_id
+		WHERE 1=1` + outerFilter
+	args := []any{safeQuery}
+	args = append(args, threadArgs...)
+	args = append(args, safeQuery, safeQuery, safeQuery)
+	args = append(args, outerArgs...)
 
-	ftsQuery += " ORDER BY score LIMIT ?"
+	ftsQuery += " GROUP BY h.event_id ORDER BY score LIMIT ?"
 	args = append(args, limit)
 
 	rows, err := s.db.QueryContext(ctx, ftsQuery, args...)
@@ -828,7 +882,7 @@ func (s *Searcher) searchEventsFTS(ctx context.Context, query string, channels [
 	return scores, snippets
 }
 
-func (s *Searcher) searchEventsVector(ctx context.Context, queryEmbedding []float64, model string, channels []string, threadID string, since, until int64, limit int) map[string]float64 {
+func (s *Searcher) searchEventsVector(ctx context.Context, queryEmbedding []float64, model string, channels []string, threadID string, since, until int64, includeDeleted bool, limit int) map[string]float64 {
 	// Load episode embeddings and find matching events
 	query := `
 		SELECT e.target_id, e.embedding_blob, e.dimension
@@ -921,6 +975,9 @@ func (s *Searcher) searchEventsVector(ctx context.Context, queryEmbedding []floa
 		filterClauses = append(filterClauses, "e.timestamp <= ?")
 		args2 = append(args2, until)
 	}
+	if !includeDeleted {
+		filterClauses = append(filterClauses, ingest.NotDeleted("e"))
+	}
 
 	if len(filterClauses) > 0 {
 		eventQuery += " AND " + strings.Join(filterClauses, " AND ")
@@ -140,3 +140,86 @@ func float64SliceToBlob(values []float64) []byte {
 	}
 	return blob
 }
+
+func TestSearchEventsFTS(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`INSERT INTO threads (id, channel, name, source_adapter, source_id, created_at, updated_at)
+		 VALUES ('t1', 'imessage', 'Quarterly planning', 'test', 't1', 1, 1)`,
+		`INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, source_adapter, source_id)
+		 VALUES ('e1', 100, 'imessage', '["text"]', 'lunch tomorrow?', 'received', 't1', 'test', 'e1')`,
+		`INSERT INTO events (id, timestamp, channel, content_types, content, direction, thread_id, source_adapter, source_id)
+		 VALUES ('e3', 150, 'imessage', '["text"]', 'see you then', 'sent', 't1', 'test', 'e3')`,
+		`INSERT INTO events (id, timestamp, channel, content_types, content, direction, source_adapter, source_id)
+		 VALUES ('e2', 200, 'gmail', '["text"]', 'the deploy broke', 'received', 'test', 'e2')`,
+		`INSERT INTO attachments (id, event_id, filename, created_at) VALUES ('a1', 'e2', 'budget.xlsx', 200)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	docEvent, err := documents.UpsertDocument(ctx, db, documents.DocumentInput{
+		DocKey:    "doc:roadmap",
+		Channel:   "doc",
+		Title:     "Roadmap",
+		Content:   "Milestones for next year.",
+		Timestamp: 300,
+	})
+	if err != nil {
+		t.Fatalf("upsert doc: %v", err)
+	}
+
+	searcher := NewSearcher(db, nil)
+	hits := func(query string) []string {
+		t.Helper()
+		resp, err := searcher.SearchEvents(ctx, EventSearchRequest{Query: query, UseFTS: true})
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		var ids []string
+		for _, r := range resp.Results {
+			ids = append(ids, r.EventID)
+		}
+		return ids
+	}
+
+	for query, want := range map[string]string{
+		"deploy":    "e2",
+		"quarterly": "e3",
+		"budget":    "e2",
+		"roadmap":   docEvent.EventID,
+	} {
+		if got := hits(query); len(got) != 1 || got[0] != want {
+			t.Fatalf("search %q: expected [%s], got %v", query, want, got)
+		}
+	}
+
+	// A thread name hit counts once, for the thread's latest event in scope.
+	resp, err := searcher.SearchEvents(ctx, EventSearchRequest{Query: "quarterly", UseFTS: true, Until: 120})
+	if err != nil {
+		t.Fatalf("search scoped: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].EventID != "e1" {
+		t.Fatalf("expected scoped thread hit on e1, got %+v", resp.Results)
+	}
+
+	// Triggers keep the external-content index in step with the source rows.
+	if _, err := db.Exec(`UPDATE events SET content = 'rolled back' WHERE id = 'e2'`); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE threads SET name = 'Offsite' WHERE id = 't1'`); err != nil {
+		t.Fatalf("rename thread: %v", err)
+	}
+	if got := hits("deploy"); len(got) != 0 {
+		t.Fatalf("expected stale content to drop out, got %v", got)
+	}
+	if got := hits("rolled"); len(got) != 1 || got[0] != "e2" {
+		t.Fatalf("expected updated content to match, got %v", got)
+	}
+	if got := hits("offsite"); len(got) != 1 || got[0] != "e3" {
+		t.Fatalf("expected renamed thread to match, got %v", got)
+	}
+}
@@ -11,6 +11,7 @@ type DocumentSearchRequest struct {
 	UseEmbeddings  bool
 	UseLexical     bool
 	TrackRetrieval bool
+	IncludeDeleted bool
 }
 
 // DocumentSearchResult represents a document search match.
@@ -44,6 +45,7 @@ type EpisodeSearchRequest struct {
 	MinScore       float64
 	Model          string
 	UseEmbeddings  bool
+	IncludeDeleted bool
 }
 
 // EpisodeSearchResult represents an episode search match.
@@ -6,7 +6,15 @@ import (
 	"time"
 )
 
-func ensureTable(db *sql.DB) error {
+// DBTX abstracts *sql.DB and *sql.Tx so adapters can record state inside
+// the transaction that wrote the rows it describes.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func ensureTable(db DBTX) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS adapter_state (
 			adapter TEXT NOT NULL,
@@ -22,7 +30,7 @@ func ensureTable(db *sql.DB) error {
 	return nil
 }
 
-func Get(db *sql.DB, adapter string, key string) (string, bool, error) {
+func Get(db DBTX, adapter string, key string) (string, bool, error) {
 	if err := ensureTable(db); err != nil {
 		return "", false, err
 	}
@@ -37,7 +45,7 @@ func Get(db *sql.DB, adapter string, key string) (string, bool, error) {
 	return v, true, nil
 }
 
-func Set(db *sql.DB, adapter string, key string, value string) error {
+func Set(db DBTX, adapter string, key string, value string) error {
 	if err := ensureTable(db); err != nil {
 		return err
 	}
@@ -54,3 +62,37 @@ func Set(db *sql.DB, adapter string, key string, value string) error {
 	}
 	return nil
 }
+
+// List returns every key for an adapter that starts with prefix.
+func List(db DBTX, adapter string, prefix string) (map[string]string, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT key, value FROM adapter_state
+		WHERE adapter = ? AND substr(key, 1, ?) = ?
+	`, adapter, len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list adapter state: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("failed to list adapter state: %w", err)
+		}
+		out[k] = v
+	}
+	return out, rows.Err()
+}
+
+func Delete(db DBTX, adapter string, key string) error {
+	if err := ensureTable(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE FROM adapter_state WHERE adapter = ? AND key = ?`, adapter, key); err != nil {
+		return fmt.Errorf("failed to delete adapter state: %w", err)
+	}
+	return nil
+}
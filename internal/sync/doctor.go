@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/config"
+)
+
+// staleWatermark is how old an enabled adapter's watermark can get before
+// doctor warns that it has stopped advancing.
+const staleWatermark = 7 * 24 * time.Hour
+
+// DoctorReport collects the probe results for one configured adapter.
+type DoctorReport struct {
+	Adapter string           `json:"adapter"`
+	Type    string           `json:"type"`
+	Enabled bool             `json:"enabled"`
+	OK      bool             `json:"ok"`
+	Checks  []adapters.Check `json:"checks"`
+}
+
+// Doctor diagnoses a configured adapter: the type's own probes, then the
+// local sync state (watermark and last job). It only reads; db may be nil
+// when the database has not been initialized.
+func Doctor(ctx context.Context, db *sql.DB, name string, cfg config.AdapterConfig) DoctorReport {
+	report := DoctorReport{Adapter: name, Type: cfg.Type, Enabled: cfg.Enabled}
+	report.Checks = adapters.Diagnose(ctx, cfg)
+
+	if db != nil {
+		// Watermarks are keyed by the adapter's own name (e.g. gmail-<account>),
+		// which only a built adapter knows.
+		if adapter, err := adapters.Build(name, cfg); err == nil {
+			report.Checks = append(report.Checks, checkWatermark(db, name, adapter.Name(), cfg.Enabled))
+		}
+		report.Checks = append(report.Checks, checkLastJob(db, name))
+	}
+
+	report.OK = true
+	for _, c := range report.Checks {
+		if c.Status == adapters.CheckFail {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+func checkWatermark(db *sql.DB, name, watermarkKey string, enabled bool) adapters.Check {
+	c := adapters.Check{Name: "watermark", Status: adapters.CheckOK}
+	wm := readWatermark(db, watermarkKey)
+	if wm == nil {
+		c.Status = adapters.CheckWarn
+		c.Detail = "never synced"
+		c.Fix = fmt.Sprintf("run 'mnemonic sync %s'", name)
+		return c
+	}
+	ts := *wm
+	// Some sources store millisecond timestamps.
+	if ts > 1e12 {
+		ts /= 1000
+	}
+	at := time.Unix(ts, 0)
+	c.Detail = at.Local().Format("2006-01-02 15:04:05")
+	switch age := time.Since(at); {
+	case age < -time.Hour:
+		c.Status = adapters.CheckFail
+		c.Detail += " (in the future)"
+		c.Fix = fmt.Sprintf("a bad source timestamp will make incremental syncs skip data; run 'mnemonic sync %s --full'", name)
+	case enabled && age > staleWatermark:
+		c.Status = adapters.CheckWarn
+		c.Detail += fmt.Sprintf(" (%d days ago)", int(age.Hours()/24))
+		c.Fix = fmt.Sprintf("check that the source still has new data, then run 'mnemonic sync %s'", name)
+	}
+	return c
+}
+
+func checkLastJob(db *sql.DB, name string) adapters.Check {
+	c := adapters.Check{Name: "last sync", Status: adapters.CheckOK}
+	var status, phase string
+	var updatedAt int64
+	var lastErr sql.NullString
+	err := db.QueryRow(`
+		SELECT status, phase, updated_at, last_error FROM sync_jobs WHERE adapter = ?
+	`, name).Scan(&status, &phase, &updatedAt, &lastErr)
+	if err != nil {
+		c.Detail = "no sync recorded"
+		return c
+	}
+	when := time.Unix(updatedAt, 0).Local().Format("2006-01-02 15:04:05")
+	switch status {
+	case "error":
+		c.Status = adapters.CheckFail
+		c.Detail = fmt.Sprintf("failed in %s at %s: %s", phase, when, lastErr.String)
+		c.Fix = fmt.Sprintf("fix the failing check above, or see 'mnemonic sync history %s'", name)
+	case "running", "queued":
+		c.Detail = fmt.Sprintf("%s since %s", status, when)
+		if time.Since(time.Unix(updatedAt, 0)) > 24*time.Hour {
+			c.Status = adapters.CheckWarn
+			c.Fix = "a previous sync likely crashed; the next sync will reset it"
+		}
+	default:
+		c.Detail = fmt.Sprintf("%s at %s", status, when)
+	}
+	return c
+}
@@ -39,6 +39,27 @@ func ensureSyncJobsTable(db *sql.DB) error {
 	return nil
 }
 
+// QueueJob marks an adapter as waiting for a sync slot.
+func QueueJob(db *sql.DB, adapter string) error {
+	if err := ensureSyncJobsTable(db); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	_, err := db.Exec(`
+		INSERT INTO sync_jobs (adapter, status, phase, cursor, started_at, updated_at, last_error, progress_json)
+		VALUES (?, 'queued', 'queued', NULL, NULL, ?, NULL, NULL)
+		ON CONFLICT(adapter) DO UPDATE SET
+			status = 'queued',
+			phase = 'queued',
+			updated_at = excluded.updated_at,
+			last_error = NULL
+	`, adapter, now)
+	if err != nil {
+		return fmt.Errorf("failed to queue job: %w", err)
+	}
+	return nil
+}
+
 func StartJob(db *sql.DB, adapter string) error {
 	if err := ensureSyncJobsTable(db); err != nil {
 		return err
@@ -0,0 +1,325 @@
+package sync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Run is one recorded adapter sync.
+type Run struct {
+	ID              int64          `json:"id"`
+	Adapter         string         `json:"adapter"`
+	SourceAdapter   string         `json:"source_adapter"`
+	Full            bool           `json:"full"`
+	Status          string         `json:"status"`
+	StartedAt       int64          `json:"started_at"`
+	FinishedAt      *int64         `json:"finished_at,omitempty"`
+	WatermarkBefore *int64         `json:"watermark_before,omitempty"`
+	WatermarkAfter  *int64         `json:"watermark_after,omitempty"`
+	Result          *AdapterResult `json:"result,omitempty"`
+	Error           string         `json:"error,omitempty"`
+}
+
+// RunChange is an event, thread or person a run created or updated.
+type RunChange struct {
+	ID        string `json:"id"`
+	Action    string `json:"action"`
+	Label     string `json:"label,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+// RunDiff lists what one run changed.
+type RunDiff struct {
+	Run     Run         `json:"run"`
+	Events  []RunChange `json:"events"`
+	Threads []RunChange `json:"threads"`
+	Persons []RunChange `json:"persons"`
+}
+
+// Triggers on events and threads attribute writes to the running sync of
+// the same source_adapter. Kept in step with schema.sql for installs that
+// have not re-run init.
+const syncRunsDDL = `
+	CREATE TABLE IF NOT EXISTS sync_runs (
+	    id INTEGER PRIMARY KEY AUTOINCREMENT,
+	    adapter TEXT NOT NULL,          -- configured adapter name
+	    source_adapter TEXT NOT NULL,   -- source_adapter of the rows it writes
+	    full INTEGER NOT NULL DEFAULT 0,
+	    status TEXT NOT NULL,           -- running, success, error, abandoned
+	    started_at INTEGER NOT NULL,
+	    finished_at INTEGER,
+	    watermark_before INTEGER,       -- sync_watermarks.last_sync_at when the run started
+	    watermark_after INTEGER,
+	    result_json TEXT,               -- AdapterResult, including perf
+	    error TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sync_runs_adapter ON sync_runs(adapter, status);
+	CREATE INDEX IF NOT EXISTS idx_sync_runs_source ON sync_runs(source_adapter, status);
+
+	-- Sync run changes: Events and threads written while a run was active,
+	-- attributed by source_adapter through the triggers below
+	CREATE TABLE IF NOT EXISTS sync_run_changes (
+	    run_id INTEGER NOT NULL REFERENCES sync_runs(id) ON DELETE CASCADE,
+	    kind TEXT NOT NULL,             -- event, thread
+	    target_id TEXT NOT NULL,
+	    action TEXT NOT NULL,           -- created, updated
+	    PRIMARY KEY (run_id, kind, target_id)
+	);
+
+	CREATE TRIGGER IF NOT EXISTS sync_runs_event_insert AFTER INSERT ON events
+	WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+	BEGIN
+	    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+	    SELECT MAX(id), 'event', new.id, 'created' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS sync_runs_event_update AFTER UPDATE ON events
+	WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+	  AND (old.content IS NOT new.content OR old.timestamp IS NOT new.timestamp
+	    OR old.content_types IS NOT new.content_types OR old.direction IS NOT new.direction
+	    OR old.thread_id IS NOT new.thread_id OR old.metadata_json IS NOT new.metadata_json)
+	BEGIN
+	    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+	    SELECT MAX(id), 'event', new.id, 'updated' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS sync_runs_thread_insert AFTER INSERT ON threads
+	WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+	BEGIN
+	    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+	    SELECT MAX(id), 'thread', new.id, 'created' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS sync_runs_thread_update AFTER UPDATE ON threads
+	WHEN EXISTS (SELECT 1 FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running')
+	  AND (old.name IS NOT new.name OR old.is_group IS NOT new.is_group OR old.parent_thread_id IS NOT new.parent_thread_id)
+	BEGIN
+	    INSERT OR IGNORE INTO sync_run_changes (run_id, kind, target_id, action)
+	    SELECT MAX(id), 'thread', new.id, 'updated' FROM sync_runs WHERE source_adapter = new.source_adapter AND status = 'running';
+	END;
+`
+
+func ensureSyncRunsTables(db *sql.DB) error {
+	if _, err := db.Exec(syncRunsDDL); err != nil {
+		return fmt.Errorf("failed to ensure sync_runs tables: %w", err)
+	}
+	return nil
+}
+
+func readWatermark(db *sql.DB, adapter string) *int64 {
+	var v sql.NullInt64
+	if err := db.QueryRow(`SELECT last_sync_at FROM sync_watermarks WHERE adapter = ?`, adapter).Scan(&v); err != nil || !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// StartRun records the start of an adapter sync and returns its run id.
+// adapter is the configured name; sourceAdapter is the source_adapter its
+// rows and watermark carry, which the change triggers match on. Runs left
+// "running" by a crashed process, under either name, are marked abandoned
+// so their change triggers stop matching.
+func StartRun(db *sql.DB, adapter, sourceAdapter string, full bool) (int64, error) {
+	if err := ensureSyncRunsTables(db); err != nil {
+		return 0, err
+	}
+	if _, err := db.Exec(`
+		UPDATE sync_runs SET status = 'abandoned', finished_at = ?
+		WHERE (adapter = ? OR source_adapter = ?) AND status = 'running'
+	`, time.Now().Unix(), adapter, sourceAdapter); err != nil {
+		return 0, fmt.Errorf("failed to close stale runs: %w", err)
+	}
+	res, err := db.Exec(`
+		INSERT INTO sync_runs (adapter, source_adapter, full, status, started_at, watermark_before)
+		VALUES (?, ?, ?, 'running', ?, ?)
+	`, adapter, sourceAdapter, full, time.Now().Unix(), readWatermark(db, sourceAdapter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to start run: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// FinishRun stores the outcome of a run.
+func FinishRun(db *sql.DB, runID int64, result AdapterResult) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run result: %w", err)
+	}
+	status := "success"
+	var errMsg *string
+	if !result.Success {
+		status = "error"
+		errMsg = &result.Error
+	}
+	_, err = db.Exec(`
+		UPDATE sync_runs
+		SET status = ?, finished_at = ?, result_json = ?, error = ?,
+			watermark_after = (SELECT last_sync_at FROM sync_watermarks WHERE adapter = sync_runs.source_adapter)
+		WHERE id = ?
+	`, status, time.Now().Unix(), string(b), errMsg, runID)
+	if err != nil {
+		return fmt.Errorf("failed to finish run: %w", err)
+	}
+	return nil
+}
+
+const runColumns = `id, adapter, source_adapter, full, status, started_at, finished_at, watermark_before, watermark_after, result_json, error`
+
+func scanRun(scan func(dest ...any) error) (Run, error) {
+	var r Run
+	var finishedAt, before, after sql.NullInt64
+	var resultJSON, errMsg sql.NullString
+	if err := scan(&r.ID, &r.Adapter, &r.SourceAdapter, &r.Full, &r.Status, &r.StartedAt, &finishedAt, &before, &after, &resultJSON, &errMsg); err != nil {
+		return r, err
+	}
+	if finishedAt.Valid {
+		r.FinishedAt = &finishedAt.Int64
+	}
+	if before.Valid {
+		r.WatermarkBefore = &before.Int64
+	}
+	if after.Valid {
+		r.WatermarkAfter = &after.Int64
+	}
+	if resultJSON.Valid && resultJSON.String != "" {
+		var res AdapterResult
+		if err := json.Unmarshal([]byte(resultJSON.String), &res); err == nil {
+			r.Result = &res
+		}
+	}
+	r.Error = errMsg.String
+	return r, nil
+}
+
+// ListRuns returns the most recent runs, optionally for one adapter.
+func ListRuns(db *sql.DB, adapter string, limit int) ([]Run, error) {
+	if err := ensureSyncRunsTables(db); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	query := `SELECT ` + runColumns + ` FROM sync_runs`
+	var args []any
+	if adapter != "" {
+		query += ` WHERE adapter = ?`
+		args = append(args, adapter)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Run
+	for rows.Next() {
+		r, err := scanRun(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating runs: %w", err)
+	}
+	return out, nil
+}
+
+// GetRun loads one run by id.
+func GetRun(db *sql.DB, runID int64) (Run, error) {
+	if err := ensureSyncRunsTables(db); err != nil {
+		return Run{}, err
+	}
+	r, err := scanRun(db.QueryRow(`SELECT `+runColumns+` FROM sync_runs WHERE id = ?`, runID).Scan)
+	if err == sql.ErrNoRows {
+		return r, fmt.Errorf("sync run %d not found", runID)
+	}
+	if err != nil {
+		return r, fmt.Errorf("failed to load run: %w", err)
+	}
+	return r, nil
+}
+
+// DiffRun lists the events and threads a run wrote, plus persons created or
+// updated while it ran. Persons carry no source adapter, so they are matched
+// by time and may include ones written by adapters running alongside it.
+func DiffRun(db *sql.DB, runID int64, limit int) (RunDiff, error) {
+	run, err := GetRun(db, runID)
+	if err != nil {
+		return RunDiff{}, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	diff := RunDiff{Run: run}
+
+	diff.Events, err = queryChanges(db, `
+		SELECT c.target_id, c.action, SUBSTR(COALESCE(e.content, ''), 1, 120), COALESCE(e.channel, ''), COALESCE(e.timestamp, 0)
+		FROM sync_run_changes c
+		LEFT JOIN events e ON e.id = c.target_id
+		WHERE c.run_id = ? AND c.kind = 'event'
+		ORDER BY e.timestamp, c.target_id
+		LIMIT ?
+	`, runID, limit)
+	if err != nil {
+		return diff, err
+	}
+	diff.Threads, err = queryChanges(db, `
+		SELECT c.target_id, c.action, COALESCE(t.name, ''), COALESCE(t.channel, ''), 0
+		FROM sync_run_changes c
+		LEFT JOIN threads t ON t.id = c.target_id
+		WHERE c.run_id = ? AND c.kind = 'thread'
+		ORDER BY c.target_id
+		LIMIT ?
+	`, runID, limit)
+	if err != nil {
+		return diff, err
+	}
+
+	end := time.Now().Unix()
+	if run.FinishedAt != nil {
+		end = *run.FinishedAt
+	}
+	diff.Persons, err = queryChanges(db, `
+		SELECT id, CASE WHEN created_at >= ? THEN 'created' ELSE 'updated' END,
+			COALESCE(display_name, canonical_name), '', 0
+		FROM persons
+		WHERE (created_at BETWEEN ? AND ?) OR (updated_at BETWEEN ? AND ?)
+		ORDER BY created_at, id
+		LIMIT ?
+	`, run.StartedAt, run.StartedAt, end, run.StartedAt, end, limit)
+	if err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+func queryChanges(db *sql.DB, query string, args ...any) ([]RunChange, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query run changes: %w", err)
+	}
+	defer rows.Close()
+
+	out := []RunChange{}
+	for rows.Next() {
+		var c RunChange
+		if err := rows.Scan(&c.ID, &c.Action, &c.Label, &c.Channel, &c.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan run change: %w", err)
+		}
+		c.Label = strings.Join(strings.Fields(c.Label), " ")
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating run changes: %w", err)
+	}
+	return out, nil
+}
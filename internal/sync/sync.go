@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strings"
+	"sort"
+	gosync "sync"
 	"time"
 
 	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/bus"
 	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/ingest"
 )
 
 // AdapterResult contains the result of syncing a single adapter
@@ -25,6 +28,8 @@ type AdapterResult struct {
 	AttachmentsUpdated int               `json:"attachments_updated"`
 	ReactionsCreated   int               `json:"reactions_created"`
 	ReactionsUpdated   int               `json:"reactions_updated"`
+	EventsDeleted      int               `json:"events_deleted"`
+	EventsPurged       int               `json:"events_purged,omitempty"`
 	Duration           string            `json:"duration"`
 	Perf               map[string]string `json:"perf,omitempty"`
 }
@@ -36,7 +41,32 @@ type SyncResult struct {
 	Adapters []AdapterResult `json:"adapters,omitempty"`
 }
 
-// SyncAll runs all enabled adapters
+// BusSyncCompleted is the bus event type Announce emits.
+const BusSyncCompleted = bus.TypeSyncCompleted
+
+// Announce publishes a sync.completed bus event when a sync wrote events, so
+// consumers such as watch-mode pipelines can pick up the new data. name is the
+// configured adapter; sourceAdapter is the source_adapter its events carry.
+func Announce(db *sql.DB, name, sourceAdapter string, eventsCreated, eventsUpdated int) error {
+	if eventsCreated == 0 && eventsUpdated == 0 {
+		return nil
+	}
+	return bus.Emit(db, BusSyncCompleted, sourceAdapter, "", bus.SyncCompletedPayload{
+		Adapter:       name,
+		EventsCreated: eventsCreated,
+		EventsUpdated: eventsUpdated,
+	})
+}
+
+// DefaultConcurrency is how many adapters SyncAll runs at once when the
+// config does not say.
+const DefaultConcurrency = 4
+
+// SyncAll runs all enabled adapters, up to cfg.Sync.Concurrency at a time.
+//
+// Adapters share db, which db.Open limits to one SQLite connection, so their
+// network and parsing work overlaps while writes land one transaction at a
+// time. Long-running adapters commit in batches to let the others in.
 func SyncAll(ctx context.Context, db *sql.DB, cfg *config.Config, full bool) SyncResult {
 	result := SyncResult{OK: true}
 
@@ -45,47 +75,56 @@ func SyncAll(ctx context.Context, db *sql.DB, cfg *config.Config, full bool) Syn
 		return result
 	}
 
-	enabledCount := 0
-	for _, adapter := range cfg.Adapters {
+	var names []string
+	for name, adapter := range cfg.Adapters {
 		if adapter.Enabled {
-			enabledCount++
+			names = append(names, name)
 		}
 	}
+	sort.Strings(names)
 
-	if enabledCount == 0 {
+	if len(names) == 0 {
 		result.Message = "No adapters enabled"
 		return result
 	}
 
-	ftsDisabled := false
-	if full {
-		if err := disableFTS(db); err != nil {
+	timeouts := make([]time.Duration, len(names))
+	for i, name := range names {
+		timeout, err := adapterTimeout(cfg, cfg.Adapters[name])
+		if err != nil {
 			result.OK = false
-			result.Message = fmt.Sprintf("Failed to disable FTS triggers: %v", err)
+			result.Message = fmt.Sprintf("Adapter '%s': %v", name, err)
 			return result
 		}
-		ftsDisabled = true
+		timeouts[i] = timeout
 	}
-	if ftsDisabled {
-		defer func() {
-			if err := rebuildFTS(db); err != nil {
-				result.OK = false
-				if result.Message == "" {
-					result.Message = fmt.Sprintf("Failed to rebuild FTS index: %v", err)
-				}
-			}
-		}()
+
+	concurrency := cfg.Sync.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
 	}
 
-	// Sync each enabled adapter
-	for name, adapterCfg := range cfg.Adapters {
-		if !adapterCfg.Enabled {
-			continue
-		}
+	// Mark everything queued up front so `sync status` shows the whole run.
+	for _, name := range names {
+		_ = QueueJob(db, name)
+	}
 
-		adapterResult := syncAdapter(ctx, db, name, adapterCfg, full)
-		result.Adapters = append(result.Adapters, adapterResult)
+	results := make([]AdapterResult, len(names))
+	sem := make(chan struct{}, concurrency)
+	var wg gosync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runAdapter(ctx, db, name, cfg.Adapters[name], full, timeouts[i])
+		}(i, name)
+	}
+	wg.Wait()
 
+	result.Adapters = results
+	for _, adapterResult := range results {
 		if !adapterResult.Success {
 			// One adapter failing doesn't stop others, but overall sync is not OK
 			result.OK = false
@@ -112,27 +151,14 @@ func SyncOne(ctx context.Context, db *sql.DB, cfg *config.Config, adapterName st
 		return result
 	}
 
-	ftsDisabled := false
-	if full {
-		if err := disableFTS(db); err != nil {
-			result.OK = false
-			result.Message = fmt.Sprintf("Failed to disable FTS triggers: %v", err)
-			return result
-		}
-		ftsDisabled = true
-	}
-	if ftsDisabled {
-		defer func() {
-			if err := rebuildFTS(db); err != nil {
-				result.OK = false
-				if result.Message == "" {
-					result.Message = fmt.Sprintf("Failed to rebuild FTS index: %v", err)
-				}
-			}
-		}()
+	timeout, err := adapterTimeout(cfg, adapterCfg)
+	if err != nil {
+		result.OK = false
+		result.Message = fmt.Sprintf("Adapter '%s': %v", adapterName, err)
+		return result
 	}
 
-	adapterResult := syncAdapter(ctx, db, adapterName, adapterCfg, full)
+	adapterResult := runAdapter(ctx, db, adapterName, adapterCfg, full, timeout)
 	result.Adapters = []AdapterResult{adapterResult}
 
 	if !adapterResult.Success {
@@ -142,250 +168,163 @@ func SyncOne(ctx context.Context, db *sql.DB, cfg *config.Config, adapterName st
 	return result
 }
 
-func disableFTS(db *sql.DB) error {
-	if _, err := db.Exec("PRAGMA trusted_schema = ON"); err != nil {
-		return err
+// adapterTimeout resolves the sync timeout for one adapter: its own timeout
+// wins over the global one. Zero means no limit.
+func adapterTimeout(cfg *config.Config, adapterCfg config.AdapterConfig) (time.Duration, error) {
+	raw := adapterCfg.Timeout
+	if raw == "" {
+		raw = cfg.Sync.Timeout
 	}
-	statements := []string{
-		"DROP TRIGGER IF EXISTS events_fts_insert",
-		"DROP TRIGGER IF EXISTS events_fts_update",
-		"DROP TRIGGER IF EXISTS events_fts_delete",
-		"DROP TABLE IF EXISTS events_fts",
+	if raw == "" {
+		return 0, nil
 	}
-	for _, stmt := range statements {
-		if _, err := db.Exec(stmt); err != nil {
-			return err
-		}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", raw)
 	}
-	return nil
+	return d, nil
 }
 
-func rebuildFTS(db *sql.DB) error {
-	if _, err := db.Exec("PRAGMA trusted_schema = ON"); err != nil {
-		return err
-	}
-	statements := []string{
-		"CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(event_id UNINDEXED, channel UNINDEXED, content, tokenize='porter unicode61')",
-		"CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events BEGIN INSERT INTO events_fts(event_id, channel, content) VALUES (new.id, new.channel, COALESCE(new.content, '')); END",
-		"CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE ON events BEGIN DELETE FROM events_fts WHERE event_id = old.id; INSERT INTO events_fts(event_id, channel, content) VALUES (new.id, new.channel, COALESCE(new.content, '')); END",
-		"CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events BEGIN DELETE FROM events_fts WHERE event_id = old.id; END",
-		"DELETE FROM events_fts",
-		"INSERT INTO events_fts(event_id, channel, content) SELECT id, channel, COALESCE(content, '') FROM events",
-	}
-	for _, stmt := range statements {
-		if _, err := db.Exec(stmt); err != nil {
-			return err
-		}
-	}
-	return nil
+// timeoutGrace is how long runAdapter waits, after cancelling a sync that
+// hit its timeout, for the adapter to wind down before giving up on it.
+var timeoutGrace = 30 * time.Second
+
+// syncGuard serializes a sync's own job and run writes with runAdapter giving
+// up on it, so a sync that finishes after its timeout was recorded cannot
+// overwrite it.
+type syncGuard struct {
+	mu        gosync.Mutex
+	abandoned bool
+	runID     int64
 }
 
-// syncAdapter syncs a single adapter and returns its result
-func syncAdapter(ctx context.Context, db *sql.DB, name string, cfg config.AdapterConfig, full bool) AdapterResult {
-	result := AdapterResult{
-		AdapterName: name,
-		Success:     false,
+// do runs f unless the sync has been abandoned. A nil guard always runs f.
+func (g *syncGuard) do(f func()) {
+	if g == nil {
+		f()
+		return
 	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.abandoned {
+		f()
+	}
+}
 
-	_ = StartJob(db, name)
-
-	// Create adapter instance based on type
-	var adapter adapters.Adapter
-	var err error
-
-	switch cfg.Type {
-	case "eve":
-		// Eve DB adapter (reads from ~/Library/Application Support/Eve/eve.db).
-		// This is useful for full rebuilds when Eve has already materialized a clean dataset.
-		adapter, err = adapters.NewEveAdapter()
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
-
-	case "gogcli":
-		// Gmail adapter via gogcli
-		accountVal, ok := cfg.Options["account"]
-		if !ok {
-			result.Error = "Gmail adapter requires 'account' in config"
-			return result
-		}
-		account, ok := accountVal.(string)
-		if !ok || account == "" {
-			result.Error = "Gmail adapter 'account' must be a string"
-			return result
-		}
-		// Standardize instance name across installs: gmail-<account email>
-		instanceName := fmt.Sprintf("gmail-%s", strings.TrimSpace(strings.ToLower(account)))
-		var opts adapters.GmailAdapterOptions
-		if v, ok := cfg.Options["workers"]; ok {
-			if n, ok := v.(int); ok {
-				opts.Workers = n
-			}
-		}
-		if v, ok := cfg.Options["qps"]; ok {
-			switch t := v.(type) {
-			case float64:
-				opts.QPS = t
-			case int:
-				opts.QPS = float64(t)
-			}
-		}
-		adapter, err = adapters.NewGmailAdapter(instanceName, account, opts)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
+// abandon drops any later writes from the sync and returns its run id.
+func (g *syncGuard) abandon() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.abandoned = true
+	return g.runID
+}
 
-	case "gogcli_calendar":
-		accountVal, ok := cfg.Options["account"]
-		if !ok {
-			result.Error = "Calendar adapter requires 'account' in config"
-			return result
-		}
-		account, ok := accountVal.(string)
-		if !ok || account == "" {
-			result.Error = "Calendar adapter 'account' must be a string"
-			return result
-		}
-		instanceName := fmt.Sprintf("calendar-%s", strings.TrimSpace(strings.ToLower(account)))
-		adapter, err = adapters.NewCalendarAdapter(instanceName, account)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
+// runAdapter builds the named adapter and syncs it under its timeout.
+func runAdapter(ctx context.Context, db *sql.DB, name string, cfg config.AdapterConfig, full bool, timeout time.Duration) AdapterResult {
+	adapter, buildErr := adapters.Build(name, cfg)
+	res, _ := runSync(ctx, db, name, cfg, adapter, buildErr, full, timeout)
+	return res
+}
 
-	case "gogcli_contacts":
-		accountVal, ok := cfg.Options["account"]
-		if !ok {
-			result.Error = "Contacts adapter requires 'account' in config"
-			return result
-		}
-		account, ok := accountVal.(string)
-		if !ok || account == "" {
-			result.Error = "Contacts adapter 'account' must be a string"
-			return result
-		}
-		instanceName := fmt.Sprintf("contacts-%s", strings.TrimSpace(strings.ToLower(account)))
-		var opts adapters.ContactsAdapterOptions
-		if v, ok := cfg.Options["workers"]; ok {
-			if n, ok := v.(int); ok {
-				opts.Workers = n
+// runSync syncs one adapter under its timeout. Adapters see the deadline
+// through ctx and get timeoutGrace to return once it passes; one that
+// ignores it is reported as timed out and abandoned. Its job and run records
+// are no longer touched, though the adapter's own writes may still land (or
+// fail once the caller closes db). The returned channel receives the sync's
+// own result once it has returned, abandoned or not.
+func runSync(ctx context.Context, db *sql.DB, name string, cfg config.AdapterConfig, adapter adapters.Adapter, buildErr error, full bool, timeout time.Duration) (AdapterResult, <-chan AdapterResult) {
+	done := make(chan AdapterResult, 1)
+	if timeout <= 0 {
+		res := syncAdapter(ctx, db, name, cfg, adapter, buildErr, full, nil)
+		done <- res
+		return res, done
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	guard := &syncGuard{}
+	finished := make(chan AdapterResult, 1)
+	go func() {
+		res := syncAdapter(ctx, db, name, cfg, adapter, buildErr, full, guard)
+		finished <- res
+		done <- res
+	}()
+
+	var res AdapterResult
+	select {
+	case res = <-finished:
+	case <-ctx.Done():
+		cancel()
+		grace := time.NewTimer(timeoutGrace)
+		defer grace.Stop()
+		select {
+		case res = <-finished:
+		case <-grace.C:
+			res = AdapterResult{
+				AdapterName: name,
+				Error:       fmt.Sprintf("Timed out after %s", timeout),
+				Duration:    time.Since(start).String(),
 			}
-		}
-		if v, ok := cfg.Options["qps"]; ok {
-			switch t := v.(type) {
-			case float64:
-				opts.QPS = t
-			case int:
-				opts.QPS = float64(t)
+			runID := guard.abandon()
+			_ = FinishJobError(db, name, "timeout", nil, res.Error, nil)
+			if runID != 0 {
+				_ = FinishRun(db, runID, res)
 			}
+			return res, done
 		}
-		adapter, err = adapters.NewContactsAdapter(instanceName, account, opts)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
-
-	case "aix":
-		sourceVal, ok := cfg.Options["source"]
-		if !ok {
-			result.Error = "aix adapter requires 'source' in config (e.g., cursor)"
-			return result
-		}
-		source, ok := sourceVal.(string)
-		if !ok || source == "" {
-			result.Error = "aix adapter 'source' must be a string"
-			return result
-		}
-		adapter, err = adapters.NewAixAdapter(source)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
+	}
+	if !res.Success && ctx.Err() == context.DeadlineExceeded {
+		res.Error = fmt.Sprintf("Timed out after %s: %s", timeout, res.Error)
+		_ = FinishJobError(db, name, "timeout", nil, res.Error, nil)
+		if guard.runID != 0 {
+			_ = FinishRun(db, guard.runID, res)
 		}
+	}
+	return res, done
+}
 
-	case "aix-events":
-		// AIX events adapter: exports trimmed turns to Events ledger
-		sourceVal, ok := cfg.Options["source"]
-		if !ok {
-			result.Error = "aix-events adapter requires 'source' in config (e.g., cursor)"
-			return result
-		}
-		source, ok := sourceVal.(string)
-		if !ok || source == "" {
-			result.Error = "aix-events adapter 'source' must be a string"
-			return result
-		}
-		adapter, err = adapters.NewAixEventsAdapter(source)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
+// syncAdapter syncs a single adapter and returns its result; buildErr is
+// what building it failed with, if anything. Its job and run writes go
+// through guard, which may be nil.
+func syncAdapter(ctx context.Context, db *sql.DB, name string, cfg config.AdapterConfig, adapter adapters.Adapter, buildErr error, full bool, guard *syncGuard) AdapterResult {
+	result := AdapterResult{
+		AdapterName: name,
+		Success:     false,
+	}
+	fail := func(phase, msg string) AdapterResult {
+		result.Error = msg
+		guard.do(func() { _ = FinishJobError(db, name, phase, nil, msg, nil) })
+		return result
+	}
 
-	case "aix-agents":
-		// AIX agents adapter: exports full fidelity to Agents ledger
-		sourceVal, ok := cfg.Options["source"]
-		if !ok {
-			result.Error = "aix-agents adapter requires 'source' in config (e.g., cursor)"
-			return result
-		}
-		source, ok := sourceVal.(string)
-		if !ok || source == "" {
-			result.Error = "aix-agents adapter 'source' must be a string"
-			return result
-		}
-		adapter, err = adapters.NewAixAgentsAdapter(source)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
+	sourceAdapter := name
+	if buildErr == nil {
+		sourceAdapter = adapter.Name()
+	}
 
-	case "nexus":
-		var opts adapters.NexusAdapterOptions
-		if v, ok := cfg.Options["events_dir"]; ok {
-			if s, ok := v.(string); ok && s != "" {
-				opts.EventsDir = s
-			}
-		}
-		if v, ok := cfg.Options["state_dir"]; ok {
-			if s, ok := v.(string); ok && s != "" {
-				opts.StateDir = s
-			}
-		}
-		if v, ok := cfg.Options["source"]; ok {
-			if s, ok := v.(string); ok && s != "" {
-				opts.Source = s
+	var runID int64
+	guard.do(func() {
+		_ = StartJob(db, name)
+		if id, err := StartRun(db, name, sourceAdapter, full); err == nil {
+			runID = id
+			if guard != nil {
+				guard.runID = id
 			}
 		}
-		adapter, err = adapters.NewNexusAdapter(opts)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
-
-	case "bird":
-		// X/Twitter adapter via bird CLI
-		username := ""
-		if usernameVal, ok := cfg.Options["username"]; ok {
-			username, _ = usernameVal.(string)
-		}
-		adapter, err = adapters.NewBirdAdapter(username)
-		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create adapter: %v", err)
-			return result
-		}
+	})
+	if runID != 0 {
+		defer guard.do(func() { _ = FinishRun(db, runID, result) })
+	}
 
-	default:
-		result.Error = fmt.Sprintf("Unknown adapter type: %s", cfg.Type)
-		return result
+	if buildErr != nil {
+		return fail("init", fmt.Sprintf("Failed to create adapter: %v", buildErr))
 	}
 
 	// Run sync
 	syncResult, err := adapter.Sync(ctx, db, full)
 	if err != nil {
-		result.Error = fmt.Sprintf("Sync failed: %v", err)
-		_ = FinishJobError(db, name, "sync", nil, result.Error, nil)
-		return result
+		return fail("sync", fmt.Sprintf("Sync failed: %v", err))
 	}
 
 	// Populate result
@@ -399,9 +338,27 @@ func syncAdapter(ctx context.Context, db *sql.DB, name string, cfg config.Adapte
 	result.AttachmentsUpdated = syncResult.AttachmentsUpdated
 	result.ReactionsCreated = syncResult.ReactionsCreated
 	result.ReactionsUpdated = syncResult.ReactionsUpdated
+	result.EventsDeleted = syncResult.EventsDeleted
 	result.Duration = syncResult.Duration.String()
 	result.Perf = syncResult.Perf
 
+	guard.do(func() { finishSync(db, name, sourceAdapter, cfg, &result) })
+	return result
+}
+
+// finishSync runs the post-sync purge and records the outcome.
+func finishSync(db *sql.DB, name, sourceAdapter string, cfg config.AdapterConfig, result *AdapterResult) {
+	if cfg.PurgeDeleted {
+		purged, err := purgeDeleted(db, sourceAdapter)
+		if err != nil {
+			result.Success = false
+			result.Error = fmt.Sprintf("Purge failed: %v", err)
+			_ = FinishJobError(db, name, "purge", nil, result.Error, nil)
+			return
+		}
+		result.EventsPurged = purged
+	}
+
 	_ = FinishJobSuccess(db, name, "sync", nil, map[string]any{
 		"events_created":      result.EventsCreated,
 		"events_updated":      result.EventsUpdated,
@@ -412,9 +369,28 @@ func syncAdapter(ctx context.Context, db *sql.DB, name string, cfg config.Adapte
 		"attachments_updated": result.AttachmentsUpdated,
 		"reactions_created":   result.ReactionsCreated,
 		"reactions_updated":   result.ReactionsUpdated,
+		"events_deleted":      result.EventsDeleted,
+		"events_purged":       result.EventsPurged,
 		"duration":            result.Duration,
 		"finished_at":         time.Now().Unix(),
 	})
+	_ = Announce(db, name, sourceAdapter, result.EventsCreated, result.EventsUpdated)
+}
 
-	return result
+// purgeDeleted runs ingest.PurgeDeleted for one adapter in its own
+// transaction.
+func purgeDeleted(db *sql.DB, adapter string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin purge tx: %w", err)
+	}
+	defer tx.Rollback()
+	purged, err := ingest.PurgeDeleted(tx, adapter)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit purge tx: %w", err)
+	}
+	return purged, nil
 }
@@ -0,0 +1,307 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Napageneral/mnemonic/internal/adapters"
+	"github.com/Napageneral/mnemonic/internal/config"
+	"github.com/Napageneral/mnemonic/internal/testutil"
+)
+
+func execAdapterConfig(t *testing.T, name, body string) config.AdapterConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".sh")
+	if err := os.WriteFile(path, []byte(body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return config.AdapterConfig{Type: "exec", Enabled: true, Options: map[string]interface{}{"command": path}}
+}
+
+func TestSyncAllRunsAdaptersConcurrently(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	slow := execAdapterConfig(t, "slow", "#!/bin/sh\nexec sleep 5\n")
+	slow.Timeout = "300ms"
+	cfg := &config.Config{
+		Sync: config.SyncConfig{Concurrency: 2},
+		Adapters: map[string]config.AdapterConfig{
+			"slow": slow,
+			"fast": execAdapterConfig(t, "fast", `#!/bin/sh
+echo '{"type":"event","id":"e1","timestamp":1700000000,"content":"hi"}'
+`),
+		},
+	}
+
+	start := time.Now()
+	res := SyncAll(context.Background(), db, cfg, false)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("slow adapter held up the run for %s", elapsed)
+	}
+	if res.OK || len(res.Adapters) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	fast, slowRes := res.Adapters[0], res.Adapters[1]
+	if fast.AdapterName != "fast" || !fast.Success || fast.EventsCreated != 1 {
+		t.Fatalf("unexpected fast result %+v", fast)
+	}
+	if slowRes.Success || !strings.Contains(slowRes.Error, "Timed out after 300ms") {
+		t.Fatalf("expected slow adapter to time out, got %+v", slowRes)
+	}
+
+	jobs, err := ListJobs(db)
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	status := map[string]string{}
+	for _, j := range jobs {
+		status[j.Adapter] = j.Status
+	}
+	if status["fast"] != "success" || status["slow"] != "error" {
+		t.Fatalf("unexpected job statuses %v", status)
+	}
+}
+
+// stuckAdapter ignores ctx and returns only once release is closed.
+type stuckAdapter struct {
+	release chan struct{}
+}
+
+func (a *stuckAdapter) Name() string { return "stuck" }
+
+func (a *stuckAdapter) Sync(ctx context.Context, db *sql.DB, full bool) (adapters.SyncResult, error) {
+	<-a.release
+	return adapters.SyncResult{EventsCreated: 1}, nil
+}
+
+func TestRunAdapterAbandonsStuckSync(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	defer func(d time.Duration) { timeoutGrace = d }(timeoutGrace)
+	timeoutGrace = 0
+
+	stuck := &stuckAdapter{release: make(chan struct{})}
+	res, late := runSync(context.Background(), db, "stuck", config.AdapterConfig{Enabled: true}, stuck, nil, false, 50*time.Millisecond)
+	if res.Success || res.Error != "Timed out after 50ms" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// The late success must not overwrite the recorded timeout.
+	close(stuck.release)
+	if r := <-late; !r.Success {
+		t.Fatalf("expected the abandoned sync to finish, got %+v", r)
+	}
+
+	jobs, err := ListJobs(db)
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != "error" || jobs[0].LastError == nil || *jobs[0].LastError != res.Error {
+		t.Fatalf("unexpected jobs %+v", jobs)
+	}
+	runs, err := ListRuns(db, "stuck", 10)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != "error" || runs[0].Error != res.Error {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+}
+
+func TestAdapterTimeout(t *testing.T) {
+	cfg := &config.Config{Sync: config.SyncConfig{Timeout: "1h"}}
+	if d, err := adapterTimeout(cfg, config.AdapterConfig{}); err != nil || d != time.Hour {
+		t.Fatalf("expected global timeout, got %s (%v)", d, err)
+	}
+	if d, err := adapterTimeout(cfg, config.AdapterConfig{Timeout: "5m"}); err != nil || d != 5*time.Minute {
+		t.Fatalf("expected adapter override, got %s (%v)", d, err)
+	}
+	if _, err := adapterTimeout(cfg, config.AdapterConfig{Timeout: "soon"}); err == nil {
+		t.Fatalf("expected invalid timeout error")
+	}
+}
+
+func TestSyncRunHistoryAndDiff(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	script := filepath.Join(t.TempDir(), "extract.sh")
+	write := func(body string) {
+		if err := os.WriteFile(script, []byte("#!/bin/sh\ncat <<'EOF'\n"+body+"EOF\n"), 0o755); err != nil {
+			t.Fatalf("write script: %v", err)
+		}
+	}
+	cfg := &config.Config{Adapters: map[string]config.AdapterConfig{
+		"chat": {Type: "exec", Enabled: true, Options: map[string]interface{}{"command": script}},
+	}}
+
+	write(`{"type":"thread","id":"t1","name":"General"}
+{"type":"event","id":"e1","thread_id":"t1","timestamp":1700000000,"content":"first"}
+{"type":"event","id":"e2","thread_id":"t1","timestamp":1700000060,"content":"second"}
+`)
+	if res := SyncOne(context.Background(), db, cfg, "chat", false); !res.OK {
+		t.Fatalf("first sync: %+v", res)
+	}
+	write(`{"type":"event","id":"e2","thread_id":"t1","timestamp":1700000060,"content":"second, edited"}
+{"type":"event","id":"e3","thread_id":"t1","timestamp":1700000120,"content":"third"}
+{"type":"event","id":"e1","thread_id":"t1","timestamp":1700000000,"content":"first"}
+`)
+	if res := SyncOne(context.Background(), db, cfg, "chat", false); !res.OK {
+		t.Fatalf("second sync: %+v", res)
+	}
+
+	runs, err := ListRuns(db, "chat", 10)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != "success" || runs[0].Result == nil || runs[0].Result.EventsCreated != 1 {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	if runs[0].WatermarkAfter == nil || runs[1].WatermarkBefore != nil {
+		t.Fatalf("expected watermarks to be recorded, got %+v", runs)
+	}
+
+	diff, err := DiffRun(db, runs[0].ID, 0)
+	if err != nil {
+		t.Fatalf("DiffRun: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range diff.Events {
+		got[c.ID] = c.Action
+	}
+	if len(got) != 2 || got["chat:e2"] != "updated" || got["chat:e3"] != "created" || len(diff.Threads) != 0 {
+		t.Fatalf("unexpected diff events=%v threads=%+v", got, diff.Threads)
+	}
+
+	first, err := DiffRun(db, runs[1].ID, 0)
+	if err != nil {
+		t.Fatalf("DiffRun first: %v", err)
+	}
+	if len(first.Events) != 2 || len(first.Threads) != 1 || first.Threads[0].Label != "General" {
+		t.Fatalf("unexpected first diff %+v", first)
+	}
+}
+
+// sourceAdapter writes under a source_adapter other than its configured name,
+// as Eve (imessage) and bird (x) do.
+type sourceAdapter struct{}
+
+func (sourceAdapter) Name() string { return "source" }
+
+func (sourceAdapter) Sync(ctx context.Context, db *sql.DB, full bool) (adapters.SyncResult, error) {
+	if _, err := db.Exec(`
+		INSERT INTO events (id, timestamp, channel, content_types, content, direction, source_adapter, source_id)
+		VALUES ('source:e1', 1700000000, 'chat', '["text"]', 'hi', 'received', 'source', 'e1')
+	`); err != nil {
+		return adapters.SyncResult{}, err
+	}
+	if _, err := db.Exec(`INSERT INTO sync_watermarks (adapter, last_sync_at) VALUES ('source', 1700000000)`); err != nil {
+		return adapters.SyncResult{}, err
+	}
+	return adapters.SyncResult{EventsCreated: 1}, nil
+}
+
+func init() {
+	adapters.Register(adapters.Registration{
+		Type:    "test-source",
+		Factory: func(string, any) (adapters.Adapter, error) { return sourceAdapter{}, nil },
+	})
+}
+
+func TestSyncRunTracksSourceAdapter(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	// A run left behind by a crashed sync under another configured name
+	// would otherwise keep matching the same source_adapter.
+	if _, err := db.Exec(`
+		INSERT INTO sync_runs (adapter, source_adapter, status, started_at) VALUES ('renamed', 'source', 'running', 1)
+	`); err != nil {
+		t.Fatalf("insert stale run: %v", err)
+	}
+
+	cfg := &config.Config{Adapters: map[string]config.AdapterConfig{
+		"configured": {Type: "test-source", Enabled: true},
+	}}
+	if res := SyncOne(context.Background(), db, cfg, "configured", false); !res.OK {
+		t.Fatalf("sync: %+v", res)
+	}
+
+	runs, err := ListRuns(db, "", 10)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(runs) != 2 || runs[1].Status != "abandoned" {
+		t.Fatalf("expected stale run to be abandoned, got %+v", runs)
+	}
+	run := runs[0]
+	if run.Adapter != "configured" || run.SourceAdapter != "source" || run.WatermarkAfter == nil || *run.WatermarkAfter != 1700000000 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	diff, err := DiffRun(db, run.ID, 0)
+	if err != nil {
+		t.Fatalf("DiffRun: %v", err)
+	}
+	if len(diff.Events) != 1 || diff.Events[0].ID != "source:e1" || diff.Events[0].Action != "created" {
+		t.Fatalf("unexpected diff events %+v", diff.Events)
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	defer db.Close()
+
+	statuses := func(r DoctorReport) map[string]string {
+		out := map[string]string{}
+		for _, c := range r.Checks {
+			out[c.Name] = c.Status
+		}
+		return out
+	}
+
+	missing := config.AdapterConfig{Type: "exec", Enabled: true, Options: map[string]interface{}{"command": "/nonexistent/extractor"}}
+	r := Doctor(context.Background(), db, "missing", missing)
+	if r.OK || statuses(r)["binary"] != "fail" {
+		t.Fatalf("expected missing binary to fail, got %+v", r)
+	}
+
+	bad := config.AdapterConfig{Type: "exec", Enabled: true, Options: map[string]interface{}{"comand": "x"}}
+	r = Doctor(context.Background(), db, "bad", bad)
+	if r.OK || statuses(r)["config"] != "fail" {
+		t.Fatalf("expected config error, got %+v", r)
+	}
+
+	good := execAdapterConfig(t, "good", `#!/bin/sh
+echo '{"type":"event","id":"e1","timestamp":1700000000,"content":"hi"}'
+`)
+	r = Doctor(context.Background(), db, "good", good)
+	if !r.OK || statuses(r)["watermark"] != "warn" {
+		t.Fatalf("expected unsynced adapter to pass with a watermark warning, got %+v", r)
+	}
+	if res := SyncOne(context.Background(), db, &config.Config{Adapters: map[string]config.AdapterConfig{"good": good}}, "good", false); !res.OK {
+		t.Fatalf("sync: %+v", res)
+	}
+	r = Doctor(context.Background(), db, "good", good)
+	if got := statuses(r); got["binary"] != "ok" || got["last sync"] != "ok" {
+		t.Fatalf("unexpected checks after sync %+v", r.Checks)
+	}
+
+	if err := FinishJobError(db, "good", "sync", nil, "Sync failed: token expired", nil); err != nil {
+		t.Fatalf("FinishJobError: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE sync_watermarks SET last_sync_at = ? WHERE adapter = 'good'`, time.Now().Add(48*time.Hour).Unix()); err != nil {
+		t.Fatalf("update watermark: %v", err)
+	}
+	r = Doctor(context.Background(), db, "good", good)
+	if got := statuses(r); r.OK || got["last sync"] != "fail" || got["watermark"] != "fail" {
+		t.Fatalf("expected last error and future watermark to fail, got %+v", r.Checks)
+	}
+}
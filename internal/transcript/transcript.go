@@ -0,0 +1,274 @@
+// Package transcript parses meeting transcripts (WebVTT, SRT and
+// speaker-labelled text such as Otter or Zoom exports) into utterances.
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Utterance is one speaker turn. Start and End are offsets from the start of
+// the recording.
+type Utterance struct {
+	Speaker string // empty when the transcript does not label speakers
+	Start   time.Duration
+	End     time.Duration
+	Text    string
+}
+
+// Format identifies a transcript syntax.
+type Format string
+
+const (
+	FormatVTT  Format = "vtt"
+	FormatSRT  Format = "srt"
+	FormatText Format = "text"
+)
+
+// Parse reads a transcript, detecting its format from the content.
+// Consecutive cues from the same labelled speaker are merged into one
+// utterance, since caption files split speech into short fragments.
+func Parse(r io.Reader) ([]Utterance, Format, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var (
+		out    []Utterance
+		format Format
+	)
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(text), "WEBVTT"):
+		format = FormatVTT
+		out, err = parseCues(text, true)
+	case cueTimingRe.MatchString(text):
+		format = FormatSRT
+		out, err = parseCues(text, false)
+	default:
+		format = FormatText
+		out = parseText(text)
+	}
+	if err != nil {
+		return nil, format, err
+	}
+	return merge(out), format, nil
+}
+
+var (
+	cueTimingRe = regexp.MustCompile(`(?m)^\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+	voiceRe     = regexp.MustCompile(`<v(?:\.[^ >]+)*\s+([^>]+)>`)
+	tagRe       = regexp.MustCompile(`</?[^>]+>`)
+
+	// "Alice Smith  0:03" (Otter) or "[Alice Smith] 10:01:02" (Zoom) on a
+	// line of its own, followed by the utterance text.
+	headerRe = regexp.MustCompile(`^\[?([^\[\]\d][^\[\]]{0,59}?)\]?\s+(\d{1,2}:\d{2}(?::\d{2})?)$`)
+	// "[00:01:02] Alice: text" or "00:01:02 Alice: text".
+	stampedRe = regexp.MustCompile(`^\[?(\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?)\]?\s+([^:]{1,60}):\s*(.*)$`)
+	// "Alice: text".
+	labelRe = regexp.MustCompile(`^([^:\d][^:]{0,59}):\s+(.*)$`)
+)
+
+// parseCues handles WebVTT and SRT, which share the cue layout of an
+// optional identifier line, a timing line and text lines.
+func parseCues(text string, vtt bool) ([]Utterance, error) {
+	var out []Utterance
+	blocks := strings.Split(strings.TrimSpace(text), "\n\n")
+	for i, block := range blocks {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if vtt && i == 0 {
+			continue // WEBVTT header
+		}
+		timing := -1
+		for j, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = j
+				break
+			}
+		}
+		if timing < 0 {
+			continue // NOTE, STYLE and REGION blocks, or stray text
+		}
+		m := cueTimingRe.FindStringSubmatch(lines[timing])
+		if m == nil {
+			return nil, fmt.Errorf("invalid cue timing %q", lines[timing])
+		}
+		start, err := parseTimestamp(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimestamp(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		body := strings.Join(lines[timing+1:], "\n")
+		speaker := ""
+		if v := voiceRe.FindStringSubmatch(body); v != nil {
+			speaker = strings.TrimSpace(v[1])
+		}
+		body = strings.TrimSpace(tagRe.ReplaceAllString(body, ""))
+		body = strings.Join(strings.Fields(body), " ")
+		if speaker == "" {
+			if l := labelRe.FindStringSubmatch(body); l != nil && plausibleSpeaker(l[1]) {
+				speaker, body = strings.TrimSpace(l[1]), strings.TrimSpace(l[2])
+			}
+		}
+		if body == "" {
+			continue
+		}
+		out = append(out, Utterance{Speaker: speaker, Start: start, End: end, Text: body})
+	}
+	return out, nil
+}
+
+// parseText handles speaker-labelled plain text. Timestamps are rebased so
+// the first one is zero, because some exports print wall-clock times.
+func parseText(text string) []Utterance {
+	var (
+		out      []Utterance
+		cur      *Utterance
+		base     time.Duration
+		haveBase bool
+	)
+	offset := func(s string) time.Duration {
+		d, err := parseTimestamp(s)
+		if err != nil {
+			return 0
+		}
+		if !haveBase {
+			base, haveBase = d, true
+		}
+		if d < base {
+			return 0
+		}
+		return d - base
+	}
+	flush := func() {
+		if cur != nil && strings.TrimSpace(cur.Text) != "" {
+			cur.Text = strings.TrimSpace(cur.Text)
+			out = append(out, *cur)
+		}
+		cur = nil
+	}
+	appendText := func(s string) {
+		if cur == nil {
+			cur = &Utterance{}
+			if len(out) > 0 {
+				cur.Start = out[len(out)-1].Start
+			}
+		}
+		if cur.Text != "" {
+			cur.Text += " "
+		}
+		cur.Text += s
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if m := stampedRe.FindStringSubmatch(line); m != nil && plausibleSpeaker(m[2]) {
+			flush()
+			cur = &Utterance{Speaker: strings.TrimSpace(m[2]), Start: offset(m[1])}
+			appendText(m[3])
+			continue
+		}
+		if m := headerRe.FindStringSubmatch(line); m != nil && plausibleSpeaker(m[1]) {
+			flush()
+			cur = &Utterance{Speaker: strings.TrimSpace(m[1]), Start: offset(m[2])}
+			continue
+		}
+		if m := labelRe.FindStringSubmatch(line); m != nil && plausibleSpeaker(m[1]) {
+			flush()
+			start := time.Duration(0)
+			if len(out) > 0 {
+				start = out[len(out)-1].Start
+			}
+			cur = &Utterance{Speaker: strings.TrimSpace(m[1]), Start: start}
+			appendText(m[2])
+			continue
+		}
+		appendText(line)
+	}
+	flush()
+
+	// Text formats only mark where a turn starts; it ends where the next
+	// one begins.
+	for i := range out {
+		out[i].End = out[i].Start
+		if i+1 < len(out) && out[i+1].Start > out[i].Start {
+			out[i].End = out[i+1].Start
+		}
+	}
+	return out
+}
+
+// plausibleSpeaker rejects labels that are more likely prose ("Note that
+// the plan is:") than a name.
+func plausibleSpeaker(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || len(strings.Fields(s)) > 4 {
+		return false
+	}
+	if strings.ContainsAny(s, ".?!,;\"") {
+		return false
+	}
+	lower := strings.ToLower(s)
+	return !strings.HasPrefix(lower, "http") && lower != "note"
+}
+
+func merge(in []Utterance) []Utterance {
+	var out []Utterance
+	for _, u := range in {
+		if n := len(out); n > 0 && u.Speaker != "" && out[n-1].Speaker == u.Speaker {
+			out[n-1].Text += " " + u.Text
+			if u.End > out[n-1].End {
+				out[n-1].End = u.End
+			}
+			continue
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// parseTimestamp parses "[HH:]MM:SS[.mmm]" with either "." or "," before
+// the fraction.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	var frac time.Duration
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits := (s[i+1:] + "000")[:3]
+		ms, err := strconv.Atoi(digits)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		frac = time.Duration(ms) * time.Millisecond
+		s = s[:i]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	var total int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total)*time.Second + frac, nil
+}
@@ -0,0 +1,70 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVTT(t *testing.T) {
+	in := "WEBVTT\nKind: captions\n\nNOTE recorded with Zoom\n\n" +
+		"1\n00:00:01.000 --> 00:00:03.500\n<v Alice Smith>Let's start with the roadmap.</v>\n\n" +
+		"2\n00:00:03.600 --> 00:00:05.000 align:start\n<v Alice Smith>Q3 first.</v>\n\n" +
+		"3\n00:00:06.000 --> 00:00:08.250\nBob: Agreed, <i>ship</i> it.\n"
+	got, format, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatVTT || len(got) != 2 {
+		t.Fatalf("expected 2 vtt utterances, got %s %+v", format, got)
+	}
+	if got[0].Speaker != "Alice Smith" || got[0].Text != "Let's start with the roadmap. Q3 first." || got[0].Start != time.Second || got[0].End != 5*time.Second {
+		t.Fatalf("unexpected first utterance %+v", got[0])
+	}
+	if got[1].Speaker != "Bob" || got[1].Text != "Agreed, ship it." || got[1].End != 8250*time.Millisecond {
+		t.Fatalf("unexpected second utterance %+v", got[1])
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	in := "\ufeff1\r\n00:01:02,500 --> 00:01:04,000\r\nAlice: Hello\r\nthere\r\n\r\n2\r\n01:00:00,000 --> 01:00:01,000\r\nNo speaker here\r\n"
+	got, format, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatSRT || len(got) != 2 {
+		t.Fatalf("expected 2 srt utterances, got %s %+v", format, got)
+	}
+	if got[0].Speaker != "Alice" || got[0].Text != "Hello there" || got[0].Start != 62500*time.Millisecond {
+		t.Fatalf("unexpected first utterance %+v", got[0])
+	}
+	if got[1].Speaker != "" || got[1].Start != time.Hour {
+		t.Fatalf("unexpected second utterance %+v", got[1])
+	}
+}
+
+func TestParseText(t *testing.T) {
+	otter := "Alice Smith  0:03\nWelcome everyone.\nLet's begin.\n\nBob Jones  1:15\nThanks. Note: budget is tight.\n"
+	got, format, err := Parse(strings.NewReader(otter))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatText || len(got) != 2 {
+		t.Fatalf("expected 2 otter utterances, got %+v", got)
+	}
+	if got[0].Speaker != "Alice Smith" || got[0].Text != "Welcome everyone. Let's begin." || got[0].Start != 0 || got[0].End != 72*time.Second {
+		t.Fatalf("unexpected otter utterance %+v", got[0])
+	}
+	if got[1].Text != "Thanks. Note: budget is tight." {
+		t.Fatalf("unexpected otter continuation %+v", got[1])
+	}
+
+	zoom := "[10:01:02] Alice: Morning\n[10:01:30] Bob: Hi\nstill Bob\n"
+	got, _, err = Parse(strings.NewReader(zoom))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(got) != 2 || got[1].Speaker != "Bob" || got[1].Start != 28*time.Second || got[1].Text != "Hi still Bob" {
+		t.Fatalf("unexpected stamped utterances %+v", got)
+	}
+}
@@ -0,0 +1,458 @@
+// Package vcard reads vCard 2.1/3.0/4.0 files and writes vCard 3.0.
+package vcard
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// Card is the subset of a vCard that maps onto persons and contacts.
+type Card struct {
+	UID     string
+	FN      string
+	Family  string
+	Given   string
+	Tels    []Typed
+	Emails  []Typed
+	IMPPs   []Typed
+	URLs    []string
+	Org     string
+	Title   string
+	BDay    string // YYYY-MM-DD, or --MM-DD when the year is unknown
+	Note    string
+	Photo   *Photo
+	Version string
+}
+
+// Typed is a TEL/EMAIL/IMPP value with its lowercased TYPE parameters
+// ("cell", "work", "home", ...). Pref marks the preferred entry.
+type Typed struct {
+	Value string
+	Types []string
+	Pref  bool
+}
+
+// HasType reports whether t carries the given TYPE (case-insensitive).
+func (t Typed) HasType(name string) bool {
+	for _, v := range t.Types {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Photo is an inline image or a URI reference.
+type Photo struct {
+	Data      []byte
+	MediaType string // image/jpeg, image/png, ...
+	URI       string
+}
+
+// Name returns FN, falling back to the structured name.
+func (c Card) Name() string {
+	if strings.TrimSpace(c.FN) != "" {
+		return strings.TrimSpace(c.FN)
+	}
+	return strings.TrimSpace(strings.Join(strings.Fields(c.Given+" "+c.Family), " "))
+}
+
+type property struct {
+	name   string
+	params map[string][]string
+	value  string
+}
+
+func (p property) param(name string) string {
+	if vs := p.params[name]; len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// types collects TYPE values, including vCard 2.1 bare parameters
+// (TEL;CELL;VOICE:...).
+func (p property) types() ([]string, bool) {
+	var out []string
+	pref := p.param("PREF") != ""
+	for _, v := range p.params["TYPE"] {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == "pref" {
+				pref = true
+				continue
+			}
+			if t != "" {
+				out = append(out, t)
+			}
+		}
+	}
+	for _, t := range p.params[""] {
+		t = strings.ToLower(t)
+		if t == "pref" {
+			pref = true
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, pref
+}
+
+// Parse reads all cards from r.
+func Parse(r io.Reader) ([]Card, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var cards []Card
+	var cur *Card
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch p.name {
+		case "BEGIN":
+			if strings.EqualFold(p.value, "VCARD") {
+				if cur != nil {
+					return nil, fmt.Errorf("line %d: nested BEGIN:VCARD", i+1)
+				}
+				cur = &Card{}
+			}
+			continue
+		case "END":
+			if strings.EqualFold(p.value, "VCARD") {
+				if cur == nil {
+					return nil, fmt.Errorf("line %d: END:VCARD without BEGIN", i+1)
+				}
+				cards = append(cards, *cur)
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if err := setProperty(cur, p); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, p.name, err)
+		}
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("unterminated vCard %q", cur.Name())
+	}
+	return cards, nil
+}
+
+// unfold joins continuation lines. vCard 2.1 quoted-printable values use a
+// trailing "=" as a soft break instead.
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 32*1024*1024)
+	var lines []string
+	qpContinues := false
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch {
+		case qpContinues && len(lines) > 0:
+			lines[len(lines)-1] += line
+		case (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0:
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+		last := lines[len(lines)-1]
+		qpContinues = strings.HasSuffix(last, "=") && strings.Contains(strings.ToUpper(last), "QUOTED-PRINTABLE")
+		if qpContinues {
+			lines[len(lines)-1] = strings.TrimSuffix(last, "=")
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read vcf: %w", err)
+	}
+	return lines, nil
+}
+
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string][]string{}}
+	inQuote := false
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return p, fmt.Errorf("missing ':' in %q", line)
+	}
+	head, value := line[:sep], line[sep+1:]
+
+	parts := splitUnquoted(head, ';')
+	name := strings.ToUpper(strings.TrimSpace(parts[0]))
+	// Drop Apple-style group prefixes ("item1.TEL").
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	p.name = name
+	for _, param := range parts[1:] {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok {
+			p.params[""] = append(p.params[""], strings.TrimSpace(param))
+			continue
+		}
+		k = strings.ToUpper(strings.TrimSpace(k))
+		p.params[k] = append(p.params[k], strings.Trim(v, `"`))
+	}
+
+	if strings.EqualFold(p.param("ENCODING"), "QUOTED-PRINTABLE") || hasBare(p, "QUOTED-PRINTABLE") {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value)))
+		if err != nil {
+			return p, fmt.Errorf("decode quoted-printable: %w", err)
+		}
+		value = string(decoded)
+	}
+	p.value = value
+	return p, nil
+}
+
+func hasBare(p property, name string) bool {
+	for _, v := range p.params[""] {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var out []string
+	var b strings.Builder
+	inQuote := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case r == sep && !inQuote:
+			out = append(out, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(out, b.String())
+}
+
+// splitComponents splits a structured value (N, ORG) on unescaped ";".
+func splitComponents(s string) []string {
+	var out []string
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			out = append(out, unescape(b.String()))
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(out, unescape(b.String()))
+}
+
+var unescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\:`, ":", `\\`, `\`)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+func setProperty(c *Card, p property) error {
+	switch p.name {
+	case "VERSION":
+		c.Version = strings.TrimSpace(p.value)
+	case "UID":
+		c.UID = strings.TrimSpace(p.value)
+	case "FN":
+		c.FN = unescape(p.value)
+	case "N":
+		parts := splitComponents(p.value)
+		c.Family = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			c.Given = strings.TrimSpace(parts[1])
+		}
+	case "TEL":
+		value := strings.TrimSpace(p.value)
+		if len(value) > 4 && strings.EqualFold(value[:4], "tel:") {
+			value = value[4:]
+		}
+		if value == "" {
+			return nil
+		}
+		types, pref := p.types()
+		c.Tels = append(c.Tels, Typed{Value: value, Types: types, Pref: pref})
+	case "EMAIL":
+		value := strings.TrimSpace(unescape(p.value))
+		if len(value) > 7 && strings.EqualFold(value[:7], "mailto:") {
+			value = value[7:]
+		}
+		if value == "" {
+			return nil
+		}
+		types, pref := p.types()
+		c.Emails = append(c.Emails, Typed{Value: value, Types: types, Pref: pref})
+	case "IMPP", "X-AIM", "X-JABBER", "X-SKYPE", "X-TWITTER":
+		value := strings.TrimSpace(unescape(p.value))
+		if value == "" {
+			return nil
+		}
+		types, pref := p.types()
+		if p.name != "IMPP" {
+			types = append(types, strings.ToLower(strings.TrimPrefix(p.name, "X-")))
+		}
+		c.IMPPs = append(c.IMPPs, Typed{Value: value, Types: types, Pref: pref})
+	case "URL":
+		if v := strings.TrimSpace(unescape(p.value)); v != "" {
+			c.URLs = append(c.URLs, v)
+		}
+	case "ORG":
+		c.Org = strings.TrimSpace(splitComponents(p.value)[0])
+	case "TITLE":
+		c.Title = strings.TrimSpace(unescape(p.value))
+	case "NOTE":
+		c.Note = unescape(p.value)
+	case "BDAY":
+		c.BDay = normalizeBDay(p.value)
+	case "PHOTO":
+		photo, err := parsePhoto(p)
+		if err != nil {
+			return err
+		}
+		c.Photo = photo
+	}
+	return nil
+}
+
+// normalizeBDay accepts 1985-04-12, 19850412, --0412, --04-12 and
+// date-times, returning YYYY-MM-DD or --MM-DD.
+func normalizeBDay(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexAny(v, "Tt"); i > 0 {
+		v = v[:i]
+	}
+	if strings.HasPrefix(v, "--") {
+		md := strings.ReplaceAll(v[2:], "-", "")
+		if len(md) == 4 {
+			return "--" + md[:2] + "-" + md[2:]
+		}
+		return v
+	}
+	digits := strings.ReplaceAll(v, "-", "")
+	if len(digits) == 8 {
+		// Apple writes 1604 as the year for birthdays without one.
+		if strings.HasPrefix(digits, "1604") {
+			return "--" + digits[4:6] + "-" + digits[6:]
+		}
+		return digits[:4] + "-" + digits[4:6] + "-" + digits[6:]
+	}
+	return v
+}
+
+func parsePhoto(p property) (*Photo, error) {
+	value := strings.TrimSpace(p.value)
+	if value == "" {
+		return nil, nil
+	}
+	// vCard 4.0 data URI.
+	if strings.HasPrefix(strings.ToLower(value), "data:") {
+		meta, payload, ok := strings.Cut(value[5:], ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid data uri")
+		}
+		mediaType, _, _ := strings.Cut(meta, ";")
+		data, err := decodeBase64(payload)
+		if err != nil {
+			return nil, err
+		}
+		return &Photo{Data: data, MediaType: mediaType}, nil
+	}
+	encoding := strings.ToUpper(p.param("ENCODING"))
+	if encoding == "B" || encoding == "BASE64" || hasBare(p, "BASE64") {
+		data, err := decodeBase64(value)
+		if err != nil {
+			return nil, err
+		}
+		mediaType := photoMediaType(p)
+		if mediaType == "" {
+			mediaType = sniffImage(data)
+		}
+		return &Photo{Data: data, MediaType: mediaType}, nil
+	}
+	return &Photo{URI: value, MediaType: photoMediaType(p)}, nil
+}
+
+func photoMediaType(p property) string {
+	if mt := p.param("MEDIATYPE"); mt != "" {
+		return mt
+	}
+	candidates := append([]string{}, p.params["TYPE"]...)
+	candidates = append(candidates, p.params[""]...)
+	for _, t := range candidates {
+		switch strings.ToUpper(t) {
+		case "JPEG", "JPG":
+			return "image/jpeg"
+		case "PNG":
+			return "image/png"
+		case "GIF":
+			return "image/gif"
+		}
+	}
+	return ""
+}
+
+func sniffImage(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return "image/gif"
+	}
+	return "application/octet-stream"
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode photo: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,119 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const sampleVCF = "BEGIN:VCARD\r\n" +
+	"VERSION:3.0\r\n" +
+	"N:Smith;Alice;;;\r\n" +
+	"FN:Alice Smith\r\n" +
+	"ORG:Acme\\, Inc.;Research\r\n" +
+	"TITLE:Principal Engineer\r\n" +
+	"item1.TEL;type=CELL;type=VOICE;type=pref:+1 (555) 123-4567\r\n" +
+	"item1.X-ABLabel:mobile\r\n" +
+	"TEL;TYPE=WORK,VOICE:555-987-6543\r\n" +
+	"EMAIL;type=INTERNET;type=WORK:alice@acme.example\r\n" +
+	"EMAIL;type=INTERNET;type=HOME:alice@home.example\r\n" +
+	"BDAY;VALUE=date:1985-04-12\r\n" +
+	"NOTE:Met at the\\nconference\r\n" +
+	"PHOTO;ENCODING=b;TYPE=JPEG:/9j/4AAQSkZJRgAB\r\n" +
+	" AQAAAQABAAD\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Bob Jones\r\n" +
+	"TEL;VALUE=uri;TYPE=\"home,voice\";PREF=1:tel:+1-555-000-1111\r\n" +
+	"BDAY:--0704\r\n" +
+	"PHOTO:data:image/png;base64,iVBORw0KGgo=\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:M=C3=BCller;J=C3=BC=\r\n" +
+	"rgen;;;\r\n" +
+	"TEL;CELL:5552223333\r\n" +
+	"END:VCARD\r\n"
+
+func TestParse(t *testing.T) {
+	cards, err := Parse(strings.NewReader(sampleVCF))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(cards) != 3 {
+		t.Fatalf("expected 3 cards, got %d", len(cards))
+	}
+
+	alice := cards[0]
+	if alice.Name() != "Alice Smith" || alice.Org != "Acme, Inc." || alice.Title != "Principal Engineer" || alice.BDay != "1985-04-12" {
+		t.Fatalf("unexpected alice %+v", alice)
+	}
+	if len(alice.Tels) != 2 || !alice.Tels[0].HasType("cell") || !alice.Tels[0].Pref || !alice.Tels[1].HasType("work") {
+		t.Fatalf("unexpected tels %+v", alice.Tels)
+	}
+	if len(alice.Emails) != 2 || !alice.Emails[0].HasType("work") || alice.Emails[1].Value != "alice@home.example" {
+		t.Fatalf("unexpected emails %+v", alice.Emails)
+	}
+	if alice.Note != "Met at the\nconference" {
+		t.Fatalf("unexpected note %q", alice.Note)
+	}
+	if alice.Photo == nil || alice.Photo.MediaType != "image/jpeg" || !bytes.HasPrefix(alice.Photo.Data, []byte("\xff\xd8\xff")) {
+		t.Fatalf("unexpected photo %+v", alice.Photo)
+	}
+
+	bob := cards[1]
+	if len(bob.Tels) != 1 || bob.Tels[0].Value != "+1-555-000-1111" || !bob.Tels[0].HasType("home") || !bob.Tels[0].Pref {
+		t.Fatalf("unexpected bob tels %+v", bob.Tels)
+	}
+	if bob.BDay != "--07-04" || bob.Photo == nil || bob.Photo.MediaType != "image/png" {
+		t.Fatalf("unexpected bob %+v", bob)
+	}
+
+	jurgen := cards[2]
+	if jurgen.Name() != "Jürgen Müller" || len(jurgen.Tels) != 1 || !jurgen.Tels[0].HasType("cell") {
+		t.Fatalf("unexpected 2.1 card %+v", jurgen)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	in := Card{
+		UID:    "person-1",
+		FN:     "Alice Smith; PhD",
+		Family: "Smith",
+		Given:  "Alice",
+		Tels:   []Typed{{Value: "+15551234567", Types: []string{"cell"}, Pref: true}},
+		Emails: []Typed{{Value: "alice@acme.example", Types: []string{"work"}}},
+		Org:    "Acme, Inc.",
+		Title:  strings.TrimSpace(strings.Repeat("Very Long Title ", 8)),
+		BDay:   "1985-04-12",
+		Photo:  &Photo{Data: bytes.Repeat([]byte{0xff, 0xd8, 0xff, 0x00}, 40), MediaType: "image/jpeg"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, in); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line not folded: %q", line)
+		}
+	}
+
+	cards, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("expected 1 card, got %d", len(cards))
+	}
+	out := cards[0]
+	if out.UID != in.UID || out.FN != in.FN || out.Org != in.Org || out.Title != in.Title || out.BDay != in.BDay {
+		t.Fatalf("round trip mismatch: %+v", out)
+	}
+	if len(out.Tels) != 1 || !out.Tels[0].Pref || !out.Tels[0].HasType("cell") || len(out.Emails) != 1 || !out.Emails[0].HasType("work") {
+		t.Fatalf("round trip identifiers mismatch: %+v %+v", out.Tels, out.Emails)
+	}
+	if out.Photo == nil || !bytes.Equal(out.Photo.Data, in.Photo.Data) {
+		t.Fatalf("round trip photo mismatch")
+	}
+}
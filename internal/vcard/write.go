@@ -0,0 +1,117 @@
+package vcard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Write encodes a card as vCard 3.0, the version most address books import.
+func Write(w io.Writer, c Card) error {
+	var lines []string
+	add := func(s string) { lines = append(lines, s) }
+
+	add("BEGIN:VCARD")
+	add("VERSION:3.0")
+	if c.UID != "" {
+		add("UID:" + escape(c.UID))
+	}
+	add("FN:" + escape(c.Name()))
+	add(fmt.Sprintf("N:%s;%s;;;", escape(c.Family), escape(c.Given)))
+	for _, t := range c.Tels {
+		add("TEL" + typeParams(t) + ":" + t.Value)
+	}
+	for _, t := range c.Emails {
+		add("EMAIL" + typeParams(Typed{Value: t.Value, Types: append([]string{"internet"}, t.Types...), Pref: t.Pref}) + ":" + escape(t.Value))
+	}
+	for _, t := range c.IMPPs {
+		add("IMPP" + typeParams(t) + ":" + escape(t.Value))
+	}
+	if c.Org != "" {
+		add("ORG:" + escape(c.Org))
+	}
+	if c.Title != "" {
+		add("TITLE:" + escape(c.Title))
+	}
+	if c.BDay != "" {
+		add("BDAY:" + c.BDay)
+	}
+	for _, u := range c.URLs {
+		add("URL:" + escape(u))
+	}
+	if c.Note != "" {
+		add("NOTE:" + escape(c.Note))
+	}
+	if p := c.Photo; p != nil {
+		switch {
+		case len(p.Data) > 0:
+			typ := strings.ToUpper(strings.TrimPrefix(p.MediaType, "image/"))
+			if typ == "" || strings.Contains(typ, "/") {
+				typ = "JPEG"
+			}
+			add(fmt.Sprintf("PHOTO;ENCODING=b;TYPE=%s:%s", typ, base64.StdEncoding.EncodeToString(p.Data)))
+		case p.URI != "":
+			add("PHOTO;VALUE=uri:" + p.URI)
+		}
+	}
+	add("END:VCARD")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, fold(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func typeParams(t Typed) string {
+	types := make([]string, 0, len(t.Types)+1)
+	seen := map[string]bool{}
+	for _, v := range t.Types {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		types = append(types, v)
+	}
+	if t.Pref && !seen["pref"] {
+		types = append(types, "pref")
+	}
+	if len(types) == 0 {
+		return ""
+	}
+	return ";TYPE=" + strings.Join(types, ",")
+}
+
+// fold splits a content line at 75 octets without breaking UTF-8 sequences
+// and terminates it with CRLF.
+func fold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line + "\r\n"
+	}
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}